@@ -0,0 +1,55 @@
+package httpx
+
+import (
+	"strconv"
+	"strings"
+)
+
+// AcceptQuality 返回 Accept 头中与 mediaType 最具体匹配的 q 值，explicit 表示是否为精确类型匹配
+// （而非 image/* 或 */* 通配）。未携带 Accept 头视为接受任意类型（q=1）；未匹配时返回 0。
+func AcceptQuality(accept, mediaType string) (q float64, explicit bool) {
+	accept = strings.TrimSpace(accept)
+	if accept == "" {
+		return 1, false
+	}
+	mediaType = strings.ToLower(mediaType)
+	mainType, _, _ := strings.Cut(mediaType, "/")
+
+	// 0: 未匹配，1: */*，2: type/*，3: 精确匹配
+	best := 0
+	for _, part := range strings.Split(accept, ",") {
+		fields := strings.Split(part, ";")
+		rangeType := strings.ToLower(strings.TrimSpace(fields[0]))
+
+		specificity := 0
+		switch {
+		case rangeType == mediaType:
+			specificity = 3
+		case rangeType == mainType+"/*":
+			specificity = 2
+		case rangeType == "*/*":
+			specificity = 1
+		}
+		if specificity == 0 || specificity < best {
+			continue
+		}
+
+		value := 1.0
+		for _, param := range fields[1:] {
+			name, raw, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if !ok || strings.ToLower(strings.TrimSpace(name)) != "q" {
+				continue
+			}
+			parsed, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+			if err != nil || parsed < 0 || parsed > 1 {
+				parsed = 0
+			}
+			value = parsed
+		}
+		// 同一具体程度出现多次时取较大的 q
+		if specificity > best || value > q {
+			best, q = specificity, value
+		}
+	}
+	return q, best == 3
+}
@@ -0,0 +1,28 @@
+package httpx
+
+import "testing"
+
+// 测试内容：验证 Accept 头的 q 值解析、通配匹配与精确匹配优先级。
+func TestAcceptQuality(t *testing.T) {
+	cases := []struct {
+		accept, mediaType string
+		q                 float64
+		explicit          bool
+	}{
+		{"", "image/webp", 1, false},
+		{"image/avif,image/webp,image/*,*/*;q=0.8", "image/webp", 1, true},
+		{"image/avif,image/webp,image/*,*/*;q=0.8", "image/png", 1, false},
+		{"image/png,image/*;q=0.8,*/*;q=0.5", "image/webp", 0.8, false},
+		{"text/html,*/*;q=0.5", "image/png", 0.5, false},
+		{"image/webp;q=0,*/*", "image/webp", 0, true},
+		{"IMAGE/WEBP; Q=0.7", "image/webp", 0.7, true},
+		{"text/html", "image/png", 0, false},
+		{"image/webp;q=abc", "image/webp", 0, true},
+	}
+	for _, tc := range cases {
+		q, explicit := AcceptQuality(tc.accept, tc.mediaType)
+		if q != tc.q || explicit != tc.explicit {
+			t.Fatalf("%q %s: 期望 (%v, %v)，实际为 (%v, %v)", tc.accept, tc.mediaType, tc.q, tc.explicit, q, explicit)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package httpx
+
+import (
+	"strings"
+	"unicode"
+)
+
+// AttachmentDisposition 构造下载用的 Content-Disposition 头：filename 为 ASCII 兜底名称，
+// filename* 按 RFC 5987 以 UTF-8 百分号编码携带完整名称，引号、反斜杠与控制字符均被替换。
+func AttachmentDisposition(filename string) string {
+	var ascii, encoded strings.Builder
+	for _, r := range filename {
+		if unicode.IsControl(r) {
+			r = '_'
+		}
+		switch {
+		case r > unicode.MaxASCII || r == '"' || r == '\\':
+			ascii.WriteByte('_')
+		default:
+			ascii.WriteRune(r)
+		}
+		for _, b := range []byte(string(r)) {
+			if isAttrChar(b) {
+				encoded.WriteByte(b)
+				continue
+			}
+			const hex = "0123456789ABCDEF"
+			encoded.WriteByte('%')
+			encoded.WriteByte(hex[b>>4])
+			encoded.WriteByte(hex[b&0x0f])
+		}
+	}
+	return `attachment; filename="` + ascii.String() + `"; filename*=UTF-8''` + encoded.String()
+}
+
+// isAttrChar 判断字节是否属于 RFC 5987 attr-char，可在 filename* 中原样出现。
+func isAttrChar(b byte) bool {
+	switch {
+	case 'a' <= b && b <= 'z', 'A' <= b && b <= 'Z', '0' <= b && b <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", b) >= 0
+}
@@ -0,0 +1,19 @@
+package httpx
+
+import "testing"
+
+// 测试内容：验证下载文件名的 ASCII 兜底与 RFC 5987 编码，以及引号、换行等字符不会破坏响应头。
+func TestAttachmentDisposition(t *testing.T) {
+	cases := []struct {
+		name, want string
+	}{
+		{"photo.png", `attachment; filename="photo.png"; filename*=UTF-8''photo.png`},
+		{"海边 日落.jpg", `attachment; filename="__ __.jpg"; filename*=UTF-8''%E6%B5%B7%E8%BE%B9%20%E6%97%A5%E8%90%BD.jpg`},
+		{"a\"b\\c\r\nd.png", `attachment; filename="a_b_c__d.png"; filename*=UTF-8''a%22b%5Cc__d.png`},
+	}
+	for _, tc := range cases {
+		if got := AttachmentDisposition(tc.name); got != tc.want {
+			t.Fatalf("%q: 期望 %s，实际为 %s", tc.name, tc.want, got)
+		}
+	}
+}
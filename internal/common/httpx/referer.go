@@ -0,0 +1,80 @@
+package httpx
+
+import (
+	"net"
+	"net/url"
+	"strings"
+)
+
+// SourceHost 返回请求来源的主机名（小写、不含端口）：优先使用 Origin，Origin 缺失或为 "null" 时使用 Referer。
+// present 表示请求是否携带了来源信息；来源无法解析时 host 为空而 present 为 true。
+func SourceHost(origin, referer string) (host string, present bool) {
+	source := strings.TrimSpace(origin)
+	if source == "" || source == "null" {
+		source = strings.TrimSpace(referer)
+	}
+	if source == "" {
+		return "", false
+	}
+	u, err := url.Parse(source)
+	if err != nil {
+		return "", true
+	}
+	return strings.ToLower(u.Hostname()), true
+}
+
+// NormalizeHostPattern 规范化域名匹配规则：支持 example.com、*.example.com，
+// 以及直接填写的 URL（如 https://example.com:8080/path，仅保留主机名）。规则非法时 ok 为 false。
+func NormalizeHostPattern(raw string) (pattern string, ok bool) {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+	if strings.Contains(raw, "://") {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return "", false
+		}
+		raw = u.Hostname()
+	} else if host, _, err := net.SplitHostPort(raw); err == nil {
+		raw = host
+	}
+
+	host, wildcard := strings.CutPrefix(raw, "*.")
+	if !validHostname(host) {
+		return "", false
+	}
+	if wildcard {
+		return "*." + host, true
+	}
+	return host, true
+}
+
+// MatchHostPattern 判断 host 是否匹配规则：*.example.com 仅匹配子域名，不匹配 example.com 本身。
+func MatchHostPattern(pattern, host string) bool {
+	if host == "" {
+		return false
+	}
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(host, "."+suffix)
+	}
+	return host == pattern
+}
+
+func validHostname(host string) bool {
+	if host == "" || len(host) > 253 {
+		return false
+	}
+	if net.ParseIP(host) != nil {
+		return true
+	}
+	for _, label := range strings.Split(host, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for i := 0; i < len(label); i++ {
+			b := label[i]
+			if (b < 'a' || b > 'z') && (b < '0' || b > '9') && b != '-' {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,65 @@
+package httpx
+
+import "testing"
+
+// 测试内容：验证来源主机解析优先使用 Origin，并在 Origin 为 null 时回退到 Referer。
+func TestSourceHost(t *testing.T) {
+	cases := []struct {
+		origin, referer string
+		host            string
+		present         bool
+	}{
+		{"", "", "", false},
+		{"https://Blog.Example.com:8443", "https://other.com/a", "blog.example.com", true},
+		{"null", "https://other.com/a.html", "other.com", true},
+		{"", "http://[::1]:8080/x", "::1", true},
+		{"", "::bad", "", true},
+	}
+	for _, tc := range cases {
+		host, present := SourceHost(tc.origin, tc.referer)
+		if host != tc.host || present != tc.present {
+			t.Fatalf("%q %q: 期望 (%q, %v)，实际为 (%q, %v)", tc.origin, tc.referer, tc.host, tc.present, host, present)
+		}
+	}
+}
+
+// 测试内容：验证域名规则的规范化与通配匹配。
+func TestHostPattern(t *testing.T) {
+	normalize := []struct {
+		raw, pattern string
+		ok           bool
+	}{
+		{" Example.COM ", "example.com", true},
+		{"*.example.com", "*.example.com", true},
+		{"https://cdn.example.com:8080/path", "cdn.example.com", true},
+		{"example.com:8080", "example.com", true},
+		{"127.0.0.1", "127.0.0.1", true},
+		{"*", "", false},
+		{"exa mple.com", "", false},
+		{"-bad.com", "", false},
+		{"", "", false},
+	}
+	for _, tc := range normalize {
+		pattern, ok := NormalizeHostPattern(tc.raw)
+		if pattern != tc.pattern || ok != tc.ok {
+			t.Fatalf("%q: 期望 (%q, %v)，实际为 (%q, %v)", tc.raw, tc.pattern, tc.ok, pattern, ok)
+		}
+	}
+
+	match := []struct {
+		pattern, host string
+		want          bool
+	}{
+		{"example.com", "example.com", true},
+		{"example.com", "www.example.com", false},
+		{"*.example.com", "www.example.com", true},
+		{"*.example.com", "example.com", false},
+		{"*.example.com", "badexample.com", false},
+		{"example.com", "", false},
+	}
+	for _, tc := range match {
+		if got := MatchHostPattern(tc.pattern, tc.host); got != tc.want {
+			t.Fatalf("%q %q: 期望 %v，实际为 %v", tc.pattern, tc.host, tc.want, got)
+		}
+	}
+}
@@ -24,8 +24,31 @@ var DefaultSettings = []model.Setting{
 	{Key: consts.ConfigSendRegistrationVerificationEmail, Value: "false", Desc: "开启发送注册验证邮件", Category: "邮件服务"},
 	{Key: consts.ConfigBlockUnverifiedUsers, Value: "false", Desc: "阻止未验证邮箱用户登录", Category: "安全"},
 	{Key: consts.ConfigMaxUploadSize, Value: "10", Desc: "单个文件最大大小 (MB)", Category: "上传"},
+	{Key: consts.ConfigMaxBatchUploadFiles, Value: "30", Desc: "单次批量上传最多文件数", Category: "上传"},
 	{Key: consts.ConfigAllowFileExtensions, Value: ".jpg,.jpeg,.png,.gif,.webp", Desc: "允许上传的文件扩展名", Category: "上传"},
 	{Key: consts.ConfigDefaultStorageQuota, Value: "1073741824", Desc: "默认用户存储配额 (Bytes, 默认为1GB)", Category: "上传"},
+	{Key: consts.ConfigImageTrashRetentionDays, Value: "30", Desc: "回收站保留天数 (删除的图片在回收站中保留的天数，到期后永久删除并释放配额)", Category: "上传"},
+	{Key: consts.ConfigIntegrationDeleteLinkHours, Value: "24", Desc: "客户端上传返回的删除链接有效期 (小时)", Category: "上传"},
+	{Key: consts.ConfigDefaultImageTTL, Value: "0", Desc: "上传图片的默认有效期 (秒，0 表示永久保存；可按用户单独设置)", Category: "上传"},
+	{Key: consts.ConfigDefaultDailyUploadCount, Value: "0", Desc: "每个用户每日最多上传的图片数 (0 表示不限制；可按用户单独设置)", Category: "上传"},
+	{Key: consts.ConfigDefaultDailyUploadBytes, Value: "0", Desc: "每个用户每日最多上传的字节数 (Bytes，0 表示不限制；可按用户单独设置)", Category: "上传"},
+	{Key: consts.ConfigDefaultMonthlyEgressBytes, Value: "0", Desc: "每个用户的图片每月最多被访问的流量 (Bytes，0 表示不限制；可按用户单独设置)", Category: "上传"},
+	{Key: consts.ConfigImageVariantEnabled, Value: "true", Desc: "允许通过 ?w=&h=&fit=&fmt= 按需生成缩略图", Category: "图片处理"},
+	{Key: consts.ConfigImageVariantAllowedSizes, Value: "64,128,160,200,240,320,480,640,800,1024,1280,1920", Desc: "允许生成的缩略图边长 (像素, 逗号分隔)", Category: "图片处理"},
+	{Key: consts.ConfigImageStripMetadata, Value: "all", Desc: "上传时清理 EXIF/XMP/IPTC 元数据 (all: 全部清理, gps: 仅清理位置信息, keep: 保留)", Category: "图片处理"},
+	{Key: consts.ConfigImageConvertFormat, Value: "none", Desc: "上传时将 PNG/BMP 转码为指定格式 (none: 不转码, webp-lossless: 无损 WebP, jpeg: 按转码质量压缩；JPEG 保持原样)", Category: "图片处理"},
+	{Key: consts.ConfigImageConvertMode, Value: "replace", Desc: "转码结果保存方式 (replace: 替换原图, alternate: 与原图并存，按 Accept 头协商返回)", Category: "图片处理"},
+	{Key: consts.ConfigImageConvertQuality, Value: "80", Desc: "转码质量 (1-100，仅对 jpeg 目标生效；webp-lossless 为无损编码)", Category: "图片处理"},
+	{Key: consts.ConfigImageConvertOnlyIfSmaller, Value: "true", Desc: "仅当转码结果比原文件小时才保存", Category: "图片处理"},
+	{Key: consts.ConfigWatermarkEnabled, Value: "false", Desc: "上传时为图片添加水印（JPEG/PNG/WebP，GIF 不处理）", Category: "水印"},
+	{Key: consts.ConfigWatermarkType, Value: "text", Desc: "水印类型 (text: 文字, image: PNG 图片)", Category: "水印"},
+	{Key: consts.ConfigWatermarkText, Value: "{site_name}", Desc: "文字水印内容（支持 {site_name} 与 {username} 占位符）", Category: "水印"},
+	{Key: consts.ConfigWatermarkImagePath, Value: "", Desc: "图片水印 PNG 文件的服务器路径", Category: "水印"},
+	{Key: consts.ConfigWatermarkPosition, Value: "bottom-right", Desc: "水印位置 (top-left, top-right, bottom-left, bottom-right, center)", Category: "水印"},
+	{Key: consts.ConfigWatermarkOpacity, Value: "60", Desc: "水印不透明度 (1-100)", Category: "水印"},
+	{Key: consts.ConfigWatermarkScale, Value: "20", Desc: "水印宽度占图片宽度的百分比 (1-100，图片水印不会被放大)", Category: "水印"},
+	{Key: consts.ConfigWatermarkMinSize, Value: "300", Desc: "图片宽或高小于该值 (像素) 时不添加水印，0 表示不限制", Category: "水印"},
+	{Key: consts.ConfigWatermarkAllowUserDisable, Value: "false", Desc: "允许用户上传时关闭水印", Category: "水印"},
 	{Key: consts.ConfigRateLimitEnabled, Value: "true", Desc: "开启接口限流", Category: "速率限制"},
 	{Key: consts.ConfigRateLimitAuthRPS, Value: "0.5", Desc: "认证接口每秒请求限制 (RPS)", Category: "速率限制"},
 	{Key: consts.ConfigRateLimitAuthBurst, Value: "2", Desc: "认证接口突发请求限制", Category: "速率限制"},
@@ -37,6 +60,13 @@ var DefaultSettings = []model.Setting{
 	{Key: consts.ConfigRateLimitEmailUpdateIntervalSeconds, Value: "120", Desc: "修改邮箱请求最小间隔（秒）", Category: "速率限制"},
 	{Key: consts.ConfigMaxRequestBodySize, Value: "2", Desc: "非文件上传接口最大请求体限制 (MB)", Category: "服务"},
 	{Key: consts.ConfigStaticCacheControl, Value: "public, max-age=31536000", Desc: "静态资源缓存设置 (Cache-Control)", Category: "服务"},
+	{Key: consts.ConfigShortLinkRedirect, Value: "false", Desc: "短链接以 302 重定向到图片原地址（关闭时直接返回图片，不暴露原地址）", Category: "短链接"},
+	{Key: consts.ConfigHotlinkEnabled, Value: "false", Desc: "开启图片防盗链（按 Referer/Origin 校验图片与头像请求，本站域名始终允许）", Category: "防盗链"},
+	{Key: consts.ConfigHotlinkAllowedReferers, Value: "", Desc: "允许引用图片的来源域名（逗号分隔，支持 *.example.com 匹配子域名）", Category: "防盗链"},
+	{Key: consts.ConfigHotlinkAllowEmptyReferer, Value: "true", Desc: "允许不携带 Referer 的请求（直接访问、部分客户端与隐私浏览器）", Category: "防盗链"},
+	{Key: consts.ConfigHotlinkBlockAction, Value: "forbidden", Desc: "拦截方式 (forbidden: 返回 403, placeholder: 返回占位图)", Category: "防盗链"},
+	{Key: consts.ConfigHotlinkPlaceholderURL, Value: "", Desc: "占位图地址（留空使用内置占位图）", Category: "防盗链"},
+	{Key: consts.ConfigHotlinkExemptSignedURLs, Value: "true", Desc: "携带有效签名的图片链接不受防盗链限制", Category: "防盗链"},
 	{Key: consts.ConfigCaptchaProvider, Value: "image", Desc: "验证码提供方（空=关闭, image, turnstile, recaptcha, hcaptcha, geetest）", Category: "验证码"},
 	{Key: consts.ConfigCaptchaTurnstileSiteKey, Value: "", Desc: "Cloudflare Turnstile Site Key", Category: "验证码"},
 	{Key: consts.ConfigCaptchaTurnstileSecretKey, Value: "", Desc: "Cloudflare Turnstile Secret Key", Category: "验证码", Sensitive: true},
@@ -162,6 +192,21 @@ func (s *DBConfig) GetDefaultStorageQuota() int64 {
 	return quota
 }
 
+// GetDefaultDailyUploadCount 获取每日上传图片数的默认上限，0 表示不限制；配置值非法时视为不限制。
+func (s *DBConfig) GetDefaultDailyUploadCount() int64 {
+	return max(s.GetInt64(consts.ConfigDefaultDailyUploadCount), 0)
+}
+
+// GetDefaultDailyUploadBytes 获取每日上传字节数的默认上限，0 表示不限制；配置值非法时视为不限制。
+func (s *DBConfig) GetDefaultDailyUploadBytes() int64 {
+	return max(s.GetInt64(consts.ConfigDefaultDailyUploadBytes), 0)
+}
+
+// GetDefaultMonthlyEgressBytes 获取每月访问流量的默认上限，0 表示不限制；配置值非法时视为不限制。
+func (s *DBConfig) GetDefaultMonthlyEgressBytes() int64 {
+	return max(s.GetInt64(consts.ConfigDefaultMonthlyEgressBytes), 0)
+}
+
 func (s *DBConfig) InitializeSettings() error {
 	if err := s.settingStore.InitializeDefaults(DefaultSettings); err != nil {
 		return err
@@ -24,6 +24,7 @@ type Config struct {
 	Database DatabaseConfig `mapstructure:"database"`
 	JWT      JWTConfig      `mapstructure:"jwt"`
 	Upload   UploadConfig   `mapstructure:"upload"`
+	Storage  StorageConfig  `mapstructure:"storage"`
 	SMTP     SMTPConfig     `mapstructure:"smtp"`
 	Redis    RedisConfig    `mapstructure:"redis"`
 }
@@ -55,6 +56,32 @@ type UploadConfig struct {
 	URLPrefix       string `mapstructure:"url_prefix"`
 	AvatarPath      string `mapstructure:"avatar_path"`
 	AvatarURLPrefix string `mapstructure:"avatar_url_prefix"`
+	// SigningSecret 私有图片签名链接使用的密钥，留空时由 JWT Secret 派生
+	SigningSecret string `mapstructure:"signing_secret"`
+	// RemoteFetchTimeout 按 URL 上传时抓取远程图片的超时（秒）
+	RemoteFetchTimeout int `mapstructure:"remote_fetch_timeout"`
+	// RemoteAllowPrivate 按 URL 上传时是否允许访问内网与回环地址，仅建议在受信任的内网部署中开启
+	RemoteAllowPrivate bool `mapstructure:"remote_allow_private"`
+	// TusPath 可续传上传（tus）未完成文件的暂存目录
+	TusPath string `mapstructure:"tus_path"`
+	// TusExpirationHours 未完成的可续传上传在最后一次写入后保留的时长（小时）
+	TusExpirationHours int `mapstructure:"tus_expiration_hours"`
+}
+
+type StorageConfig struct {
+	Driver string   `mapstructure:"driver"` // local, s3
+	S3     S3Config `mapstructure:"s3"`
+}
+
+type S3Config struct {
+	Endpoint     string `mapstructure:"endpoint"`
+	Region       string `mapstructure:"region"`
+	Bucket       string `mapstructure:"bucket"`
+	AccessKey    string `mapstructure:"access_key"`
+	SecretKey    string `mapstructure:"secret_key"`
+	Prefix       string `mapstructure:"prefix"`         // 对象 key 全局前缀
+	UsePathStyle bool   `mapstructure:"use_path_style"` // MinIO 等自建服务通常需要开启
+	PublicURL    string `mapstructure:"public_url"`     // 头像公开访问地址（如 CDN），留空则由服务端代理；图片始终由服务端代理
 }
 
 type SMTPConfig struct {
@@ -118,6 +145,20 @@ func initViper(customConfigDir string) *viper.Viper {
 	v.SetDefault("upload.url_prefix", "/imgs/")
 	v.SetDefault("upload.avatar_path", "uploads/avatars")
 	v.SetDefault("upload.avatar_url_prefix", "/avatars/")
+	v.SetDefault("upload.signing_secret", "")
+	v.SetDefault("upload.remote_fetch_timeout", 15)
+	v.SetDefault("upload.remote_allow_private", false)
+	v.SetDefault("upload.tus_path", "uploads/tus")
+	v.SetDefault("upload.tus_expiration_hours", 24)
+	v.SetDefault("storage.driver", "local")
+	v.SetDefault("storage.s3.endpoint", "")
+	v.SetDefault("storage.s3.region", "us-east-1")
+	v.SetDefault("storage.s3.bucket", "")
+	v.SetDefault("storage.s3.access_key", "")
+	v.SetDefault("storage.s3.secret_key", "")
+	v.SetDefault("storage.s3.prefix", "")
+	v.SetDefault("storage.s3.use_path_style", false)
+	v.SetDefault("storage.s3.public_url", "")
 	v.SetDefault("server.port", "8080")
 	v.SetDefault("server.mode", "debug")
 	v.SetDefault("server.trusted_proxies", "")
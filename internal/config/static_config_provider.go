@@ -6,6 +6,9 @@ import (
 	jwtpkg "perfect-pic-server/internal/pkg/jwt"
 	"perfect-pic-server/internal/pkg/ratelimit"
 	redispkg "perfect-pic-server/internal/pkg/redis"
+	"perfect-pic-server/internal/pkg/remotefetch"
+	"perfect-pic-server/internal/pkg/storage"
+	"perfect-pic-server/internal/pkg/urlsign"
 	"time"
 
 	"github.com/google/wire"
@@ -39,6 +42,22 @@ func NewJWTConfig(cfg *Config) *jwtpkg.Config {
 	}
 }
 
+// NewURLSignConfig 优先使用独立的签名密钥，未配置时复用 JWT Secret（签名器内部会再做密钥派生）。
+func NewURLSignConfig(cfg *Config) *urlsign.Config {
+	secret := cfg.Upload.SigningSecret
+	if secret == "" {
+		secret = cfg.JWT.Secret
+	}
+	return &urlsign.Config{Secret: []byte(secret)}
+}
+
+func NewRemoteFetchConfig(cfg *Config) *remotefetch.Config {
+	return &remotefetch.Config{
+		Timeout:              time.Duration(cfg.Upload.RemoteFetchTimeout) * time.Second,
+		AllowPrivateNetworks: cfg.Upload.RemoteAllowPrivate,
+	}
+}
+
 func NewDBConnectionConfig(cfg *Config) *database.DbConnectionConfig {
 	return &database.DbConnectionConfig{
 		Type:     cfg.Database.Type,
@@ -58,11 +77,36 @@ func NewRateLimiterConfig(cfg *Config) *ratelimit.Config {
 	}
 }
 
+func NewStorageConfig(cfg *Config) *storage.Config {
+	return &storage.Config{
+		Driver: cfg.Storage.Driver,
+		Local: storage.LocalConfig{
+			ImagePath:       cfg.Upload.Path,
+			ImageURLPrefix:  cfg.Upload.URLPrefix,
+			AvatarPath:      cfg.Upload.AvatarPath,
+			AvatarURLPrefix: cfg.Upload.AvatarURLPrefix,
+		},
+		S3: storage.S3Config{
+			Endpoint:     cfg.Storage.S3.Endpoint,
+			Region:       cfg.Storage.S3.Region,
+			Bucket:       cfg.Storage.S3.Bucket,
+			AccessKey:    cfg.Storage.S3.AccessKey,
+			SecretKey:    cfg.Storage.S3.SecretKey,
+			Prefix:       cfg.Storage.S3.Prefix,
+			UsePathStyle: cfg.Storage.S3.UsePathStyle,
+			PublicURL:    cfg.Storage.S3.PublicURL,
+		},
+	}
+}
+
 var StaticConfigSet = wire.NewSet(
 	NewStaticConfig,
 	NewCacheConfig,
 	NewRedisClientConfig,
 	NewJWTConfig,
+	NewURLSignConfig,
+	NewRemoteFetchConfig,
 	NewDBConnectionConfig,
 	NewRateLimiterConfig,
+	NewStorageConfig,
 )
@@ -0,0 +1,25 @@
+package consts
+
+// 个人访问令牌的权限范围。
+const (
+	AccessTokenScopeUpload = "upload"
+	AccessTokenScopeRead   = "read"
+	AccessTokenScopeDelete = "delete"
+)
+
+const (
+	// AccessTokenPrefix 个人访问令牌明文的固定前缀，用于与登录 JWT 区分
+	AccessTokenPrefix        = "ppt_"
+	MaxUserAccessTokenCount  = 50
+	AccessTokenNameMaxRunes  = 64
+	AccessTokenMaxExpireDays = 3650
+)
+
+// 客户端配置签发的访问令牌来源，手动创建的令牌来源为空。
+const (
+	AccessTokenSourceShareX = "sharex"
+	AccessTokenSourcePicGo  = "picgo"
+)
+
+// AccessTokenScopes 全部可用的权限范围，按固定顺序保存。
+var AccessTokenScopes = []string{AccessTokenScopeUpload, AccessTokenScopeRead, AccessTokenScopeDelete}
@@ -0,0 +1,8 @@
+package consts
+
+const (
+	// HotlinkActionForbidden 拦截盗链请求时返回 403。
+	HotlinkActionForbidden = "forbidden"
+	// HotlinkActionPlaceholder 拦截盗链请求时返回占位图。
+	HotlinkActionPlaceholder = "placeholder"
+)
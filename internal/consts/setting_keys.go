@@ -35,12 +35,81 @@ const (
 	// ConfigMaxUploadSize 图片最大上传限制 (MB)
 	ConfigMaxUploadSize = "max_upload_size"
 
+	// ConfigMaxBatchUploadFiles 单次批量上传允许的最大文件数
+	ConfigMaxBatchUploadFiles = "max_batch_upload_files"
+
 	// ConfigAllowFileExtensions 允许上传的文件扩展名 (逗号分隔)
 	ConfigAllowFileExtensions = "allow_file_extensions"
 
+	// ConfigImageVariantEnabled 是否允许通过 URL 参数按需生成缩略图/变体 (true/false)
+	ConfigImageVariantEnabled = "image_variant_enabled"
+
+	// ConfigImageVariantAllowedSizes 允许生成的变体边长列表 (像素, 逗号分隔)
+	ConfigImageVariantAllowedSizes = "image_variant_allowed_sizes"
+
+	// ConfigImageStripMetadata 上传图片的元数据清理模式 (all: 全部清理 / gps: 仅清理位置信息 / keep: 保留)
+	ConfigImageStripMetadata = "image_strip_metadata"
+
+	// ConfigImageConvertFormat 上传时转码的目标格式 (none: 不转码 / webp-lossless: PNG/BMP 转为无损 WebP / jpeg: PNG/BMP 按质量转为 JPEG)
+	ConfigImageConvertFormat = "image_convert_format"
+
+	// ConfigImageConvertMode 转码结果的保存方式 (replace: 替换原图 / alternate: 与原图并存，按 Accept 头协商返回)
+	ConfigImageConvertMode = "image_convert_mode"
+
+	// ConfigImageConvertQuality 转码质量 (1-100)
+	ConfigImageConvertQuality = "image_convert_quality"
+
+	// ConfigImageConvertOnlyIfSmaller 仅当转码结果比原文件小时才保存 (true/false)
+	ConfigImageConvertOnlyIfSmaller = "image_convert_only_if_smaller"
+
+	// ConfigWatermarkEnabled 是否为上传图片添加水印 (true/false)
+	ConfigWatermarkEnabled = "watermark_enabled"
+
+	// ConfigWatermarkType 水印类型 (text: 文字 / image: PNG 图片)
+	ConfigWatermarkType = "watermark_type"
+
+	// ConfigWatermarkText 文字水印内容，支持 {site_name} 与 {username} 占位符
+	ConfigWatermarkText = "watermark_text"
+
+	// ConfigWatermarkImagePath 图片水印使用的 PNG 文件路径 (服务器本地路径)
+	ConfigWatermarkImagePath = "watermark_image_path"
+
+	// ConfigWatermarkPosition 水印位置 (top-left, top-right, bottom-left, bottom-right, center)
+	ConfigWatermarkPosition = "watermark_position"
+
+	// ConfigWatermarkOpacity 水印不透明度 (1-100)
+	ConfigWatermarkOpacity = "watermark_opacity"
+
+	// ConfigWatermarkScale 水印宽度占图片宽度的百分比 (1-100)
+	ConfigWatermarkScale = "watermark_scale"
+
+	// ConfigWatermarkMinSize 添加水印的最小图片边长 (像素, 宽或高小于该值时不添加)
+	ConfigWatermarkMinSize = "watermark_min_size"
+
+	// ConfigWatermarkAllowUserDisable 是否允许用户上传时关闭水印 (true/false)
+	ConfigWatermarkAllowUserDisable = "watermark_allow_user_disable"
+
 	// ConfigDefaultStorageQuota 默认存储配额 (字节)
 	ConfigDefaultStorageQuota = "default_storage_quota"
 
+	// ConfigDefaultImageTTL 上传图片的默认有效期 (秒, 0 表示永久保存)
+	ConfigDefaultImageTTL = "default_image_ttl"
+
+	// ConfigDefaultDailyUploadCount 每个用户每日最多上传的图片数 (0 表示不限制)
+	ConfigDefaultDailyUploadCount = "default_daily_upload_count"
+
+	// ConfigDefaultDailyUploadBytes 每个用户每日最多上传的字节数 (0 表示不限制)
+	ConfigDefaultDailyUploadBytes = "default_daily_upload_bytes"
+
+	// ConfigDefaultMonthlyEgressBytes 每个用户的图片每月最多被访问下载的字节数 (0 表示不限制)
+	ConfigDefaultMonthlyEgressBytes = "default_monthly_egress_bytes"
+
+	// ConfigImageTrashRetentionDays 回收站中图片的保留天数，超过后永久删除
+	ConfigImageTrashRetentionDays = "image_trash_retention_days"
+
+	// ConfigIntegrationDeleteLinkHours 兼容上传响应中删除链接的有效期（小时）
+	ConfigIntegrationDeleteLinkHours = "integration_delete_link_hours"
+
 	// ConfigRateLimitEnabled 是否开启限流
 	ConfigRateLimitEnabled = "rate_limit_enabled"
 
@@ -74,6 +143,27 @@ const (
 	// ConfigStaticCacheControl 静态资源缓存设置 (Cache-Control header value)
 	ConfigStaticCacheControl = "static_cache_control"
 
+	// ConfigShortLinkRedirect 短链接以 302 重定向到图片地址，而不是直接返回图片内容 (true/false)
+	ConfigShortLinkRedirect = "short_link_redirect"
+
+	// ConfigHotlinkEnabled 是否开启图片防盗链 (true/false)
+	ConfigHotlinkEnabled = "hotlink_enabled"
+
+	// ConfigHotlinkAllowedReferers 允许引用图片的来源域名 (逗号分隔，支持 *.example.com)
+	ConfigHotlinkAllowedReferers = "hotlink_allowed_referers"
+
+	// ConfigHotlinkAllowEmptyReferer 是否允许不携带 Referer/Origin 的请求 (true/false)
+	ConfigHotlinkAllowEmptyReferer = "hotlink_allow_empty_referer"
+
+	// ConfigHotlinkBlockAction 拦截盗链请求的方式 (forbidden: 返回 403 / placeholder: 返回占位图)
+	ConfigHotlinkBlockAction = "hotlink_block_action"
+
+	// ConfigHotlinkPlaceholderURL 占位图地址 (留空使用内置占位图)
+	ConfigHotlinkPlaceholderURL = "hotlink_placeholder_url"
+
+	// ConfigHotlinkExemptSignedURLs 携带有效签名的图片链接是否跳过防盗链检查 (true/false)
+	ConfigHotlinkExemptSignedURLs = "hotlink_exempt_signed_urls"
+
 	// ConfigCaptchaProvider 验证码提供方 (image, turnstile, recaptcha, hcaptcha, geetest)
 	ConfigCaptchaProvider = "captcha_provider"
 
@@ -0,0 +1,13 @@
+package consts
+
+const (
+	// ShortLinkPathPrefix 短链接的访问路径前缀。
+	ShortLinkPathPrefix = "/s/"
+
+	// ShortCodeLength 随机生成的短码长度（base62）。
+	ShortCodeLength = 7
+
+	// ShortCodeMinLength/ShortCodeMaxLength 管理员自定义短码的长度范围。
+	ShortCodeMinLength = 3
+	ShortCodeMaxLength = 32
+)
@@ -2,8 +2,11 @@ package di
 
 import (
 	"perfect-pic-server/internal/config"
+	"perfect-pic-server/internal/handler"
 	"perfect-pic-server/internal/middleware"
+	"perfect-pic-server/internal/pkg/storage"
 	"perfect-pic-server/internal/router"
+	"perfect-pic-server/internal/service"
 
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
@@ -16,9 +19,17 @@ type Application struct {
 	RedisDB               *redis.Client
 	StaticConfig          *config.Config
 	StaticCacheMiddleware *middleware.StaticCacheMiddleware
+	ImageVariant          *middleware.ImageVariantMiddleware
+	ImageAccess           *middleware.ImageAccessMiddleware
+	Hotlink               *middleware.HotlinkMiddleware
+	ShortLink             *middleware.ShortLinkMiddleware
+	ImageHandler          *handler.ImageHandler
+	ImageService          *service.ImageService
+	TusService            *service.TusService
+	Storage               *storage.Manager
 }
 
-func NewApplication(r *router.Router, dbConfig *config.DBConfig, gormDB *gorm.DB, redisDB *redis.Client, staticConfig *config.Config, staticCacheMiddleware *middleware.StaticCacheMiddleware) *Application {
+func NewApplication(r *router.Router, dbConfig *config.DBConfig, gormDB *gorm.DB, redisDB *redis.Client, staticConfig *config.Config, staticCacheMiddleware *middleware.StaticCacheMiddleware, imageVariant *middleware.ImageVariantMiddleware, imageAccess *middleware.ImageAccessMiddleware, hotlink *middleware.HotlinkMiddleware, shortLink *middleware.ShortLinkMiddleware, imageHandler *handler.ImageHandler, imageService *service.ImageService, tusService *service.TusService, storages *storage.Manager) *Application {
 	return &Application{
 		Router:                r,
 		DbConfig:              dbConfig,
@@ -26,5 +37,13 @@ func NewApplication(r *router.Router, dbConfig *config.DBConfig, gormDB *gorm.DB
 		RedisDB:               redisDB,
 		StaticConfig:          staticConfig,
 		StaticCacheMiddleware: staticCacheMiddleware,
+		ImageVariant:          imageVariant,
+		ImageAccess:           imageAccess,
+		Hotlink:               hotlink,
+		ShortLink:             shortLink,
+		ImageHandler:          imageHandler,
+		ImageService:          imageService,
+		TusService:            tusService,
+		Storage:               storages,
 	}
 }
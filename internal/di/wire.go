@@ -13,6 +13,9 @@ import (
 	jwtpkg "perfect-pic-server/internal/pkg/jwt"
 	"perfect-pic-server/internal/pkg/ratelimit"
 	"perfect-pic-server/internal/pkg/redis"
+	"perfect-pic-server/internal/pkg/remotefetch"
+	"perfect-pic-server/internal/pkg/storage"
+	"perfect-pic-server/internal/pkg/urlsign"
 	"perfect-pic-server/internal/repository"
 	"perfect-pic-server/internal/router"
 	"perfect-pic-server/internal/service"
@@ -29,9 +32,12 @@ func InitializeApplication() (*Application, error) {
 		database.NewGormDB,
 		redis.NewRedisClient,
 		jwtpkg.NewJWT,
+		urlsign.NewSigner,
+		remotefetch.NewFetcher,
 		cache.NewStore,
 		ratelimit.RateLimiter,
 		pkgmail.NewMailer,
+		storage.NewManager,
 		repository.RepoSet,
 		service.ServiceSet,
 		admin.AdminUseCaseSet,
@@ -16,6 +16,9 @@ import (
 	"perfect-pic-server/internal/pkg/jwt"
 	"perfect-pic-server/internal/pkg/ratelimit"
 	"perfect-pic-server/internal/pkg/redis"
+	"perfect-pic-server/internal/pkg/remotefetch"
+	"perfect-pic-server/internal/pkg/storage"
+	"perfect-pic-server/internal/pkg/urlsign"
 	"perfect-pic-server/internal/repository"
 	"perfect-pic-server/internal/router"
 	"perfect-pic-server/internal/service"
@@ -42,7 +45,9 @@ func InitializeApplication() (*Application, error) {
 	cacheConfig := config.NewCacheConfig(configConfig)
 	store := cache.NewStore(client, cacheConfig)
 	userService := service.NewUserService(userStore, dbConfig, store, jwtJWT)
-	authMiddleware := middleware.NewAuthMiddleware(jwtJWT, userService)
+	accessTokenStore := repository.NewAccessTokenRepository(db)
+	accessTokenService := service.NewAccessTokenService(accessTokenStore)
+	authMiddleware := middleware.NewAuthMiddleware(jwtJWT, userService, accessTokenService)
 	ratelimitConfig := config.NewRateLimiterConfig(configConfig)
 	baseRateLimiter := ratelimit.NewBaseRateLimiter(client, ratelimitConfig)
 	tokenBucketLimiter := ratelimit.NewTokenBucketLimiter(baseRateLimiter)
@@ -62,19 +67,36 @@ func InitializeApplication() (*Application, error) {
 	passkeyUseCase := app.NewPasskeyUseCase(passkeyService, passkeyStore, authService, userStore)
 	authHandler := handler.NewAuthHandler(authService, captchaService, authUseCase, initService, dbConfig, passkeyUseCase)
 	imageStore := repository.NewImageRepository(db)
-	statUseCase := admin.NewStatUseCase(imageStore, userStore)
-	systemHandler := handler.NewSystemHandler(initService, statUseCase, dbConfig, configConfig, userService)
+	storageConfig := config.NewStorageConfig(configConfig)
+	manager, err := storage.NewManager(storageConfig)
+	if err != nil {
+		return nil, err
+	}
+	urlsignConfig := config.NewURLSignConfig(configConfig)
+	signer := urlsign.NewSigner(urlsignConfig)
+	remotefetchConfig := config.NewRemoteFetchConfig(configConfig)
+	fetcher := remotefetch.NewFetcher(remotefetchConfig)
+	imageService := service.NewImageService(imageStore, dbConfig, configConfig, manager, signer, fetcher)
+	statUseCase := admin.NewStatUseCase(imageStore, userStore, imageService)
+	systemHandler := handler.NewSystemHandler(initService, statUseCase, dbConfig, configConfig, manager, userService, imageService)
 	settingsService := service.NewSettingsService(settingStore, dbConfig)
 	settingsUseCase := admin.NewSettingsUseCase(emailService)
 	settingsHandler := handler.NewSettingsHandler(settingsService, settingsUseCase)
 	userUseCase := app.NewUserUseCase(authService, userService, userStore, emailService, dbConfig)
-	imageService := service.NewImageService(imageStore, dbConfig, configConfig)
 	userManageUseCase := admin.NewUserManageUseCase(userService, imageService, passkeyService)
-	imageUseCase := app.NewImageUseCase(imageService, userService, userStore, configConfig, dbConfig)
-	userHandler := handler.NewUserHandler(userService, userUseCase, userManageUseCase, imageService, imageUseCase, authService, passkeyService, passkeyUseCase)
-	imageHandler := handler.NewImageHandler(imageService, imageUseCase)
+	tusService := service.NewTusService(dbConfig, configConfig, store)
+	imageUseCase := app.NewImageUseCase(imageService, tusService, userService, userStore, configConfig, dbConfig)
+	integrationUseCase := app.NewIntegrationUseCase(accessTokenService, dbConfig)
+	userHandler := handler.NewUserHandler(userService, userUseCase, userManageUseCase, imageService, imageUseCase, authService, passkeyService, passkeyUseCase, accessTokenService, integrationUseCase)
+	albumStore := repository.NewAlbumRepository(db)
+	albumService := service.NewAlbumService(albumStore, imageStore)
+	imageHandler := handler.NewImageHandler(imageService, imageUseCase, albumService)
 	routerRouter := router.NewRouter(authMiddleware, rateLimitMiddleware, bodyLimitMiddleware, securityHeadersMiddleware, authHandler, systemHandler, settingsHandler, userHandler, imageHandler)
 	staticCacheMiddleware := middleware.NewStaticCacheMiddleware(dbConfig)
-	application := NewApplication(routerRouter, dbConfig, db, client, configConfig, staticCacheMiddleware)
+	imageVariantMiddleware := middleware.NewImageVariantMiddleware(imageService)
+	imageAccessMiddleware := middleware.NewImageAccessMiddleware(jwtJWT, imageService, userService)
+	hotlinkMiddleware := middleware.NewHotlinkMiddleware(dbConfig, imageService)
+	shortLinkMiddleware := middleware.NewShortLinkMiddleware(dbConfig, imageService)
+	application := NewApplication(routerRouter, dbConfig, db, client, configConfig, staticCacheMiddleware, imageVariantMiddleware, imageAccessMiddleware, hotlinkMiddleware, shortLinkMiddleware, imageHandler, imageService, tusService, manager)
 	return application, nil
 }
@@ -0,0 +1,31 @@
+package dto
+
+import "perfect-pic-server/internal/model"
+
+type CreateAlbumRequest struct {
+	Title       string `json:"title" binding:"required"`
+	Description string `json:"description"`
+	SortOrder   int    `json:"sort_order"`
+	Visibility  string `json:"visibility"`
+}
+
+// UpdateAlbumRequest 相册更新参数，为 nil 的字段保持不变；CoverImageID 为 0 表示清除封面。
+type UpdateAlbumRequest struct {
+	Title        *string `json:"title"`
+	Description  *string `json:"description"`
+	CoverImageID *uint   `json:"cover_image_id"`
+	SortOrder    *int    `json:"sort_order"`
+	Visibility   *string `json:"visibility"`
+}
+
+// AlbumImagesRequest 批量加入/移出相册的图片 ID。
+type AlbumImagesRequest struct {
+	IDs []uint `json:"ids" binding:"required"`
+}
+
+// AlbumResponse 相册信息，包含图片数量与封面图片。
+type AlbumResponse struct {
+	model.Album
+	ImageCount int64        `json:"image_count"`
+	Cover      *model.Image `json:"cover,omitempty"`
+}
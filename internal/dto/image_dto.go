@@ -1,6 +1,9 @@
 package dto
 
-import "perfect-pic-server/internal/model"
+import (
+	"perfect-pic-server/internal/model"
+	"time"
+)
 
 type PaginationRequest struct {
 	Page     int
@@ -12,15 +15,150 @@ type ImageResponse struct {
 	Username string `json:"username"`
 }
 
+// ImageUploadResult 图片上传结果；Duplicate 为 true 表示用户已上传过相同内容，Image 为已有记录。
+type ImageUploadResult struct {
+	Image *model.Image
+	URL   string
+	// ShortURL 短链接（站内相对地址），短码生成失败时为空
+	ShortURL  string
+	Duplicate bool
+}
+
+// ImageBatchUploadItem 批量上传中单个文件的处理结果，Err 非空表示该文件上传失败。
+type ImageBatchUploadItem struct {
+	Filename string
+	Result   *ImageUploadResult
+	Err      error
+}
+
+// ImageUploadInfo 上传时随文件提交的可选描述信息。
+type ImageUploadInfo struct {
+	Title       string
+	Description string
+	// Visibility 为空时视为 public
+	Visibility string
+	// DisableWatermark 请求跳过水印，仅在管理员允许用户关闭水印时可用
+	DisableWatermark bool
+	// Username 上传者用户名，由服务端填写，用于文字水印的 {username} 占位符
+	Username string
+	// ExpiresIn 有效期（秒），ExpiresAt 过期时间（Unix 秒），二者最多提供一个；均为 0 时使用默认有效期
+	ExpiresIn int64
+	ExpiresAt int64
+	// DefaultTTL 上传者的默认有效期（秒），由服务端按用户设置填写，为空时使用系统默认值
+	DefaultTTL *int64
+}
+
+// UploadImageByURLRequest 按地址上传图片：URL 为 http/https 地址或 data:image/...;base64, 形式的 data URI。
+// Filename 仅对 data URI 生效；Watermark 为 false 表示请求关闭水印。
+type UploadImageByURLRequest struct {
+	URL         string `json:"url" binding:"required"`
+	Filename    string `json:"filename"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Visibility  string `json:"visibility"`
+	Watermark   *bool  `json:"watermark"`
+	ExpiresIn   int64  `json:"expires_in"`
+	ExpiresAt   int64  `json:"expires_at"`
+}
+
+// TusUpload 可续传上传（tus）的进度状态，以 JSON 形式保存在缓存中；Metadata 为客户端提交的原始 Upload-Metadata。
+type TusUpload struct {
+	ID        string `json:"id"`
+	UserID    uint   `json:"user_id"`
+	Length    int64  `json:"length"`
+	Offset    int64  `json:"offset"`
+	Metadata  string `json:"metadata"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// UpdateImageInfoRequest 修改图片描述信息，未提供的字段保持不变。
+type UpdateImageInfoRequest struct {
+	OriginalName *string `json:"original_name"`
+	Title        *string `json:"title"`
+	Description  *string `json:"description"`
+	Visibility   *string `json:"visibility"`
+}
+
+// CreateSignedURLRequest 生成签名链接；ExpiresIn 为有效期（秒），为 0 时使用默认值。
+type CreateSignedURLRequest struct {
+	ExpiresIn int64 `json:"expires_in"`
+}
+
+// SignedURLResponse 签名链接及其过期时间（Unix 秒）。
+type SignedURLResponse struct {
+	URL       string `json:"url"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
 type BatchDeleteImagesRequest struct {
 	IDs []uint `json:"ids" binding:"required"`
 }
 
+// RestoreImagesRequest 从回收站恢复图片。
+type RestoreImagesRequest struct {
+	IDs []uint `json:"ids" binding:"required"`
+}
+
+// TrashedImageResponse 回收站中的图片；DeletedAt 为移入回收站的时间，PurgeAt 为将被永久删除的时间（Unix 秒）。
+type TrashedImageResponse struct {
+	model.Image
+	URL       string `json:"url"`
+	DeletedAt int64  `json:"deleted_at"`
+	PurgeAt   int64  `json:"purge_at"`
+}
+
 type ListImagesRequest struct {
 	PaginationRequest
-	UserID      *uint
-	Username    string
-	Filename    string
-	ID          *uint
-	PreloadUser bool
+	UserID   *uint
+	Username string
+	Filename string
+	ID       *uint
+	AlbumID  *uint
+	// Search 全文检索关键词（匹配标签等可检索字段），Tag 按标签名精确过滤
+	Search string
+	Tag    string
+	// TakenFrom 拍摄时间下界（含），TakenTo 拍摄时间上界（不含）
+	TakenFrom *time.Time
+	TakenTo   *time.Time
+	// SortBy 排序字段：uploaded_at（默认）/ taken_at / views；SortOrder：desc（默认）/ asc
+	SortBy          string
+	SortOrder       string
+	PreloadUser     bool
+	PreloadMetadata bool
+	PreloadTags     bool
+}
+
+// ImageDetailResponse 图片详情，包含访问地址与拍摄信息。
+type ImageDetailResponse struct {
+	model.Image
+	URL      string `json:"url"`
+	ShortURL string `json:"short_url,omitempty"`
+}
+
+// ImageStatsResponse 图片最近 Days 天的访问统计：Daily 按日期升序列出每天的访问次数（无访问的日期为 0），
+// Referrers 为区间内访问次数最多的来源站点；TotalViews 为图片的累计访问次数。
+type ImageStatsResponse struct {
+	ImageID    uint                      `json:"image_id"`
+	TotalViews int64                     `json:"total_views"`
+	Days       int                       `json:"days"`
+	Daily      []model.ImageViewStat     `json:"daily"`
+	Referrers  []model.ImageReferrerStat `json:"referrers"`
+}
+
+// SetImageShortCodeRequest 管理员为图片设置自定义短码，留空表示重新生成随机短码。
+type SetImageShortCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// ImageVariantRequest 图片变体（缩略图）请求参数，对应 /imgs/...?w=&h=&fit=&fmt=
+type ImageVariantRequest struct {
+	Width  int    `form:"w"`
+	Height int    `form:"h"`
+	Fit    string `form:"fit"`
+	Format string `form:"fmt"`
+}
+
+// SetImageTagsRequest 整体替换图片标签，传空数组表示清空。
+type SetImageTagsRequest struct {
+	Tags []string `json:"tags" binding:"required"`
 }
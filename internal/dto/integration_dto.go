@@ -0,0 +1,50 @@
+package dto
+
+// IntegrationUploadResponse 兼容上传接口的响应，字段供 PicGo（jsonPath）与 ShareX（{json:...}）直接引用，地址均为绝对地址。
+type IntegrationUploadResponse struct {
+	ID           uint   `json:"id"`
+	Filename     string `json:"filename"`
+	URL          string `json:"url"`
+	ShortURL     string `json:"short_url,omitempty"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	DeleteURL    string `json:"delete_url"`
+	Duplicate    bool   `json:"duplicate"`
+	ExpiresAt    *int64 `json:"expires_at,omitempty"`
+}
+
+// ShareXUploaderConfig ShareX 自定义上传器配置（.sxcu 文件）。
+type ShareXUploaderConfig struct {
+	Version         string            `json:"Version"`
+	Name            string            `json:"Name"`
+	DestinationType string            `json:"DestinationType"`
+	RequestMethod   string            `json:"RequestMethod"`
+	RequestURL      string            `json:"RequestURL"`
+	Headers         map[string]string `json:"Headers"`
+	Body            string            `json:"Body"`
+	FileFormName    string            `json:"FileFormName"`
+	URL             string            `json:"URL"`
+	ThumbnailURL    string            `json:"ThumbnailURL"`
+	DeletionURL     string            `json:"DeletionURL"`
+	ErrorMessage    string            `json:"ErrorMessage"`
+}
+
+// PicGoWebUploaderConfig picgo-plugin-web-uploader 插件的配置项；customHeader 与 customBody 为 JSON 字符串。
+type PicGoWebUploaderConfig struct {
+	URL          string `json:"url"`
+	ParamName    string `json:"paramName"`
+	JSONPath     string `json:"jsonPath"`
+	CustomHeader string `json:"customHeader"`
+	CustomBody   string `json:"customBody"`
+}
+
+// PicGoConfig PicGo / PicGo-Core 配置文件（Typora 通过 PicGo 上传时共用），使用 web-uploader 插件作为图床。
+type PicGoConfig struct {
+	PicBed       PicGoPicBedConfig `json:"picBed"`
+	PicGoPlugins map[string]bool   `json:"picgoPlugins"`
+}
+
+type PicGoPicBedConfig struct {
+	Uploader    string                 `json:"uploader"`
+	Current     string                 `json:"current"`
+	WebUploader PicGoWebUploaderConfig `json:"web-uploader"`
+}
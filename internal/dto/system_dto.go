@@ -16,8 +16,46 @@ type SystemInfoResponse struct {
 }
 
 type ServerStatsResponse struct {
-	ImageCount   int64              `json:"image_count"`
-	StorageUsage int64              `json:"storage_usage"`
-	UserCount    int64              `json:"user_count"`
-	SystemInfo   SystemInfoResponse `json:"system_info"`
+	ImageCount   int64 `json:"image_count"`
+	StorageUsage int64 `json:"storage_usage"`
+	UserCount    int64 `json:"user_count"`
+	// ShortLinkCollisions 自服务启动以来生成随机短码时的冲突次数
+	ShortLinkCollisions int64              `json:"short_link_collisions"`
+	SystemInfo          SystemInfoResponse `json:"system_info"`
+}
+
+// StorageReconcileReport 存储对账报告。DryRun 为 false 时列出的问题均已尝试修复，修复失败的项记录在 Errors 中。
+type StorageReconcileReport struct {
+	DryRun        bool                 `json:"dry_run"`
+	ScannedFiles  int                  `json:"scanned_files"`
+	ScannedImages int                  `json:"scanned_images"`
+	OrphanFiles   []StorageOrphanFile  `json:"orphan_files"`
+	OrphanSize    int64                `json:"orphan_size"`
+	MissingFiles  []StorageMissingFile `json:"missing_files"`
+	StorageDrift  []StorageDrift       `json:"storage_drift"`
+	Errors        []string             `json:"errors,omitempty"`
+}
+
+// StorageOrphanFile 没有任何记录引用的文件；Storage 为 images 或 avatars。
+type StorageOrphanFile struct {
+	Storage    string `json:"storage"`
+	Key        string `json:"key"`
+	Size       int64  `json:"size"`
+	ModifiedAt int64  `json:"modified_at"`
+}
+
+// StorageMissingFile 记录存在但文件缺失的项；Kind 为 image（原图）、alternate（备用格式）或 avatar（头像）。
+type StorageMissingFile struct {
+	Kind    string `json:"kind"`
+	Key     string `json:"key"`
+	ImageID uint   `json:"image_id,omitempty"`
+	UserID  uint   `json:"user_id"`
+}
+
+// StorageDrift 用户记录的已用空间与按图片记录统计的实际占用不一致。
+type StorageDrift struct {
+	UserID   uint   `json:"user_id"`
+	Username string `json:"username"`
+	Recorded int64  `json:"recorded"`
+	Actual   int64  `json:"actual"`
 }
@@ -31,13 +31,26 @@ type EmailVerifyRedisPayload struct {
 }
 
 type UserProfileResponse struct {
-	ID           uint   `json:"id"`
-	Username     string `json:"username"`
-	Email        string `json:"email"`
-	Avatar       string `json:"avatar"`
-	Admin        bool   `json:"admin"`
-	StorageQuota *int64 `json:"storage_quota"`
-	StorageUsed  int64  `json:"storage_used"`
+	ID           uint          `json:"id"`
+	Username     string        `json:"username"`
+	Email        string        `json:"email"`
+	Avatar       string        `json:"avatar"`
+	Admin        bool          `json:"admin"`
+	StorageQuota *int64        `json:"storage_quota"`
+	StorageUsed  int64         `json:"storage_used"`
+	ImageTTL     *int64        `json:"image_ttl"`
+	Usage        UserUsageInfo `json:"usage"`
+}
+
+// UserUsageInfo 用户当前生效的用量限额与本周期已用量；限额为 0 表示不限制。
+// 每日计数按服务器本地时区的自然日重置，每月流量按自然月重置。
+type UserUsageInfo struct {
+	DailyUploadCountLimit   int64 `json:"daily_upload_count_limit"`
+	DailyUploadCountUsed    int64 `json:"daily_upload_count_used"`
+	DailyUploadBytesLimit   int64 `json:"daily_upload_bytes_limit"`
+	DailyUploadBytesUsed    int64 `json:"daily_upload_bytes_used"`
+	MonthlyEgressBytesLimit int64 `json:"monthly_egress_bytes_limit"`
+	MonthlyEgressBytesUsed  int64 `json:"monthly_egress_bytes_used"`
 }
 
 type UserListRequest struct {
@@ -63,7 +76,13 @@ type CreateUserRequest struct {
 	Email         *string `json:"email"`
 	EmailVerified *bool   `json:"email_verified"`
 	StorageQuota  *int64  `json:"storage_quota"`
+	ImageTTL      *int64  `json:"image_ttl"`
 	Status        *int    `json:"status"`
+
+	// 用量限额：-1 表示恢复系统默认值，0 表示不限制
+	DailyUploadCount   *int64 `json:"daily_upload_count"`
+	DailyUploadBytes   *int64 `json:"daily_upload_bytes"`
+	MonthlyEgressBytes *int64 `json:"monthly_egress_bytes"`
 }
 
 type UpdateUserRequest struct {
@@ -72,7 +91,13 @@ type UpdateUserRequest struct {
 	Email         *string `json:"email"`
 	EmailVerified *bool   `json:"email_verified"`
 	StorageQuota  *int64  `json:"storage_quota"`
+	ImageTTL      *int64  `json:"image_ttl"`
 	Status        *int    `json:"status"`
+
+	// 用量限额：-1 表示恢复系统默认值，0 表示不限制
+	DailyUploadCount   *int64 `json:"daily_upload_count"`
+	DailyUploadBytes   *int64 `json:"daily_upload_bytes"`
+	MonthlyEgressBytes *int64 `json:"monthly_egress_bytes"`
 }
 
 type UpdateSelfUsernameRequest struct {
@@ -97,3 +122,29 @@ type FinishPasskeyRegistrationRequest struct {
 type UpdatePasskeyNameRequest struct {
 	Name string `json:"name" binding:"required"`
 }
+
+// CreateAccessTokenRequest 创建个人访问令牌；ExpiresInDays 为 0 表示永不过期。
+type CreateAccessTokenRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	Scopes        []string `json:"scopes" binding:"required"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+// AccessTokenResponse 个人访问令牌信息，时间字段为 Unix 秒，未设置时为 null。
+type AccessTokenResponse struct {
+	ID         uint     `json:"id"`
+	Name       string   `json:"name"`
+	TokenHint  string   `json:"token_hint"`
+	Scopes     []string `json:"scopes"`
+	Source     string   `json:"source"`
+	CreatedAt  int64    `json:"created_at"`
+	ExpiresAt  *int64   `json:"expires_at"`
+	LastUsedAt *int64   `json:"last_used_at"`
+	LastUsedIP string   `json:"last_used_ip"`
+}
+
+// CreatedAccessTokenResponse 创建令牌的响应，Token 明文仅在此时返回一次。
+type CreatedAccessTokenResponse struct {
+	AccessTokenResponse
+	Token string `json:"token"`
+}
@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"math"
+	"net/http"
+	"perfect-pic-server/internal/common/httpx"
+	moduledto "perfect-pic-server/internal/dto"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxAlbumBatchSize 单次加入/移出相册的图片数量上限，与批量删除保持一致。
+const maxAlbumBatchSize = 50
+
+// ListMyAlbums 分页获取当前用户的相册列表
+func (h *ImageHandler) ListMyAlbums(c *gin.Context) {
+	userID, _ := c.Get("id")
+	uid, ok := userID.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的用户ID类型"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+
+	albums, total, page, pageSize, err := h.albumService.ListAlbums(uid, page, pageSize)
+	if err != nil {
+		httpx.WriteServiceError(c, err, "获取相册列表失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"list":      albums,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// CreateMyAlbum 创建相册
+func (h *ImageHandler) CreateMyAlbum(c *gin.Context) {
+	userID, _ := c.Get("id")
+	uid, ok := userID.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的用户ID类型"})
+		return
+	}
+
+	var req moduledto.CreateAlbumRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	album, err := h.albumService.CreateAlbum(uid, req)
+	if err != nil {
+		httpx.WriteServiceError(c, err, "创建相册失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, album)
+}
+
+// GetMyAlbum 获取相册详情
+func (h *ImageHandler) GetMyAlbum(c *gin.Context) {
+	uid, albumID, ok := albumRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	album, err := h.albumService.GetAlbum(uid, albumID)
+	if err != nil {
+		httpx.WriteServiceError(c, err, "获取相册失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, album)
+}
+
+// UpdateMyAlbum 更新相册标题、描述、封面、排序与可见性
+func (h *ImageHandler) UpdateMyAlbum(c *gin.Context) {
+	uid, albumID, ok := albumRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	var req moduledto.UpdateAlbumRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	album, err := h.albumService.UpdateAlbum(uid, albumID, req)
+	if err != nil {
+		httpx.WriteServiceError(c, err, "更新相册失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, album)
+}
+
+// DeleteMyAlbum 删除相册（不删除相册内的图片）
+func (h *ImageHandler) DeleteMyAlbum(c *gin.Context) {
+	uid, albumID, ok := albumRequestIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := h.albumService.DeleteAlbum(uid, albumID); err != nil {
+		httpx.WriteServiceError(c, err, "删除相册失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+}
+
+// AddMyAlbumImages 批量将图片加入相册
+func (h *ImageHandler) AddMyAlbumImages(c *gin.Context) {
+	uid, albumID, ok := albumRequestIDs(c)
+	if !ok {
+		return
+	}
+	ids, ok := bindAlbumImageIDs(c)
+	if !ok {
+		return
+	}
+
+	added, err := h.albumService.AddAlbumImages(uid, albumID, ids)
+	if err != nil {
+		httpx.WriteServiceError(c, err, "加入相册失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "加入成功", "added_count": added})
+}
+
+// RemoveMyAlbumImages 批量将图片移出相册
+func (h *ImageHandler) RemoveMyAlbumImages(c *gin.Context) {
+	uid, albumID, ok := albumRequestIDs(c)
+	if !ok {
+		return
+	}
+	ids, ok := bindAlbumImageIDs(c)
+	if !ok {
+		return
+	}
+
+	removed, err := h.albumService.RemoveAlbumImages(uid, albumID, ids)
+	if err != nil {
+		httpx.WriteServiceError(c, err, "移出相册失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "移出成功", "removed_count": removed})
+}
+
+// albumRequestIDs 读取当前用户 ID 与路径中的相册 ID，失败时直接写入错误响应并返回 false。
+func albumRequestIDs(c *gin.Context) (uint, uint, bool) {
+	userID, _ := c.Get("id")
+	uid, ok := userID.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的用户ID类型"})
+		return 0, 0, false
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 || id > math.MaxUint {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id 参数错误"})
+		return 0, 0, false
+	}
+	return uid, uint(id), true
+}
+
+// bindAlbumImageIDs 解析批量操作的图片 ID 列表，校验数量上限。
+func bindAlbumImageIDs(c *gin.Context) ([]uint, bool) {
+	var req moduledto.AlbumImagesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return nil, false
+	}
+
+	if len(req.IDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请选择图片"})
+		return nil, false
+	}
+
+	if len(req.IDs) > maxAlbumBatchSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "一次最多只能操作 50 张图片"})
+		return nil, false
+	}
+	return req.IDs, true
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"perfect-pic-server/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 测试内容：验证相册的创建、加入图片、按相册过滤图片列表、更新与删除接口流程。
+func TestAlbumHandlers_Flow(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDB(t)
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+	img := model.Image{Filename: "a.png", Path: "a.png", Size: 1, Width: 1, Height: 1, MimeType: ".png", UploadedAt: 1, UserID: u.ID}
+	_ = testGormDB.Create(&img).Error
+	other := model.Image{Filename: "b.png", Path: "b.png", Size: 1, Width: 1, Height: 1, MimeType: ".png", UploadedAt: 1, UserID: u.ID}
+	_ = testGormDB.Create(&other).Error
+
+	auth := func(c *gin.Context) { c.Set("id", u.ID); c.Next() }
+	r := gin.New()
+	r.GET("/albums", auth, testHandler.ListMyAlbums)
+	r.POST("/albums", auth, testHandler.CreateMyAlbum)
+	r.GET("/albums/:id", auth, testHandler.GetMyAlbum)
+	r.PATCH("/albums/:id", auth, testHandler.UpdateMyAlbum)
+	r.DELETE("/albums/:id", auth, testHandler.DeleteMyAlbum)
+	r.POST("/albums/:id/images", auth, testHandler.AddMyAlbumImages)
+	r.DELETE("/albums/:id/images", auth, testHandler.RemoveMyAlbumImages)
+	r.GET("/images", auth, testHandler.GetMyImages)
+
+	do := func(method, target string, body any) *httptest.ResponseRecorder {
+		var data []byte
+		if body != nil {
+			data, _ = json.Marshal(body)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(method, target, bytes.NewReader(data)))
+		return rec
+	}
+
+	rec := do(http.MethodPost, "/albums", gin.H{"title": "旅行"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("create 期望 200，实际为 %d body=%s", rec.Code, rec.Body.String())
+	}
+	var album struct {
+		ID uint `json:"id"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &album)
+	base := "/albums/" + strconv.FormatUint(uint64(album.ID), 10)
+
+	if rec := do(http.MethodPost, base+"/images", gin.H{"ids": []uint{img.ID}}); rec.Code != http.StatusOK {
+		t.Fatalf("add 期望 200，实际为 %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	rec = do(http.MethodGet, "/images?album_id="+strconv.FormatUint(uint64(album.ID), 10), nil)
+	var list struct {
+		Total int64 `json:"total"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &list)
+	if rec.Code != http.StatusOK || list.Total != 1 {
+		t.Fatalf("按相册过滤期望 1 张图片，实际为 %d body=%s", rec.Code, rec.Body.String())
+	}
+	if rec := do(http.MethodGet, "/images?album_id=abc", nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("非法 album_id 期望 400，实际为 %d", rec.Code)
+	}
+
+	rec = do(http.MethodPatch, base, gin.H{"cover_image_id": img.ID, "visibility": "public"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("update 期望 200，实际为 %d body=%s", rec.Code, rec.Body.String())
+	}
+	rec = do(http.MethodPatch, base, gin.H{"cover_image_id": other.ID})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("相册外封面期望 400，实际为 %d", rec.Code)
+	}
+
+	rec = do(http.MethodGet, "/albums", nil)
+	var albums struct {
+		List []struct {
+			ImageCount int64  `json:"image_count"`
+			Visibility string `json:"visibility"`
+			Cover      *struct {
+				ID uint `json:"id"`
+			} `json:"cover"`
+		} `json:"list"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &albums)
+	if len(albums.List) != 1 || albums.List[0].ImageCount != 1 || albums.List[0].Visibility != "public" || albums.List[0].Cover == nil || albums.List[0].Cover.ID != img.ID {
+		t.Fatalf("非预期相册列表: %s", rec.Body.String())
+	}
+
+	if rec := do(http.MethodDelete, base+"/images", gin.H{"ids": []uint{img.ID}}); rec.Code != http.StatusOK {
+		t.Fatalf("remove 期望 200，实际为 %d", rec.Code)
+	}
+	if rec := do(http.MethodDelete, base, nil); rec.Code != http.StatusOK {
+		t.Fatalf("delete 期望 200，实际为 %d", rec.Code)
+	}
+	if rec := do(http.MethodGet, base, nil); rec.Code != http.StatusNotFound {
+		t.Fatalf("删除后期望 404，实际为 %d", rec.Code)
+	}
+}
+
+// 测试内容：验证批量加入相册的参数校验（空列表、超过 50 个、非法相册 ID）。
+func TestAddMyAlbumImages_Validation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDB(t)
+
+	r := gin.New()
+	r.POST("/albums/:id/images", func(c *gin.Context) { c.Set("id", uint(1)); c.Next() }, testHandler.AddMyAlbumImages)
+
+	ids := make([]uint, 51)
+	for i := range ids {
+		ids[i] = uint(i + 1)
+	}
+	tooMany, _ := json.Marshal(gin.H{"ids": ids})
+	empty, _ := json.Marshal(gin.H{"ids": []uint{}})
+
+	cases := []struct {
+		target string
+		body   []byte
+	}{
+		{"/albums/1/images", tooMany},
+		{"/albums/1/images", empty},
+		{"/albums/0/images", empty},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, tc.target, bytes.NewReader(tc.body)))
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s (%d bytes): 期望 400，实际为 %d", tc.target, len(tc.body), rec.Code)
+		}
+	}
+}
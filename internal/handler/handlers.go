@@ -2,6 +2,7 @@ package handler
 
 import (
 	"perfect-pic-server/internal/config"
+	"perfect-pic-server/internal/pkg/storage"
 	"perfect-pic-server/internal/service"
 	"perfect-pic-server/internal/usecase/admin"
 	"perfect-pic-server/internal/usecase/app"
@@ -19,19 +20,22 @@ type AuthHandler struct {
 }
 
 type UserHandler struct {
-	userService       *service.UserService
-	userUseCase       *app.UserUseCase
-	userManageUseCase *admin.UserManageUseCase
-	imageService      *service.ImageService
-	imageUseCase      *app.ImageUseCase
-	authService       *service.AuthService
-	passkeyService    *service.PasskeyService
-	passkeyUseCase    *app.PasskeyUseCase
+	userService        *service.UserService
+	userUseCase        *app.UserUseCase
+	userManageUseCase  *admin.UserManageUseCase
+	imageService       *service.ImageService
+	imageUseCase       *app.ImageUseCase
+	authService        *service.AuthService
+	passkeyService     *service.PasskeyService
+	passkeyUseCase     *app.PasskeyUseCase
+	tokenService       *service.AccessTokenService
+	integrationUseCase *app.IntegrationUseCase
 }
 
 type ImageHandler struct {
 	imageService *service.ImageService
 	imageUseCase *app.ImageUseCase
+	albumService *service.AlbumService
 }
 
 type SystemHandler struct {
@@ -39,7 +43,9 @@ type SystemHandler struct {
 	statUseCase  *admin.StatUseCase
 	dbConfig     *config.DBConfig
 	staticConfig *config.Config
+	storage      *storage.Manager
 	userService  *service.UserService
+	imageService *service.ImageService
 }
 
 type SettingsHandler struct {
@@ -74,21 +80,25 @@ func NewUserHandler(
 	authService *service.AuthService,
 	passkeyService *service.PasskeyService,
 	passkeyUseCase *app.PasskeyUseCase,
+	tokenService *service.AccessTokenService,
+	integrationUseCase *app.IntegrationUseCase,
 ) *UserHandler {
 	return &UserHandler{
-		userService:       userService,
-		userUseCase:       userUseCase,
-		userManageUseCase: userManageUseCase,
-		imageService:      imageService,
-		imageUseCase:      imageUseCase,
-		authService:       authService,
-		passkeyService:    passkeyService,
-		passkeyUseCase:    passkeyUseCase,
+		userService:        userService,
+		userUseCase:        userUseCase,
+		userManageUseCase:  userManageUseCase,
+		imageService:       imageService,
+		imageUseCase:       imageUseCase,
+		authService:        authService,
+		passkeyService:     passkeyService,
+		passkeyUseCase:     passkeyUseCase,
+		tokenService:       tokenService,
+		integrationUseCase: integrationUseCase,
 	}
 }
 
-func NewImageHandler(imageService *service.ImageService, imageUseCase *app.ImageUseCase) *ImageHandler {
-	return &ImageHandler{imageService: imageService, imageUseCase: imageUseCase}
+func NewImageHandler(imageService *service.ImageService, imageUseCase *app.ImageUseCase, albumService *service.AlbumService) *ImageHandler {
+	return &ImageHandler{imageService: imageService, imageUseCase: imageUseCase, albumService: albumService}
 }
 
 func NewSystemHandler(
@@ -96,13 +106,17 @@ func NewSystemHandler(
 	statUseCase *admin.StatUseCase,
 	dbConfig *config.DBConfig,
 	staticConfig *config.Config,
-	userService *service.UserService) *SystemHandler {
+	storages *storage.Manager,
+	userService *service.UserService,
+	imageService *service.ImageService) *SystemHandler {
 	return &SystemHandler{
 		initService:  initService,
 		statUseCase:  statUseCase,
 		dbConfig:     dbConfig,
 		staticConfig: staticConfig,
+		storage:      storages,
 		userService:  userService,
+		imageService: imageService,
 	}
 }
 
@@ -1,13 +1,20 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"math"
 	"net/http"
 	platformservice "perfect-pic-server/internal/common"
 	"perfect-pic-server/internal/common/httpx"
 	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/model"
+	"perfect-pic-server/internal/service"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -31,7 +38,124 @@ func (h *ImageHandler) UploadImage(c *gin.Context) {
 		return
 	}
 
-	imageRecord, url, err := h.imageUseCase.ProcessImageUpload(file, uid)
+	info, err := uploadInfoFromForm(c)
+	if err != nil {
+		httpx.WriteServiceError(c, err, "参数错误")
+		return
+	}
+	result, err := h.imageUseCase.ProcessImageUpload(file, uid, info)
+	writeUploadResult(c, result, err)
+}
+
+// BatchUploadImages 单次请求上传多个文件（重复的 file 字段），标题等表单字段对所有文件生效。
+// 部分文件失败时其余文件仍会保存，响应中按提交顺序返回每个文件的结果。
+func (h *ImageHandler) BatchUploadImages(c *gin.Context) {
+	form, err := c.MultipartForm()
+	if err != nil || len(form.File["file"]) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请选择文件"})
+		return
+	}
+
+	userID, _ := c.Get("id")
+	uid, ok := userID.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的用户ID类型"})
+		return
+	}
+
+	info, err := uploadInfoFromForm(c)
+	if err != nil {
+		httpx.WriteServiceError(c, err, "参数错误")
+		return
+	}
+	items, err := h.imageUseCase.ProcessImageBatchUpload(form.File["file"], uid, info)
+	if err != nil {
+		httpx.WriteServiceError(c, err, "上传失败，请稍后重试")
+		return
+	}
+
+	succeeded := 0
+	results := make([]gin.H, 0, len(items))
+	for _, item := range items {
+		entry := gin.H{"filename": item.Filename}
+		if item.Err != nil {
+			message := "上传失败，请稍后重试"
+			if serviceErr, ok := platformservice.AsServiceError(item.Err); ok {
+				message = serviceErr.Message
+			} else {
+				log.Printf("Batch upload %s failed: %v", item.Filename, item.Err)
+			}
+			entry["error"] = message
+		} else {
+			succeeded++
+			entry["id"] = item.Result.Image.ID
+			entry["url"] = item.Result.URL
+			entry["short_url"] = item.Result.ShortURL
+			entry["duplicate"] = item.Result.Duplicate
+			entry["expires_at"] = item.Result.Image.ExpiresAt
+		}
+		results = append(results, entry)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"msg":       fmt.Sprintf("上传完成：成功 %d 个，失败 %d 个", succeeded, len(items)-succeeded),
+		"succeeded": succeeded,
+		"failed":    len(items) - succeeded,
+		"results":   results,
+	})
+}
+
+// uploadInfoFromForm 读取随上传表单提交的描述信息与有效期。
+// watermark=false 表示请求关闭水印，其它取值或未提交均按系统设置处理。
+func uploadInfoFromForm(c *gin.Context) (moduledto.ImageUploadInfo, error) {
+	expiresIn, expiresAt, err := service.ParseUploadExpiry(c.PostForm("expires_in"), c.PostForm("expires_at"))
+	if err != nil {
+		return moduledto.ImageUploadInfo{}, err
+	}
+	disableWatermark := false
+	if raw := c.PostForm("watermark"); raw != "" {
+		if enabled, err := strconv.ParseBool(raw); err == nil && !enabled {
+			disableWatermark = true
+		}
+	}
+	return moduledto.ImageUploadInfo{
+		Title:            c.PostForm("title"),
+		Description:      c.PostForm("description"),
+		Visibility:       c.PostForm("visibility"),
+		DisableWatermark: disableWatermark,
+		ExpiresIn:        expiresIn,
+		ExpiresAt:        expiresAt,
+	}, nil
+}
+
+// UploadImageByURL 按远程地址或 data URI 上传图片（JSON 请求体）。
+func (h *ImageHandler) UploadImageByURL(c *gin.Context) {
+	userID, _ := c.Get("id")
+	uid, ok := userID.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的用户ID类型"})
+		return
+	}
+
+	var req moduledto.UploadImageByURLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	result, err := h.imageUseCase.ProcessImageURLUpload(c.Request.Context(), uid, req.URL, req.Filename, moduledto.ImageUploadInfo{
+		Title:            req.Title,
+		Description:      req.Description,
+		Visibility:       req.Visibility,
+		DisableWatermark: req.Watermark != nil && !*req.Watermark,
+		ExpiresIn:        req.ExpiresIn,
+		ExpiresAt:        req.ExpiresAt,
+	})
+	writeUploadResult(c, result, err)
+}
+
+// writeUploadResult 输出上传结果，两种上传方式共用同一响应格式。
+func writeUploadResult(c *gin.Context, result *moduledto.ImageUploadResult, err error) {
 	if err != nil {
 		if _, ok := platformservice.AsServiceError(err); !ok {
 			log.Printf("Upload failed: %v", err)
@@ -41,9 +165,12 @@ func (h *ImageHandler) UploadImage(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"msg": "上传成功",
-		"url": url,
-		"id":  imageRecord.ID,
+		"msg":        "上传成功",
+		"url":        result.URL,
+		"short_url":  result.ShortURL,
+		"id":         result.Image.ID,
+		"duplicate":  result.Duplicate,
+		"expires_at": result.Image.ExpiresAt,
 	})
 }
 
@@ -81,14 +208,39 @@ func (h *ImageHandler) GetMyImages(c *gin.Context) {
 		imageID = &id
 	}
 
+	var albumID *uint
+	if albumStr := c.Query("album_id"); albumStr != "" {
+		parsed, err := strconv.ParseUint(albumStr, 10, 64)
+		if err != nil || parsed == 0 || parsed > math.MaxUint {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "album_id 参数错误"})
+			return
+		}
+		id := uint(parsed)
+		albumID = &id
+	}
+
+	takenFrom, takenTo, ok := parseTakenRange(c)
+	if !ok {
+		return
+	}
+
 	images, total, page, pageSize, err := h.imageService.ListImages(moduledto.ListImagesRequest{
 		PaginationRequest: moduledto.PaginationRequest{Page: page, PageSize: pageSize},
 		UserID:            &uid,
 		Filename:          filename,
 		ID:                imageID,
+		AlbumID:           albumID,
+		TakenFrom:         takenFrom,
+		TakenTo:           takenTo,
+		Search:            c.Query("q"),
+		Tag:               c.Query("tag"),
+		SortBy:            c.Query("sort"),
+		SortOrder:         c.Query("order"),
+		PreloadMetadata:   true,
+		PreloadTags:       true,
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "获取图片列表失败"})
+		httpx.WriteServiceError(c, err, "获取图片列表失败")
 		return
 	}
 
@@ -100,7 +252,125 @@ func (h *ImageHandler) GetMyImages(c *gin.Context) {
 	})
 }
 
-// DeleteMyImage 用户删除自己的图片
+// GetMyImageDetail 获取用户自己图片的详情（含 EXIF 拍摄信息与 GPS）
+func (h *ImageHandler) GetMyImageDetail(c *gin.Context) {
+	userID, _ := c.Get("id")
+	uid, ok := userID.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的用户ID类型"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 || id > math.MaxUint {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id 参数错误"})
+		return
+	}
+
+	detail, err := h.imageService.GetImageDetail(uint(id), uid)
+	if err != nil {
+		httpx.WriteServiceError(c, err, "获取图片详情失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, detail)
+}
+
+// UpdateMyImage 修改用户自己图片的原始文件名、标题、描述与可见性
+func (h *ImageHandler) UpdateMyImage(c *gin.Context) {
+	userID, _ := c.Get("id")
+	uid, ok := userID.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的用户ID类型"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 || id > math.MaxUint {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id 参数错误"})
+		return
+	}
+
+	var req moduledto.UpdateImageInfoRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	detail, err := h.imageService.UpdateImageInfo(uint(id), uid, req)
+	if err != nil {
+		httpx.WriteServiceError(c, err, "更新图片信息失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, detail)
+}
+
+// CreateMyImageSignedURL 为用户自己的图片生成带过期时间的签名访问链接（用于分享私有图片）
+func (h *ImageHandler) CreateMyImageSignedURL(c *gin.Context) {
+	userID, _ := c.Get("id")
+	uid, ok := userID.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的用户ID类型"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 || id > math.MaxUint {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id 参数错误"})
+		return
+	}
+
+	// 请求体可省略，此时使用默认有效期
+	var req moduledto.CreateSignedURLRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	signed, err := h.imageService.CreateSignedURL(uint(id), uid, req.ExpiresIn)
+	if err != nil {
+		httpx.WriteServiceError(c, err, "生成签名链接失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, signed)
+}
+
+// GetMyImageStats 获取用户自己图片最近若干天的访问统计（每日访问次数与主要来源站点）
+func (h *ImageHandler) GetMyImageStats(c *gin.Context) {
+	userID, _ := c.Get("id")
+	uid, ok := userID.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的用户ID类型"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 || id > math.MaxUint {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id 参数错误"})
+		return
+	}
+
+	days := 0
+	if daysStr := c.Query("days"); daysStr != "" {
+		days, err = strconv.Atoi(daysStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days 参数错误"})
+			return
+		}
+	}
+
+	stats, err := h.imageService.GetImageStats(uint(id), uid, days)
+	if err != nil {
+		httpx.WriteServiceError(c, err, "获取访问统计失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// DeleteMyImage 用户删除自己的图片（移入回收站）
 func (h *ImageHandler) DeleteMyImage(c *gin.Context) {
 	userID, _ := c.Get("id")
 	idParam := c.Param("id")
@@ -122,15 +392,15 @@ func (h *ImageHandler) DeleteMyImage(c *gin.Context) {
 		return
 	}
 
-	if err := h.imageService.DeleteImage(image); err != nil {
+	if err := h.imageService.TrashImages([]model.Image{*image}); err != nil {
 		httpx.WriteServiceError(c, err, "删除失败")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
+	c.JSON(http.StatusOK, gin.H{"message": "已移入回收站"})
 }
 
-// BatchDeleteMyImages 批量删除用户自己的图片
+// BatchDeleteMyImages 批量删除用户自己的图片（移入回收站）
 func (h *ImageHandler) BatchDeleteMyImages(c *gin.Context) {
 	userID, _ := c.Get("id")
 	uid, ok := userID.(uint)
@@ -166,10 +436,45 @@ func (h *ImageHandler) BatchDeleteMyImages(c *gin.Context) {
 		return
 	}
 
-	if err := h.imageService.BatchDeleteImages(images); err != nil {
+	if err := h.imageService.TrashImages(images); err != nil {
 		httpx.WriteServiceError(c, err, "删除失败")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "删除成功", "deleted_count": len(images)})
+	c.JSON(http.StatusOK, gin.H{"message": "已移入回收站", "deleted_count": len(images)})
+}
+
+// parseTakenRange 解析 taken_from / taken_to 查询参数（YYYY-MM-DD 或 RFC3339）。
+// 仅给出日期的 taken_to 包含当天；解析失败时直接写入 400 响应并返回 false。
+func parseTakenRange(c *gin.Context) (*time.Time, *time.Time, bool) {
+	parse := func(name string, endOfDay bool) (*time.Time, bool) {
+		value := strings.TrimSpace(c.Query(name))
+		if value == "" {
+			return nil, true
+		}
+		if t, err := time.Parse(time.RFC3339, value); err == nil {
+			// 拍摄时间以 UTC 存储，统一时区后再比较
+			t = t.UTC()
+			return &t, true
+		}
+		t, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": name + " 参数错误，应为 YYYY-MM-DD 或 RFC3339 格式"})
+			return nil, false
+		}
+		if endOfDay {
+			t = t.AddDate(0, 0, 1)
+		}
+		return &t, true
+	}
+
+	from, ok := parse("taken_from", false)
+	if !ok {
+		return nil, nil, false
+	}
+	to, ok := parse("taken_to", true)
+	if !ok {
+		return nil, nil, false
+	}
+	return from, to, true
 }
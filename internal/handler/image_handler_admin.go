@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"perfect-pic-server/internal/common/httpx"
 	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/service"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
@@ -50,13 +51,25 @@ func (h *ImageHandler) GetImageList(c *gin.Context) {
 		imageID = &id
 	}
 
+	takenFrom, takenTo, ok := parseTakenRange(c)
+	if !ok {
+		return
+	}
+
 	images, total, page, pageSize, err := h.imageService.ListImages(moduledto.ListImagesRequest{
 		PaginationRequest: moduledto.PaginationRequest{Page: page, PageSize: pageSize},
 		Username:          username,
 		Filename:          filename,
 		UserID:            userID,
 		ID:                imageID,
+		TakenFrom:         takenFrom,
+		TakenTo:           takenTo,
+		Search:            c.Query("q"),
+		Tag:               c.Query("tag"),
+		SortBy:            c.Query("sort"),
+		SortOrder:         c.Query("order"),
 		PreloadUser:       true,
+		PreloadTags:       true,
 	})
 	if err != nil {
 		httpx.WriteServiceError(c, err, "获取图片列表失败")
@@ -103,6 +116,34 @@ func (h *ImageHandler) DeleteImage(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "删除成功"})
 }
 
+// SetImageShortCode 为图片设置自定义短码，code 为空时重新生成随机短码。
+func (h *ImageHandler) SetImageShortCode(c *gin.Context) {
+	idParam := c.Param("id")
+	id, err := strconv.ParseUint(idParam, 10, 64)
+	if err != nil || id == 0 || id > math.MaxUint {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id 参数错误"})
+		return
+	}
+
+	var req moduledto.SetImageShortCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	image, err := h.imageService.SetImageShortCode(uint(id), req.Code)
+	if err != nil {
+		httpx.WriteServiceError(c, err, "设置短链接失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":    "短链接设置成功",
+		"short_code": *image.ShortCode,
+		"short_url":  service.ShortURL(image),
+	})
+}
+
 // BatchDeleteImages 批量删除图片
 func (h *ImageHandler) BatchDeleteImages(c *gin.Context) {
 	var req moduledto.BatchDeleteImagesRequest
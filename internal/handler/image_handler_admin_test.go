@@ -184,3 +184,55 @@ func TestDeleteImageHandler_InvalidID(t *testing.T) {
 		t.Fatalf("期望 400，实际为 %d body=%s", w.Code, w.Body.String())
 	}
 }
+
+// 测试内容：验证管理员设置自定义短码、短码冲突、格式错误与留空重新生成的响应。
+func TestSetImageShortCodeHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDB(t)
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+	img := model.Image{Filename: "a.png", Path: "2026/02/13/a.png", Size: 4, MimeType: ".png", UploadedAt: 1, UserID: u.ID}
+	_ = testGormDB.Create(&img).Error
+	other := model.Image{Filename: "b.png", Path: "2026/02/13/b.png", Size: 4, MimeType: ".png", UploadedAt: 1, UserID: u.ID}
+	_ = testGormDB.Create(&other).Error
+
+	r := gin.New()
+	r.PUT("/images/:id/short-code", testHandler.SetImageShortCode)
+	put := func(id uint, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/images/"+strconv.FormatUint(uint64(id), 10)+"/short-code", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := put(img.ID, `{"code":"logo"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望 200，实际为 %d body=%s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		ShortCode string `json:"short_code"`
+		ShortURL  string `json:"short_url"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.ShortCode != "logo" || resp.ShortURL != "/s/logo" {
+		t.Fatalf("响应不符: %+v", resp)
+	}
+
+	if w := put(other.ID, `{"code":"logo"}`); w.Code != http.StatusConflict {
+		t.Fatalf("短码冲突期望 409，实际为 %d", w.Code)
+	}
+	if w := put(other.ID, `{"code":"a/b"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("短码格式错误期望 400，实际为 %d", w.Code)
+	}
+	if w := put(9999, `{"code":"x123"}`); w.Code != http.StatusNotFound {
+		t.Fatalf("图片不存在期望 404，实际为 %d", w.Code)
+	}
+
+	w = put(img.ID, `{"code":""}`)
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if w.Code != http.StatusOK || resp.ShortCode == "logo" || resp.ShortURL != "/s/"+resp.ShortCode {
+		t.Fatalf("期望重新生成短码，实际为 %d %+v", w.Code, resp)
+	}
+}
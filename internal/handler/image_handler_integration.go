@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"bytes"
+	"html/template"
+	"log"
+	"math"
+	"net/http"
+	platformservice "perfect-pic-server/internal/common"
+	"perfect-pic-server/internal/common/httpx"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IntegrationUploadImage 供 PicGo、ShareX、Typora 等客户端使用的兼容上传接口（multipart 字段 file），
+// 与普通上传共用校验与保存流程，响应中返回绝对地址的图片链接、缩略图与删除链接。
+func (h *ImageHandler) IntegrationUploadImage(c *gin.Context) {
+	file, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "请选择文件"})
+		return
+	}
+
+	userID, _ := c.Get("id")
+	uid, ok := userID.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的用户ID类型"})
+		return
+	}
+
+	info, err := uploadInfoFromForm(c)
+	if err != nil {
+		httpx.WriteServiceError(c, err, "参数错误")
+		return
+	}
+	result, err := h.imageUseCase.ProcessImageUpload(file, uid, info)
+	if err != nil {
+		if _, ok := platformservice.AsServiceError(err); !ok {
+			log.Printf("Integration upload failed: %v", err)
+		}
+		httpx.WriteServiceError(c, err, "上传失败，请稍后重试")
+		return
+	}
+
+	c.JSON(http.StatusOK, h.imageService.BuildIntegrationUploadResponse(result))
+}
+
+// deleteLinkPageTemplate 删除链接的确认页与结果页。GET 只展示确认页，由页面中的表单 POST 到同一地址执行删除，
+// 避免链接预览、爬虫等自动访问误删图片。
+var deleteLinkPageTemplate = template.Must(template.New("delete").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head><meta charset="utf-8"><meta name="robots" content="noindex"><title>删除图片</title></head>
+<body>
+{{if .Deleted}}<p>图片 {{.Filename}} 已移入回收站。</p>{{else}}<p>确定要删除图片 {{.Filename}} 吗？图片将移入回收站。</p>
+<form method="post" action="{{.Action}}"><button type="submit">确认删除</button></form>{{end}}
+</body>
+</html>
+`))
+
+type deleteLinkPage struct {
+	Filename string
+	Action   string
+	Deleted  bool
+}
+
+// ConfirmDeleteImageBySignedLink 展示兼容上传响应中删除链接的确认页，无需登录（ShareX 等客户端在浏览器中打开该链接）。
+func (h *ImageHandler) ConfirmDeleteImageBySignedLink(c *gin.Context) {
+	id, ok := parseSignedLinkImageID(c)
+	if !ok {
+		return
+	}
+
+	image, err := h.imageService.VerifyImageDeleteLink(id, c.Query("exp"), c.Query("sig"))
+	if err != nil {
+		httpx.WriteServiceError(c, err, "删除链接无效")
+		return
+	}
+
+	renderDeleteLinkPage(c, deleteLinkPage{Filename: image.OriginalName, Action: c.Request.URL.RequestURI()})
+}
+
+// DeleteImageBySignedLink 通过兼容上传响应中的删除链接删除图片，无需登录。
+// POST 来自确认页的表单并返回结果页，DELETE 供脚本调用并返回 JSON。
+func (h *ImageHandler) DeleteImageBySignedLink(c *gin.Context) {
+	id, ok := parseSignedLinkImageID(c)
+	if !ok {
+		return
+	}
+
+	image, err := h.imageService.DeleteImageWithSignature(id, c.Query("exp"), c.Query("sig"))
+	if err != nil {
+		httpx.WriteServiceError(c, err, "删除失败")
+		return
+	}
+
+	if c.Request.Method == http.MethodPost {
+		renderDeleteLinkPage(c, deleteLinkPage{Filename: image.OriginalName, Deleted: true})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "已移入回收站"})
+}
+
+func parseSignedLinkImageID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 || id > math.MaxUint {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id 参数错误"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func renderDeleteLinkPage(c *gin.Context, page deleteLinkPage) {
+	var buf bytes.Buffer
+	if err := deleteLinkPageTemplate.Execute(&buf, page); err != nil {
+		log.Printf("Render delete link page failed: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "页面渲染失败"})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+}
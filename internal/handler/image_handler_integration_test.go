@@ -0,0 +1,261 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"perfect-pic-server/internal/config"
+	"perfect-pic-server/internal/consts"
+	"perfect-pic-server/internal/middleware"
+	"perfect-pic-server/internal/model"
+	"perfect-pic-server/internal/pkg/urlsign"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 测试内容：验证兼容上传接口返回绝对地址的图片、缩略图与删除链接，删除链接无需登录，GET 仅展示确认页而不删除，
+// POST 执行删除，篡改签名后被拒绝。
+func TestIntegrationUploadAndSignedDeleteHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	_ = testGormDB.Save(&model.Setting{Key: consts.ConfigBaseURL, Value: "https://pic.example.com/"}).Error
+	testService.ClearCache()
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+
+	r := gin.New()
+	r.POST("/integrations/upload", func(c *gin.Context) { c.Set("id", u.ID); c.Next() }, testHandler.IntegrationUploadImage)
+	r.GET("/api/integrations/delete/:id", testHandler.ConfirmDeleteImageBySignedLink)
+	r.POST("/api/integrations/delete/:id", testHandler.DeleteImageBySignedLink)
+	r.GET("/imgs/*filepath", middleware.NewImageVariantMiddleware(testHandler.ImageHandler.imageService).ImageVariant(), testHandler.ServeImage)
+
+	var pngBuf bytes.Buffer
+	_ = png.Encode(&pngBuf, image.NewNRGBA(image.Rect(0, 0, 640, 480)))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newUploadRequest(t, "/integrations/upload", "a.png", pngBuf.Bytes()))
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望 200，实际为 %d body=%s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		ID           uint   `json:"id"`
+		Filename     string `json:"filename"`
+		URL          string `json:"url"`
+		ThumbnailURL string `json:"thumbnail_url"`
+		DeleteURL    string `json:"delete_url"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.ID == 0 || resp.Filename != "a.png" || !strings.HasPrefix(resp.URL, "https://pic.example.com/") {
+		t.Fatalf("响应不符: %s", w.Body.String())
+	}
+	thumbnailURL, err := url.Parse(resp.ThumbnailURL)
+	if err != nil || thumbnailURL.Host != "pic.example.com" || thumbnailURL.Query().Get("w") == "" {
+		t.Fatalf("缩略图地址不符: %s", resp.ThumbnailURL)
+	}
+	// 默认允许尺寸下缩略图地址可以直接访问
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, thumbnailURL.RequestURI(), nil))
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "image/") {
+		t.Fatalf("缩略图期望 200，实际为 %d body=%s", w.Code, w.Body.String())
+	}
+
+	deleteURL, err := url.Parse(resp.DeleteURL)
+	if err != nil || deleteURL.Host != "pic.example.com" || deleteURL.Query().Get("sig") == "" || deleteURL.Query().Get("exp") == "" {
+		t.Fatalf("删除链接不符: %s", resp.DeleteURL)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, deleteURL.Path+"?exp="+deleteURL.Query().Get("exp")+"&sig=bad", nil))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("签名错误期望 403，实际为 %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, deleteURL.RequestURI(), nil))
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") ||
+		!strings.Contains(w.Body.String(), `method="post"`) || !strings.Contains(w.Body.String(), "a.png") {
+		t.Fatalf("期望返回确认页，实际为 %d body=%s", w.Code, w.Body.String())
+	}
+	var count int64
+	_ = testGormDB.Model(&model.Image{}).Where("id = ?", resp.ID).Count(&count).Error
+	if count != 1 {
+		t.Fatalf("期望 GET 不删除图片")
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, deleteURL.RequestURI(), nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "已移入回收站") {
+		t.Fatalf("期望 200，实际为 %d body=%s", w.Code, w.Body.String())
+	}
+	_ = testGormDB.Model(&model.Image{}).Where("id = ?", resp.ID).Count(&count).Error
+	if count != 0 {
+		t.Fatalf("期望图片已删除")
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, deleteURL.RequestURI(), nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("重复删除期望 404，实际为 %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newUploadRequest(t, "/integrations/upload", "a.exe", []byte("x")))
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"error"`) {
+		t.Fatalf("不支持的类型期望 400 且返回 error 字段，实际为 %d body=%s", w.Code, w.Body.String())
+	}
+}
+
+// 测试内容：验证删除链接在过期后重放被拒绝（确认页与删除均返回 403），图片保持不变。
+func TestIntegrationSignedDeleteHandler_ExpiredLinkRejected(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDB(t)
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+	img := model.Image{Filename: "a.png", OriginalName: "a.png", Path: "2024/01/01/a.png", Size: 1, UploadedAt: 1, UserID: u.ID}
+	_ = testGormDB.Create(&img).Error
+
+	r := gin.New()
+	r.GET("/api/integrations/delete/:id", testHandler.ConfirmDeleteImageBySignedLink)
+	r.POST("/api/integrations/delete/:id", testHandler.DeleteImageBySignedLink)
+	r.DELETE("/api/integrations/delete/:id", testHandler.DeleteImageBySignedLink)
+
+	exp := time.Now().Add(-time.Minute).Unix()
+	signer := urlsign.NewSigner(config.NewURLSignConfig(config.NewStaticConfig()))
+	key := fmt.Sprintf("delete/%d/%s", img.ID, img.Path)
+
+	// 同一签名方式在有效期内可以打开确认页，确保下面的拒绝来自过期而非签名错误
+	validExp := time.Now().Add(time.Hour).Unix()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/integrations/delete/%d?exp=%d&sig=%s", img.ID, validExp, url.QueryEscape(signer.Sign(key, validExp))), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("有效链接期望 200，实际为 %d body=%s", w.Code, w.Body.String())
+	}
+
+	sig := signer.Sign(key, exp)
+	path := fmt.Sprintf("/api/integrations/delete/%d?exp=%d&sig=%s", img.ID, exp, url.QueryEscape(sig))
+	for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodDelete} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		if w.Code != http.StatusForbidden {
+			t.Fatalf("%s 过期链接期望 403，实际为 %d body=%s", method, w.Code, w.Body.String())
+		}
+	}
+
+	var count int64
+	_ = testGormDB.Model(&model.Image{}).Where("id = ?", img.ID).Count(&count).Error
+	if count != 1 {
+		t.Fatalf("期望过期链接不删除图片")
+	}
+}
+
+// 测试内容：验证 ShareX 与 PicGo 配置预填上传地址与新签发的 upload 令牌，且令牌可用于认证；
+// 重复下载配置时轮换同一客户端的令牌，旧令牌失效且不累积。
+func TestIntegrationConfigHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDB(t)
+
+	_ = testGormDB.Save(&model.Setting{Key: consts.ConfigBaseURL, Value: "https://pic.example.com"}).Error
+	testService.ClearCache()
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+
+	r := gin.New()
+	setUser := func(c *gin.Context) { c.Set("id", u.ID); c.Next() }
+	r.GET("/sharex.sxcu", setUser, testHandler.GetShareXConfig)
+	r.GET("/picgo.json", setUser, testHandler.GetPicGoConfig)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/sharex.sxcu", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Header().Get("Content-Disposition"), ".sxcu") {
+		t.Fatalf("期望返回 sxcu 附件，实际为 %d %v", w.Code, w.Header())
+	}
+	var sharex struct {
+		RequestURL   string            `json:"RequestURL"`
+		Headers      map[string]string `json:"Headers"`
+		FileFormName string            `json:"FileFormName"`
+		URL          string            `json:"URL"`
+		DeletionURL  string            `json:"DeletionURL"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &sharex)
+	if sharex.RequestURL != "https://pic.example.com/api/user/integrations/upload" || sharex.FileFormName != "file" ||
+		sharex.URL != "{json:url}" || sharex.DeletionURL != "{json:delete_url}" {
+		t.Fatalf("ShareX 配置不符: %s", w.Body.String())
+	}
+	plain := strings.TrimPrefix(sharex.Headers["Authorization"], "Bearer ")
+	token, err := testHandler.UserHandler.tokenService.AuthenticateAccessToken(plain)
+	if err != nil || token.UserID != u.ID || token.Source != consts.AccessTokenSourceShareX || token.Scopes != consts.AccessTokenScopeUpload {
+		t.Fatalf("期望签发 upload 令牌，实际为 %+v %v", token, err)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/picgo.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望 200，实际为 %d", w.Code)
+	}
+	var picgo struct {
+		PicBed struct {
+			Uploader    string `json:"uploader"`
+			WebUploader struct {
+				URL          string `json:"url"`
+				ParamName    string `json:"paramName"`
+				JSONPath     string `json:"jsonPath"`
+				CustomHeader string `json:"customHeader"`
+			} `json:"web-uploader"`
+		} `json:"picBed"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &picgo)
+	web := picgo.PicBed.WebUploader
+	if picgo.PicBed.Uploader != "web-uploader" || web.URL != sharex.RequestURL || web.ParamName != "file" || web.JSONPath != "url" {
+		t.Fatalf("PicGo 配置不符: %s", w.Body.String())
+	}
+	var header map[string]string
+	if err := json.Unmarshal([]byte(web.CustomHeader), &header); err != nil || !strings.HasPrefix(header["Authorization"], "Bearer ppt_") {
+		t.Fatalf("customHeader 不符: %q", web.CustomHeader)
+	}
+
+	var count int64
+	_ = testGormDB.Model(&model.PersonalAccessToken{}).Where("user_id = ?", u.ID).Count(&count).Error
+	if count != 2 {
+		t.Fatalf("期望签发 2 个令牌，实际为 %d", count)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/sharex.sxcu", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望 200，实际为 %d", w.Code)
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &sharex)
+	rotated := strings.TrimPrefix(sharex.Headers["Authorization"], "Bearer ")
+	if rotated == plain {
+		t.Fatalf("期望重新签发令牌")
+	}
+	if _, err := testHandler.UserHandler.tokenService.AuthenticateAccessToken(plain); err == nil {
+		t.Fatalf("期望旧的 ShareX 令牌被吊销")
+	}
+	if _, err := testHandler.UserHandler.tokenService.AuthenticateAccessToken(rotated); err != nil {
+		t.Fatalf("期望新令牌可用于认证: %v", err)
+	}
+	_ = testGormDB.Model(&model.PersonalAccessToken{}).Where("user_id = ?", u.ID).Count(&count).Error
+	if count != 2 {
+		t.Fatalf("期望重复下载配置后仍为 2 个令牌，实际为 %d", count)
+	}
+}
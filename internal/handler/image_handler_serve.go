@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"perfect-pic-server/internal/common/httpx"
+	"perfect-pic-server/internal/pkg/storage"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ServeImage 提供 /imgs/*filepath 的图片访问，按 Accept 头在原图与备用格式之间协商。
+// 携带 download=1 时始终返回原图，并以原始文件名作为附件下载。
+//
+// 本地存储返回的文件支持 Range 与 If-Modified-Since 等条件请求；路径校验与符号链接防护由存储层完成。
+func (h *ImageHandler) ServeImage(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("filepath"), "/")
+	if c.Query("download") == "1" {
+		reader, info, filename, err := h.imageService.OpenImageDownload(key)
+		if err != nil {
+			httpx.WriteServiceError(c, err, "读取图片失败")
+			return
+		}
+		defer func() { _ = reader.Close() }()
+		c.Header("Content-Disposition", httpx.AttachmentDisposition(filename))
+		writeImageObject(c, reader, info)
+		return
+	}
+
+	reader, info, negotiable, err := h.imageService.OpenImage(key, c.GetHeader("Accept"))
+	if negotiable {
+		// 即使本次未命中备用格式也需声明，避免缓存将原图返回给支持备用格式的客户端
+		c.Header("Vary", "Accept")
+	}
+	if err != nil {
+		httpx.WriteServiceError(c, err, "读取图片失败")
+		return
+	}
+	defer func() { _ = reader.Close() }()
+	writeImageObject(c, reader, info)
+}
+
+// writeImageObject 输出存储对象；可 Seek 的对象交由 http.ServeContent 处理条件请求与 Range。
+func writeImageObject(c *gin.Context, reader io.Reader, info *storage.ObjectInfo) {
+	contentType := info.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	if seeker, ok := reader.(io.ReadSeeker); ok {
+		c.Header("Content-Type", contentType)
+		http.ServeContent(c.Writer, c.Request, info.Key, info.LastModified, seeker)
+		return
+	}
+
+	headers := map[string]string{}
+	if !info.LastModified.IsZero() {
+		headers["Last-Modified"] = info.LastModified.UTC().Format(http.TimeFormat)
+	}
+	c.DataFromReader(http.StatusOK, info.Size, contentType, reader, headers)
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func writeServeTestFile(t *testing.T, rel string, content string) {
+	t.Helper()
+	full := filepath.Join("uploads", "imgs", filepath.FromSlash(rel))
+	_ = os.MkdirAll(filepath.Dir(full), 0755)
+	if err := os.WriteFile(full, []byte(content), 0644); err != nil {
+		t.Fatalf("write %s: %v", rel, err)
+	}
+}
+
+func serveImageRequest(r *gin.Engine, target, accept string, header ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+// 测试内容：验证图片访问按 Accept 协商返回备用格式并声明 Vary: Accept，不接受时返回原图。
+func TestServeImage_NegotiatesAlternate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	writeServeTestFile(t, "2026/10/16/a.png", "original-png")
+	writeServeTestFile(t, "2026/10/16/a.png.variants/alternate.webp", "alternate-webp")
+	writeServeTestFile(t, "2026/10/16/b.png", "plain-png")
+	writeServeTestFile(t, "2026/10/16/c.gif", "plain-gif")
+
+	r := gin.New()
+	r.GET("/imgs/*filepath", testHandler.ServeImage)
+
+	cases := []struct {
+		target, accept, body, contentType, vary string
+	}{
+		{"/imgs/2026/10/16/a.png", "image/avif,image/webp,image/*,*/*;q=0.8", "alternate-webp", "image/webp", "Accept"},
+		{"/imgs/2026/10/16/a.png", "image/png,image/*;q=0.8,*/*;q=0.5", "original-png", "image/png", "Accept"},
+		{"/imgs/2026/10/16/a.png", "image/webp;q=0,*/*", "original-png", "image/png", "Accept"},
+		{"/imgs/2026/10/16/a.png", "", "original-png", "image/png", "Accept"},
+		{"/imgs/2026/10/16/b.png", "image/webp,*/*", "plain-png", "image/png", "Accept"},
+		{"/imgs/2026/10/16/c.gif", "image/webp,*/*", "plain-gif", "image/gif", ""},
+	}
+	for _, tc := range cases {
+		rec := serveImageRequest(r, tc.target, tc.accept)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s [%s]: 期望 200，实际为 %d", tc.target, tc.accept, rec.Code)
+		}
+		if rec.Body.String() != tc.body || rec.Header().Get("Content-Type") != tc.contentType {
+			t.Fatalf("%s [%s]: 期望 %s/%s，实际为 %s/%s", tc.target, tc.accept, tc.body, tc.contentType, rec.Body.String(), rec.Header().Get("Content-Type"))
+		}
+		if rec.Header().Get("Vary") != tc.vary {
+			t.Fatalf("%s [%s]: 期望 Vary=%q，实际为 %q", tc.target, tc.accept, tc.vary, rec.Header().Get("Vary"))
+		}
+		if rec.Header().Get("Last-Modified") == "" {
+			t.Fatalf("%s: 期望返回 Last-Modified", tc.target)
+		}
+	}
+
+	// 本地文件支持 Range 请求
+	rec := serveImageRequest(r, "/imgs/2026/10/16/b.png", "", "Range", "bytes=0-4")
+	if rec.Code != http.StatusPartialContent || rec.Body.String() != "plain" {
+		t.Fatalf("Range 期望 206 plain，实际为 %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+// 测试内容：验证不存在的文件、目录、越界路径与符号链接均返回 404。
+func TestServeImage_NotFoundAndSymlink(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	writeServeTestFile(t, "2026/a.png", "png")
+	_ = os.WriteFile(filepath.Join(tmp, "secret.txt"), []byte("secret"), 0644)
+	if err := os.Symlink(filepath.Join(tmp, "secret.txt"), filepath.Join("uploads", "imgs", "link.png")); err != nil {
+		t.Skipf("symlink not supported: %v", err)
+	}
+
+	r := gin.New()
+	r.GET("/imgs/*filepath", testHandler.ServeImage)
+
+	for _, target := range []string{"/imgs/missing.png", "/imgs/2026", "/imgs/2026/..%2f..%2fsecret.txt", "/imgs/link.png"} {
+		rec := serveImageRequest(r, target, "image/webp,*/*")
+		if rec.Code != http.StatusNotFound {
+			t.Fatalf("%s: 期望 404，实际为 %d body=%s", target, rec.Code, rec.Body.String())
+		}
+	}
+}
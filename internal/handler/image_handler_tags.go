@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"math"
+	"net/http"
+	"perfect-pic-server/internal/common/httpx"
+	moduledto "perfect-pic-server/internal/dto"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetMyImageTags 整体替换用户自己图片的标签
+func (h *ImageHandler) SetMyImageTags(c *gin.Context) {
+	userID, _ := c.Get("id")
+	uid, ok := userID.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的用户ID类型"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 || id > math.MaxUint {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id 参数错误"})
+		return
+	}
+
+	var req moduledto.SetImageTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	tags, err := h.imageService.SetImageTags(uint(id), uid, req.Tags)
+	if err != nil {
+		httpx.WriteServiceError(c, err, "保存标签失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tags": tags})
+}
+
+// ListMyTags 获取当前用户的标签（按使用次数排序），支持 prefix 前缀补全
+func (h *ImageHandler) ListMyTags(c *gin.Context) {
+	userID, _ := c.Get("id")
+	uid, ok := userID.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的用户ID类型"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	tags, err := h.imageService.ListTags(uid, c.Query("prefix"), limit)
+	if err != nil {
+		httpx.WriteServiceError(c, err, "获取标签列表失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"list": tags})
+}
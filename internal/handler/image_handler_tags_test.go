@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"perfect-pic-server/internal/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 测试内容：验证设置图片标签、标签补全以及图片列表按 q/tag 检索的接口流程。
+func TestImageTagHandlers_Flow(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDB(t)
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+	img := model.Image{Filename: "a.png", Path: "a.png", Size: 1, Width: 1, Height: 1, MimeType: ".png", UploadedAt: 1, UserID: u.ID}
+	_ = testGormDB.Create(&img).Error
+	other := model.Image{Filename: "b.png", Path: "b.png", Size: 1, Width: 1, Height: 1, MimeType: ".png", UploadedAt: 1, UserID: u.ID}
+	_ = testGormDB.Create(&other).Error
+
+	auth := func(c *gin.Context) { c.Set("id", u.ID); c.Next() }
+	r := gin.New()
+	r.PUT("/images/:id/tags", auth, testHandler.SetMyImageTags)
+	r.GET("/tags", auth, testHandler.ListMyTags)
+	r.GET("/images", auth, testHandler.GetMyImages)
+	r.GET("/admin/images", testHandler.GetImageList)
+
+	do := func(method, target string, body any) *httptest.ResponseRecorder {
+		var data []byte
+		if body != nil {
+			data, _ = json.Marshal(body)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(method, target, bytes.NewReader(data)))
+		return rec
+	}
+
+	target := "/images/" + strconv.FormatUint(uint64(img.ID), 10) + "/tags"
+	if rec := do(http.MethodPut, target, gin.H{}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("缺少 tags 字段期望 400，实际为 %d", rec.Code)
+	}
+	if rec := do(http.MethodPut, "/images/999/tags", gin.H{"tags": []string{"x"}}); rec.Code != http.StatusNotFound {
+		t.Fatalf("不存在的图片期望 404，实际为 %d", rec.Code)
+	}
+	rec := do(http.MethodPut, target, gin.H{"tags": []string{"Cat", "猫咪"}})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("set tags 期望 200，实际为 %d body=%s", rec.Code, rec.Body.String())
+	}
+	var tagsResp struct {
+		Tags []string `json:"tags"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &tagsResp)
+	if len(tagsResp.Tags) != 2 || tagsResp.Tags[0] != "cat" {
+		t.Fatalf("期望返回规范化后的标签，实际为 %v", tagsResp.Tags)
+	}
+
+	rec = do(http.MethodGet, "/tags?prefix=ca", nil)
+	var suggest struct {
+		List []struct {
+			Name  string `json:"name"`
+			Count int64  `json:"count"`
+		} `json:"list"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &suggest)
+	if rec.Code != http.StatusOK || len(suggest.List) != 1 || suggest.List[0].Name != "cat" || suggest.List[0].Count != 1 {
+		t.Fatalf("期望补全返回 cat，实际为 %d body=%s", rec.Code, rec.Body.String())
+	}
+
+	var list struct {
+		List []struct {
+			ID   uint     `json:"id"`
+			Tags []string `json:"tags"`
+		} `json:"list"`
+		Total int64 `json:"total"`
+	}
+	for _, path := range []string{"/images?q=%E7%8C%AB", "/images?tag=CAT", "/admin/images?q=ca"} {
+		rec = do(http.MethodGet, path, nil)
+		list.List = nil
+		_ = json.Unmarshal(rec.Body.Bytes(), &list)
+		if rec.Code != http.StatusOK || list.Total != 1 || list.List[0].ID != img.ID || len(list.List[0].Tags) != 2 {
+			t.Fatalf("%s 期望只返回带标签的图片，实际为 %d body=%s", path, rec.Code, rec.Body.String())
+		}
+	}
+}
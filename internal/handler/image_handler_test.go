@@ -2,6 +2,7 @@ package handler
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"mime/multipart"
 	"net/http"
@@ -9,8 +10,11 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"perfect-pic-server/internal/consts"
 	"perfect-pic-server/internal/model"
 	"perfect-pic-server/internal/testutils"
 
@@ -51,11 +55,12 @@ func TestUploadAndListAndDeleteImagesHandlers(t *testing.T) {
 	}
 
 	var uploadResp struct {
-		ID  uint   `json:"id"`
-		URL string `json:"url"`
+		ID       uint   `json:"id"`
+		URL      string `json:"url"`
+		ShortURL string `json:"short_url"`
 	}
 	_ = json.Unmarshal(rec.Body.Bytes(), &uploadResp)
-	if uploadResp.ID == 0 || uploadResp.URL == "" {
+	if uploadResp.ID == 0 || uploadResp.URL == "" || len(uploadResp.ShortURL) != len("/s/")+7 {
 		t.Fatalf("非预期 upload resp: %+v", uploadResp)
 	}
 
@@ -76,14 +81,17 @@ func TestUploadAndListAndDeleteImagesHandlers(t *testing.T) {
 		t.Fatalf("期望 file exists: %v", err)
 	}
 
-	// 删除图片。
+	// 删除图片：移入回收站，文件在永久删除前保留。
 	rec3 := httptest.NewRecorder()
 	r.ServeHTTP(rec3, httptest.NewRequest(http.MethodDelete, "/images/"+strconv.FormatUint(uint64(uploadResp.ID), 10), nil))
 	if rec3.Code != http.StatusOK {
 		t.Fatalf("delete 期望 200，实际为 %d body=%s", rec3.Code, rec3.Body.String())
 	}
-	if _, err := os.Stat(full); !os.IsNotExist(err) {
-		t.Fatalf("期望 file deleted, err=%v", err)
+	if _, err := os.Stat(full); err != nil {
+		t.Fatalf("期望回收站中的图片文件仍然存在: %v", err)
+	}
+	if err := testGormDB.First(&model.Image{}, uploadResp.ID).Error; err == nil {
+		t.Fatalf("期望删除后的图片不再出现在常规查询中")
 	}
 }
 
@@ -221,6 +229,167 @@ func TestUploadImageHandler_UnsupportedExtReturns400(t *testing.T) {
 	}
 }
 
+// newBatchUploadRequest 构造包含多个 file 字段的上传请求。
+func newBatchUploadRequest(t *testing.T, path string, files map[string][]byte, order []string) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for _, name := range order {
+		part, err := w.CreateFormFile("file", name)
+		if err != nil {
+			t.Fatalf("create form file: %v", err)
+		}
+		_, _ = part.Write(files[name])
+	}
+	_ = w.WriteField("visibility", "unlisted")
+	_ = w.Close()
+
+	req := httptest.NewRequest(http.MethodPost, path, &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+// 测试内容：验证批量上传逐个返回结果，校验失败的文件不影响其它文件保存，并按总大小预先检查配额与文件数上限。
+func TestBatchUploadImagesHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+
+	r := gin.New()
+	r.POST("/upload/batch", func(c *gin.Context) { c.Set("id", u.ID); c.Next() }, testHandler.BatchUploadImages)
+
+	second := testutils.JPEGWithEXIF(testutils.EXIFFixture{})
+	files := map[string][]byte{"a.png": testutils.MinimalPNG(), "bad.exe": testutils.MinimalPNG(), "b.jpg": second}
+	order := []string{"a.png", "bad.exe", "b.jpg"}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, newBatchUploadRequest(t, "/upload/batch", files, order))
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望 200，实际为 %d body=%s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Succeeded int `json:"succeeded"`
+		Failed    int `json:"failed"`
+		Results   []struct {
+			Filename string `json:"filename"`
+			ID       uint   `json:"id"`
+			URL      string `json:"url"`
+			Error    string `json:"error"`
+		} `json:"results"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if resp.Succeeded != 2 || resp.Failed != 1 || len(resp.Results) != 3 {
+		t.Fatalf("非预期响应: %s", w.Body.String())
+	}
+	for i, name := range order {
+		item := resp.Results[i]
+		if item.Filename != name {
+			t.Fatalf("期望第 %d 项为 %s，实际为 %s", i, name, item.Filename)
+		}
+		if failed := name == "bad.exe"; failed != (item.Error != "") || failed == (item.ID != 0 && item.URL != "") {
+			t.Fatalf("%s 结果不符: %+v", name, item)
+		}
+	}
+
+	var images []model.Image
+	_ = testGormDB.Where("user_id = ?", u.ID).Find(&images).Error
+	var user model.User
+	_ = testGormDB.First(&user, u.ID).Error
+	if len(images) != 2 || user.StorageUsed != images[0].Size+images[1].Size || images[0].Visibility != "unlisted" {
+		t.Fatalf("期望保存 2 张图片并计入用量，实际为 %d 张，用量 %d", len(images), user.StorageUsed)
+	}
+
+	// 总大小超过剩余空间时整批拒绝
+	q := user.StorageUsed + int64(len(second))
+	_ = testGormDB.Model(&model.User{}).Where("id = ?", u.ID).Update("storage_quota", &q).Error
+	big := map[string][]byte{"c.png": testutils.MinimalPNG(), "d.jpg": second}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newBatchUploadRequest(t, "/upload/batch", big, []string{"c.png", "d.jpg"}))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("总大小超出配额期望 403，实际为 %d body=%s", w.Code, w.Body.String())
+	}
+
+	_ = testGormDB.Save(&model.Setting{Key: consts.ConfigMaxBatchUploadFiles, Value: "1"}).Error
+	testService.ClearCache()
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, newBatchUploadRequest(t, "/upload/batch", big, []string{"c.png", "d.jpg"}))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("超过文件数上限期望 400，实际为 %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/upload/batch", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("未提交文件期望 400，实际为 %d", w.Code)
+	}
+}
+
+// 测试内容：验证按地址上传接口支持 data URI 与远程地址，并对绑定错误、非法地址与配额不足返回对应状态码。
+func TestUploadImageByURLHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(testutils.MinimalPNG())
+	}))
+	defer srv.Close()
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+
+	r := gin.New()
+	r.POST("/upload/url", func(c *gin.Context) { c.Set("id", u.ID); c.Next() }, testHandler.UploadImageByURL)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/upload/url", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	dataURI := "data:image/png;base64," + base64.StdEncoding.EncodeToString(testutils.MinimalPNG())
+	w := post(`{"url":"` + dataURI + `","filename":"shot","title":"截图"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("data URI 期望 200，实际为 %d body=%s", w.Code, w.Body.String())
+	}
+	var img model.Image
+	if err := testGormDB.Order("id desc").First(&img).Error; err != nil {
+		t.Fatalf("query image: %v", err)
+	}
+	if img.OriginalName != "shot.png" || img.Title != "截图" {
+		t.Fatalf("记录不符: %+v", img)
+	}
+
+	if w := post(`{"url":"` + srv.URL + `/a"}`); w.Code != http.StatusOK {
+		t.Fatalf("远程地址期望 200，实际为 %d body=%s", w.Code, w.Body.String())
+	}
+	if w := post(`{bad`); w.Code != http.StatusBadRequest {
+		t.Fatalf("绑定错误期望 400，实际为 %d", w.Code)
+	}
+	if w := post(`{"url":"ftp://example.com/a.png"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("非法地址期望 400，实际为 %d", w.Code)
+	}
+
+	q := int64(1)
+	_ = testGormDB.Model(&model.User{}).Where("id = ?", u.ID).Update("storage_quota", &q).Error
+	if w := post(`{"url":"` + dataURI + `"}`); w.Code != http.StatusForbidden {
+		t.Fatalf("配额不足期望 403，实际为 %d body=%s", w.Code, w.Body.String())
+	}
+}
+
 // 测试内容：验证批量删除接口的绑定错误、空列表与未找到分支。
 func TestBatchDeleteMyImagesHandler_Errors(t *testing.T) {
 	gin.SetMode(gin.TestMode)
@@ -308,3 +477,295 @@ func newUploadRequest(t *testing.T, path, filename string, content []byte) *http
 	req.Header.Set("Content-Type", w.FormDataContentType())
 	return req
 }
+
+// 测试内容：验证图片详情接口返回拍摄信息，其他用户访问返回 404，非法的拍摄时间参数返回 400。
+func TestGetMyImageDetailHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+	img := model.Image{Filename: "a.jpg", Path: "a.jpg", Size: 1, Width: 1, Height: 1, MimeType: ".jpg", UploadedAt: 1, UserID: u.ID,
+		Metadata: &model.ImageMetadata{Make: "TestMaker", ISO: 200}}
+	_ = testGormDB.Create(&img).Error
+
+	r := gin.New()
+	r.GET("/images/:id", func(c *gin.Context) { c.Set("id", u.ID); c.Next() }, testHandler.GetMyImageDetail)
+	r.GET("/other/:id", func(c *gin.Context) { c.Set("id", u.ID+100); c.Next() }, testHandler.GetMyImageDetail)
+	r.GET("/images", func(c *gin.Context) { c.Set("id", u.ID); c.Next() }, testHandler.GetMyImages)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/images/"+strconv.FormatUint(uint64(img.ID), 10), nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("detail 期望 200，实际为 %d body=%s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		ID       uint   `json:"id"`
+		URL      string `json:"url"`
+		Metadata struct {
+			Make string `json:"make"`
+			ISO  int    `json:"iso"`
+		} `json:"metadata"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if resp.ID != img.ID || resp.URL == "" || resp.Metadata.Make != "TestMaker" || resp.Metadata.ISO != 200 {
+		t.Fatalf("非预期 detail resp: %s", rec.Body.String())
+	}
+
+	rec2 := httptest.NewRecorder()
+	r.ServeHTTP(rec2, httptest.NewRequest(http.MethodGet, "/other/"+strconv.FormatUint(uint64(img.ID), 10), nil))
+	if rec2.Code != http.StatusNotFound {
+		t.Fatalf("其他用户期望 404，实际为 %d", rec2.Code)
+	}
+
+	rec3 := httptest.NewRecorder()
+	r.ServeHTTP(rec3, httptest.NewRequest(http.MethodGet, "/images?taken_from=yesterday", nil))
+	if rec3.Code != http.StatusBadRequest {
+		t.Fatalf("非法 taken_from 期望 400，实际为 %d", rec3.Code)
+	}
+
+	rec4 := httptest.NewRecorder()
+	r.ServeHTTP(rec4, httptest.NewRequest(http.MethodGet, "/images?sort=size", nil))
+	if rec4.Code != http.StatusBadRequest {
+		t.Fatalf("非法 sort 期望 400，实际为 %d", rec4.Code)
+	}
+}
+
+// 测试内容：验证上传时提交标题与描述、PATCH 修改图片信息，以及 download=1 时以原始文件名作为附件返回原图。
+func TestImageInfoHandlers_UploadUpdateAndDownload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+
+	auth := func(c *gin.Context) { c.Set("id", u.ID); c.Next() }
+	r := gin.New()
+	r.POST("/upload", auth, testHandler.UploadImage)
+	r.PATCH("/images/:id", auth, testHandler.UpdateMyImage)
+	r.GET("/imgs/*filepath", testHandler.ServeImage)
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	_ = w.WriteField("title", "日落")
+	_ = w.WriteField("description", "beach")
+	part, _ := w.CreateFormFile("file", "海边.png")
+	_, _ = part.Write(testutils.MinimalPNG())
+	_ = w.Close()
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("upload 期望 200，实际为 %d body=%s", rec.Code, rec.Body.String())
+	}
+	var uploadResp struct {
+		ID uint `json:"id"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &uploadResp)
+
+	var img model.Image
+	_ = testGormDB.First(&img, uploadResp.ID).Error
+	if img.OriginalName != "海边.png" || img.Title != "日落" || img.Description != "beach" {
+		t.Fatalf("非预期图片信息: %+v", img)
+	}
+
+	target := "/images/" + strconv.FormatUint(uint64(img.ID), 10)
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, target, strings.NewReader(`{"title":"晚霞","original_name":"sunset.png"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("patch 期望 200，实际为 %d body=%s", rec.Code, rec.Body.String())
+	}
+	var detail struct {
+		Title        string `json:"title"`
+		Description  string `json:"description"`
+		OriginalName string `json:"original_name"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &detail)
+	if detail.Title != "晚霞" || detail.Description != "beach" || detail.OriginalName != "sunset.png" {
+		t.Fatalf("非预期 patch resp: %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, target, strings.NewReader(`{"title":1}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("非法参数期望 400，实际为 %d", rec.Code)
+	}
+
+	rec = serveImageRequest(r, "/imgs/"+img.Path+"?download=1", "image/webp,*/*")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("download 期望 200，实际为 %d body=%s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != `attachment; filename="sunset.png"; filename*=UTF-8''sunset.png` {
+		t.Fatalf("非预期 Content-Disposition: %q", got)
+	}
+	if rec.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("期望下载返回原图，实际 Content-Type 为 %q", rec.Header().Get("Content-Type"))
+	}
+
+	rec = serveImageRequest(r, "/imgs/"+img.Path, "")
+	if rec.Header().Get("Content-Disposition") != "" {
+		t.Fatalf("期望普通访问不携带 Content-Disposition")
+	}
+}
+
+// 测试内容：验证签名链接接口可省略请求体、校验有效期，并对他人图片返回 404。
+func TestCreateMyImageSignedURLHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDB(t)
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+	img := model.Image{Filename: "a.png", Path: "a.png", Size: 1, Width: 1, Height: 1, MimeType: ".png", UploadedAt: 1, UserID: u.ID, Visibility: model.ImageVisibilityPrivate}
+	_ = testGormDB.Create(&img).Error
+
+	r := gin.New()
+	r.POST("/images/:id/signed-url", func(c *gin.Context) { c.Set("id", u.ID); c.Next() }, testHandler.CreateMyImageSignedURL)
+	r.POST("/other/:id/signed-url", func(c *gin.Context) { c.Set("id", u.ID+100); c.Next() }, testHandler.CreateMyImageSignedURL)
+
+	target := "/images/" + strconv.FormatUint(uint64(img.ID), 10) + "/signed-url"
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("期望 200，实际为 %d body=%s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		URL       string `json:"url"`
+		ExpiresAt int64  `json:"expires_at"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if !strings.Contains(resp.URL, "/a.png?exp=") || !strings.Contains(resp.URL, "&sig=") || resp.ExpiresAt == 0 {
+		t.Fatalf("非预期签名链接: %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, strings.NewReader(`{"expires_in":-5}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("非法有效期期望 400，实际为 %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/other/"+strconv.FormatUint(uint64(img.ID), 10)+"/signed-url", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("他人图片期望 404，实际为 %d", rec.Code)
+	}
+}
+
+// 测试内容：验证上传表单的 expires_in 写入过期时间，未提交时使用用户默认有效期，非法取值返回 400。
+func TestUploadImageHandler_Expiry(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	ttl := int64(120)
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com", ImageTTL: &ttl}
+	_ = testGormDB.Create(&u).Error
+
+	r := gin.New()
+	r.POST("/upload", func(c *gin.Context) { c.Set("id", u.ID); c.Next() }, testHandler.UploadImage)
+	upload := func(filename string, content []byte, fields map[string]string) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		w := multipart.NewWriter(&body)
+		part, _ := w.CreateFormFile("file", filename)
+		_, _ = part.Write(content)
+		for k, v := range fields {
+			_ = w.WriteField(k, v)
+		}
+		_ = w.Close()
+		req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+		req.Header.Set("Content-Type", w.FormDataContentType())
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+	expiresAt := func(rec *httptest.ResponseRecorder) int64 {
+		var resp struct {
+			ExpiresAt *int64 `json:"expires_at"`
+		}
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		if rec.Code != http.StatusOK || resp.ExpiresAt == nil {
+			t.Fatalf("期望返回过期时间，实际为 %d body=%s", rec.Code, rec.Body.String())
+		}
+		return *resp.ExpiresAt - time.Now().Unix()
+	}
+
+	if w := upload("a.png", testutils.MinimalPNG(), map[string]string{"expires_in": "1h"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("非法 expires_in 期望 400，实际为 %d", w.Code)
+	}
+	if left := expiresAt(upload("a.png", testutils.MinimalPNG(), map[string]string{"expires_in": "60"})); left < 58 || left > 60 {
+		t.Fatalf("期望约 60 秒后过期，实际剩余 %d 秒", left)
+	}
+	if left := expiresAt(upload("b.jpg", testutils.JPEGWithEXIF(testutils.EXIFFixture{}), nil)); left < 118 || left > 120 {
+		t.Fatalf("期望使用用户默认有效期约 120 秒，实际剩余 %d 秒", left)
+	}
+}
+
+// 测试内容：验证用户访问统计接口返回每日访问次数与来源站点，非法 days 参数返回 400，他人图片返回 404。
+func TestGetMyImageStatsHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDB(t)
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+	img := model.Image{Filename: "a.png", Path: "a.png", Size: 1, Width: 1, Height: 1, MimeType: ".png", UploadedAt: 1, UserID: u.ID}
+	_ = testGormDB.Create(&img).Error
+	testHandler.ImageHandler.imageService.RecordImageView(img.ID, "https://blog.example.com/post")
+	if err := testHandler.ImageHandler.imageService.FlushImageViews(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	r := gin.New()
+	r.GET("/images/:id/stats", func(c *gin.Context) { c.Set("id", u.ID); c.Next() }, testHandler.GetMyImageStats)
+	r.GET("/other/:id/stats", func(c *gin.Context) { c.Set("id", u.ID+100); c.Next() }, testHandler.GetMyImageStats)
+
+	id := strconv.FormatUint(uint64(img.ID), 10)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/images/"+id+"/stats?days=7", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("期望 200，实际为 %d body=%s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		TotalViews int64 `json:"total_views"`
+		Daily      []struct {
+			Date  string `json:"date"`
+			Views int64  `json:"views"`
+		} `json:"daily"`
+		Referrers []struct {
+			Host  string `json:"host"`
+			Views int64  `json:"views"`
+		} `json:"referrers"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	if resp.TotalViews != 1 || len(resp.Daily) != 7 || resp.Daily[6].Views != 1 || resp.Daily[6].Date == "" ||
+		len(resp.Referrers) != 1 || resp.Referrers[0].Host != "blog.example.com" {
+		t.Fatalf("非预期 stats resp: %s", rec.Body.String())
+	}
+
+	for target, want := range map[string]int{
+		"/images/" + id + "/stats?days=abc": http.StatusBadRequest,
+		"/images/" + id + "/stats?days=0x":  http.StatusBadRequest,
+		"/images/" + id + "/stats?days=400": http.StatusBadRequest,
+		"/images/abc/stats":                 http.StatusBadRequest,
+		"/other/" + id + "/stats":           http.StatusNotFound,
+	} {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		if rec.Code != want {
+			t.Fatalf("%s 期望 %d，实际为 %d", target, want, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"net/http"
+	"perfect-pic-server/internal/common/httpx"
+	moduledto "perfect-pic-server/internal/dto"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxTrashBatchSize 单次恢复或永久删除的最大图片数，与批量删除保持一致。
+const maxTrashBatchSize = 50
+
+// ListMyTrash 分页获取用户回收站中的图片，按删除时间倒序。
+func (h *ImageHandler) ListMyTrash(c *gin.Context) {
+	userID, _ := c.Get("id")
+	uid, ok := userID.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的用户ID类型"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "10"))
+	items, total, page, pageSize, err := h.imageService.ListTrashedImages(uid, page, pageSize)
+	if err != nil {
+		httpx.WriteServiceError(c, err, "获取回收站列表失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"list":      items,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// RestoreMyTrash 从回收站恢复图片。
+func (h *ImageHandler) RestoreMyTrash(c *gin.Context) {
+	userID, _ := c.Get("id")
+	uid, ok := userID.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的用户ID类型"})
+		return
+	}
+
+	var req moduledto.RestoreImagesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+	if len(req.IDs) > maxTrashBatchSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "一次最多只能恢复 50 张图片"})
+		return
+	}
+
+	restored, err := h.imageService.RestoreTrashedImages(uid, req.IDs)
+	if err != nil {
+		httpx.WriteServiceError(c, err, "恢复图片失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "恢复成功", "restored_count": restored})
+}
+
+// DeleteMyTrash 永久删除回收站中的指定图片，立即释放文件与存储空间。
+func (h *ImageHandler) DeleteMyTrash(c *gin.Context) {
+	userID, _ := c.Get("id")
+	uid, ok := userID.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的用户ID类型"})
+		return
+	}
+
+	var req moduledto.BatchDeleteImagesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+	if len(req.IDs) > maxTrashBatchSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "一次最多只能删除 50 张图片"})
+		return
+	}
+
+	deleted, err := h.imageService.DeleteTrashedImages(uid, req.IDs)
+	if err != nil {
+		httpx.WriteServiceError(c, err, "删除失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "已永久删除", "deleted_count": deleted})
+}
+
+// EmptyMyTrash 清空回收站。
+func (h *ImageHandler) EmptyMyTrash(c *gin.Context) {
+	userID, _ := c.Get("id")
+	uid, ok := userID.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的用户ID类型"})
+		return
+	}
+
+	deleted, err := h.imageService.EmptyTrash(uid)
+	if err != nil {
+		httpx.WriteServiceError(c, err, "清空回收站失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "回收站已清空", "deleted_count": deleted})
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"perfect-pic-server/internal/model"
+	"perfect-pic-server/internal/testutils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 测试内容：验证回收站的列表、恢复、永久删除与清空接口，以及配额在永久删除前持续占用。
+func TestTrashHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+
+	setUser := func(c *gin.Context) { c.Set("id", u.ID); c.Next() }
+	r := gin.New()
+	r.POST("/upload", setUser, testHandler.UploadImage)
+	r.DELETE("/images/batch", setUser, testHandler.BatchDeleteMyImages)
+	r.GET("/trash", setUser, testHandler.ListMyTrash)
+	r.POST("/trash/restore", setUser, testHandler.RestoreMyTrash)
+	r.DELETE("/trash/batch", setUser, testHandler.DeleteMyTrash)
+	r.DELETE("/trash", setUser, testHandler.EmptyMyTrash)
+
+	upload := func(filename string, content []byte) uint {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, newUploadRequest(t, "/upload", filename, content))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("upload 期望 200，实际为 %d body=%s", rec.Code, rec.Body.String())
+		}
+		var resp struct {
+			ID uint `json:"id"`
+		}
+		_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+		return resp.ID
+	}
+	doJSON := func(method, target string, payload any) *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		if payload != nil {
+			_ = json.NewEncoder(&body).Encode(payload)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(method, target, &body))
+		return rec
+	}
+	storageUsed := func() int64 {
+		var got model.User
+		_ = testGormDB.First(&got, u.ID).Error
+		return got.StorageUsed
+	}
+
+	id1 := upload("a.png", testutils.MinimalPNG())
+	id2 := upload("b.jpg", testutils.JPEGWithEXIF(testutils.EXIFFixture{}))
+	used := storageUsed()
+
+	if rec := doJSON(http.MethodDelete, "/images/batch", gin.H{"ids": []uint{id1, id2}}); rec.Code != http.StatusOK {
+		t.Fatalf("batch delete 期望 200，实际为 %d body=%s", rec.Code, rec.Body.String())
+	}
+	if storageUsed() != used {
+		t.Fatalf("期望移入回收站后配额不变，storage_used=%d", storageUsed())
+	}
+
+	rec := doJSON(http.MethodGet, "/trash?page=1&page_size=10", nil)
+	var listResp struct {
+		List []struct {
+			ID        uint   `json:"id"`
+			URL       string `json:"url"`
+			DeletedAt int64  `json:"deleted_at"`
+			PurgeAt   int64  `json:"purge_at"`
+		} `json:"list"`
+		Total int64 `json:"total"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &listResp)
+	if rec.Code != http.StatusOK || listResp.Total != 2 || len(listResp.List) != 2 {
+		t.Fatalf("trash list 不符: %d body=%s", rec.Code, rec.Body.String())
+	}
+	if item := listResp.List[0]; item.URL == "" || item.DeletedAt == 0 || item.PurgeAt <= item.DeletedAt {
+		t.Fatalf("回收站条目不符: %+v", item)
+	}
+
+	if rec := doJSON(http.MethodPost, "/trash/restore", gin.H{"ids": []uint{id1}}); rec.Code != http.StatusOK {
+		t.Fatalf("restore 期望 200，实际为 %d body=%s", rec.Code, rec.Body.String())
+	}
+	if err := testGormDB.First(&model.Image{}, id1).Error; err != nil {
+		t.Fatalf("期望恢复后图片重新可见: %v", err)
+	}
+	if rec := doJSON(http.MethodPost, "/trash/restore", gin.H{"ids": []uint{id1}}); rec.Code != http.StatusNotFound {
+		t.Fatalf("恢复不在回收站的图片期望 404，实际为 %d", rec.Code)
+	}
+	if rec := doJSON(http.MethodPost, "/trash/restore", gin.H{}); rec.Code != http.StatusBadRequest {
+		t.Fatalf("缺少 ids 期望 400，实际为 %d", rec.Code)
+	}
+
+	if rec := doJSON(http.MethodDelete, "/trash/batch", gin.H{"ids": []uint{id2}}); rec.Code != http.StatusOK {
+		t.Fatalf("trash delete 期望 200，实际为 %d body=%s", rec.Code, rec.Body.String())
+	}
+	if err := testGormDB.Unscoped().First(&model.Image{}, id2).Error; err == nil {
+		t.Fatalf("期望永久删除后记录不存在")
+	}
+	if storageUsed() >= used {
+		t.Fatalf("期望永久删除后释放配额，storage_used=%d", storageUsed())
+	}
+
+	if rec := doJSON(http.MethodDelete, "/images/batch", gin.H{"ids": []uint{id1}}); rec.Code != http.StatusOK {
+		t.Fatalf("batch delete 期望 200，实际为 %d", rec.Code)
+	}
+	if rec := doJSON(http.MethodDelete, "/trash", nil); rec.Code != http.StatusOK || !bytes.Contains(rec.Body.Bytes(), []byte(`"deleted_count":1`)) {
+		t.Fatalf("empty trash 不符: %d body=%s", rec.Code, rec.Body.String())
+	}
+	if storageUsed() != 0 {
+		t.Fatalf("期望清空回收站后释放全部配额，storage_used=%d", storageUsed())
+	}
+}
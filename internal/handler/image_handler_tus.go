@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	platformservice "perfect-pic-server/internal/common"
+	"perfect-pic-server/internal/common/httpx"
+	moduledto "perfect-pic-server/internal/dto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	tusVersion     = "1.0.0"
+	tusExtensions  = "creation,termination,expiration"
+	tusContentType = "application/offset+octet-stream"
+)
+
+// TusOptions 返回服务端支持的 tus 协议版本、扩展与最大上传大小。
+func (h *ImageHandler) TusOptions(c *gin.Context) {
+	c.Header("Tus-Resumable", tusVersion)
+	c.Header("Tus-Version", tusVersion)
+	c.Header("Tus-Extension", tusExtensions)
+	c.Header("Tus-Max-Size", strconv.FormatInt(h.imageUseCase.TusMaxSize(), 10))
+	c.Status(http.StatusNoContent)
+}
+
+// CreateTusUpload 创建可续传上传（creation 扩展），文件名等信息通过 Upload-Metadata 提交。
+func (h *ImageHandler) CreateTusUpload(c *gin.Context) {
+	uid, ok := tusPrecheck(c)
+	if !ok {
+		return
+	}
+
+	length, err := strconv.ParseInt(c.GetHeader("Upload-Length"), 10, 64)
+	if err != nil || length < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload-Length 无效"})
+		return
+	}
+	if length > h.imageUseCase.TusMaxSize() {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "文件大小超过上传限制"})
+		return
+	}
+
+	upload, err := h.imageUseCase.CreateTusUpload(uid, length, c.GetHeader("Upload-Metadata"))
+	if err != nil {
+		httpx.WriteServiceError(c, err, "创建上传失败")
+		return
+	}
+
+	c.Header("Location", strings.TrimSuffix(c.Request.URL.Path, "/")+"/"+upload.ID)
+	setTusProgressHeaders(c, upload)
+	c.Status(http.StatusCreated)
+}
+
+// HeadTusUpload 返回上传的当前偏移量，客户端据此断点续传。
+func (h *ImageHandler) HeadTusUpload(c *gin.Context) {
+	uid, ok := tusPrecheck(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	upload, err := h.imageUseCase.GetTusUpload(uid, c.Param("id"))
+	if err != nil {
+		// HEAD 响应不能携带响应体
+		if serviceErr, ok := platformservice.AsServiceError(err); ok && serviceErr.Code == platformservice.ErrorCodeNotFound {
+			c.Status(http.StatusNotFound)
+		} else {
+			c.Status(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	c.Header("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	if upload.Metadata != "" {
+		c.Header("Upload-Metadata", upload.Metadata)
+	}
+	setTusProgressHeaders(c, upload)
+	c.Status(http.StatusOK)
+}
+
+// PatchTusUpload 从 Upload-Offset 处写入一段数据。上传未完成时返回 204；
+// 写满后按普通上传流程保存图片，并以与普通上传相同的 JSON 响应返回图片地址。
+func (h *ImageHandler) PatchTusUpload(c *gin.Context) {
+	uid, ok := tusPrecheck(c)
+	if !ok {
+		return
+	}
+
+	if c.ContentType() != tusContentType {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Content-Type 必须为 " + tusContentType})
+		return
+	}
+	offset, err := strconv.ParseInt(c.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload-Offset 无效"})
+		return
+	}
+
+	upload, result, err := h.imageUseCase.WriteTusUpload(uid, c.Param("id"), offset, c.Request.Body)
+	if upload != nil {
+		setTusProgressHeaders(c, upload)
+	}
+	if err != nil || result != nil {
+		writeUploadResult(c, result, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// DeleteTusUpload 终止上传（termination 扩展）。
+func (h *ImageHandler) DeleteTusUpload(c *gin.Context) {
+	uid, ok := tusPrecheck(c)
+	if !ok {
+		return
+	}
+
+	if err := h.imageUseCase.TerminateTusUpload(uid, c.Param("id")); err != nil {
+		if _, ok := platformservice.AsServiceError(err); !ok {
+			log.Printf("Terminate tus upload failed: %v", err)
+		}
+		httpx.WriteServiceError(c, err, "终止上传失败")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// tusPrecheck 为响应设置 Tus-Resumable 头，并校验用户身份与客户端的协议版本。
+func tusPrecheck(c *gin.Context) (uint, bool) {
+	c.Header("Tus-Resumable", tusVersion)
+
+	userID, _ := c.Get("id")
+	uid, ok := userID.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "无效的用户ID类型"})
+		return 0, false
+	}
+	if c.GetHeader("Tus-Resumable") != tusVersion {
+		c.Header("Tus-Version", tusVersion)
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "不支持的 tus 协议版本"})
+		return 0, false
+	}
+	return uid, true
+}
+
+func setTusProgressHeaders(c *gin.Context, upload *moduledto.TusUpload) {
+	c.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	if upload.Offset < upload.Length {
+		c.Header("Upload-Expires", time.Unix(upload.ExpiresAt, 0).UTC().Format(http.TimeFormat))
+	}
+}
@@ -0,0 +1,202 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+
+	"perfect-pic-server/internal/model"
+	"perfect-pic-server/internal/testutils"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newTusRouter(uid uint) *gin.Engine {
+	r := gin.New()
+	setUser := func(c *gin.Context) { c.Set("id", uid); c.Next() }
+	g := r.Group("/tus", setUser)
+	g.OPTIONS("", testHandler.TusOptions)
+	g.POST("", testHandler.CreateTusUpload)
+	g.HEAD("/:id", testHandler.HeadTusUpload)
+	g.PATCH("/:id", testHandler.PatchTusUpload)
+	g.DELETE("/:id", testHandler.DeleteTusUpload)
+	return r
+}
+
+func doTus(r *gin.Engine, method, target string, body io.Reader, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, body)
+	req.Header.Set("Tus-Resumable", "1.0.0")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+// 测试内容：验证 tus 上传的完整流程：创建、分段 PATCH、HEAD 查询进度，写满后保存图片并返回与普通上传相同的响应。
+func TestTusUploadHandlers_Flow(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+	r := newTusRouter(u.ID)
+
+	w := doTus(r, http.MethodOptions, "/tus", nil, nil)
+	if w.Code != http.StatusNoContent || w.Header().Get("Tus-Version") != "1.0.0" || w.Header().Get("Tus-Max-Size") == "" {
+		t.Fatalf("OPTIONS 响应不符: %d %v", w.Code, w.Header())
+	}
+
+	content := testutils.MinimalPNG()
+	metadata := "filename " + base64.StdEncoding.EncodeToString([]byte("a.png")) + ",title " + base64.StdEncoding.EncodeToString([]byte("分段"))
+	w = doTus(r, http.MethodPost, "/tus", nil, map[string]string{
+		"Upload-Length":   strconv.Itoa(len(content)),
+		"Upload-Metadata": metadata,
+	})
+	if w.Code != http.StatusCreated || w.Header().Get("Tus-Resumable") != "1.0.0" {
+		t.Fatalf("创建期望 201，实际为 %d body=%s", w.Code, w.Body.String())
+	}
+	location := w.Header().Get("Location")
+	if len(location) <= len("/tus/") || w.Header().Get("Upload-Expires") == "" {
+		t.Fatalf("创建响应头不符: %v", w.Header())
+	}
+
+	half := len(content) / 2
+	patch := func(offset int, data []byte) *httptest.ResponseRecorder {
+		return doTus(r, http.MethodPatch, location, bytes.NewReader(data), map[string]string{
+			"Content-Type":  "application/offset+octet-stream",
+			"Upload-Offset": strconv.Itoa(offset),
+		})
+	}
+	w = patch(0, content[:half])
+	if w.Code != http.StatusNoContent || w.Header().Get("Upload-Offset") != strconv.Itoa(half) {
+		t.Fatalf("PATCH 期望 204 且偏移量为 %d，实际为 %d %q", half, w.Code, w.Header().Get("Upload-Offset"))
+	}
+
+	w = doTus(r, http.MethodHead, location, nil, nil)
+	if w.Code != http.StatusOK || w.Header().Get("Upload-Offset") != strconv.Itoa(half) ||
+		w.Header().Get("Upload-Length") != strconv.Itoa(len(content)) || w.Header().Get("Cache-Control") != "no-store" {
+		t.Fatalf("HEAD 响应不符: %d %v", w.Code, w.Header())
+	}
+
+	if w := patch(0, content); w.Code != http.StatusConflict {
+		t.Fatalf("偏移量不一致期望 409，实际为 %d", w.Code)
+	}
+
+	w = patch(half, content[half:])
+	if w.Code != http.StatusOK {
+		t.Fatalf("最后一段期望 200，实际为 %d body=%s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		ID  uint   `json:"id"`
+		URL string `json:"url"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	var img model.Image
+	if err := testGormDB.First(&img, resp.ID).Error; err != nil || resp.URL == "" {
+		t.Fatalf("期望保存图片记录，实际为 %+v %v", resp, err)
+	}
+	if img.OriginalName != "a.png" || img.Title != "分段" || img.Size != int64(len(content)) {
+		t.Fatalf("图片记录不符: %+v", img)
+	}
+
+	if w := doTus(r, http.MethodHead, location, nil, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("完成后 HEAD 期望 404，实际为 %d", w.Code)
+	}
+}
+
+// 测试内容：验证 tus 接口对协议版本、请求头、内容类型、大小限制、文件类型、元数据、配额与终止上传的处理，
+// 元数据在创建时校验，写满后保存失败时保留已接收的数据。
+func TestTusUploadHandlers_Errors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	q := int64(100)
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com", StorageQuota: &q}
+	_ = testGormDB.Create(&u).Error
+	r := newTusRouter(u.ID)
+	pngMeta := "filename " + base64.StdEncoding.EncodeToString([]byte("a.png"))
+
+	req := httptest.NewRequest(http.MethodPost, "/tus", nil)
+	req.Header.Set("Upload-Length", "10")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusPreconditionFailed || rec.Header().Get("Tus-Version") != "1.0.0" {
+		t.Fatalf("缺少 Tus-Resumable 期望 412，实际为 %d", rec.Code)
+	}
+
+	cases := []struct {
+		name    string
+		headers map[string]string
+		code    int
+	}{
+		{"缺少长度", map[string]string{"Upload-Metadata": pngMeta}, http.StatusBadRequest},
+		{"超过大小限制", map[string]string{"Upload-Length": strconv.Itoa(1 << 40), "Upload-Metadata": pngMeta}, http.StatusRequestEntityTooLarge},
+		{"不支持的类型", map[string]string{"Upload-Length": "10", "Upload-Metadata": "filename " + base64.StdEncoding.EncodeToString([]byte("a.exe"))}, http.StatusBadRequest},
+		{"元数据格式错误", map[string]string{"Upload-Length": "10", "Upload-Metadata": "filename !!!"}, http.StatusBadRequest},
+		{"有效期格式错误", map[string]string{"Upload-Length": "10", "Upload-Metadata": pngMeta + ",expires_in " + base64.StdEncoding.EncodeToString([]byte("soon"))}, http.StatusBadRequest},
+		{"有效期超出范围", map[string]string{"Upload-Length": "10", "Upload-Metadata": pngMeta + ",expires_in " + base64.StdEncoding.EncodeToString([]byte("-1"))}, http.StatusBadRequest},
+		{"可见性错误", map[string]string{"Upload-Length": "10", "Upload-Metadata": pngMeta + ",visibility " + base64.StdEncoding.EncodeToString([]byte("secret"))}, http.StatusBadRequest},
+		{"标题过长", map[string]string{"Upload-Length": "10", "Upload-Metadata": pngMeta + ",title " + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte("x"), 1000))}, http.StatusBadRequest},
+		{"配额不足", map[string]string{"Upload-Length": "101", "Upload-Metadata": pngMeta}, http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		if w := doTus(r, http.MethodPost, "/tus", nil, tc.headers); w.Code != tc.code {
+			t.Fatalf("%s: 期望 %d，实际为 %d body=%s", tc.name, tc.code, w.Code, w.Body.String())
+		}
+	}
+
+	w := doTus(r, http.MethodPost, "/tus", nil, map[string]string{"Upload-Length": "10", "Upload-Metadata": pngMeta})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("创建期望 201，实际为 %d body=%s", w.Code, w.Body.String())
+	}
+	location := w.Header().Get("Location")
+
+	if w := doTus(r, http.MethodPatch, location, bytes.NewReader([]byte("x")), map[string]string{"Upload-Offset": "0"}); w.Code != http.StatusUnsupportedMediaType {
+		t.Fatalf("错误的 Content-Type 期望 415，实际为 %d", w.Code)
+	}
+	if w := doTus(r, http.MethodPatch, location, bytes.NewReader([]byte("x")), map[string]string{"Content-Type": "application/offset+octet-stream"}); w.Code != http.StatusBadRequest {
+		t.Fatalf("缺少 Upload-Offset 期望 400，实际为 %d", w.Code)
+	}
+	if w := doTus(r, http.MethodPatch, "/tus/unknown", bytes.NewReader([]byte("x")), map[string]string{"Content-Type": "application/offset+octet-stream", "Upload-Offset": "0"}); w.Code != http.StatusNotFound {
+		t.Fatalf("不存在的上传期望 404，实际为 %d", w.Code)
+	}
+
+	// 内容不是有效图片时，写满后按普通上传流程拒绝，已接收的数据保留到客户端终止或过期
+	w = doTus(r, http.MethodPatch, location, bytes.NewReader([]byte("0123456789")), map[string]string{"Content-Type": "application/offset+octet-stream", "Upload-Offset": "0"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("无效图片内容期望 400，实际为 %d body=%s", w.Code, w.Body.String())
+	}
+	if w := doTus(r, http.MethodHead, location, nil, nil); w.Code != http.StatusOK || w.Header().Get("Upload-Offset") != "10" {
+		t.Fatalf("保存失败后 HEAD 期望 200 且保留进度，实际为 %d %v", w.Code, w.Header())
+	}
+	if w := doTus(r, http.MethodDelete, location, nil, nil); w.Code != http.StatusNoContent {
+		t.Fatalf("终止上传期望 204，实际为 %d", w.Code)
+	}
+
+	w = doTus(r, http.MethodPost, "/tus", nil, map[string]string{"Upload-Length": "10", "Upload-Metadata": pngMeta})
+	location = w.Header().Get("Location")
+	if w := doTus(r, http.MethodDelete, location, nil, nil); w.Code != http.StatusNoContent {
+		t.Fatalf("终止上传期望 204，实际为 %d", w.Code)
+	}
+	if w := doTus(r, http.MethodDelete, location, nil, nil); w.Code != http.StatusNotFound {
+		t.Fatalf("重复终止期望 404，实际为 %d", w.Code)
+	}
+}
@@ -17,3 +17,15 @@ func (h *SystemHandler) GetServerStats(c *gin.Context) {
 
 	c.JSON(http.StatusOK, stats)
 }
+
+// ReconcileStorage 对账存储文件与数据库记录并返回报告；默认仅预演，dry_run=false 时执行修复。
+func (h *SystemHandler) ReconcileStorage(c *gin.Context) {
+	dryRun := c.DefaultQuery("dry_run", "true") != "false"
+	report, err := h.imageService.ReconcileStorage(dryRun)
+	if err != nil {
+		httpx.WriteServiceError(c, err, "存储对账失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
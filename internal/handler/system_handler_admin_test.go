@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
 
 	"perfect-pic-server/internal/model"
@@ -52,3 +53,56 @@ func TestGetServerStats_ReturnsCounts(t *testing.T) {
 		t.Fatalf("非预期 stats: %+v", resp)
 	}
 }
+
+// 测试内容：验证存储对账接口默认仅预演并返回 JSON 报告，dry_run=false 时执行修复。
+func TestReconcileStorageHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	u := model.User{Username: "u1", Password: "x", Status: 1, Email: "u1@example.com", StorageUsed: 10}
+	_ = testGormDB.Create(&u).Error
+	img := model.Image{Filename: "a.png", Path: "2026/02/13/a.png", Size: 10, MimeType: ".png", UploadedAt: 1, UserID: u.ID}
+	_ = testGormDB.Create(&img).Error
+
+	r := gin.New()
+	r.POST("/storage/reconcile", testHandler.ReconcileStorage)
+
+	var resp struct {
+		DryRun       bool `json:"dry_run"`
+		MissingFiles []struct {
+			Kind    string `json:"kind"`
+			ImageID uint   `json:"image_id"`
+		} `json:"missing_files"`
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/storage/reconcile", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望 200，实际为 %d body=%s", w.Code, w.Body.String())
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	if !resp.DryRun || len(resp.MissingFiles) != 1 || resp.MissingFiles[0].Kind != "image" || resp.MissingFiles[0].ImageID != img.ID {
+		t.Fatalf("非预期对账报告: %s", w.Body.String())
+	}
+	if err := testGormDB.First(&model.Image{}, img.ID).Error; err != nil {
+		t.Fatalf("期望预演不修改记录: %v", err)
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/storage/reconcile?dry_run=false", nil))
+	if w.Code != http.StatusOK || !json.Valid(w.Body.Bytes()) {
+		t.Fatalf("期望 200，实际为 %d body=%s", w.Code, w.Body.String())
+	}
+	if err := testGormDB.Unscoped().First(&model.Image{}, img.ID).Error; err == nil {
+		t.Fatalf("期望修复后删除文件缺失的图片记录")
+	}
+	var got model.User
+	_ = testGormDB.First(&got, u.ID).Error
+	if got.StorageUsed != 0 {
+		t.Fatalf("期望修复后 storage_used 为 0，实际为 %d", got.StorageUsed)
+	}
+}
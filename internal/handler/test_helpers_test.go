@@ -8,6 +8,9 @@ import (
 	"perfect-pic-server/internal/pkg/cache"
 	pkgmail "perfect-pic-server/internal/pkg/email"
 	jwtpkg "perfect-pic-server/internal/pkg/jwt"
+	"perfect-pic-server/internal/pkg/remotefetch"
+	"perfect-pic-server/internal/pkg/storage"
+	"perfect-pic-server/internal/pkg/urlsign"
 	"perfect-pic-server/internal/repository"
 	"perfect-pic-server/internal/service"
 	"perfect-pic-server/internal/testutils"
@@ -53,20 +56,26 @@ func setupTestDB(t *testing.T) {
 
 	authService := service.NewAuthService(dbConfig, tokenService)
 	userService := service.NewUserService(userStore, dbConfig, cacheStore, tokenService)
-	imageService := service.NewImageService(imageStore, dbConfig, staticConfig)
+	storages, err := storage.NewManager(config.NewStorageConfig(staticConfig))
+	if err != nil {
+		t.Fatalf("init storage: %v", err)
+	}
+	imageService := service.NewImageService(imageStore, dbConfig, staticConfig, storages, urlsign.NewSigner(config.NewURLSignConfig(staticConfig)), remotefetch.NewFetcher(&remotefetch.Config{AllowPrivateNetworks: true}))
 	emailService := service.NewEmailService(dbConfig, pkgmail.NewMailer(), staticConfig)
 	captchaService := service.NewCaptchaService(dbConfig)
 	initService := service.NewInitService(systemStore, dbConfig)
 	passkeyService := service.NewPasskeyService(passkeyStore, dbConfig, cacheStore)
 	settingsService := service.NewSettingsService(settingStore, dbConfig)
+	accessTokenService := service.NewAccessTokenService(repository.NewAccessTokenRepository(gdb))
+	albumService := service.NewAlbumService(repository.NewAlbumRepository(gdb), imageStore)
 
 	authUseCase := appuc.NewAuthUseCase(authService, userStore, userService, emailService, initService, dbConfig)
 	userUseCase := appuc.NewUserUseCase(authService, userService, userStore, emailService, dbConfig)
-	imageUseCase := appuc.NewImageUseCase(imageService, userService, userStore, staticConfig, dbConfig)
+	imageUseCase := appuc.NewImageUseCase(imageService, service.NewTusService(dbConfig, staticConfig, cacheStore), userService, userStore, staticConfig, dbConfig)
 	passkeyUseCase := appuc.NewPasskeyUseCase(passkeyService, passkeyStore, authService, userStore)
 	userManageUseCase := adminuc.NewUserManageUseCase(userService, imageService, passkeyService)
 	settingsUseCase := adminuc.NewSettingsUseCase(emailService)
-	statUseCase := adminuc.NewStatUseCase(imageStore, userStore)
+	statUseCase := adminuc.NewStatUseCase(imageStore, userStore, imageService)
 
 	testService = dbConfig
 	testUserSvc = userService
@@ -83,9 +92,9 @@ func setupTestDB(t *testing.T) {
 
 	testHandler = &compositeHandler{
 		AuthHandler:     NewAuthHandler(authService, captchaService, authUseCase, initService, dbConfig, passkeyUseCase),
-		UserHandler:     NewUserHandler(userService, userUseCase, userManageUseCase, imageService, imageUseCase, authService, passkeyService, passkeyUseCase),
-		ImageHandler:    NewImageHandler(imageService, imageUseCase),
-		SystemHandler:   NewSystemHandler(initService, statUseCase, dbConfig, staticConfig, userService),
+		UserHandler:     NewUserHandler(userService, userUseCase, userManageUseCase, imageService, imageUseCase, authService, passkeyService, passkeyUseCase, accessTokenService, appuc.NewIntegrationUseCase(accessTokenService, dbConfig)),
+		ImageHandler:    NewImageHandler(imageService, imageUseCase, albumService),
+		SystemHandler:   NewSystemHandler(initService, statUseCase, dbConfig, staticConfig, storages, userService, imageService),
 		SettingsHandler: NewSettingsHandler(settingsService, settingsUseCase),
 	}
 }
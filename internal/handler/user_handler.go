@@ -328,3 +328,84 @@ func (h *UserHandler) UpdateSelfPasskeyName(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "Passkey 名称更新成功"})
 }
+
+// ListSelfAccessTokens 获取当前用户的个人访问令牌列表（不含令牌明文）。
+func (h *UserHandler) ListSelfAccessTokens(c *gin.Context) {
+	userID, exists := c.Get("id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "获取用户ID失败"})
+		return
+	}
+
+	uid, ok := userID.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "获取用户ID失败"})
+		return
+	}
+
+	tokens, err := h.tokenService.ListUserAccessTokens(uid)
+	if err != nil {
+		httpx.WriteServiceError(c, err, "获取访问令牌列表失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"list": tokens})
+}
+
+// CreateSelfAccessToken 为当前用户创建个人访问令牌，令牌明文仅在本次响应中返回。
+func (h *UserHandler) CreateSelfAccessToken(c *gin.Context) {
+	userID, exists := c.Get("id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "获取用户ID失败"})
+		return
+	}
+
+	uid, ok := userID.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "获取用户ID失败"})
+		return
+	}
+
+	var req moduledto.CreateAccessTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "参数错误"})
+		return
+	}
+
+	token, err := h.tokenService.CreateUserAccessToken(uid, req)
+	if err != nil {
+		httpx.WriteServiceError(c, err, "创建访问令牌失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, token)
+}
+
+// RevokeSelfAccessToken 吊销当前用户指定 ID 的个人访问令牌。
+func (h *UserHandler) RevokeSelfAccessToken(c *gin.Context) {
+	userID, exists := c.Get("id")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "获取用户ID失败"})
+		return
+	}
+
+	uid, ok := userID.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "获取用户ID失败"})
+		return
+	}
+
+	idParam := c.Param("id")
+	tokenID, err := strconv.ParseUint(idParam, 10, 64)
+	if err != nil || tokenID == 0 || tokenID > math.MaxUint {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "id 参数错误"})
+		return
+	}
+
+	if err := h.tokenService.RevokeUserAccessToken(uid, uint(tokenID)); err != nil {
+		httpx.WriteServiceError(c, err, "吊销访问令牌失败")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "访问令牌已吊销"})
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"net/http"
+	"perfect-pic-server/internal/common/httpx"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetShareXConfig 下载 ShareX 自定义上传器配置（.sxcu），每次下载都会签发一个新的上传令牌。
+func (h *UserHandler) GetShareXConfig(c *gin.Context) {
+	userID, _ := c.Get("id")
+	uid, ok := userID.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "获取用户ID失败"})
+		return
+	}
+
+	config, err := h.integrationUseCase.ShareXConfig(uid)
+	if err != nil {
+		httpx.WriteServiceError(c, err, "生成 ShareX 配置失败")
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="perfect-pic.sxcu"`)
+	c.Header("Cache-Control", "no-store")
+	c.IndentedJSON(http.StatusOK, config)
+}
+
+// GetPicGoConfig 生成 PicGo / PicGo-Core 配置（Typora 通过 PicGo 上传时共用），每次生成都会签发一个新的上传令牌。
+func (h *UserHandler) GetPicGoConfig(c *gin.Context) {
+	userID, _ := c.Get("id")
+	uid, ok := userID.(uint)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "获取用户ID失败"})
+		return
+	}
+
+	config, err := h.integrationUseCase.PicGoConfig(uid)
+	if err != nil {
+		httpx.WriteServiceError(c, err, "生成 PicGo 配置失败")
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="picgo-config.json"`)
+	c.Header("Cache-Control", "no-store")
+	c.IndentedJSON(http.StatusOK, config)
+}
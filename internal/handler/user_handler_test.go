@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 
 	"perfect-pic-server/internal/model"
@@ -370,3 +371,75 @@ func TestUpdateSelfPasskeyNameHandler_BadRequest(t *testing.T) {
 		t.Fatalf("期望 400，实际为 %d body=%s", w.Code, w.Body.String())
 	}
 }
+
+// 测试内容：验证访问令牌的创建、列表与吊销接口，明文令牌仅在创建时返回。
+func TestSelfAccessTokenHandlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDB(t)
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+
+	r := gin.New()
+	setUser := func(c *gin.Context) { c.Set("id", u.ID); c.Next() }
+	r.GET("/tokens", setUser, testHandler.ListSelfAccessTokens)
+	r.POST("/tokens", setUser, testHandler.CreateSelfAccessToken)
+	r.DELETE("/tokens/:id", setUser, testHandler.RevokeSelfAccessToken)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/tokens", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := post(`{"name":"x"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("缺少权限范围期望 400，实际为 %d", w.Code)
+	}
+	if w := post(`{"name":"x","scopes":["admin"]}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("不支持的权限范围期望 400，实际为 %d", w.Code)
+	}
+
+	w := post(`{"name":"PicGo","scopes":["upload","read"],"expires_in_days":7}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("期望 201，实际为 %d body=%s", w.Code, w.Body.String())
+	}
+	var created struct {
+		ID    uint   `json:"id"`
+		Token string `json:"token"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &created)
+	if created.ID == 0 || !strings.HasPrefix(created.Token, "ppt_") {
+		t.Fatalf("创建响应不符: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tokens", nil))
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), created.Token) {
+		t.Fatalf("列表期望 200 且不含令牌明文，实际为 %d body=%s", w.Code, w.Body.String())
+	}
+	var list struct {
+		List []map[string]any `json:"list"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &list)
+	if len(list.List) != 1 || list.List[0]["token_hint"] == nil || list.List[0]["scopes"] == nil {
+		t.Fatalf("列表内容不符: %s", w.Body.String())
+	}
+
+	for target, code := range map[string]int{
+		"/tokens/abc": http.StatusBadRequest,
+		"/tokens/" + strconv.FormatUint(uint64(created.ID), 10): http.StatusOK,
+	} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, target, nil))
+		if w.Code != code {
+			t.Fatalf("%s: 期望 %d，实际为 %d", target, code, w.Code)
+		}
+	}
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/tokens/"+strconv.FormatUint(uint64(created.ID), 10), nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("重复吊销期望 404，实际为 %d", w.Code)
+	}
+}
@@ -29,16 +29,14 @@ func (h *SystemHandler) GetWebInfo(c *gin.Context) {
 }
 
 func (h *SystemHandler) GetImagePrefix(c *gin.Context) {
-	cfg := h.staticConfig
 	c.JSON(http.StatusOK, gin.H{
-		"image_prefix": cfg.Upload.URLPrefix,
+		"image_prefix": h.imageService.ImageURL(""),
 	})
 }
 
 func (h *SystemHandler) GetAvatarPrefix(c *gin.Context) {
-	cfg := h.staticConfig
 	c.JSON(http.StatusOK, gin.H{
-		"avatar_prefix": cfg.Upload.AvatarURLPrefix,
+		"avatar_prefix": h.imageService.AvatarURL(""),
 	})
 }
 
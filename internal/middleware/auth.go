@@ -3,6 +3,7 @@ package middleware
 import (
 	"errors"
 	"net/http"
+	"perfect-pic-server/internal/consts"
 	"perfect-pic-server/internal/pkg/jwt"
 	"perfect-pic-server/internal/service"
 	"strings"
@@ -12,11 +13,14 @@ import (
 )
 
 type AuthMiddleware struct {
-	jwt         *jwt.JWT
-	userService *service.UserService
+	jwt          *jwt.JWT
+	userService  *service.UserService
+	tokenService *service.AccessTokenService
 }
 
-func (m *AuthMiddleware) JWTAuth() gin.HandlerFunc {
+// JWTAuth 校验登录令牌。传入 scopes 时，该路由同时接受具备其中任一权限范围的个人访问令牌（ppt_ 开头）；
+// 未传入时个人访问令牌一律拒绝，仅允许登录令牌访问。
+func (m *AuthMiddleware) JWTAuth(scopes ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if m.jwt == nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "认证组件未初始化"})
@@ -40,6 +44,11 @@ func (m *AuthMiddleware) JWTAuth() gin.HandlerFunc {
 			return
 		}
 
+		if strings.HasPrefix(parts[1], consts.AccessTokenPrefix) {
+			m.accessTokenAuth(c, parts[1], scopes)
+			return
+		}
+
 		//解析 Token
 		claims, err := m.jwt.ParseLoginToken(parts[1])
 		if err != nil {
@@ -54,6 +63,37 @@ func (m *AuthMiddleware) JWTAuth() gin.HandlerFunc {
 	}
 }
 
+// accessTokenAuth 校验个人访问令牌及其权限范围，通过后异步记录最近使用信息。
+func (m *AuthMiddleware) accessTokenAuth(c *gin.Context, plain string, scopes []string) {
+	if m.tokenService == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "访问令牌服务未初始化"})
+		c.Abort()
+		return
+	}
+
+	token, err := m.tokenService.AuthenticateAccessToken(plain)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "访问令牌无效或已过期"})
+		c.Abort()
+		return
+	}
+	if len(scopes) == 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "该接口不支持使用访问令牌"})
+		c.Abort()
+		return
+	}
+	if !token.HasAnyScope(scopes...) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "访问令牌缺少所需的权限范围: " + strings.Join(scopes, "、")})
+		c.Abort()
+		return
+	}
+
+	m.tokenService.TouchAccessToken(token, c.ClientIP())
+	c.Set("id", token.UserID)
+	c.Set("username", token.User.Username)
+	c.Next()
+}
+
 // UserStatusCheck 检查用户状态是否被封禁
 //
 //nolint:gocyclo
@@ -3,6 +3,8 @@ package middleware
 import (
 	"net/http"
 	"net/http/httptest"
+	"perfect-pic-server/internal/consts"
+	moduledto "perfect-pic-server/internal/dto"
 	"perfect-pic-server/internal/pkg/cache"
 	"perfect-pic-server/internal/pkg/jwt"
 	"perfect-pic-server/internal/repository"
@@ -30,7 +32,7 @@ func buildTestStatusCache() *cache.Store {
 func TestJWTAuth_MissingHeaderUnauthorized(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	jwtService := buildTestJWT()
-	authMiddleware := NewAuthMiddleware(jwtService, nil)
+	authMiddleware := NewAuthMiddleware(jwtService, nil, nil)
 
 	r := gin.New()
 	r.GET("/x", authMiddleware.JWTAuth(), func(c *gin.Context) { c.Status(http.StatusOK) })
@@ -48,7 +50,7 @@ func TestJWTAuth_MissingHeaderUnauthorized(t *testing.T) {
 func TestJWTAuth_ValidTokenSetsContext(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	jwtService := buildTestJWT()
-	authMiddleware := NewAuthMiddleware(jwtService, nil)
+	authMiddleware := NewAuthMiddleware(jwtService, nil, nil)
 
 	r := gin.New()
 	r.GET("/x", authMiddleware.JWTAuth(), func(c *gin.Context) {
@@ -88,7 +90,7 @@ func TestUserStatusCheck_BannedForbidden(t *testing.T) {
 	statusCache := buildTestStatusCache()
 	userStore := repository.NewUserRepository(gdb)
 	userService := service.NewUserService(userStore, testService, statusCache, buildTestJWT())
-	authMiddleware := NewAuthMiddleware(buildTestJWT(), userService)
+	authMiddleware := NewAuthMiddleware(buildTestJWT(), userService, nil)
 
 	u := model.User{Username: "alice", Password: "x", Status: 2, Email: "a@example.com"}
 	if err := testGormDB.Create(&u).Error; err != nil {
@@ -119,7 +121,7 @@ func TestUserStatusCheck_NormalOK(t *testing.T) {
 	statusCache := buildTestStatusCache()
 	userStore := repository.NewUserRepository(gdb)
 	userService := service.NewUserService(userStore, testService, statusCache, buildTestJWT())
-	authMiddleware := NewAuthMiddleware(buildTestJWT(), userService)
+	authMiddleware := NewAuthMiddleware(buildTestJWT(), userService, nil)
 
 	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
 	_ = testGormDB.Create(&u).Error
@@ -148,7 +150,7 @@ func TestUserStatusCheck_ErrorBranches(t *testing.T) {
 	statusCache := buildTestStatusCache()
 	userStore := repository.NewUserRepository(gdb)
 	userService := service.NewUserService(userStore, testService, statusCache, buildTestJWT())
-	authMiddleware := NewAuthMiddleware(buildTestJWT(), userService)
+	authMiddleware := NewAuthMiddleware(buildTestJWT(), userService, nil)
 
 	// 缺少 id
 	r1 := gin.New()
@@ -209,7 +211,7 @@ func TestAdminCheck(t *testing.T) {
 	statusCache := buildTestStatusCache()
 	userStore := repository.NewUserRepository(gdb)
 	userService := service.NewUserService(userStore, testService, statusCache, buildTestJWT())
-	authMiddleware := NewAuthMiddleware(buildTestJWT(), userService)
+	authMiddleware := NewAuthMiddleware(buildTestJWT(), userService, nil)
 
 	normalUser := model.User{Username: "normal_user", Password: "x", Status: 1, Email: "normal@example.com", Admin: false}
 	if err := testGormDB.Create(&normalUser).Error; err != nil {
@@ -263,7 +265,7 @@ func TestAdminCheck(t *testing.T) {
 	r4 := gin.New()
 	r4.GET("/admin",
 		func(c *gin.Context) { c.Set("id", adminUser.ID); c.Next() },
-		NewAuthMiddleware(buildTestJWT(), nil).AdminCheck(),
+		NewAuthMiddleware(buildTestJWT(), nil, nil).AdminCheck(),
 		func(c *gin.Context) { c.Status(http.StatusOK) },
 	)
 	w4 := httptest.NewRecorder()
@@ -288,3 +290,75 @@ func TestClearUserStatusCache_RemovesLocalCache(t *testing.T) {
 		t.Fatalf("期望缓存条目被移除")
 	}
 }
+
+// 测试内容：验证个人访问令牌按路由要求的权限范围放行或拒绝，过期令牌返回 401，并异步记录最近使用时间与 IP。
+func TestJWTAuth_PersonalAccessToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	gdb := setupTestDB(t)
+	tokenService := service.NewAccessTokenService(repository.NewAccessTokenRepository(gdb))
+	authMiddleware := NewAuthMiddleware(buildTestJWT(), nil, tokenService)
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+	created, err := tokenService.CreateUserAccessToken(u.ID, moduledto.CreateAccessTokenRequest{
+		Name:   "PicGo",
+		Scopes: []string{consts.AccessTokenScopeUpload},
+	})
+	if err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+
+	r := gin.New()
+	ok := func(c *gin.Context) {
+		id, _ := c.Get("id")
+		username, _ := c.Get("username")
+		if id != u.ID || username != "alice" {
+			c.JSON(500, gin.H{"bad": true})
+			return
+		}
+		c.Status(http.StatusOK)
+	}
+	r.POST("/upload", authMiddleware.JWTAuth(consts.AccessTokenScopeUpload), ok)
+	r.DELETE("/images", authMiddleware.JWTAuth(consts.AccessTokenScopeDelete), ok)
+	r.GET("/tokens", authMiddleware.JWTAuth(), ok)
+
+	do := func(method, target, token string) int {
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := do(http.MethodPost, "/upload", created.Token); code != http.StatusOK {
+		t.Fatalf("具备权限范围期望 200，实际为 %d", code)
+	}
+	if code := do(http.MethodDelete, "/images", created.Token); code != http.StatusForbidden {
+		t.Fatalf("缺少权限范围期望 403，实际为 %d", code)
+	}
+	if code := do(http.MethodGet, "/tokens", created.Token); code != http.StatusForbidden {
+		t.Fatalf("不支持访问令牌的接口期望 403，实际为 %d", code)
+	}
+	if code := do(http.MethodPost, "/upload", created.Token+"0"); code != http.StatusUnauthorized {
+		t.Fatalf("无效令牌期望 401，实际为 %d", code)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		var stored model.PersonalAccessToken
+		_ = testGormDB.First(&stored, created.ID).Error
+		if stored.LastUsedAt != nil && stored.LastUsedIP != "" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("期望记录最近使用信息，实际为 %+v", stored)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	_ = testGormDB.Model(&model.PersonalAccessToken{}).Where("id = ?", created.ID).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error
+	if code := do(http.MethodPost, "/upload", created.Token); code != http.StatusUnauthorized {
+		t.Fatalf("过期令牌期望 401，实际为 %d", code)
+	}
+}
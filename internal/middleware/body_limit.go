@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"encoding/base64"
 	"fmt"
 	"net/http"
 	"perfect-pic-server/internal/config"
@@ -34,12 +35,33 @@ func (m *BodyLimitMiddleware) BodyLimitMiddleware() gin.HandlerFunc {
 
 // UploadBodyLimitMiddleware 限制上传/头像接口的请求体大小
 func (m *BodyLimitMiddleware) UploadBodyLimitMiddleware() gin.HandlerFunc {
+	return m.uploadBodyLimit(func(maxBytes int64) int64 { return maxBytes })
+}
+
+// EncodedUploadBodyLimitMiddleware 限制以 base64 data URI 提交图片的 JSON 接口的请求体大小，
+// 在 max_upload_size 的基础上计入 base64 编码膨胀（4/3）与其余 JSON 字段的余量。
+func (m *BodyLimitMiddleware) EncodedUploadBodyLimitMiddleware() gin.HandlerFunc {
+	return m.uploadBodyLimit(func(maxBytes int64) int64 { return int64(base64.StdEncoding.EncodedLen(int(maxBytes))) + 64*1024 })
+}
+
+// BatchUploadBodyLimitMiddleware 限制批量上传接口的请求体大小：单文件上限乘以允许的文件数，另加表单字段的余量。
+func (m *BodyLimitMiddleware) BatchUploadBodyLimitMiddleware() gin.HandlerFunc {
+	return m.uploadBodyLimit(func(maxBytes int64) int64 {
+		maxFiles := m.dbConfig.GetInt(consts.ConfigMaxBatchUploadFiles)
+		if maxFiles <= 0 {
+			maxFiles = 1
+		}
+		return maxBytes*int64(maxFiles) + 1024*1024
+	})
+}
+
+func (m *BodyLimitMiddleware) uploadBodyLimit(bodyBytes func(maxBytes int64) int64) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		maxSizeMB := m.dbConfig.GetInt(consts.ConfigMaxUploadSize)
 		if maxSizeMB <= 0 {
 			maxSizeMB = 10
 		}
-		maxBytes := int64(maxSizeMB) * 1024 * 1024
+		maxBytes := bodyBytes(int64(maxSizeMB) * 1024 * 1024)
 
 		if c.Request.ContentLength > maxBytes && c.Request.ContentLength != -1 {
 			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("文件大小不能超过 %dMB", maxSizeMB)})
@@ -100,3 +100,28 @@ func TestBodyLimitMiddleware_DoesNotBypassByPathSuffix(t *testing.T) {
 		t.Fatalf("期望 413，实际为 %d body=%s", w.Code, w.Body.String())
 	}
 }
+
+// 测试内容：验证批量上传的请求体上限按单文件上限乘以允许的文件数计算。
+func TestBatchUploadBodyLimitMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDB(t)
+
+	_ = testGormDB.Save(&model.Setting{Key: consts.ConfigMaxUploadSize, Value: "1"}).Error
+	_ = testGormDB.Save(&model.Setting{Key: consts.ConfigMaxBatchUploadFiles, Value: "3"}).Error
+	testService.ClearCache()
+	bodyLimit := NewBodyLimitMiddleware(testService)
+
+	r := gin.New()
+	r.POST("/upload/batch", bodyLimit.BatchUploadBodyLimitMiddleware(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for size, want := range map[int]int{3 * 1024 * 1024: http.StatusOK, 5 * 1024 * 1024: http.StatusRequestEntityTooLarge} {
+		payload := bytes.Repeat([]byte("a"), size)
+		req := httptest.NewRequest(http.MethodPost, "/upload/batch", bytes.NewReader(payload))
+		req.ContentLength = int64(len(payload))
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != want {
+			t.Fatalf("请求体 %d 字节: 期望 %d，实际为 %d", size, want, w.Code)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"perfect-pic-server/internal/common/httpx"
+	"perfect-pic-server/internal/config"
+	"perfect-pic-server/internal/consts"
+	"perfect-pic-server/internal/service"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// hotlinkPlaceholderSVG 未配置占位图地址时返回的内置占位图。
+var hotlinkPlaceholderSVG = []byte(`<svg xmlns="http://www.w3.org/2000/svg" width="320" height="180" viewBox="0 0 320 180">` +
+	`<rect width="320" height="180" fill="#f2f3f5"/>` +
+	`<text x="160" y="96" font-family="sans-serif" font-size="18" fill="#8a8f99" text-anchor="middle">图片禁止外链访问</text>` +
+	`</svg>`)
+
+type HotlinkMiddleware struct {
+	dbConfig     *config.DBConfig
+	imageService *service.ImageService
+}
+
+// HotlinkProtection 按 Referer/Origin 校验图片请求的来源：本站域名（请求 Host 与 base_url）及白名单域名放行，
+// 其余请求按配置返回 403 或占位图。signedURLs 表示该前缀下的链接可能携带图片签名（仅图片前缀适用）。
+// 需挂载在 StaticCacheMiddleware 之后，以便拦截响应覆盖其缓存策略。
+func (m *HotlinkMiddleware) HotlinkProtection(signedURLs bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !m.dbConfig.GetBool(consts.ConfigHotlinkEnabled) || m.allowed(c, signedURLs) {
+			c.Next()
+			return
+		}
+
+		// 拦截结果随来源变化，禁止任何缓存保存
+		c.Header("Cache-Control", "no-store")
+		if strings.ToLower(strings.TrimSpace(m.dbConfig.GetString(consts.ConfigHotlinkBlockAction))) == consts.HotlinkActionPlaceholder {
+			if placeholder := strings.TrimSpace(m.dbConfig.GetString(consts.ConfigHotlinkPlaceholderURL)); placeholder != "" {
+				c.Redirect(http.StatusFound, placeholder)
+			} else {
+				c.Data(http.StatusOK, "image/svg+xml", hotlinkPlaceholderSVG)
+			}
+			c.Abort()
+			return
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "禁止盗链访问"})
+		c.Abort()
+	}
+}
+
+func (m *HotlinkMiddleware) allowed(c *gin.Context, signedURLs bool) bool {
+	host, present := httpx.SourceHost(c.GetHeader("Origin"), c.GetHeader("Referer"))
+	if !present && m.dbConfig.GetBool(consts.ConfigHotlinkAllowEmptyReferer) {
+		return true
+	}
+	if present && m.allowedHost(c, host) {
+		return true
+	}
+	if signedURLs && m.imageService != nil && m.dbConfig.GetBool(consts.ConfigHotlinkExemptSignedURLs) {
+		key := strings.TrimPrefix(c.Param("filepath"), "/")
+		if m.imageService.VerifySignedURL(key, c.Query("exp"), c.Query("sig")) {
+			return true
+		}
+	}
+	return m.isPlaceholder(c)
+}
+
+// allowedHost 判断来源主机是否为本站或在白名单内。
+func (m *HotlinkMiddleware) allowedHost(c *gin.Context, host string) bool {
+	if host == "" {
+		return false
+	}
+	if host == requestHostname(c.Request.Host) {
+		return true
+	}
+	if base, err := url.Parse(m.dbConfig.GetString(consts.ConfigBaseURL)); err == nil && host == strings.ToLower(base.Hostname()) {
+		return true
+	}
+	for _, part := range strings.Split(m.dbConfig.GetString(consts.ConfigHotlinkAllowedReferers), ",") {
+		if pattern, ok := httpx.NormalizeHostPattern(part); ok && httpx.MatchHostPattern(pattern, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPlaceholder 判断请求的是否为本站占位图本身，避免占位图位于受保护前缀下时重定向循环。
+func (m *HotlinkMiddleware) isPlaceholder(c *gin.Context) bool {
+	placeholder := strings.TrimSpace(m.dbConfig.GetString(consts.ConfigHotlinkPlaceholderURL))
+	if placeholder == "" {
+		return false
+	}
+	u, err := url.Parse(placeholder)
+	if err != nil || (u.Host != "" && strings.ToLower(u.Hostname()) != requestHostname(c.Request.Host)) {
+		return false
+	}
+	return u.Path == c.Request.URL.Path
+}
+
+func requestHostname(hostport string) string {
+	if host, _, err := net.SplitHostPort(hostport); err == nil {
+		return strings.ToLower(host)
+	}
+	return strings.ToLower(hostport)
+}
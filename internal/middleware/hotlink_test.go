@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"perfect-pic-server/internal/config"
+	"perfect-pic-server/internal/consts"
+	"perfect-pic-server/internal/model"
+	"perfect-pic-server/internal/pkg/storage"
+	"perfect-pic-server/internal/pkg/urlsign"
+	"perfect-pic-server/internal/repository"
+	"perfect-pic-server/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func saveHotlinkSettings(t *testing.T, values map[string]string) {
+	t.Helper()
+	for key, value := range values {
+		if err := testGormDB.Save(&model.Setting{Key: key, Value: value}).Error; err != nil {
+			t.Fatalf("设置配置项失败: %v", err)
+		}
+	}
+	testService.ClearCache()
+}
+
+// 测试内容：验证防盗链中间件按 Referer/Origin 白名单放行、本站来源始终放行、空来源与签名链接按配置豁免，其余请求返回 403。
+func TestHotlinkMiddleware_AllowList(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDB(t)
+
+	root := t.TempDir()
+	_ = os.WriteFile(filepath.Join(root, "a.png"), []byte("x"), 0644)
+	storages, err := storage.NewManager(&storage.Config{Local: storage.LocalConfig{ImagePath: root, ImageURLPrefix: "/imgs/"}})
+	if err != nil {
+		t.Fatalf("init storage: %v", err)
+	}
+	staticConfig := config.NewStaticConfig()
+	signer := urlsign.NewSigner(config.NewURLSignConfig(staticConfig))
+	imageService := service.NewImageService(repository.NewImageRepository(testGormDB), testService, staticConfig, storages, signer, nil)
+	m := NewHotlinkMiddleware(testService, imageService)
+
+	r := gin.New()
+	r.Group("/imgs", m.HotlinkProtection(true)).StaticFS("", gin.Dir(root, false))
+	r.Group("/avatars", m.HotlinkProtection(false)).StaticFS("", gin.Dir(root, false))
+
+	do := func(target string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Host = "pic.example.org:8080"
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// 未开启时不做任何检查
+	if w := do("/imgs/a.png", map[string]string{"Referer": "https://evil.com/"}); w.Code != http.StatusOK {
+		t.Fatalf("未开启防盗链时期望 200，实际为 %d", w.Code)
+	}
+
+	saveHotlinkSettings(t, map[string]string{
+		consts.ConfigHotlinkEnabled:           "true",
+		consts.ConfigHotlinkAllowedReferers:   "blog.example.com, *.friends.net",
+		consts.ConfigHotlinkAllowEmptyReferer: "false",
+		consts.ConfigBaseURL:                  "https://www.example.org",
+	})
+
+	exp := time.Now().Add(time.Hour).Unix()
+	signed := "?" + url.Values{"exp": {strconv.FormatInt(exp, 10)}, "sig": {signer.Sign("a.png", exp)}}.Encode()
+
+	cases := []struct {
+		name    string
+		target  string
+		headers map[string]string
+		code    int
+	}{
+		{"白名单域名", "/imgs/a.png", map[string]string{"Referer": "https://blog.example.com/post/1"}, http.StatusOK},
+		{"通配子域名", "/imgs/a.png", map[string]string{"Referer": "https://a.friends.net/"}, http.StatusOK},
+		{"通配不含主域", "/imgs/a.png", map[string]string{"Referer": "https://friends.net/"}, http.StatusForbidden},
+		{"Origin 优先", "/imgs/a.png", map[string]string{"Origin": "https://evil.com", "Referer": "https://blog.example.com/"}, http.StatusForbidden},
+		{"请求 Host", "/imgs/a.png", map[string]string{"Referer": "http://pic.example.org:8080/gallery"}, http.StatusOK},
+		{"base_url", "/imgs/a.png", map[string]string{"Origin": "https://www.example.org"}, http.StatusOK},
+		{"外部来源", "/imgs/a.png", map[string]string{"Referer": "https://evil.com/"}, http.StatusForbidden},
+		{"空来源禁止", "/imgs/a.png", nil, http.StatusForbidden},
+		{"签名豁免", "/imgs/a.png" + signed, map[string]string{"Referer": "https://evil.com/"}, http.StatusOK},
+		{"头像不适用签名", "/avatars/a.png" + signed, map[string]string{"Referer": "https://evil.com/"}, http.StatusForbidden},
+	}
+	for _, tc := range cases {
+		w := do(tc.target, tc.headers)
+		if w.Code != tc.code {
+			t.Fatalf("%s: 期望 %d，实际为 %d", tc.name, tc.code, w.Code)
+		}
+		if tc.code == http.StatusForbidden && w.Header().Get("Cache-Control") != "no-store" {
+			t.Fatalf("%s: 期望拦截响应禁止缓存，实际为 %q", tc.name, w.Header().Get("Cache-Control"))
+		}
+	}
+
+	saveHotlinkSettings(t, map[string]string{
+		consts.ConfigHotlinkAllowEmptyReferer: "true",
+		consts.ConfigHotlinkExemptSignedURLs:  "false",
+	})
+	if w := do("/imgs/a.png", nil); w.Code != http.StatusOK {
+		t.Fatalf("允许空来源时期望 200，实际为 %d", w.Code)
+	}
+	if w := do("/imgs/a.png"+signed, map[string]string{"Referer": "https://evil.com/"}); w.Code != http.StatusForbidden {
+		t.Fatalf("关闭签名豁免后期望 403，实际为 %d", w.Code)
+	}
+}
+
+// 测试内容：验证拦截方式为占位图时返回内置占位图或重定向到配置的占位图，且占位图自身不被拦截。
+func TestHotlinkMiddleware_Placeholder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDB(t)
+
+	root := t.TempDir()
+	_ = os.WriteFile(filepath.Join(root, "a.png"), []byte("x"), 0644)
+	_ = os.WriteFile(filepath.Join(root, "blocked.png"), []byte("p"), 0644)
+	m := NewHotlinkMiddleware(testService, nil)
+
+	r := gin.New()
+	r.Group("/imgs", m.HotlinkProtection(true)).StaticFS("", gin.Dir(root, false))
+
+	do := func(target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Referer", "https://evil.com/")
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	saveHotlinkSettings(t, map[string]string{
+		consts.ConfigHotlinkEnabled:     "true",
+		consts.ConfigHotlinkBlockAction: consts.HotlinkActionPlaceholder,
+	})
+	w := do("/imgs/a.png")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "image/svg+xml" {
+		t.Fatalf("期望返回内置占位图，实际为 %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+
+	saveHotlinkSettings(t, map[string]string{consts.ConfigHotlinkPlaceholderURL: "/imgs/blocked.png"})
+	w = do("/imgs/a.png")
+	if w.Code != http.StatusFound || w.Header().Get("Location") != "/imgs/blocked.png" {
+		t.Fatalf("期望重定向到占位图，实际为 %d %q", w.Code, w.Header().Get("Location"))
+	}
+	w = do("/imgs/blocked.png")
+	if w.Code != http.StatusOK || w.Body.String() != "p" {
+		t.Fatalf("期望占位图自身可访问，实际为 %d %q", w.Code, w.Body.String())
+	}
+}
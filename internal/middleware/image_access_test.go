@@ -0,0 +1,319 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"perfect-pic-server/internal/config"
+	"perfect-pic-server/internal/consts"
+	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/model"
+	"perfect-pic-server/internal/pkg/cache"
+	"perfect-pic-server/internal/pkg/jwt"
+	"perfect-pic-server/internal/pkg/storage"
+	"perfect-pic-server/internal/pkg/urlsign"
+	"perfect-pic-server/internal/repository"
+	"perfect-pic-server/internal/service"
+	"perfect-pic-server/internal/testutils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 测试内容：验证私有图片仅允许所有者令牌、管理员或有效签名访问，且响应禁止共享缓存；公开图片与变体路径不受影响。
+func TestImageAccessMiddleware_PrivateImages(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDB(t)
+
+	root := t.TempDir()
+	for _, rel := range []string{"private.png", "private.png.variants/alternate.webp", "public.png"} {
+		full := filepath.Join(root, filepath.FromSlash(rel))
+		_ = os.MkdirAll(filepath.Dir(full), 0755)
+		_ = os.WriteFile(full, []byte("x"), 0644)
+	}
+
+	owner := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&owner).Error
+	other := model.User{Username: "bob", Password: "x", Status: 1, Email: "b@example.com"}
+	_ = testGormDB.Create(&other).Error
+	admin := model.User{Username: "root", Password: "x", Status: 1, Email: "r@example.com", Admin: true}
+	_ = testGormDB.Create(&admin).Error
+	_ = testGormDB.Create(&model.Image{Filename: "p.png", Path: "private.png", Size: 1, Width: 1, Height: 1, MimeType: ".png", UploadedAt: 1, UserID: owner.ID, Visibility: model.ImageVisibilityPrivate}).Error
+	_ = testGormDB.Create(&model.Image{Filename: "q.png", Path: "public.png", Size: 1, Width: 1, Height: 1, MimeType: ".png", UploadedAt: 1, UserID: owner.ID, Visibility: model.ImageVisibilityUnlisted}).Error
+
+	storages, err := storage.NewManager(&storage.Config{Local: storage.LocalConfig{ImagePath: root, ImageURLPrefix: "/imgs/"}})
+	if err != nil {
+		t.Fatalf("init storage: %v", err)
+	}
+	staticConfig := config.NewStaticConfig()
+	signer := urlsign.NewSigner(config.NewURLSignConfig(staticConfig))
+	imageService := service.NewImageService(repository.NewImageRepository(testGormDB), testService, staticConfig, storages, signer, nil)
+	userService := service.NewUserService(repository.NewUserRepository(testGormDB), testService, cache.NewStore(nil, &cache.Config{}), nil)
+	jwtService := jwt.NewJWT(&jwt.Config{JWTSecret: []byte("test_secret"), Duration: time.Hour})
+	m := NewImageAccessMiddleware(jwtService, imageService, userService)
+
+	r := gin.New()
+	r.Group("/imgs", m.ImageAccess()).StaticFS("", gin.Dir(root, false))
+
+	token := func(u model.User) string {
+		tok, _ := jwtService.GenerateLoginToken(u.ID, u.Username, false)
+		return "Bearer " + tok
+	}
+	do := func(target, auth string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec
+	}
+
+	exp := time.Now().Add(time.Hour).Unix()
+	signed := "?" + url.Values{"exp": {strconv.FormatInt(exp, 10)}, "sig": {signer.Sign("private.png", exp)}}.Encode()
+	expired := time.Now().Add(-time.Minute).Unix()
+	expiredQuery := "?" + url.Values{"exp": {strconv.FormatInt(expired, 10)}, "sig": {signer.Sign("private.png", expired)}}.Encode()
+
+	cases := []struct {
+		name, target, auth string
+		code               int
+	}{
+		{"匿名访问私有图片", "/imgs/private.png", "", http.StatusForbidden},
+		{"匿名访问私有图片的变体文件", "/imgs/private.png.variants/alternate.webp", "", http.StatusForbidden},
+		{"其他用户访问", "/imgs/private.png", token(other), http.StatusForbidden},
+		{"无效令牌", "/imgs/private.png", "Bearer invalid", http.StatusForbidden},
+		{"所有者访问", "/imgs/private.png", token(owner), http.StatusOK},
+		{"管理员访问", "/imgs/private.png", token(admin), http.StatusOK},
+		{"有效签名", "/imgs/private.png" + signed, "", http.StatusOK},
+		{"签名同样适用于变体文件", "/imgs/private.png.variants/alternate.webp" + signed, "", http.StatusOK},
+		{"过期签名", "/imgs/private.png" + expiredQuery, "", http.StatusForbidden},
+		{"签名与路径不匹配", "/imgs/public.png" + signed, "", http.StatusOK},
+		{"unlisted 图片可直接访问", "/imgs/public.png", "", http.StatusOK},
+	}
+	for _, tc := range cases {
+		rec := do(tc.target, tc.auth)
+		if rec.Code != tc.code {
+			t.Fatalf("%s: 期望 %d，实际为 %d body=%s", tc.name, tc.code, rec.Code, rec.Body.String())
+		}
+	}
+
+	if cc := do("/imgs/private.png", token(owner)).Header().Get("Cache-Control"); cc != "private, no-store" {
+		t.Fatalf("期望私有图片禁止共享缓存，实际为 %q", cc)
+	}
+	if cc := do("/imgs/public.png", "").Header().Get("Cache-Control"); cc != "" {
+		t.Fatalf("期望公开图片不修改 Cache-Control，实际为 %q", cc)
+	}
+}
+
+// 测试内容：验证图片访问按实际输出字节数累计所有者的每月流量，用尽后拒绝访问，未被记录引用的文件不计流量。
+func TestImageAccessMiddleware_MonthlyEgress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDB(t)
+
+	root := t.TempDir()
+	for name, content := range map[string]string{"a.png": "abcdef", "orphan.png": "xyz"} {
+		_ = os.WriteFile(filepath.Join(root, name), []byte(content), 0644)
+	}
+
+	limit := int64(10)
+	owner := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com", MonthlyEgressBytes: &limit}
+	_ = testGormDB.Create(&owner).Error
+	_ = testGormDB.Create(&model.Image{Filename: "a.png", Path: "a.png", Size: 6, Width: 1, Height: 1, MimeType: ".png", UploadedAt: 1, UserID: owner.ID}).Error
+
+	storages, err := storage.NewManager(&storage.Config{Local: storage.LocalConfig{ImagePath: root, ImageURLPrefix: "/imgs/"}})
+	if err != nil {
+		t.Fatalf("init storage: %v", err)
+	}
+	staticConfig := config.NewStaticConfig()
+	imageService := service.NewImageService(repository.NewImageRepository(testGormDB), testService, staticConfig, storages, urlsign.NewSigner(config.NewURLSignConfig(staticConfig)), nil)
+	userService := service.NewUserService(repository.NewUserRepository(testGormDB), testService, cache.NewStore(nil, &cache.Config{}), nil)
+	m := NewImageAccessMiddleware(nil, imageService, userService)
+
+	r := gin.New()
+	r.Group("/imgs", m.ImageAccess()).StaticFS("", gin.Dir(root, false))
+	do := func(target string) int {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		return rec.Code
+	}
+
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusForbidden} {
+		if code := do("/imgs/a.png"); code != want {
+			t.Fatalf("第 %d 次访问期望 %d，实际为 %d", i+1, want, code)
+		}
+	}
+	if usage := userService.GetUsageInfo(&owner); usage.MonthlyEgressBytesUsed != 12 || usage.MonthlyEgressBytesLimit != 10 {
+		t.Fatalf("期望本月已用流量为 12，实际为 %+v", usage)
+	}
+	if code := do("/imgs/orphan.png"); code != http.StatusOK {
+		t.Fatalf("未被引用的文件期望 200，实际为 %d", code)
+	}
+}
+
+// 测试内容：验证成功的图片访问计入访问次数与来源站点，被拒绝的访问不计入，且计数在写入数据库前仅保存在内存中。
+func TestImageAccessMiddleware_RecordsViews(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDB(t)
+
+	root := t.TempDir()
+	for _, name := range []string{"pub.png", "priv.png"} {
+		_ = os.WriteFile(filepath.Join(root, name), []byte("abc"), 0644)
+	}
+	owner := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&owner).Error
+	pub := model.Image{Filename: "pub.png", Path: "pub.png", Size: 3, Width: 1, Height: 1, MimeType: ".png", UploadedAt: 1, UserID: owner.ID}
+	priv := model.Image{Filename: "priv.png", Path: "priv.png", Size: 3, Width: 1, Height: 1, MimeType: ".png", UploadedAt: 1, UserID: owner.ID, Visibility: model.ImageVisibilityPrivate}
+	for _, img := range []*model.Image{&pub, &priv} {
+		if err := testGormDB.Create(img).Error; err != nil {
+			t.Fatalf("create image: %v", err)
+		}
+	}
+
+	storages, err := storage.NewManager(&storage.Config{Local: storage.LocalConfig{ImagePath: root, ImageURLPrefix: "/imgs/"}})
+	if err != nil {
+		t.Fatalf("init storage: %v", err)
+	}
+	staticConfig := config.NewStaticConfig()
+	imageService := service.NewImageService(repository.NewImageRepository(testGormDB), testService, staticConfig, storages, urlsign.NewSigner(config.NewURLSignConfig(staticConfig)), nil)
+	m := NewImageAccessMiddleware(nil, imageService, nil)
+
+	r := gin.New()
+	r.Group("/imgs", m.ImageAccess()).StaticFS("", gin.Dir(root, false))
+	do := func(target string, referer string) int {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if referer != "" {
+			req.Header.Set("Referer", referer)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := do("/imgs/pub.png", "https://Blog.Example.com/post/1"); code != http.StatusOK {
+		t.Fatalf("期望 200，实际为 %d", code)
+	}
+	_ = do("/imgs/pub.png", "")
+	if code := do("/imgs/priv.png", "https://blog.example.com/"); code != http.StatusForbidden {
+		t.Fatalf("期望匿名访问私有图片返回 403，实际为 %d", code)
+	}
+
+	var got model.Image
+	_ = testGormDB.First(&got, pub.ID).Error
+	if got.Views != 0 {
+		t.Fatalf("期望写入前不修改数据库，实际访问次数为 %d", got.Views)
+	}
+	if err := imageService.FlushImageViews(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	_ = testGormDB.First(&got, pub.ID).Error
+	if got.Views != 2 {
+		t.Fatalf("期望访问次数为 2，实际为 %d", got.Views)
+	}
+	var gotPriv model.Image
+	_ = testGormDB.First(&gotPriv, priv.ID).Error
+	if gotPriv.Views != 0 {
+		t.Fatalf("期望被拒绝的访问不计数，实际为 %d", gotPriv.Views)
+	}
+	var referrers []model.ImageReferrerStat
+	_ = testGormDB.Where("image_id = ?", pub.ID).Find(&referrers).Error
+	if len(referrers) != 1 || referrers[0].Host != "blog.example.com" || referrers[0].Views != 1 {
+		t.Fatalf("来源站点统计不符: %+v", referrers)
+	}
+}
+
+// 测试内容：验证两名用户上传相同内容（去重后共享同一文件）时，流量配额、流量与访问统计按访问地址或短链接指明的记录
+// 分别计入各自所有者，一方流量用尽不影响另一方的图片。
+func TestImageAccessMiddleware_DeduplicatedRecords(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDB(t)
+
+	limit := int64(1)
+	alice := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com", MonthlyEgressBytes: &limit}
+	_ = testGormDB.Create(&alice).Error
+	bob := model.User{Username: "bob", Password: "x", Status: 1, Email: "b@example.com"}
+	_ = testGormDB.Create(&bob).Error
+
+	root := t.TempDir()
+	storages, err := storage.NewManager(&storage.Config{Local: storage.LocalConfig{ImagePath: root, ImageURLPrefix: "/imgs/"}})
+	if err != nil {
+		t.Fatalf("init storage: %v", err)
+	}
+	staticConfig := config.NewStaticConfig()
+	imageService := service.NewImageService(repository.NewImageRepository(testGormDB), testService, staticConfig, storages, urlsign.NewSigner(config.NewURLSignConfig(staticConfig)), nil)
+	userService := service.NewUserService(repository.NewUserRepository(testGormDB), testService, cache.NewStore(nil, &cache.Config{}), nil)
+	m := NewImageAccessMiddleware(nil, imageService, userService)
+	shortLink := NewShortLinkMiddleware(testService, imageService)
+
+	aliceRes, err := imageService.ProcessImageData("a.png", testutils.MinimalPNG(), alice.ID, 0, 1<<20, moduledto.ImageUploadInfo{})
+	if err != nil {
+		t.Fatalf("ProcessImageData: %v", err)
+	}
+	bobRes, err := imageService.ProcessImageData("b.png", testutils.MinimalPNG(), bob.ID, 0, 1<<20, moduledto.ImageUploadInfo{})
+	if err != nil {
+		t.Fatalf("ProcessImageData: %v", err)
+	}
+	if aliceRes.Image.Path != bobRes.Image.Path || aliceRes.Image.ID == bobRes.Image.ID {
+		t.Fatalf("期望相同内容去重为同一文件的两条记录: alice=%+v bob=%+v", aliceRes.Image, bobRes.Image)
+	}
+
+	r := gin.New()
+	r.Group("/imgs", m.ImageAccess()).StaticFS("", gin.Dir(root, false))
+	r.Group(consts.ShortLinkPathPrefix, shortLink.ResolveShortLink(), m.ImageAccess()).GET(":code", func(c *gin.Context) {
+		c.File(filepath.Join(root, filepath.FromSlash(c.Param("filepath"))))
+	})
+	do := func(target, referer string) int {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if referer != "" {
+			req.Header.Set("Referer", referer)
+		}
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// alice 的首次访问用尽其流量，之后访问其记录被拒绝
+	if code := do(aliceRes.URL, ""); code != http.StatusOK {
+		t.Fatalf("期望 alice 的图片首次访问返回 200，实际为 %d", code)
+	}
+	if code := do(aliceRes.URL, ""); code != http.StatusForbidden {
+		t.Fatalf("期望 alice 流量用尽后返回 403，实际为 %d", code)
+	}
+	// bob 的相同图片不受 alice 流量影响，且流量计入 bob
+	if code := do(bobRes.URL, "https://bob.example.com/"); code != http.StatusOK {
+		t.Fatalf("期望 bob 的图片返回 200，实际为 %d", code)
+	}
+	if code := do(service.ShortURL(bobRes.Image), "https://bob.example.com/"); code != http.StatusOK {
+		t.Fatalf("期望 bob 的短链接返回 200，实际为 %d", code)
+	}
+	if code := do(service.ShortURL(aliceRes.Image), ""); code != http.StatusForbidden {
+		t.Fatalf("期望 alice 的短链接在流量用尽后返回 403，实际为 %d", code)
+	}
+
+	size := int64(len(testutils.MinimalPNG()))
+	if usage := userService.GetUsageInfo(&alice); usage.MonthlyEgressBytesUsed != size {
+		t.Fatalf("期望 alice 仅计入自己图片的流量 %d，实际为 %+v", size, usage)
+	}
+	if usage := userService.GetUsageInfo(&bob); usage.MonthlyEgressBytesUsed != 2*size {
+		t.Fatalf("期望 bob 计入两次访问的流量 %d，实际为 %+v", 2*size, usage)
+	}
+
+	if err := imageService.FlushImageViews(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	var gotAlice, gotBob model.Image
+	_ = testGormDB.First(&gotAlice, aliceRes.Image.ID).Error
+	_ = testGormDB.First(&gotBob, bobRes.Image.ID).Error
+	if gotAlice.Views != 1 || gotBob.Views != 2 {
+		t.Fatalf("期望访问次数分别计入各自记录: alice=%d bob=%d", gotAlice.Views, gotBob.Views)
+	}
+	var referrers []model.ImageReferrerStat
+	_ = testGormDB.Find(&referrers).Error
+	if len(referrers) != 1 || referrers[0].ImageID != bobRes.Image.ID || referrers[0].Views != 2 {
+		t.Fatalf("期望来源站点统计计入 bob 的记录: %+v", referrers)
+	}
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"net/http"
+	"perfect-pic-server/internal/common/httpx"
+	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/service"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ImageVariantMiddleware struct {
+	imageService *service.ImageService
+}
+
+// ImageVariant 拦截带 w/h/fit/fmt 参数的图片请求并返回按需生成的变体；
+// 不带这些参数或功能关闭时交给后续的图片访问处理返回原图。
+func (m *ImageVariantMiddleware) ImageVariant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			c.Next()
+			return
+		}
+		if !hasVariantQuery(c) || !m.imageService.IsImageVariantEnabled() {
+			c.Next()
+			return
+		}
+
+		var req moduledto.ImageVariantRequest
+		if err := c.ShouldBindQuery(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "缩略图参数格式错误"})
+			c.Abort()
+			return
+		}
+
+		key := strings.TrimPrefix(c.Param("filepath"), "/")
+		reader, info, err := m.imageService.GetImageVariant(key, req)
+		if err != nil {
+			httpx.WriteServiceError(c, err, "生成缩略图失败")
+			c.Abort()
+			return
+		}
+		defer func() { _ = reader.Close() }()
+
+		headers := map[string]string{}
+		if !info.LastModified.IsZero() {
+			headers["Last-Modified"] = info.LastModified.UTC().Format(http.TimeFormat)
+		}
+		c.DataFromReader(http.StatusOK, info.Size, info.ContentType, reader, headers)
+		c.Abort()
+	}
+}
+
+func hasVariantQuery(c *gin.Context) bool {
+	for _, key := range []string{"w", "h", "fit", "fmt"} {
+		if _, ok := c.GetQuery(key); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"perfect-pic-server/internal/config"
+	"perfect-pic-server/internal/pkg/storage"
+	"perfect-pic-server/internal/pkg/urlsign"
+	"perfect-pic-server/internal/repository"
+	"perfect-pic-server/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func buildTestImageVariantRouter(t *testing.T, root string) *gin.Engine {
+	t.Helper()
+	storages, err := storage.NewManager(&storage.Config{Local: storage.LocalConfig{ImagePath: root, ImageURLPrefix: "/imgs/"}})
+	if err != nil {
+		t.Fatalf("init storage: %v", err)
+	}
+	imageService := service.NewImageService(repository.NewImageRepository(testGormDB), testService, config.NewStaticConfig(), storages, urlsign.NewSigner(config.NewURLSignConfig(config.NewStaticConfig())), nil)
+	m := NewImageVariantMiddleware(imageService)
+
+	r := gin.New()
+	r.Group("/imgs", m.ImageVariant()).StaticFS("", gin.Dir(root, false))
+	return r
+}
+
+// 测试内容：验证带缩略图参数的请求返回变体，不带参数时回落到原图。
+func TestImageVariantMiddleware_ServesVariantOrOriginal(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDB(t)
+
+	root := t.TempDir()
+	f, _ := os.Create(filepath.Join(root, "a.png"))
+	_ = png.Encode(f, image.NewNRGBA(image.Rect(0, 0, 300, 300)))
+	_ = f.Close()
+
+	r := buildTestImageVariantRouter(t, root)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/imgs/a.png?w=64&fmt=jpeg", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("期望 200，实际为 %d body=%s", w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "image/jpeg" {
+		t.Fatalf("期望 image/jpeg，实际为 %q", ct)
+	}
+	cfg, _, err := image.DecodeConfig(w.Body)
+	if err != nil || cfg.Width != 64 {
+		t.Fatalf("期望宽度 64，实际为 %d err=%v", cfg.Width, err)
+	}
+
+	w2 := httptest.NewRecorder()
+	r.ServeHTTP(w2, httptest.NewRequest(http.MethodGet, "/imgs/a.png", nil))
+	if w2.Code != http.StatusOK || w2.Header().Get("Content-Type") != "image/png" {
+		t.Fatalf("期望原图 200 image/png，实际为 %d %q", w2.Code, w2.Header().Get("Content-Type"))
+	}
+
+	w3 := httptest.NewRecorder()
+	r.ServeHTTP(w3, httptest.NewRequest(http.MethodGet, "/imgs/a.png?w=65", nil))
+	if w3.Code != http.StatusBadRequest {
+		t.Fatalf("期望不在允许列表中的尺寸返回 400，实际为 %d", w3.Code)
+	}
+}
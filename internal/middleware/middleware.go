@@ -9,10 +9,11 @@ import (
 	"github.com/google/wire"
 )
 
-func NewAuthMiddleware(jwt *jwt.JWT, userService *service.UserService) *AuthMiddleware {
+func NewAuthMiddleware(jwt *jwt.JWT, userService *service.UserService, tokenService *service.AccessTokenService) *AuthMiddleware {
 	return &AuthMiddleware{
-		jwt:         jwt,
-		userService: userService,
+		jwt:          jwt,
+		userService:  userService,
+		tokenService: tokenService,
 	}
 }
 
@@ -40,10 +41,30 @@ func NewStaticCacheMiddleware(dbConfig *config.DBConfig) *StaticCacheMiddleware
 	return &StaticCacheMiddleware{dbConfig: dbConfig}
 }
 
+func NewImageVariantMiddleware(imageService *service.ImageService) *ImageVariantMiddleware {
+	return &ImageVariantMiddleware{imageService: imageService}
+}
+
+func NewImageAccessMiddleware(jwt *jwt.JWT, imageService *service.ImageService, userService *service.UserService) *ImageAccessMiddleware {
+	return &ImageAccessMiddleware{jwt: jwt, imageService: imageService, userService: userService}
+}
+
+func NewHotlinkMiddleware(dbConfig *config.DBConfig, imageService *service.ImageService) *HotlinkMiddleware {
+	return &HotlinkMiddleware{dbConfig: dbConfig, imageService: imageService}
+}
+
+func NewShortLinkMiddleware(dbConfig *config.DBConfig, imageService *service.ImageService) *ShortLinkMiddleware {
+	return &ShortLinkMiddleware{dbConfig: dbConfig, imageService: imageService}
+}
+
 var MiddlewareSet = wire.NewSet(
 	NewAuthMiddleware,
 	NewBodyLimitMiddleware,
 	NewRateLimitMiddleware,
 	NewSecurityHeadersMiddleware,
 	NewStaticCacheMiddleware,
+	NewImageVariantMiddleware,
+	NewImageAccessMiddleware,
+	NewHotlinkMiddleware,
+	NewShortLinkMiddleware,
 )
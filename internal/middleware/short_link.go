@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"perfect-pic-server/internal/common/httpx"
+	"perfect-pic-server/internal/config"
+	"perfect-pic-server/internal/consts"
+	"perfect-pic-server/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ShortLinkMiddleware struct {
+	dbConfig     *config.DBConfig
+	imageService *service.ImageService
+}
+
+// ResolveShortLink 将 /s/:code 解析为图片的存储 key（写入 filepath 路由参数）并记录短码对应的图片 ID，
+// 之后的防盗链、访问权限、缩略图与原图处理与图片访问前缀完全一致；开启重定向时改为 302 跳转到图片记录的
+// 访问地址，并保留查询参数。
+func (m *ShortLinkMiddleware) ResolveShortLink() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		image, err := m.imageService.FindImageByShortCode(c.Param("code"))
+		if err != nil {
+			httpx.WriteServiceError(c, err, "读取短链接失败")
+			c.Abort()
+			return
+		}
+
+		if m.dbConfig.GetBool(consts.ConfigShortLinkRedirect) {
+			target := m.imageService.ImageRecordURL(image)
+			query := c.Request.URL.Query()
+			query.Del(service.ImageRecordQueryParam)
+			if rawQuery := query.Encode(); rawQuery != "" {
+				target += "&" + rawQuery
+			}
+			// 短码可被管理员修改，跳转结果不做长期缓存
+			c.Header("Cache-Control", "no-cache")
+			c.Redirect(http.StatusFound, target)
+			c.Abort()
+			return
+		}
+
+		c.Params = append(c.Params, gin.Param{Key: "filepath", Value: "/" + image.Path})
+		c.Set("image_id", image.ID)
+		c.Next()
+	}
+}
@@ -0,0 +1,29 @@
+package model
+
+const (
+	// AlbumVisibilityPrivate 仅所有者可见（默认）。
+	AlbumVisibilityPrivate = "private"
+	// AlbumVisibilityPublic 公开相册。
+	AlbumVisibilityPublic = "public"
+)
+
+// Album 用户创建的相册，通过 AlbumImage 与 Image 多对多关联。
+type Album struct {
+	ID           uint   `json:"id" gorm:"primaryKey"`
+	UserID       uint   `json:"user_id" gorm:"not null;index"`
+	Title        string `json:"title" gorm:"not null;size:100"`
+	Description  string `json:"description" gorm:"not null;size:1000;default:''"`
+	CoverImageID *uint  `json:"cover_image_id"` // 封面图片，必须是相册内的图片；图片移出相册或被删除时置空
+	SortOrder    int    `json:"sort_order" gorm:"not null;default:0"`
+	Visibility   string `json:"visibility" gorm:"not null;size:16;default:'private'"`
+	CreatedAt    int64  `json:"created_at"`
+	UpdatedAt    int64  `json:"updated_at"`
+	User         User   `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE;" json:"-"`
+}
+
+// AlbumImage 相册与图片的关联记录，同一图片可属于多个相册。
+type AlbumImage struct {
+	AlbumID uint  `json:"album_id" gorm:"primaryKey;autoIncrement:false"`
+	ImageID uint  `json:"image_id" gorm:"primaryKey;autoIncrement:false;index"`
+	AddedAt int64 `json:"added_at" gorm:"not null"`
+}
@@ -1,14 +1,54 @@
 package model
 
+import "gorm.io/gorm"
+
+const (
+	ImageVisibilityPublic   = "public"
+	ImageVisibilityUnlisted = "unlisted"
+	ImageVisibilityPrivate  = "private"
+)
+
 type Image struct {
 	ID         uint   `json:"id" gorm:"primaryKey"`
 	Filename   string `json:"filename" gorm:"not null;unique"`
-	Path       string `json:"path" gorm:"not null;unique"`
+	Path       string `json:"path" gorm:"not null;index"` // 内容去重后多条记录可能共享同一路径
 	Size       int64  `json:"size" gorm:"not null"`
 	Width      int    `json:"width" gorm:"not null"`
 	Height     int    `json:"height" gorm:"not null"`
 	MimeType   string `json:"mime_type" gorm:"not null"`
+	SHA256     string `json:"sha256" gorm:"index;size:64"`                                // 为空表示去重功能上线前上传的独占文件
+	AltType    string `json:"alt_mime_type,omitempty" gorm:"size:16;not null;default:''"` // 转码生成的备用格式（如 .webp），为空表示没有备用文件
+	AltSize    int64  `json:"alt_size,omitempty" gorm:"not null;default:0"`
 	UploadedAt int64  `json:"uploaded_at" gorm:"not null;index"`
 	UserID     uint   `json:"user_id" gorm:"not null;index"`
 	User       User   `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE;" json:"-"`
+	// Metadata 拍摄信息，仅在按需预加载时返回
+	Metadata *ImageMetadata `gorm:"foreignKey:ImageID;references:ID;constraint:OnDelete:CASCADE;" json:"metadata,omitempty"`
+	// Tags 图片标签名，仅在按需加载时返回
+	Tags []string `gorm:"-" json:"tags,omitempty"`
+
+	// OriginalName 上传时的原始文件名（已清理路径与控制字符），用于区分图片与下载命名
+	OriginalName string `json:"original_name" gorm:"size:255;not null;default:''"`
+	Title        string `json:"title" gorm:"size:100;not null;default:''"`
+	Description  string `json:"description" gorm:"size:1000;not null;default:''"`
+	// Visibility 可见性：public 公开，unlisted 不公开列出但可凭链接访问，private 仅所有者或签名链接可访问
+	Visibility string `json:"visibility" gorm:"size:16;not null;default:'public';index"`
+	// ShortCode 短链接 /s/{code} 的短码（随机 base62 或管理员自定义），为空表示尚未生成
+	ShortCode *string `json:"short_code,omitempty" gorm:"size:32;uniqueIndex"`
+	// ExpiresAt 过期时间（Unix 秒），到期后不再可访问并由后台清理任务删除；为空表示永久保存
+	ExpiresAt *int64 `json:"expires_at,omitempty" gorm:"index"`
+	// DeletedAt 移入回收站的时间；回收站中的图片不可访问，但仍占用文件与配额，直到被永久删除
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	// Views 累计访问次数，访问计数定期批量写入，存在最长一个写入周期的延迟
+	Views int64 `json:"views" gorm:"not null;default:0"`
+}
+
+// Expired 判断图片在 now（Unix 秒）时是否已过期。
+func (img *Image) Expired(now int64) bool {
+	return img.ExpiresAt != nil && *img.ExpiresAt <= now
+}
+
+// StorageSize 返回该记录计入用户配额的字节数（原文件与备用格式文件之和）。
+func (img *Image) StorageSize() int64 {
+	return img.Size + img.AltSize
 }
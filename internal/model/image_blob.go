@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// ImageBlob 内容寻址的物理文件，多个内容相同的 Image 记录共享同一个 ImageBlob。
+type ImageBlob struct {
+	ID        uint `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Hash      string `json:"hash" gorm:"not null;uniqueIndex;size:64"` // SHA-256 (hex)
+	Path      string `json:"path" gorm:"not null"`                     // 存储中的对象 key
+	Size      int64  `json:"size" gorm:"not null"`
+	RefCount  int64  `json:"ref_count" gorm:"not null;default:0"` // 引用该文件的图片记录数
+}
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// ImageMetadata 上传时从 EXIF 中解析出的拍摄信息，与 Image 一对一。
+//
+// GPS 字段仅在图片所有者查看详情时返回，管理端列表不会预加载该表。
+type ImageMetadata struct {
+	ID           uint       `json:"-" gorm:"primaryKey"`
+	ImageID      uint       `json:"image_id" gorm:"not null;uniqueIndex"`
+	Make         string     `json:"make" gorm:"size:128"`
+	Model        string     `json:"model" gorm:"size:128"`
+	LensModel    string     `json:"lens_model" gorm:"size:128"`
+	ExposureTime string     `json:"exposure_time" gorm:"size:32"` // 形如 1/250
+	FNumber      float64    `json:"f_number"`
+	ISO          int        `json:"iso"`
+	FocalLength  float64    `json:"focal_length"`          // 毫米
+	TakenAt      *time.Time `json:"taken_at" gorm:"index"` // EXIF DateTimeOriginal (UTC)
+	Orientation  int        `json:"orientation" gorm:"not null;default:0"`
+	GPSLatitude  *float64   `json:"gps_latitude,omitempty"`
+	GPSLongitude *float64   `json:"gps_longitude,omitempty"`
+	GPSAltitude  *float64   `json:"gps_altitude,omitempty"`
+}
+
+func (ImageMetadata) TableName() string {
+	return "image_metadata"
+}
@@ -0,0 +1,16 @@
+package model
+
+// ImageViewStat 图片按天汇总的访问次数，由内存中的访问计数定期批量写入。
+type ImageViewStat struct {
+	ImageID uint   `json:"-" gorm:"primaryKey;autoIncrement:false"`
+	Day     string `json:"date" gorm:"primaryKey;size:10"` // YYYY-MM-DD（服务器本地时区）
+	Views   int64  `json:"views" gorm:"not null;default:0"`
+}
+
+// ImageReferrerStat 图片按天与来源站点（Referer 的主机名）汇总的访问次数，不含没有 Referer 的访问。
+type ImageReferrerStat struct {
+	ImageID uint   `json:"-" gorm:"primaryKey;autoIncrement:false"`
+	Day     string `json:"-" gorm:"primaryKey;size:10"`
+	Host    string `json:"host" gorm:"primaryKey;size:255"`
+	Views   int64  `json:"views" gorm:"not null;default:0"`
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// PersonalAccessToken 个人访问令牌，供脚本与桌面客户端以 Bearer ppt_... 方式调用 API。
+// 仅保存令牌的 SHA-256 摘要，明文只在创建时返回一次。
+type PersonalAccessToken struct {
+	ID        uint `json:"id" gorm:"primaryKey"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	UserID    uint   `json:"user_id" gorm:"not null;index"`
+	Name      string `json:"name" gorm:"not null;size:64"`
+	TokenHash string `json:"-" gorm:"not null;uniqueIndex;size:64"`
+	// TokenHint 令牌明文的开头部分，用于在列表中辨认令牌
+	TokenHint string `json:"token_hint" gorm:"not null;size:16"`
+	// Scopes 逗号分隔的权限范围，如 upload,read
+	Scopes string `json:"scopes" gorm:"not null;size:64"`
+	// Source 令牌来源：手动创建为空，客户端配置签发的为客户端标识（如 sharex、picgo），重新签发时只轮换同来源的令牌
+	Source     string     `json:"source" gorm:"not null;size:16;default:''"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip" gorm:"not null;size:64;default:''"`
+	User       User       `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE;" json:"-"`
+}
+
+// HasAnyScope 判断令牌是否具备任一指定的权限范围。
+func (t *PersonalAccessToken) HasAnyScope(scopes ...string) bool {
+	for _, granted := range strings.Split(t.Scopes, ",") {
+		for _, scope := range scopes {
+			if granted == scope {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+package model
+
+// Tag 用户自定义的图片标签，名称在同一用户内唯一（统一为小写）。
+type Tag struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	UserID    uint   `json:"user_id" gorm:"not null;uniqueIndex:idx_tags_user_name"`
+	Name      string `json:"name" gorm:"not null;size:32;uniqueIndex:idx_tags_user_name"`
+	CreatedAt int64  `json:"created_at"`
+	User      User   `gorm:"foreignKey:UserID;references:ID;constraint:OnDelete:CASCADE;" json:"-"`
+}
+
+// ImageTag 图片与标签的关联记录。
+type ImageTag struct {
+	ImageID uint `json:"image_id" gorm:"primaryKey;autoIncrement:false"`
+	TagID   uint `json:"tag_id" gorm:"primaryKey;autoIncrement:false;index"`
+}
+
+// ImageSearchDocument 图片的全文检索文档，由可检索字段拼接而成，在这些字段变更时同步重建。
+//
+// 各数据库在该表上建立各自的全文索引：SQLite 使用 FTS5 外部内容表，
+// PostgreSQL 使用 to_tsvector 表达式 GIN 索引，MySQL 使用 ngram FULLTEXT 索引。
+type ImageSearchDocument struct {
+	ImageID uint   `gorm:"primaryKey;autoIncrement:false"`
+	Content string `gorm:"type:text;not null"`
+}
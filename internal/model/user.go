@@ -20,5 +20,10 @@ type User struct {
 	EmailVerified bool           `json:"email_verified" gorm:"default:false"`
 	StorageQuota  *int64         `json:"storage_quota"`
 	StorageUsed   int64          `json:"storage_used" gorm:"default:0"` // 已用存储空间 (Bytes)
-	Photos        []Image        `json:"-"`
+	ImageTTL      *int64         `json:"image_ttl"`                     // 上传图片的默认有效期 (秒, 0 表示永久)，为空时使用系统默认值
+	// 用量限额为空时使用系统默认值，0 表示不限制
+	DailyUploadCount   *int64  `json:"daily_upload_count"`   // 每日最多上传的图片数
+	DailyUploadBytes   *int64  `json:"daily_upload_bytes"`   // 每日最多上传的字节数
+	MonthlyEgressBytes *int64  `json:"monthly_egress_bytes"` // 图片每月最多被访问的流量 (Bytes)
+	Photos             []Image `json:"-"`
 }
@@ -3,6 +3,7 @@ package cache
 import (
 	"context"
 	"errors"
+	"strconv"
 	"sync"
 	"time"
 
@@ -11,6 +12,15 @@ import (
 
 const redisOpTimeout = time.Second
 
+// incrByScript 原子地累加计数并在键尚无过期时间时设置过期时间，避免 INCRBY 与 EXPIRE 之间进程退出留下永久计数。
+var incrByScript = redis.NewScript(`
+local value = redis.call('INCRBY', KEYS[1], ARGV[1])
+if redis.call('PTTL', KEYS[1]) < 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[2])
+end
+return value
+`)
+
 type localEntry struct {
 	value     string
 	expiresAt time.Time
@@ -106,6 +116,28 @@ func (s *Store) Delete(keys ...string) {
 	}
 }
 
+// IncrBy 将 key 上的整数计数原子地增加 delta（可为负数）并返回增加后的值。
+// 计数首次创建时设置过期时间 ttl，之后的累加不会延长过期时间；Redis 不可用时自动回退本地内存。
+func (s *Store) IncrBy(key string, delta int64, ttl time.Duration) int64 {
+	if value, ok := s.tryIncrByRedis(key, delta, ttl); ok {
+		return value
+	}
+	return s.incrByLocal(key, delta, ttl)
+}
+
+// GetInt64 读取整数计数，不存在或无法解析时返回 0。
+func (s *Store) GetInt64(key string) int64 {
+	value, ok := s.Get(key)
+	if !ok {
+		return 0
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
 // CompareAndDeletePair 对一对关联缓存键执行“比较并删除”。
 // 典型用法：valueKey 保存业务值，indexKey 保存 valueKey（或其标识）用于反向索引。
 // 参数顺序：indexKey, expectedIndexValue, valueKey, expectedValue。
@@ -164,6 +196,20 @@ func (s *Store) trySetIndexedRedis(indexKey, valueKey, value string, ttl time.Du
 	return true
 }
 
+func (s *Store) tryIncrByRedis(key string, delta int64, ttl time.Duration) (int64, bool) {
+	if s.redisClient == nil {
+		return 0, false
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisOpTimeout)
+	defer cancel()
+
+	value, err := incrByScript.Run(ctx, s.redisClient, []string{key}, delta, ttl.Milliseconds()).Int64()
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
+
 func (s *Store) getRedis(key string) (string, bool, error) {
 	if s.redisClient == nil {
 		return "", false, nil
@@ -302,6 +348,22 @@ func (s *Store) getLocal(key string) (string, bool) {
 	return entry.value, true
 }
 
+func (s *Store) incrByLocal(key string, delta int64, ttl time.Duration) int64 {
+	s.localMu.Lock()
+	defer s.localMu.Unlock()
+	now := time.Now()
+
+	entry, ok := s.local[key]
+	if !ok || now.After(entry.expiresAt) {
+		entry = localEntry{value: "0", expiresAt: now.Add(ttl)}
+	}
+	current, _ := strconv.ParseInt(entry.value, 10, 64)
+	current += delta
+	entry.value = strconv.FormatInt(current, 10)
+	s.local[key] = entry
+	return current
+}
+
 func (s *Store) getAndDeleteLocal(key string) (string, bool) {
 	s.localMu.Lock()
 	defer s.localMu.Unlock()
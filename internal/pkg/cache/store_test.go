@@ -111,3 +111,28 @@ func TestCleanupExpiredLocal_RemovesExpired(t *testing.T) {
 		t.Fatalf("expected expired key removed")
 	}
 }
+
+func TestIncrBy_LocalFallback(t *testing.T) {
+	s := newTestStore("test")
+	key := s.RedisKey("counter")
+
+	if got := s.GetInt64(key); got != 0 {
+		t.Fatalf("expected missing counter to read 0, got=%d", got)
+	}
+	if got := s.IncrBy(key, 5, time.Minute); got != 5 {
+		t.Fatalf("expected 5, got=%d", got)
+	}
+	if got := s.IncrBy(key, -2, time.Minute); got != 3 {
+		t.Fatalf("expected 3, got=%d", got)
+	}
+	if got := s.GetInt64(key); got != 3 {
+		t.Fatalf("expected stored counter 3, got=%d", got)
+	}
+
+	expiring := s.RedisKey("counter_exp")
+	s.IncrBy(expiring, 1, 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if got := s.IncrBy(expiring, 1, time.Minute); got != 1 {
+		t.Fatalf("expected expired counter to restart at 1, got=%d", got)
+	}
+}
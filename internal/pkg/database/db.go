@@ -106,11 +106,24 @@ func NewGormDB(cfg *DbConnectionConfig) (*gorm.DB, error) {
 		&model.Setting{},
 		&model.Image{},
 		&model.PasskeyCredential{},
+		&model.ImageBlob{},
+		&model.ImageMetadata{},
+		&model.Album{},
+		&model.AlbumImage{},
+		&model.Tag{},
+		&model.ImageTag{},
+		&model.ImageSearchDocument{},
+		&model.PersonalAccessToken{},
+		&model.ImageViewStat{},
+		&model.ImageReferrerStat{},
 	)
 
 	if err != nil {
 		return nil, fmt.Errorf("数据库迁移失败: %w", err)
 	}
+	if err := MigrateSearchIndex(gormDB); err != nil {
+		return nil, err
+	}
 
 	if cfg.Type == "sqlite" {
 		// 仅提示：如果数据库是旧版本、images 表曾在没有外键约束的情况下创建，
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// MigrateSearchIndex 为 image_search_documents 表创建对应数据库的全文索引，需在 AutoMigrate 之后调用。
+//
+// 中文等不以空格分词的文本无法按词切分，因此 SQLite 使用 trigram 分词器、PostgreSQL 使用 pg_trgm 索引，
+// 检索时按子串匹配；MySQL 使用 ngram 解析器。PostgreSQL 无法启用 pg_trgm 时仅记录警告，不影响启动。
+func MigrateSearchIndex(db *gorm.DB) error {
+	switch db.Dialector.Name() {
+	case "sqlite":
+		// FTS5 外部内容表，由触发器与 image_search_documents 保持同步
+		statements := []string{
+			`CREATE VIRTUAL TABLE IF NOT EXISTS image_search_fts USING fts5(content, content='image_search_documents', content_rowid='image_id', tokenize='trigram')`,
+			`CREATE TRIGGER IF NOT EXISTS image_search_documents_ai AFTER INSERT ON image_search_documents BEGIN
+				INSERT INTO image_search_fts(rowid, content) VALUES (new.image_id, new.content);
+			END`,
+			`CREATE TRIGGER IF NOT EXISTS image_search_documents_ad AFTER DELETE ON image_search_documents BEGIN
+				INSERT INTO image_search_fts(image_search_fts, rowid, content) VALUES ('delete', old.image_id, old.content);
+			END`,
+			`CREATE TRIGGER IF NOT EXISTS image_search_documents_au AFTER UPDATE ON image_search_documents BEGIN
+				INSERT INTO image_search_fts(image_search_fts, rowid, content) VALUES ('delete', old.image_id, old.content);
+				INSERT INTO image_search_fts(rowid, content) VALUES (new.image_id, new.content);
+			END`,
+		}
+		for _, stmt := range statements {
+			if err := db.Exec(stmt).Error; err != nil {
+				return fmt.Errorf("创建 SQLite 全文索引失败: %w", err)
+			}
+		}
+	case "postgres":
+		if !ensurePgTrgm(db) {
+			// 检索使用 ILIKE 子串匹配，缺少 pg_trgm 时结果不变，只是退化为全表扫描
+			log.Printf("⚠️ PostgreSQL 未安装 pg_trgm 扩展且当前账号无权创建，图片检索将不使用索引；可由数据库管理员执行 CREATE EXTENSION pg_trgm 后重启服务")
+			return nil
+		}
+		if err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_image_search_documents_content_trgm ON image_search_documents USING GIN (content gin_trgm_ops)`).Error; err != nil {
+			return fmt.Errorf("创建 PostgreSQL 全文索引失败: %w", err)
+		}
+	case "mysql":
+		var count int64
+		if err := db.Raw(`SELECT COUNT(*) FROM information_schema.statistics
+			WHERE table_schema = DATABASE() AND table_name = 'image_search_documents' AND index_name = 'idx_image_search_documents_content'`).
+			Scan(&count).Error; err != nil {
+			return fmt.Errorf("检查 MySQL 全文索引失败: %w", err)
+		}
+		if count == 0 {
+			// ngram 解析器使中文等无空格分词的文本也能被检索
+			if err := db.Exec(`ALTER TABLE image_search_documents ADD FULLTEXT INDEX idx_image_search_documents_content (content) WITH PARSER ngram`).Error; err != nil {
+				return fmt.Errorf("创建 MySQL 全文索引失败: %w", err)
+			}
+		}
+	}
+	return nil
+}
+
+// ensurePgTrgm 确保 pg_trgm 扩展可用：已安装时直接返回，否则尝试创建（托管数据库的普通账号可能无权创建）。
+func ensurePgTrgm(db *gorm.DB) bool {
+	var count int64
+	if err := db.Raw(`SELECT COUNT(*) FROM pg_extension WHERE extname = 'pg_trgm'`).Scan(&count).Error; err == nil && count > 0 {
+		return true
+	}
+	if err := db.Exec(`CREATE EXTENSION IF NOT EXISTS pg_trgm`).Error; err != nil {
+		log.Printf("Create pg_trgm extension error: %v\n", err)
+		return false
+	}
+	return true
+}
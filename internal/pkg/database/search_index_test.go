@@ -0,0 +1,49 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"perfect-pic-server/internal/model"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// 测试内容：验证 SQLite 全文索引使用 trigram 分词且可重复迁移，触发器同步的文档可按中文子串检索。
+func TestMigrateSearchIndex_SQLiteTrigram(t *testing.T) {
+	gdb, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if err := gdb.AutoMigrate(&model.ImageSearchDocument{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := MigrateSearchIndex(gdb); err != nil {
+			t.Fatalf("第 %d 次迁移失败: %v", i+1, err)
+		}
+	}
+	if err := gdb.Create(&model.ImageSearchDocument{ImageID: 1, Content: "海边日落 sunset"}).Error; err != nil {
+		t.Fatalf("create doc: %v", err)
+	}
+
+	var ids []uint
+	if err := gdb.Raw(`SELECT rowid FROM image_search_fts WHERE content LIKE ?`, "%边日%").Scan(&ids).Error; err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != 1 {
+		t.Fatalf("期望按中文子串命中文档，实际为 %v", ids)
+	}
+
+	// 更新后的文档同样写入 trigram 索引
+	if err := gdb.Model(&model.ImageSearchDocument{}).Where("image_id = ?", 1).Update("content", "雪山日出").Error; err != nil {
+		t.Fatalf("update doc: %v", err)
+	}
+	if err := gdb.Raw(`SELECT rowid FROM image_search_fts WHERE image_search_fts MATCH ?`, `"山日出"`).Scan(&ids).Error; err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != 1 {
+		t.Fatalf("期望更新后的文档写入 trigram 索引，实际为 %v", ids)
+	}
+}
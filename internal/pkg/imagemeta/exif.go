@@ -0,0 +1,258 @@
+package imagemeta
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	tagMake               = 0x010F
+	tagModel              = 0x0110
+	tagExposureTime       = 0x829A
+	tagFNumber            = 0x829D
+	tagISO                = 0x8827
+	tagDateTimeOriginal   = 0x9003
+	tagOffsetTimeOriginal = 0x9011
+	tagFocalLength        = 0x920A
+	tagLensModel          = 0xA434
+
+	tagGPSLatitudeRef  = 0x0001
+	tagGPSLatitude     = 0x0002
+	tagGPSLongitudeRef = 0x0003
+	tagGPSLongitude    = 0x0004
+	tagGPSAltitudeRef  = 0x0005
+	tagGPSAltitude     = 0x0006
+)
+
+// exifDateTimeLayout EXIF 日期时间格式。
+const exifDateTimeLayout = "2006:01:02 15:04:05"
+
+// EXIF 从图片中解析出的拍摄信息，缺失的字段保持零值。
+type EXIF struct {
+	Make      string
+	Model     string
+	LensModel string
+	// ExposureTime 曝光时间，形如 "1/250" 或 "2"（秒）
+	ExposureTime string
+	FNumber      float64
+	ISO          int
+	// FocalLength 焦距（毫米）
+	FocalLength float64
+	// DateTimeOriginal 拍摄时间（UTC）；未记录时区时按 UTC 解释
+	DateTimeOriginal *time.Time
+	Orientation      int
+	GPS              *GPS
+}
+
+// GPS 拍摄地点，经纬度为十进制度数（南纬、西经为负）。
+type GPS struct {
+	Latitude  float64
+	Longitude float64
+	Altitude  *float64
+}
+
+// ParseEXIF 解析 JPEG、PNG、WebP 中的 EXIF 信息；图片不含 EXIF 时返回 nil, nil。
+func ParseEXIF(data []byte) (*EXIF, error) {
+	tiff := findEXIF(data)
+	if tiff == nil {
+		return nil, nil
+	}
+	r, ifd0Off, err := newTIFFReader(tiff)
+	if err != nil {
+		return nil, err
+	}
+	ifd0, err := r.readIFD(ifd0Off)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &EXIF{
+		Make:        r.stringTag(ifd0, tagMake),
+		Model:       r.stringTag(ifd0, tagModel),
+		Orientation: tiffOrientation(tiff),
+	}
+
+	if sub := r.subIFD(ifd0, tagExifIFD); sub != nil {
+		res.LensModel = r.stringTag(sub, tagLensModel)
+		if num, den, ok := r.rationalTag(sub, tagExposureTime, 0); ok && num > 0 && den > 0 {
+			res.ExposureTime = formatExposure(num, den)
+		}
+		if v, ok := r.floatTag(sub, tagFNumber); ok {
+			res.FNumber = v
+		}
+		if e, ok := sub.find(tagISO); ok {
+			if v, ok := r.uintValue(e); ok {
+				res.ISO = int(v)
+			}
+		}
+		if v, ok := r.floatTag(sub, tagFocalLength); ok {
+			res.FocalLength = v
+		}
+		res.DateTimeOriginal = parseEXIFTime(r.stringTag(sub, tagDateTimeOriginal), r.stringTag(sub, tagOffsetTimeOriginal))
+	}
+
+	if sub := r.subIFD(ifd0, tagGPSIFD); sub != nil {
+		res.GPS = r.parseGPS(sub)
+	}
+	return res, nil
+}
+
+// findEXIF 定位图片中的 TIFF 格式 EXIF 数据。
+func findEXIF(data []byte) []byte {
+	var tiff []byte
+	switch {
+	case isJPEG(data):
+		_ = walkJPEG(data, func(seg jpegSegment) error {
+			if tiff == nil && seg.marker == jpegAPP1 && bytes.HasPrefix(seg.payload, exifHeader) {
+				tiff = seg.payload[len(exifHeader):]
+			}
+			return nil
+		}, func([]byte) {})
+	case isPNG(data):
+		_ = walkPNG(data, func(c pngChunk) error {
+			if tiff == nil && c.typ == "eXIf" {
+				tiff = c.data
+			}
+			return nil
+		})
+	case isWebP(data):
+		_ = walkWebP(data, func(c riffChunk) error {
+			if tiff == nil && c.fourcc == "EXIF" {
+				tiff = bytes.TrimPrefix(c.data, exifHeader)
+			}
+			return nil
+		})
+	}
+	return tiff
+}
+
+// subIFD 读取指针条目指向的子目录。
+func (r *tiffReader) subIFD(d *ifd, tag uint16) *ifd {
+	e, ok := d.find(tag)
+	if !ok {
+		return nil
+	}
+	off, ok := r.uintValue(e)
+	if !ok {
+		return nil
+	}
+	sub, err := r.readIFD(off)
+	if err != nil {
+		return nil
+	}
+	return sub
+}
+
+// stringTag 读取 ASCII 条目，去除结尾的 NUL 与空白。
+func (r *tiffReader) stringTag(d *ifd, tag uint16) string {
+	e, ok := d.find(tag)
+	if !ok || e.Type != 2 {
+		return ""
+	}
+	start, end, _, ok := r.valueRange(e)
+	if !ok {
+		return ""
+	}
+	value, _, _ := bytes.Cut(r.data[start:end], []byte{0})
+	return strings.TrimSpace(strings.ToValidUTF8(string(value), ""))
+}
+
+// rationalTag 读取 RATIONAL 条目的第 index 个值。
+func (r *tiffReader) rationalTag(d *ifd, tag uint16, index int) (uint32, uint32, bool) {
+	e, ok := d.find(tag)
+	if !ok || e.Type != 5 || uint32(index) >= e.Count {
+		return 0, 0, false
+	}
+	start, _, _, ok := r.valueRange(e)
+	if !ok {
+		return 0, 0, false
+	}
+	p := start + index*8
+	return r.order.Uint32(r.data[p:]), r.order.Uint32(r.data[p+4:]), true
+}
+
+// floatTag 将 RATIONAL 条目的第一个值转换为浮点数。
+func (r *tiffReader) floatTag(d *ifd, tag uint16) (float64, bool) {
+	num, den, ok := r.rationalTag(d, tag, 0)
+	if !ok || den == 0 {
+		return 0, false
+	}
+	return float64(num) / float64(den), true
+}
+
+// parseGPS 解析 GPS 子目录，缺少经纬度时返回 nil。
+func (r *tiffReader) parseGPS(d *ifd) *GPS {
+	lat, ok := r.gpsCoordinate(d, tagGPSLatitude)
+	if !ok {
+		return nil
+	}
+	lon, ok := r.gpsCoordinate(d, tagGPSLongitude)
+	if !ok {
+		return nil
+	}
+	if strings.EqualFold(r.stringTag(d, tagGPSLatitudeRef), "S") {
+		lat = -lat
+	}
+	if strings.EqualFold(r.stringTag(d, tagGPSLongitudeRef), "W") {
+		lon = -lon
+	}
+	if math.Abs(lat) > 90 || math.Abs(lon) > 180 {
+		return nil
+	}
+
+	res := &GPS{Latitude: lat, Longitude: lon}
+	if alt, ok := r.floatTag(d, tagGPSAltitude); ok {
+		// AltitudeRef 为 1 表示海平面以下
+		if e, ok := d.find(tagGPSAltitudeRef); ok && e.Type == 1 && r.data[e.pos+8] == 1 {
+			alt = -alt
+		}
+		res.Altitude = &alt
+	}
+	return res
+}
+
+// gpsCoordinate 将度、分、秒三个 RATIONAL 转换为十进制度数。
+func (r *tiffReader) gpsCoordinate(d *ifd, tag uint16) (float64, bool) {
+	var parts [3]float64
+	for i := range parts {
+		num, den, ok := r.rationalTag(d, tag, i)
+		if !ok || den == 0 {
+			return 0, false
+		}
+		parts[i] = float64(num) / float64(den)
+	}
+	return parts[0] + parts[1]/60 + parts[2]/3600, true
+}
+
+// formatExposure 将曝光时间格式化为摄影中常用的写法。
+func formatExposure(num, den uint32) string {
+	if num >= den {
+		return strconv.FormatFloat(float64(num)/float64(den), 'f', -1, 64)
+	}
+	return fmt.Sprintf("1/%d", int(math.Round(float64(den)/float64(num))))
+}
+
+// parseEXIFTime 解析 DateTimeOriginal，offset 形如 "+08:00"。
+func parseEXIFTime(value, offset string) *time.Time {
+	if value == "" {
+		return nil
+	}
+	loc := time.UTC
+	if offset != "" {
+		if t, err := time.Parse("-07:00", offset); err == nil {
+			_, secs := t.Zone()
+			loc = time.FixedZone(offset, secs)
+		}
+	}
+	t, err := time.ParseInLocation(exifDateTimeLayout, value, loc)
+	if err != nil || t.Year() < 1800 {
+		return nil
+	}
+	// 统一存储为 UTC，保证跨数据库排序一致
+	t = t.UTC()
+	return &t
+}
@@ -0,0 +1,106 @@
+package imagemeta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image/png"
+	"math"
+	"testing"
+	"time"
+)
+
+// fullTestEXIF 包含 EXIF 子目录与 GPS 子目录的完整拍摄信息。
+func fullTestEXIF() []byte {
+	return buildTIFF([]testTag{
+		asciiTag(tagMake, "TestMaker"),
+		asciiTag(tagModel, "TestCam X1"),
+		shortTag(tagOrientation, 6),
+		{tag: tagExifIFD, child: []testTag{
+			rationalTag(tagExposureTime, 1, 250),
+			rationalTag(tagFNumber, 28, 10),
+			shortTag(tagISO, 400),
+			asciiTag(tagDateTimeOriginal, "2024:05:06 07:08:09"),
+			asciiTag(tagOffsetTimeOriginal, "+08:00"),
+			rationalTag(tagFocalLength, 35, 1),
+			asciiTag(tagLensModel, "TestLens 35mm F1.4"),
+		}},
+		{tag: tagGPSIFD, child: []testTag{
+			asciiTag(tagGPSLatitudeRef, "S"),
+			rationalTag(tagGPSLatitude, 33, 1, 30, 1, 0, 1),
+			asciiTag(tagGPSLongitudeRef, "E"),
+			rationalTag(tagGPSLongitude, 151, 1, 12, 1, 36, 1),
+			{tag: tagGPSAltitudeRef, typ: 1, count: 1, value: []byte{0}},
+			rationalTag(tagGPSAltitude, 105, 2),
+		}},
+	})
+}
+
+// 测试内容：验证从 JPEG 中解析相机、镜头、曝光参数、拍摄时间、方向与 GPS。
+func TestParseEXIF_JPEG(t *testing.T) {
+	plain := newTestJPEG(t)
+	data := append([]byte(nil), plain[:2]...)
+	data = append(data, jpegAPP1Exif(fullTestEXIF())...)
+	data = append(data, plain[2:]...)
+
+	got, err := ParseEXIF(data)
+	if err != nil || got == nil {
+		t.Fatalf("ParseEXIF: %v %v", got, err)
+	}
+	if got.Make != "TestMaker" || got.Model != "TestCam X1" || got.LensModel != "TestLens 35mm F1.4" {
+		t.Fatalf("相机信息不符: %+v", got)
+	}
+	if got.ExposureTime != "1/250" || got.FNumber != 2.8 || got.ISO != 400 || got.FocalLength != 35 {
+		t.Fatalf("曝光参数不符: %+v", got)
+	}
+	if got.Orientation != 6 {
+		t.Fatalf("期望方向 6，实际为 %d", got.Orientation)
+	}
+	want := time.Date(2024, 5, 5, 23, 8, 9, 0, time.UTC)
+	if got.DateTimeOriginal == nil || !got.DateTimeOriginal.Equal(want) || got.DateTimeOriginal.Location() != time.UTC {
+		t.Fatalf("期望拍摄时间 %v，实际为 %v", want, got.DateTimeOriginal)
+	}
+	if got.GPS == nil || math.Abs(got.GPS.Latitude+33.5) > 1e-9 || math.Abs(got.GPS.Longitude-151.21) > 1e-9 {
+		t.Fatalf("GPS 不符: %+v", got.GPS)
+	}
+	if got.GPS.Altitude == nil || *got.GPS.Altitude != 52.5 {
+		t.Fatalf("期望海拔 52.5，实际为 %v", got.GPS.Altitude)
+	}
+}
+
+// 测试内容：验证 PNG eXIf 块可被解析，无 EXIF 时返回 nil，损坏的 EXIF 返回错误。
+func TestParseEXIF_PNGAndMissing(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, newTestImage()); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	plain := buf.Bytes()
+	if got, err := ParseEXIF(plain); got != nil || err != nil {
+		t.Fatalf("期望无 EXIF 时返回 nil, nil，实际为 %+v %v", got, err)
+	}
+
+	ihdrEnd := len(pngSignature) + 12 + 13
+	data := append([]byte(nil), plain[:ihdrEnd]...)
+	data = appendPNGChunk(data, "eXIf", fullTestEXIF())
+	data = append(data, plain[ihdrEnd:]...)
+	got, err := ParseEXIF(data)
+	if err != nil || got == nil || got.Model != "TestCam X1" || got.ISO != 400 {
+		t.Fatalf("期望解析 PNG eXIf，实际为 %+v %v", got, err)
+	}
+
+	broken := append([]byte(nil), plain[:ihdrEnd]...)
+	broken = appendPNGChunk(broken, "eXIf", binary.LittleEndian.AppendUint32([]byte("II*\x00"), 4096))
+	broken = append(broken, plain[ihdrEnd:]...)
+	if _, err := ParseEXIF(broken); err == nil {
+		t.Fatalf("期望损坏的 EXIF 返回错误")
+	}
+}
+
+// 测试内容：验证曝光时间的格式化。
+func TestFormatExposure(t *testing.T) {
+	cases := map[[2]uint32]string{{1, 250}: "1/250", {10, 2500}: "1/250", {2, 1}: "2", {5, 2}: "2.5"}
+	for in, want := range cases {
+		if got := formatExposure(in[0], in[1]); got != want {
+			t.Fatalf("%v: 期望 %q，实际为 %q", in, want, got)
+		}
+	}
+}
@@ -0,0 +1,164 @@
+package imagemeta
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+const (
+	jpegEOI  = 0xD9
+	jpegSOS  = 0xDA
+	jpegAPP0 = 0xE0
+	jpegAPP1 = 0xE1
+	jpegAPP2 = 0xE2
+	jpegAPPE = 0xEE
+	jpegAPPF = 0xEF
+	jpegCOM  = 0xFE
+)
+
+// jpegSegment 描述一个带长度的 JPEG 段。
+type jpegSegment struct {
+	marker byte
+	// payload 不含 marker 与长度字段
+	payload []byte
+	// raw 包含 marker 与长度字段的完整段
+	raw []byte
+}
+
+// walkJPEG 依次遍历 SOS 之前（以及渐进式图片中各扫描之间）的全部带长度段。
+//
+// 熵编码数据与独立 marker 通过 passthrough 原样交给调用方；遇到 EOI 后停止，EOI 之后的附加数据被忽略。
+func walkJPEG(data []byte, segment func(seg jpegSegment) error, passthrough func(raw []byte)) error {
+	if !isJPEG(data) {
+		return ErrMalformed
+	}
+	passthrough(data[0:2])
+	pos := 2
+	for {
+		if pos >= len(data) || data[pos] != 0xFF {
+			return ErrMalformed
+		}
+		start := pos
+		// 跳过填充用的 0xFF
+		for pos < len(data) && data[pos] == 0xFF {
+			pos++
+		}
+		if pos >= len(data) {
+			return ErrMalformed
+		}
+		marker := data[pos]
+		pos++
+
+		switch {
+		case marker == jpegEOI:
+			passthrough([]byte{0xFF, jpegEOI})
+			return nil
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7):
+			passthrough(data[start:pos])
+			continue
+		}
+
+		if pos+2 > len(data) {
+			return ErrMalformed
+		}
+		length := int(binary.BigEndian.Uint16(data[pos:]))
+		if length < 2 || pos+length > len(data) {
+			return ErrMalformed
+		}
+		end := pos + length
+
+		if marker == jpegSOS {
+			// 扫描头之后是熵编码数据，直到遇到非 RST 的下一个 marker
+			scanEnd := end
+			for scanEnd+1 < len(data) {
+				if data[scanEnd] == 0xFF {
+					next := data[scanEnd+1]
+					if next != 0x00 && next != 0xFF && (next < 0xD0 || next > 0xD7) {
+						break
+					}
+				}
+				scanEnd++
+			}
+			if scanEnd+1 >= len(data) {
+				// 缺少 EOI 的截断文件：保留剩余数据
+				passthrough(data[start:])
+				return nil
+			}
+			passthrough(data[start:scanEnd])
+			pos = scanEnd
+			continue
+		}
+
+		if err := segment(jpegSegment{marker: marker, payload: data[pos+2 : end], raw: data[start:end]}); err != nil {
+			return err
+		}
+		pos = end
+	}
+}
+
+// stripJPEG 清理 JPEG 中的 APP1(EXIF/XMP)、APP13(IPTC) 等元数据段。
+func stripJPEG(data []byte, mode StripMode) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	err := walkJPEG(data, func(seg jpegSegment) error {
+		out = append(out, filterJPEGSegment(seg, mode)...)
+		return nil
+	}, func(raw []byte) {
+		out = append(out, raw...)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// filterJPEGSegment 返回段在输出中的替代内容，返回空表示丢弃。
+func filterJPEGSegment(seg jpegSegment, mode StripMode) []byte {
+	switch {
+	case seg.marker == jpegAPP1 && bytes.HasPrefix(seg.payload, exifHeader):
+		tiff := seg.payload[len(exifHeader):]
+		if mode == StripAll {
+			// 保留方向信息，否则手机竖拍的照片会以错误方向显示
+			if o := tiffOrientation(tiff); o > 1 {
+				return jpegAPP1Exif(orientationTIFF(o))
+			}
+			return nil
+		}
+		scrubbed, err := scrubTIFFGPS(tiff)
+		if err != nil {
+			// 无法解析的 EXIF 无法确认是否包含位置信息，直接移除
+			return nil
+		}
+		return jpegAPP1Exif(scrubbed)
+	case seg.marker == jpegAPP1 && bytes.HasPrefix(seg.payload, xmpHeader):
+		if mode == StripAll || xmpHasGPS(seg.payload) {
+			return nil
+		}
+		return seg.raw
+	case seg.marker == jpegAPP1 && bytes.HasPrefix(seg.payload, xmpExtendedHeader):
+		// 扩展 XMP 被拆分为多段，无法逐段判断内容
+		return nil
+	case seg.marker == jpegAPP2 && bytes.HasPrefix(seg.payload, []byte("MPF\x00")):
+		// 多图索引指向 EOI 之后的附加图片，附加数据已被移除
+		return nil
+	}
+
+	if mode == StripAll {
+		switch {
+		case seg.marker == jpegAPP0, seg.marker == jpegAPP2, seg.marker == jpegAPPE:
+			// JFIF/JFXX、ICC 色彩配置、Adobe 颜色变换参数影响解码结果，需保留
+			return seg.raw
+		case seg.marker >= jpegAPP1 && seg.marker <= jpegAPPF, seg.marker == jpegCOM:
+			return nil
+		}
+	}
+	return seg.raw
+}
+
+// jpegAPP1Exif 将 TIFF 数据封装为 EXIF APP1 段。
+func jpegAPP1Exif(tiff []byte) []byte {
+	length := 2 + len(exifHeader) + len(tiff)
+	out := make([]byte, 0, 2+length)
+	out = append(out, 0xFF, jpegAPP1, byte(length>>8), byte(length))
+	out = append(out, exifHeader...)
+	return append(out, tiff...)
+}
@@ -0,0 +1,100 @@
+package imagemeta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"strings"
+)
+
+// pngChunk 描述一个 PNG 数据块。
+type pngChunk struct {
+	typ  string
+	data []byte
+	// raw 包含长度、类型、数据与 CRC 的完整块
+	raw []byte
+}
+
+// walkPNG 依次遍历 PNG 数据块，遇到 IEND 后停止，IEND 之后的附加数据被忽略。
+func walkPNG(data []byte, chunk func(c pngChunk) error) error {
+	if !isPNG(data) {
+		return ErrMalformed
+	}
+	pos := len(pngSignature)
+	for {
+		if pos+12 > len(data) {
+			return ErrMalformed
+		}
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		end := pos + 12 + length
+		if length < 0 || end > len(data) {
+			return ErrMalformed
+		}
+		c := pngChunk{typ: string(data[pos+4 : pos+8]), data: data[pos+8 : pos+8+length], raw: data[pos:end]}
+		if err := chunk(c); err != nil {
+			return err
+		}
+		if c.typ == "IEND" {
+			return nil
+		}
+		pos = end
+	}
+}
+
+// stripPNG 清理 PNG 中的 eXIf 与文本块（XMP、IPTC 等均以文本块形式存放）。
+func stripPNG(data []byte, mode StripMode) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+	err := walkPNG(data, func(c pngChunk) error {
+		switch c.typ {
+		case "eXIf":
+			if mode == StripAll {
+				return nil
+			}
+			scrubbed, err := scrubTIFFGPS(c.data)
+			if err != nil {
+				return nil //nolint:nilerr // 无法解析的 EXIF 直接移除
+			}
+			out = appendPNGChunk(out, c.typ, scrubbed)
+		case "tEXt", "zTXt", "iTXt":
+			if mode == StripAll || pngTextHasGPS(c) {
+				return nil
+			}
+			out = append(out, c.raw...)
+		default:
+			out = append(out, c.raw...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// pngTextHasGPS 判断文本块是否可能包含位置信息。
+//
+// EXIF 原始档案与压缩的 XMP 无法廉价地检查内容，一律视为包含。
+func pngTextHasGPS(c pngChunk) bool {
+	keyword, rest, _ := bytes.Cut(c.data, []byte{0})
+	key := strings.ToLower(string(keyword))
+	if strings.HasPrefix(key, "raw profile type exif") || strings.HasPrefix(key, "raw profile type app1") {
+		return true
+	}
+	if key != "xml:com.adobe.xmp" {
+		return false
+	}
+	if c.typ != "iTXt" || len(rest) == 0 || rest[0] != 0 {
+		return true
+	}
+	return xmpHasGPS(rest)
+}
+
+// appendPNGChunk 写入一个数据块并计算 CRC。
+func appendPNGChunk(out []byte, typ string, data []byte) []byte {
+	out = binary.BigEndian.AppendUint32(out, uint32(len(data)))
+	start := len(out)
+	out = append(out, typ...)
+	out = append(out, data...)
+	return binary.BigEndian.AppendUint32(out, crc32.ChecksumIEEE(out[start:]))
+}
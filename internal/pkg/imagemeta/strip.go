@@ -0,0 +1,82 @@
+// Package imagemeta 在不重新编码像素的前提下读取、清理图片中的元数据（EXIF/XMP/IPTC）。
+package imagemeta
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+)
+
+// StripMode 元数据清理模式。
+type StripMode string
+
+const (
+	// StripAll 移除全部 EXIF/XMP/IPTC 等元数据，仅保留渲染所需的方向信息与色彩配置。
+	StripAll StripMode = "all"
+	// StripGPS 仅移除地理位置信息，其余元数据原样保留。
+	StripGPS StripMode = "gps"
+	// StripNone 保留全部元数据。
+	StripNone StripMode = "keep"
+)
+
+// ErrMalformed 表示图片结构损坏，无法安全地定位元数据。
+var ErrMalformed = errors.New("imagemeta: malformed image")
+
+// ParseStripMode 解析清理模式，非法取值返回 false。
+func ParseStripMode(mode string) (StripMode, bool) {
+	switch StripMode(strings.ToLower(strings.TrimSpace(mode))) {
+	case StripAll:
+		return StripAll, true
+	case StripGPS:
+		return StripGPS, true
+	case StripNone:
+		return StripNone, true
+	default:
+		return "", false
+	}
+}
+
+// Strip 按模式清理 JPEG、PNG、WebP 中的元数据，其它格式原样返回。
+//
+// 只增删或改写元数据段/块，图像数据逐字节保留，因此不会产生二次压缩损失。
+func Strip(data []byte, mode StripMode) ([]byte, error) {
+	if mode == StripNone || mode == "" {
+		return data, nil
+	}
+	switch {
+	case isJPEG(data):
+		return stripJPEG(data, mode)
+	case isPNG(data):
+		return stripPNG(data, mode)
+	case isWebP(data):
+		return stripWebP(data, mode)
+	default:
+		return data, nil
+	}
+}
+
+var (
+	exifHeader        = []byte("Exif\x00\x00")
+	xmpHeader         = []byte("http://ns.adobe.com/xap/1.0/\x00")
+	xmpExtendedHeader = []byte("http://ns.adobe.com/xmp/extension/\x00")
+	pngSignature      = []byte("\x89PNG\r\n\x1a\n")
+)
+
+func isJPEG(data []byte) bool {
+	return len(data) >= 3 && data[0] == 0xFF && data[1] == 0xD8 && data[2] == 0xFF
+}
+
+func isPNG(data []byte) bool {
+	return bytes.HasPrefix(data, pngSignature)
+}
+
+func isWebP(data []byte) bool {
+	return len(data) >= 12 && string(data[0:4]) == "RIFF" && string(data[8:12]) == "WEBP"
+}
+
+// xmpHasGPS 判断 XMP 包中是否包含经纬度等位置信息。
+func xmpHasGPS(packet []byte) bool {
+	return bytes.Contains(packet, []byte("GPSLatitude")) ||
+		bytes.Contains(packet, []byte("GPSLongitude")) ||
+		bytes.Contains(packet, []byte("GPSAltitude"))
+}
@@ -0,0 +1,339 @@
+package imagemeta
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"sort"
+	"testing"
+
+	"github.com/HugoSmits86/nativewebp"
+	_ "golang.org/x/image/webp"
+)
+
+// testTag 测试用 TIFF 条目；child 非空时条目为指向子目录的 LONG 指针。
+type testTag struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	value []byte
+	child []testTag
+}
+
+func asciiTag(tag uint16, s string) testTag {
+	return testTag{tag: tag, typ: 2, count: uint32(len(s) + 1), value: append([]byte(s), 0)}
+}
+
+func shortTag(tag uint16, v uint16) testTag {
+	return testTag{tag: tag, typ: 3, count: 1, value: binary.LittleEndian.AppendUint16(nil, v)}
+}
+
+func rationalTag(tag uint16, pairs ...uint32) testTag {
+	var value []byte
+	for _, v := range pairs {
+		value = binary.LittleEndian.AppendUint32(value, v)
+	}
+	return testTag{tag: tag, typ: 5, count: uint32(len(pairs) / 2), value: value}
+}
+
+// buildTIFF 构造小端序的 TIFF 结构。
+func buildTIFF(ifd0 []testTag) []byte {
+	buf := []byte{'I', 'I', 42, 0, 8, 0, 0, 0}
+	return appendTestIFD(buf, ifd0)
+}
+
+func appendTestIFD(buf []byte, tags []testTag) []byte {
+	tags = append([]testTag(nil), tags...)
+	sort.Slice(tags, func(i, j int) bool { return tags[i].tag < tags[j].tag })
+
+	start := len(buf)
+	buf = append(buf, make([]byte, 2+len(tags)*12+4)...)
+	binary.LittleEndian.PutUint16(buf[start:], uint16(len(tags)))
+	for i, tag := range tags {
+		p := start + 2 + i*12
+		binary.LittleEndian.PutUint16(buf[p:], tag.tag)
+		if tag.child != nil {
+			binary.LittleEndian.PutUint16(buf[p+2:], 4)
+			binary.LittleEndian.PutUint32(buf[p+4:], 1)
+			binary.LittleEndian.PutUint32(buf[p+8:], uint32(len(buf)))
+			buf = appendTestIFD(buf, tag.child)
+			continue
+		}
+		binary.LittleEndian.PutUint16(buf[p+2:], tag.typ)
+		binary.LittleEndian.PutUint32(buf[p+4:], tag.count)
+		if len(tag.value) <= 4 {
+			copy(buf[p+8:], tag.value)
+			continue
+		}
+		binary.LittleEndian.PutUint32(buf[p+8:], uint32(len(buf)))
+		buf = append(buf, tag.value...)
+		if len(buf)%2 == 1 {
+			buf = append(buf, 0)
+		}
+	}
+	return buf
+}
+
+// testEXIF 包含相机型号、方向与 GPS 坐标的 EXIF 数据。
+func testEXIF() []byte {
+	return buildTIFF([]testTag{
+		asciiTag(0x010F, "TestMaker"),
+		asciiTag(0x0110, "TestCam"),
+		shortTag(tagOrientation, 6),
+		{tag: tagGPSIFD, child: []testTag{
+			asciiTag(0x0001, "N"),
+			rationalTag(0x0002, 31, 1, 14, 1, 1234, 100),
+			asciiTag(0x0003, "E"),
+			rationalTag(0x0004, 121, 1, 28, 1, 5678, 100),
+		}},
+	})
+}
+
+const testXMP = `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF><rdf:Description exif:GPSLatitude="31,14.2N" dc:creator="tester"/></rdf:RDF></x:xmpmeta>`
+
+func newTestImage() image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, 16, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 16; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x * 16), G: uint8(y * 32), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+func jpegSegmentBytes(marker byte, payload []byte) []byte {
+	length := len(payload) + 2
+	return append([]byte{0xFF, marker, byte(length >> 8), byte(length)}, payload...)
+}
+
+// newTestJPEG 生成带有 EXIF、XMP、IPTC 与注释的 JPEG，并在 EOI 后附加尾随数据。
+func newTestJPEG(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, newTestImage(), &jpeg.Options{Quality: 90}); err != nil {
+		t.Fatalf("encode jpeg: %v", err)
+	}
+	plain := buf.Bytes()
+
+	out := append([]byte(nil), plain[:2]...)
+	out = append(out, jpegSegmentBytes(jpegAPP1, append(append([]byte(nil), exifHeader...), testEXIF()...))...)
+	out = append(out, jpegSegmentBytes(jpegAPP1, append(append([]byte(nil), xmpHeader...), testXMP...))...)
+	out = append(out, jpegSegmentBytes(0xED, []byte("Photoshop 3.0\x008BIM\x04\x04IPTC-CITY"))...)
+	out = append(out, jpegSegmentBytes(jpegCOM, []byte("secret comment"))...)
+	out = append(out, plain[2:]...)
+	return append(out, []byte("TRAILER-GPSLatitude")...)
+}
+
+// 测试内容：验证 StripAll 移除 JPEG 中全部元数据段与尾随数据，仅保留方向信息且像素数据不变。
+func TestStrip_JPEGAll(t *testing.T) {
+	data := newTestJPEG(t)
+
+	out, err := Strip(data, StripAll)
+	if err != nil {
+		t.Fatalf("Strip: %v", err)
+	}
+	for _, needle := range []string{"TestMaker", "GPSLatitude", "IPTC-CITY", "secret comment", "TRAILER"} {
+		if bytes.Contains(out, []byte(needle)) {
+			t.Fatalf("期望移除 %q", needle)
+		}
+	}
+	if len(out) >= len(data) {
+		t.Fatalf("期望清理后体积变小: before=%d after=%d", len(data), len(out))
+	}
+
+	var orientation int
+	_ = walkJPEG(out, func(seg jpegSegment) error {
+		if seg.marker == jpegAPP1 && bytes.HasPrefix(seg.payload, exifHeader) {
+			orientation = tiffOrientation(seg.payload[len(exifHeader):])
+		}
+		return nil
+	}, func([]byte) {})
+	if orientation != 6 {
+		t.Fatalf("期望保留方向 6，实际为 %d", orientation)
+	}
+
+	orig, err := jpeg.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decode original: %v", err)
+	}
+	stripped, err := jpeg.Decode(bytes.NewReader(out))
+	if err != nil {
+		t.Fatalf("decode stripped: %v", err)
+	}
+	if !bytes.Equal(orig.(*image.YCbCr).Y, stripped.(*image.YCbCr).Y) {
+		t.Fatalf("期望像素数据保持不变")
+	}
+}
+
+// 测试内容：验证 StripGPS 仅移除 JPEG 中的 GPS 信息，相机型号与 IPTC 保留。
+func TestStrip_JPEGGPSOnly(t *testing.T) {
+	data := newTestJPEG(t)
+
+	out, err := Strip(data, StripGPS)
+	if err != nil {
+		t.Fatalf("Strip: %v", err)
+	}
+	for _, needle := range []string{"TestMaker", "TestCam", "IPTC-CITY", "secret comment"} {
+		if !bytes.Contains(out, []byte(needle)) {
+			t.Fatalf("期望保留 %q", needle)
+		}
+	}
+	if bytes.Contains(out, []byte("GPSLatitude")) {
+		t.Fatalf("期望移除包含 GPS 的 XMP 与尾随数据")
+	}
+
+	var tiff []byte
+	_ = walkJPEG(out, func(seg jpegSegment) error {
+		if seg.marker == jpegAPP1 && bytes.HasPrefix(seg.payload, exifHeader) {
+			tiff = seg.payload[len(exifHeader):]
+		}
+		return nil
+	}, func([]byte) {})
+	if tiff == nil {
+		t.Fatalf("期望保留 EXIF 段")
+	}
+	r, off, err := newTIFFReader(tiff)
+	if err != nil {
+		t.Fatalf("newTIFFReader: %v", err)
+	}
+	ifd0, err := r.readIFD(off)
+	if err != nil {
+		t.Fatalf("readIFD: %v", err)
+	}
+	if _, ok := ifd0.find(tagGPSIFD); ok {
+		t.Fatalf("期望移除 GPS 指针")
+	}
+	if len(ifd0.entries) != 3 {
+		t.Fatalf("期望剩余 3 个条目，实际为 %d", len(ifd0.entries))
+	}
+	// 原 GPS 坐标（31/1）不应再出现在数据中
+	if bytes.Contains(tiff, binary.LittleEndian.AppendUint32(binary.LittleEndian.AppendUint32(nil, 31), 1)) {
+		t.Fatalf("期望清零 GPS 坐标")
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(out)); err != nil {
+		t.Fatalf("decode stripped: %v", err)
+	}
+}
+
+// 测试内容：验证 StripNone 原样返回，非支持格式与损坏的 JPEG 分别原样返回与报错。
+func TestStrip_KeepAndMalformed(t *testing.T) {
+	data := newTestJPEG(t)
+	out, err := Strip(data, StripNone)
+	if err != nil || !bytes.Equal(out, data) {
+		t.Fatalf("期望 StripNone 原样返回")
+	}
+
+	gif := []byte("GIF89a-not-touched")
+	if out, err := Strip(gif, StripAll); err != nil || !bytes.Equal(out, gif) {
+		t.Fatalf("期望非支持格式原样返回")
+	}
+
+	truncated := data[:40]
+	if _, err := Strip(truncated, StripAll); err == nil {
+		t.Fatalf("期望截断的 JPEG 返回错误")
+	}
+}
+
+// 测试内容：验证 PNG 的 eXIf 与文本块被移除，图像仍可解码。
+func TestStrip_PNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, newTestImage()); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	plain := buf.Bytes()
+	// 在 IHDR 之后插入元数据块
+	ihdrEnd := len(pngSignature) + 12 + 13
+	data := append([]byte(nil), plain[:ihdrEnd]...)
+	data = appendPNGChunk(data, "eXIf", testEXIF())
+	data = appendPNGChunk(data, "tEXt", []byte("Comment\x00hello"))
+	data = appendPNGChunk(data, "iTXt", append([]byte("XML:com.adobe.xmp\x00\x00\x00\x00\x00"), testXMP...))
+	data = append(data, plain[ihdrEnd:]...)
+
+	all, err := Strip(data, StripAll)
+	if err != nil {
+		t.Fatalf("Strip all: %v", err)
+	}
+	if bytes.Contains(all, []byte("eXIf")) || bytes.Contains(all, []byte("hello")) || bytes.Contains(all, []byte("GPSLatitude")) {
+		t.Fatalf("期望移除全部元数据块")
+	}
+	if _, err := png.Decode(bytes.NewReader(all)); err != nil {
+		t.Fatalf("decode stripped: %v", err)
+	}
+
+	gps, err := Strip(data, StripGPS)
+	if err != nil {
+		t.Fatalf("Strip gps: %v", err)
+	}
+	if !bytes.Contains(gps, []byte("TestMaker")) || !bytes.Contains(gps, []byte("hello")) {
+		t.Fatalf("期望保留非位置元数据")
+	}
+	if bytes.Contains(gps, []byte("GPSLatitude")) {
+		t.Fatalf("期望移除包含 GPS 的 XMP")
+	}
+	if _, err := png.Decode(bytes.NewReader(gps)); err != nil {
+		t.Fatalf("decode stripped (CRC 应已更新): %v", err)
+	}
+}
+
+// 测试内容：验证 WebP 的 EXIF/XMP 块被移除，VP8X 标志位与 RIFF 长度同步更新。
+func TestStrip_WebP(t *testing.T) {
+	var buf bytes.Buffer
+	if err := nativewebp.Encode(&buf, newTestImage(), nil); err != nil {
+		t.Fatalf("encode webp: %v", err)
+	}
+	simple := buf.Bytes()
+
+	vp8x := make([]byte, 10)
+	vp8x[0] = webpFlagEXIF | webpFlagXMP
+	vp8x[4] = 16 - 1
+	vp8x[7] = 8 - 1
+	data := []byte("RIFF\x00\x00\x00\x00WEBP")
+	data = appendRIFFChunk(data, "VP8X", vp8x)
+	data = append(data, simple[12:]...)
+	data = appendRIFFChunk(data, "EXIF", testEXIF())
+	data = appendRIFFChunk(data, "XMP ", []byte(testXMP))
+	binary.LittleEndian.PutUint32(data[4:8], uint32(len(data)-8))
+
+	out, err := Strip(data, StripAll)
+	if err != nil {
+		t.Fatalf("Strip: %v", err)
+	}
+	if bytes.Contains(out, []byte("EXIF")) || bytes.Contains(out, []byte("XMP ")) {
+		t.Fatalf("期望移除 EXIF/XMP 块")
+	}
+	if out[20]&(webpFlagEXIF|webpFlagXMP) != 0 {
+		t.Fatalf("期望清除 VP8X 标志位，实际为 %#x", out[20])
+	}
+	if got := binary.LittleEndian.Uint32(out[4:8]); int(got) != len(out)-8 {
+		t.Fatalf("期望 RIFF 长度为 %d，实际为 %d", len(out)-8, got)
+	}
+	if _, _, err := image.Decode(bytes.NewReader(out)); err != nil {
+		t.Fatalf("decode stripped: %v", err)
+	}
+
+	gps, err := Strip(data, StripGPS)
+	if err != nil {
+		t.Fatalf("Strip gps: %v", err)
+	}
+	if !bytes.Contains(gps, []byte("TestMaker")) || bytes.Contains(gps, []byte("GPSLatitude")) {
+		t.Fatalf("期望仅移除位置信息")
+	}
+	if gps[20]&webpFlagEXIF == 0 || gps[20]&webpFlagXMP != 0 {
+		t.Fatalf("期望保留 EXIF 标志并清除 XMP 标志，实际为 %#x", gps[20])
+	}
+}
+
+// 测试内容：验证清理模式解析。
+func TestParseStripMode(t *testing.T) {
+	for input, want := range map[string]StripMode{"all": StripAll, " GPS ": StripGPS, "keep": StripNone} {
+		if got, ok := ParseStripMode(input); !ok || got != want {
+			t.Fatalf("%q: 期望 %q，实际为 %q", input, want, got)
+		}
+	}
+	if _, ok := ParseStripMode("none"); ok {
+		t.Fatalf("期望非法取值返回 false")
+	}
+}
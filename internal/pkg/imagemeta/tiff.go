@@ -0,0 +1,210 @@
+package imagemeta
+
+import (
+	"encoding/binary"
+)
+
+const (
+	tagOrientation = 0x0112
+	tagExifIFD     = 0x8769
+	tagGPSIFD      = 0x8825
+)
+
+// tiffTypeSizes TIFF 字段类型对应的单个值字节数。
+var tiffTypeSizes = map[uint16]int{
+	1: 1, 2: 1, 3: 2, 4: 4, 5: 8, 6: 1, 7: 1, 8: 2, 9: 4, 10: 8, 11: 4, 12: 8,
+}
+
+// tiffReader 读取 EXIF 所使用的 TIFF 结构。
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+type ifdEntry struct {
+	Tag   uint16
+	Type  uint16
+	Count uint32
+	// pos 条目在 data 中的起始偏移
+	pos int
+}
+
+type ifd struct {
+	offset  int
+	entries []ifdEntry
+}
+
+// newTIFFReader 解析 TIFF 头，返回读取器与 IFD0 偏移。
+func newTIFFReader(data []byte) (*tiffReader, uint32, error) {
+	if len(data) < 8 {
+		return nil, 0, ErrMalformed
+	}
+	r := &tiffReader{data: data}
+	switch string(data[0:2]) {
+	case "II":
+		r.order = binary.LittleEndian
+	case "MM":
+		r.order = binary.BigEndian
+	default:
+		return nil, 0, ErrMalformed
+	}
+	if r.order.Uint16(data[2:4]) != 42 {
+		return nil, 0, ErrMalformed
+	}
+	return r, r.order.Uint32(data[4:8]), nil
+}
+
+// readIFD 读取指定偏移处的 IFD 条目列表。
+func (r *tiffReader) readIFD(offset uint32) (*ifd, error) {
+	off := int(offset)
+	if off < 8 || off+2 > len(r.data) {
+		return nil, ErrMalformed
+	}
+	n := int(r.order.Uint16(r.data[off:]))
+	if off+2+n*12 > len(r.data) {
+		return nil, ErrMalformed
+	}
+	res := &ifd{offset: off, entries: make([]ifdEntry, 0, n)}
+	for i := 0; i < n; i++ {
+		pos := off + 2 + i*12
+		res.entries = append(res.entries, ifdEntry{
+			Tag:   r.order.Uint16(r.data[pos:]),
+			Type:  r.order.Uint16(r.data[pos+2:]),
+			Count: r.order.Uint32(r.data[pos+4:]),
+			pos:   pos,
+		})
+	}
+	return res, nil
+}
+
+// find 返回指定标签的条目。
+func (d *ifd) find(tag uint16) (ifdEntry, bool) {
+	for _, e := range d.entries {
+		if e.Tag == tag {
+			return e, true
+		}
+	}
+	return ifdEntry{}, false
+}
+
+// valueRange 返回条目取值在 data 中的区间，以及该取值是否存放在条目外部。
+func (r *tiffReader) valueRange(e ifdEntry) (start int, end int, external bool, ok bool) {
+	size, known := tiffTypeSizes[e.Type]
+	if !known {
+		return 0, 0, false, false
+	}
+	total := uint64(size) * uint64(e.Count)
+	if total <= 4 {
+		return e.pos + 8, e.pos + 8 + int(total), false, true
+	}
+	off := uint64(r.order.Uint32(r.data[e.pos+8:]))
+	if off+total > uint64(len(r.data)) {
+		return 0, 0, false, false
+	}
+	return int(off), int(off + total), true, true
+}
+
+// uintValue 读取 SHORT/LONG 类型条目的第一个值。
+func (r *tiffReader) uintValue(e ifdEntry) (uint32, bool) {
+	start, _, _, ok := r.valueRange(e)
+	if !ok || e.Count == 0 {
+		return 0, false
+	}
+	switch e.Type {
+	case 3:
+		return uint32(r.order.Uint16(r.data[start:])), true
+	case 4:
+		return r.order.Uint32(r.data[start:]), true
+	default:
+		return 0, false
+	}
+}
+
+// tiffOrientation 读取 IFD0 中的方向标签，缺失或非法时返回 0。
+func tiffOrientation(data []byte) int {
+	r, ifd0Off, err := newTIFFReader(data)
+	if err != nil {
+		return 0
+	}
+	ifd0, err := r.readIFD(ifd0Off)
+	if err != nil {
+		return 0
+	}
+	e, ok := ifd0.find(tagOrientation)
+	if !ok {
+		return 0
+	}
+	v, ok := r.uintValue(e)
+	if !ok || v < 1 || v > 8 {
+		return 0
+	}
+	return int(v)
+}
+
+// orientationTIFF 构造仅包含方向标签的最小 TIFF 结构。
+func orientationTIFF(orientation int) []byte {
+	out := []byte{'M', 'M', 0, 42, 0, 0, 0, 8, 0, 1}
+	entry := make([]byte, 12)
+	binary.BigEndian.PutUint16(entry[0:], tagOrientation)
+	binary.BigEndian.PutUint16(entry[2:], 3)
+	binary.BigEndian.PutUint32(entry[4:], 1)
+	binary.BigEndian.PutUint16(entry[8:], uint16(orientation))
+	out = append(out, entry...)
+	return append(out, 0, 0, 0, 0)
+}
+
+// scrubTIFFGPS 返回移除 GPS IFD 后的 TIFF 数据。
+//
+// GPS 子目录及其外部取值被原地清零，IFD0 中的 GPS 指针条目被移除，
+// 其余数据的偏移保持不变，因此不需要重排整个结构。
+func scrubTIFFGPS(data []byte) ([]byte, error) {
+	out := append([]byte(nil), data...)
+	r, ifd0Off, err := newTIFFReader(out)
+	if err != nil {
+		return nil, err
+	}
+	ifd0, err := r.readIFD(ifd0Off)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, e := range ifd0.entries {
+		if e.Tag != tagGPSIFD {
+			continue
+		}
+		if gpsOff, ok := r.uintValue(e); ok {
+			r.zeroIFD(gpsOff)
+		}
+
+		// 将后续条目与 next 指针整体前移一个条目，并清空腾出的 12 字节
+		n := len(ifd0.entries)
+		start := ifd0.offset + 2
+		tailEnd := start + n*12 + 4
+		if tailEnd > len(out) {
+			tailEnd = len(out)
+		}
+		copy(out[start+i*12:], out[start+(i+1)*12:tailEnd])
+		clear(out[tailEnd-12 : tailEnd])
+		r.order.PutUint16(out[ifd0.offset:], uint16(n-1))
+		break
+	}
+	return out, nil
+}
+
+// zeroIFD 清零指定 IFD 的全部条目及其外部取值；结构异常时尽力而为。
+func (r *tiffReader) zeroIFD(offset uint32) {
+	d, err := r.readIFD(offset)
+	if err != nil {
+		return
+	}
+	for _, e := range d.entries {
+		if start, end, external, ok := r.valueRange(e); ok && external {
+			clear(r.data[start:end])
+		}
+	}
+	end := d.offset + 2 + len(d.entries)*12 + 4
+	if end > len(r.data) {
+		end = len(r.data)
+	}
+	clear(r.data[d.offset:end])
+}
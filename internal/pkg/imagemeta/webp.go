@@ -0,0 +1,109 @@
+package imagemeta
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+const (
+	webpFlagXMP  = 0x04
+	webpFlagEXIF = 0x08
+)
+
+// riffChunk 描述一个 WebP（RIFF）数据块。
+type riffChunk struct {
+	fourcc string
+	data   []byte
+	// raw 包含 FourCC、长度、数据与填充字节的完整块
+	raw []byte
+}
+
+// walkWebP 依次遍历 WebP 容器中的数据块。
+func walkWebP(data []byte, chunk func(c riffChunk) error) error {
+	if !isWebP(data) {
+		return ErrMalformed
+	}
+	riffEnd := 8 + int(binary.LittleEndian.Uint32(data[4:8]))
+	if riffEnd > len(data) {
+		return ErrMalformed
+	}
+	pos := 12
+	for pos < riffEnd {
+		if pos+8 > riffEnd {
+			return ErrMalformed
+		}
+		size := int(binary.LittleEndian.Uint32(data[pos+4:]))
+		end := pos + 8 + size + size%2
+		if size < 0 || pos+8+size > riffEnd {
+			return ErrMalformed
+		}
+		if end > riffEnd {
+			end = riffEnd
+		}
+		if err := chunk(riffChunk{fourcc: string(data[pos : pos+4]), data: data[pos+8 : pos+8+size], raw: data[pos:end]}); err != nil {
+			return err
+		}
+		pos = end
+	}
+	return nil
+}
+
+// stripWebP 清理 WebP 中的 EXIF 与 XMP 块，并同步更新 VP8X 标志位。
+func stripWebP(data []byte, mode StripMode) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, data[0:12]...)
+	vp8xFlags := -1
+	var clearFlags byte
+
+	err := walkWebP(data, func(c riffChunk) error {
+		switch c.fourcc {
+		case "VP8X":
+			if len(c.data) > 0 {
+				vp8xFlags = len(out) + 8
+			}
+			out = append(out, c.raw...)
+		case "EXIF":
+			if mode == StripAll {
+				clearFlags |= webpFlagEXIF
+				return nil
+			}
+			// 部分编码器会在 EXIF 块中保留 JPEG 风格的 "Exif\0\0" 前缀
+			prefix := len(c.data) - len(bytes.TrimPrefix(c.data, exifHeader))
+			scrubbed, err := scrubTIFFGPS(c.data[prefix:])
+			if err != nil {
+				clearFlags |= webpFlagEXIF
+				return nil //nolint:nilerr // 无法解析的 EXIF 直接移除
+			}
+			out = appendRIFFChunk(out, c.fourcc, append(append([]byte(nil), c.data[:prefix]...), scrubbed...))
+		case "XMP ":
+			if mode == StripAll || xmpHasGPS(c.data) {
+				clearFlags |= webpFlagXMP
+				return nil
+			}
+			out = append(out, c.raw...)
+		default:
+			out = append(out, c.raw...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if vp8xFlags >= 0 {
+		out[vp8xFlags] &^= clearFlags
+	}
+	binary.LittleEndian.PutUint32(out[4:8], uint32(len(out)-8))
+	return out, nil
+}
+
+// appendRIFFChunk 写入一个数据块，奇数长度时补齐填充字节。
+func appendRIFFChunk(out []byte, fourcc string, data []byte) []byte {
+	out = append(out, fourcc...)
+	out = binary.LittleEndian.AppendUint32(out, uint32(len(data)))
+	out = append(out, data...)
+	if len(data)%2 == 1 {
+		out = append(out, 0)
+	}
+	return out
+}
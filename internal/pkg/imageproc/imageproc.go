@@ -0,0 +1,237 @@
+package imageproc
+
+import (
+	"errors"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+
+	"github.com/HugoSmits86/nativewebp"
+	xdraw "golang.org/x/image/draw"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// FitContain 等比缩放至完全落入目标尺寸内（默认）。
+	FitContain = "contain"
+	// FitCover 等比缩放至完全覆盖目标尺寸，并居中裁剪多余部分。
+	FitCover = "cover"
+	// FitFill 忽略宽高比，拉伸至目标尺寸。
+	FitFill = "fill"
+)
+
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatGIF  = "gif"
+	FormatWebP = "webp"
+	FormatBMP  = "bmp"
+)
+
+// DefaultJPEGQuality 未指定质量时使用的 JPEG 编码质量。
+const DefaultJPEGQuality = 85
+
+// ErrUnsupportedFormat 表示不支持的编码格式。
+var ErrUnsupportedFormat = errors.New("imageproc: unsupported format")
+
+// NormalizeFit 规范化 fit 参数，返回空字符串表示非法取值。
+func NormalizeFit(fit string) string {
+	switch strings.ToLower(strings.TrimSpace(fit)) {
+	case "", FitContain:
+		return FitContain
+	case FitCover:
+		return FitCover
+	case FitFill:
+		return FitFill
+	default:
+		return ""
+	}
+}
+
+// NormalizeFormat 规范化格式名（支持 jpg/jpeg、png、gif、webp、bmp），非法取值返回空字符串。
+func NormalizeFormat(format string) string {
+	switch strings.ToLower(strings.TrimPrefix(strings.TrimSpace(format), ".")) {
+	case "jpg", "jpeg":
+		return FormatJPEG
+	case "png":
+		return FormatPNG
+	case "gif":
+		return FormatGIF
+	case "webp":
+		return FormatWebP
+	case "bmp":
+		return FormatBMP
+	default:
+		return ""
+	}
+}
+
+// CanEncode 判断格式是否可以作为输出格式。
+func CanEncode(format string) bool {
+	switch format {
+	case FormatJPEG, FormatPNG, FormatWebP:
+		return true
+	default:
+		return false
+	}
+}
+
+// Ext 返回输出格式对应的文件扩展名。
+func Ext(format string) string {
+	if format == FormatJPEG {
+		return ".jpg"
+	}
+	return "." + format
+}
+
+// ContentType 返回输出格式对应的 MIME 类型。
+func ContentType(format string) string {
+	return "image/" + format
+}
+
+// DecodeConfig 读取图片尺寸与格式，不解码像素数据。
+func DecodeConfig(r io.Reader) (image.Config, string, error) {
+	return image.DecodeConfig(r)
+}
+
+// Decode 解码图片（仅取第一帧）。
+func Decode(r io.Reader) (image.Image, string, error) {
+	return image.Decode(r)
+}
+
+// TargetSize 计算缩放后的目标尺寸，永远不会放大原图。
+//
+// width/height 为 0 表示该方向按比例自适应；两者均为 0 时返回原尺寸。
+func TargetSize(srcW, srcH, width, height int, fit string) (int, int) {
+	if srcW <= 0 || srcH <= 0 || (width <= 0 && height <= 0) {
+		return srcW, srcH
+	}
+	if width > 0 && height > 0 {
+		if fit == FitCover || fit == FitFill {
+			// 目标框超出原图时按框的宽高比整体缩小，保证输出比例与请求一致。
+			return scaled(width, height, min(1, float64(srcW)/float64(width), float64(srcH)/float64(height)))
+		}
+		return scaled(srcW, srcH, min(float64(width)/float64(srcW), float64(height)/float64(srcH)))
+	}
+	if width > 0 {
+		return scaled(srcW, srcH, float64(width)/float64(srcW))
+	}
+	return scaled(srcW, srcH, float64(height)/float64(srcH))
+}
+
+// Resize 按 fit 规则将图片缩放到 width x height。
+func Resize(src image.Image, width, height int, fit string) image.Image {
+	b := src.Bounds()
+	dstW, dstH := TargetSize(b.Dx(), b.Dy(), width, height, fit)
+	if dstW == b.Dx() && dstH == b.Dy() {
+		return src
+	}
+
+	srcRect := b
+	if fit == FitCover && width > 0 && height > 0 {
+		srcRect = coverCrop(b, dstW, dstH)
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dstW, dstH))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), src, srcRect, draw.Src, nil)
+	return dst
+}
+
+// Encode 将图片按指定格式编码写出。quality 仅对 JPEG 生效，<=0 时使用默认质量；WebP 始终使用无损编码。
+func Encode(w io.Writer, img image.Image, format string, quality int) error {
+	switch format {
+	case FormatJPEG:
+		if quality <= 0 || quality > 100 {
+			quality = DefaultJPEGQuality
+		}
+		return jpeg.Encode(w, flatten(img), &jpeg.Options{Quality: quality})
+	case FormatPNG:
+		return png.Encode(w, img)
+	case FormatGIF:
+		return gif.Encode(w, img, nil)
+	case FormatWebP:
+		return nativewebp.Encode(w, img, nil)
+	default:
+		return ErrUnsupportedFormat
+	}
+}
+
+// coverCrop 计算 cover 模式下需要从原图中截取的居中区域。
+func coverCrop(b image.Rectangle, dstW, dstH int) image.Rectangle {
+	srcW, srcH := b.Dx(), b.Dy()
+	if srcW*dstH > srcH*dstW {
+		cropW := srcH * dstW / dstH
+		x0 := b.Min.X + (srcW-cropW)/2
+		return image.Rect(x0, b.Min.Y, x0+cropW, b.Max.Y)
+	}
+	cropH := srcW * dstH / dstW
+	y0 := b.Min.Y + (srcH-cropH)/2
+	return image.Rect(b.Min.X, y0, b.Max.X, y0+cropH)
+}
+
+// flatten 将带透明通道的图片铺到白底上，避免 JPEG 编码后透明区域变黑。
+func flatten(img image.Image) image.Image {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		return img
+	}
+	b := img.Bounds()
+	dst := image.NewRGBA(b)
+	draw.Draw(dst, b, image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, b, img, b.Min, draw.Over)
+	return dst
+}
+
+func scaled(w, h int, scale float64) (int, int) {
+	if scale >= 1 {
+		return w, h
+	}
+	return max(1, int(float64(w)*scale+0.5)), max(1, int(float64(h)*scale+0.5))
+}
+
+// ApplyOrientation 按 EXIF 方向标签（1-8）旋转/翻转像素，返回可直接正向显示的图片。
+//
+// 方向为 0、1 或非法取值时原样返回；5-8 会交换宽高。
+func ApplyOrientation(img image.Image, orientation int) image.Image {
+	if orientation < 2 || orientation > 8 {
+		return img
+	}
+	b := img.Bounds()
+	src := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+
+	w, h := b.Dx(), b.Dy()
+	dstW, dstH := w, h
+	if orientation >= 5 {
+		dstW, dstH = h, w
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, dstW, dstH))
+	for y := 0; y < dstH; y++ {
+		for x := 0; x < dstW; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2:
+				sx, sy = w-1-x, y
+			case 3:
+				sx, sy = w-1-x, h-1-y
+			case 4:
+				sx, sy = x, h-1-y
+			case 5:
+				sx, sy = y, x
+			case 6:
+				sx, sy = y, h-1-x
+			case 7:
+				sx, sy = w-1-y, h-1-x
+			case 8:
+				sx, sy = w-1-y, x
+			}
+			copy(dst.Pix[dst.PixOffset(x, y):dst.PixOffset(x, y)+4], src.Pix[src.PixOffset(sx, sy):src.PixOffset(sx, sy)+4])
+		}
+	}
+	return dst
+}
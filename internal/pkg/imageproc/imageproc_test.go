@@ -0,0 +1,127 @@
+package imageproc
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func newTestImage(w, h int) image.Image {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+// 测试内容：验证各 fit 模式下的目标尺寸计算，且不会放大原图。
+func TestTargetSize(t *testing.T) {
+	cases := []struct {
+		name         string
+		srcW, srcH   int
+		w, h         int
+		fit          string
+		wantW, wantH int
+	}{
+		{"仅宽度", 400, 200, 100, 0, FitContain, 100, 50},
+		{"仅高度", 400, 200, 0, 50, FitContain, 100, 50},
+		{"contain", 400, 200, 100, 100, FitContain, 100, 50},
+		{"cover", 400, 200, 100, 100, FitCover, 100, 100},
+		{"fill", 400, 200, 100, 80, FitFill, 100, 80},
+		{"不放大", 40, 20, 100, 0, FitContain, 40, 20},
+		{"cover 超出原图保持比例", 40, 20, 100, 100, FitCover, 20, 20},
+		{"无尺寸", 40, 20, 0, 0, FitContain, 40, 20},
+	}
+	for _, tc := range cases {
+		gotW, gotH := TargetSize(tc.srcW, tc.srcH, tc.w, tc.h, tc.fit)
+		if gotW != tc.wantW || gotH != tc.wantH {
+			t.Fatalf("%s: 期望 %dx%d，实际为 %dx%d", tc.name, tc.wantW, tc.wantH, gotW, gotH)
+		}
+	}
+}
+
+// 测试内容：验证 Resize 输出尺寸符合 fit 规则。
+func TestResize(t *testing.T) {
+	src := newTestImage(200, 100)
+
+	if b := Resize(src, 64, 64, FitCover).Bounds(); b.Dx() != 64 || b.Dy() != 64 {
+		t.Fatalf("cover 期望 64x64，实际为 %dx%d", b.Dx(), b.Dy())
+	}
+	if b := Resize(src, 64, 64, FitContain).Bounds(); b.Dx() != 64 || b.Dy() != 32 {
+		t.Fatalf("contain 期望 64x32，实际为 %dx%d", b.Dx(), b.Dy())
+	}
+	if got := Resize(src, 400, 0, FitContain); got != src {
+		t.Fatalf("期望目标尺寸不小于原图时直接返回原图")
+	}
+}
+
+// 测试内容：验证各输出格式编码后可被重新解码。
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	src := newTestImage(16, 8)
+	for _, format := range []string{FormatJPEG, FormatPNG, FormatWebP} {
+		var buf bytes.Buffer
+		if err := Encode(&buf, src, format, 0); err != nil {
+			t.Fatalf("%s 编码失败: %v", format, err)
+		}
+		img, decodedFormat, err := Decode(bytes.NewReader(buf.Bytes()))
+		if err != nil {
+			t.Fatalf("%s 解码失败: %v", format, err)
+		}
+		if decodedFormat != format {
+			t.Fatalf("期望格式 %s，实际为 %s", format, decodedFormat)
+		}
+		if b := img.Bounds(); b.Dx() != 16 || b.Dy() != 8 {
+			t.Fatalf("%s 期望 16x8，实际为 %dx%d", format, b.Dx(), b.Dy())
+		}
+	}
+
+	if err := Encode(&bytes.Buffer{}, src, FormatBMP, 0); err != ErrUnsupportedFormat {
+		t.Fatalf("期望 BMP 输出返回 ErrUnsupportedFormat，实际为 %v", err)
+	}
+}
+
+// 测试内容：验证 fit 与格式参数的规范化。
+func TestNormalizeFitAndFormat(t *testing.T) {
+	if NormalizeFit("") != FitContain || NormalizeFit("COVER") != FitCover || NormalizeFit("bad") != "" {
+		t.Fatalf("NormalizeFit 结果不符合预期")
+	}
+	if NormalizeFormat(".JPG") != FormatJPEG || NormalizeFormat("webp") != FormatWebP || NormalizeFormat("tiff") != "" {
+		t.Fatalf("NormalizeFormat 结果不符合预期")
+	}
+}
+
+// 测试内容：验证按 EXIF 方向旋转/翻转后的尺寸与像素位置。
+func TestApplyOrientation(t *testing.T) {
+	// 3x2 图片，左上角为红色
+	src := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	red := color.NRGBA{R: 255, A: 255}
+	src.Set(0, 0, red)
+
+	cases := []struct {
+		orientation  int
+		wantW, wantH int
+		redX, redY   int
+	}{
+		{1, 3, 2, 0, 0},
+		{2, 3, 2, 2, 0},
+		{3, 3, 2, 2, 1},
+		{4, 3, 2, 0, 1},
+		{5, 2, 3, 0, 0},
+		{6, 2, 3, 1, 0},
+		{7, 2, 3, 1, 2},
+		{8, 2, 3, 0, 2},
+	}
+	for _, tc := range cases {
+		got := ApplyOrientation(src, tc.orientation)
+		b := got.Bounds()
+		if b.Dx() != tc.wantW || b.Dy() != tc.wantH {
+			t.Fatalf("orientation %d: 期望 %dx%d，实际为 %dx%d", tc.orientation, tc.wantW, tc.wantH, b.Dx(), b.Dy())
+		}
+		if c := color.NRGBAModel.Convert(got.At(tc.redX, tc.redY)).(color.NRGBA); c != red {
+			t.Fatalf("orientation %d: 期望 (%d,%d) 为红色，实际为 %v", tc.orientation, tc.redX, tc.redY, c)
+		}
+	}
+}
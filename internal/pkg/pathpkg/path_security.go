@@ -1,6 +1,7 @@
 package pathpkg
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -8,6 +9,9 @@ import (
 	"strings"
 )
 
+// ErrSymlink 表示路径链路中存在符号链接，调用方可据此与其它 IO 错误区分。
+var ErrSymlink = errors.New("检测到符号链接穿透风险")
+
 // SecureJoin 将相对路径安全拼接到 basePath 下。
 //
 // 说明：
@@ -74,7 +78,7 @@ func EnsurePathNotSymlink(path string) error {
 	}
 
 	if info.Mode()&os.ModeSymlink != 0 {
-		return fmt.Errorf("检测到符号链接穿透风险: %s", absPath)
+		return fmt.Errorf("%w: %s", ErrSymlink, absPath)
 	}
 
 	return nil
@@ -111,7 +115,7 @@ func EnsureNoSymlinkBetween(basePath, targetPath string) error {
 		info, statErr := os.Lstat(current)
 		if statErr == nil {
 			if info.Mode()&os.ModeSymlink != 0 {
-				return fmt.Errorf("检测到符号链接穿透风险: %s", current)
+				return fmt.Errorf("%w: %s", ErrSymlink, current)
 			}
 		} else if !os.IsNotExist(statErr) {
 			return fmt.Errorf("检查路径失败: %w", statErr)
@@ -0,0 +1,201 @@
+package remotefetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultTimeout      = 15 * time.Second
+	defaultMaxRedirects = 5
+	userAgent           = "PerfectPic-RemoteFetch/1.0"
+)
+
+var (
+	// ErrInvalidURL 地址格式非法或协议不是 http/https。
+	ErrInvalidURL = errors.New("remotefetch: invalid url")
+	// ErrBlockedAddress 目标解析到内网、回环等受限地址。
+	ErrBlockedAddress = errors.New("remotefetch: blocked address")
+	// ErrTooLarge 响应体超过大小上限。
+	ErrTooLarge = errors.New("remotefetch: response too large")
+	// ErrTooManyRedirects 重定向次数超过上限。
+	ErrTooManyRedirects = errors.New("remotefetch: too many redirects")
+	// ErrBadStatus 远端返回非 2xx 状态码。
+	ErrBadStatus = errors.New("remotefetch: unexpected status")
+)
+
+// blockedPrefixes 除 netip 已能识别的回环、私有、链路本地、组播与未指定地址外，额外禁止的地址段。
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+	netip.MustParsePrefix("2002::/16"),
+}
+
+type Config struct {
+	// Timeout 单次抓取（含重定向与读取响应体）的总超时，<=0 时使用默认值
+	Timeout time.Duration
+	// MaxRedirects 允许跟随的最大重定向次数，<=0 时使用默认值
+	MaxRedirects int
+	// AllowPrivateNetworks 允许访问内网与回环地址（仅用于受信任的内网部署）
+	AllowPrivateNetworks bool
+}
+
+// Fetcher 抓取远程资源并防御 SSRF：仅允许 http/https，在建立连接时校验实际解析出的 IP（避免 DNS 重绑定），
+// 每次重定向都重新校验地址，不使用环境变量中的代理，并在流式读取时限制响应体大小。
+type Fetcher struct {
+	client       *http.Client
+	maxRedirects int
+	allowPrivate bool
+}
+
+// Result 抓取结果。
+type Result struct {
+	Data        []byte
+	ContentType string
+	// FinalURL 跟随重定向后的最终地址
+	FinalURL *url.URL
+}
+
+func NewFetcher(config *Config) *Fetcher {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	maxRedirects := config.MaxRedirects
+	if maxRedirects <= 0 {
+		maxRedirects = defaultMaxRedirects
+	}
+
+	f := &Fetcher{maxRedirects: maxRedirects, allowPrivate: config.AllowPrivateNetworks}
+	dialer := &net.Dialer{Timeout: timeout, Control: f.checkDial}
+	transport := &http.Transport{
+		// 不使用代理：经代理连接时无法校验目标的真实地址
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   timeout,
+		ResponseHeaderTimeout: timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+	f.client = &http.Client{
+		Transport:     transport,
+		Timeout:       timeout,
+		CheckRedirect: f.checkRedirect,
+	}
+	return f
+}
+
+// Fetch 以 GET 抓取 rawURL，响应体超过 maxBytes 时返回 ErrTooLarge。
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string, maxBytes int64) (*Result, error) {
+	u, err := f.validateURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, ErrInvalidURL
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "image/*")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		// 保留底层的哨兵错误，便于调用方区分
+		for _, sentinel := range []error{ErrBlockedAddress, ErrInvalidURL, ErrTooManyRedirects} {
+			if errors.Is(err, sentinel) {
+				return nil, sentinel
+			}
+		}
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%w: %d", ErrBadStatus, resp.StatusCode)
+	}
+	if maxBytes > 0 && resp.ContentLength > maxBytes {
+		return nil, ErrTooLarge
+	}
+
+	reader := io.Reader(resp.Body)
+	if maxBytes > 0 {
+		reader = io.LimitReader(resp.Body, maxBytes+1)
+	}
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if maxBytes > 0 && int64(len(data)) > maxBytes {
+		return nil, ErrTooLarge
+	}
+	return &Result{Data: data, ContentType: resp.Header.Get("Content-Type"), FinalURL: resp.Request.URL}, nil
+}
+
+// validateURL 校验协议与主机，并提前拒绝字面量形式的受限 IP。
+func (f *Fetcher) validateURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" || u.User != nil {
+		return nil, ErrInvalidURL
+	}
+	if addr, err := netip.ParseAddr(u.Hostname()); err == nil && !f.allowedAddr(addr) {
+		return nil, ErrBlockedAddress
+	}
+	return u, nil
+}
+
+func (f *Fetcher) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) > f.maxRedirects {
+		return ErrTooManyRedirects
+	}
+	_, err := f.validateURL(req.URL.String())
+	return err
+}
+
+// checkDial 在建立 TCP 连接前校验实际连接的 IP，域名解析结果与重定向目标均在此处兜底。
+func (f *Fetcher) checkDial(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return ErrBlockedAddress
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || !f.allowedAddr(addr) {
+		return ErrBlockedAddress
+	}
+	return nil
+}
+
+func (f *Fetcher) allowedAddr(addr netip.Addr) bool {
+	if f.allowPrivate {
+		return true
+	}
+	return IsPublicAddr(addr)
+}
+
+// IsPublicAddr 判断地址是否为可公开访问的单播地址。
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap().WithZone("")
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
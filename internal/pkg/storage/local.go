@@ -0,0 +1,209 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"perfect-pic-server/internal/pkg/pathpkg"
+)
+
+// LocalStorage 基于本地磁盘的存储实现。
+//
+// 根目录在每次操作时重新解析为绝对路径，并执行与原静态目录一致的符号链接防护。
+type LocalStorage struct {
+	root      string
+	urlPrefix string
+}
+
+func NewLocalStorage(root string, urlPrefix string) *LocalStorage {
+	return &LocalStorage{root: root, urlPrefix: urlPrefix}
+}
+
+// Root 返回本地存储根目录（未解析的原始配置值）。
+func (s *LocalStorage) Root() string {
+	return s.root
+}
+
+func (s *LocalStorage) Put(key string, r io.Reader, _ int64, _ string) error {
+	rootAbs, fullPath, err := s.resolve(key)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(fullPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("无法创建存储目录: %w", err)
+	}
+	if err := pathpkg.EnsureNoSymlinkBetween(rootAbs, dir); err != nil {
+		return err
+	}
+
+	// 先写入同目录下的临时文件再重命名，避免并发读取到写了一半的文件。
+	out, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("无法创建文件: %w", err)
+	}
+	tmpPath := out.Name()
+	if _, err := io.Copy(out, r); err != nil {
+		_ = out.Close()
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("文件保存失败: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("文件保存失败: %w", err)
+	}
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("文件保存失败: %w", err)
+	}
+	if err := os.Rename(tmpPath, fullPath); err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("文件保存失败: %w", err)
+	}
+	return nil
+}
+
+func (s *LocalStorage) Get(key string) (io.ReadCloser, *ObjectInfo, error) {
+	_, fullPath, err := s.resolve(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	f, err := os.Open(fullPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, ErrNotExist
+		}
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, nil, err
+	}
+	if info.IsDir() {
+		_ = f.Close()
+		return nil, nil, ErrNotExist
+	}
+	return f, localObjectInfo(key, info), nil
+}
+
+func (s *LocalStorage) Delete(key string) error {
+	_, fullPath, err := s.resolve(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalStorage) DeletePrefix(prefix string) error {
+	rootAbs, fullPath, err := s.resolve(prefix)
+	if err != nil {
+		return err
+	}
+	// 在执行 RemoveAll 前再做一次链路检查，确保目标目录链路未被并发替换为符号链接。
+	if err := pathpkg.EnsureNoSymlinkBetween(rootAbs, fullPath); err != nil {
+		return err
+	}
+	return os.RemoveAll(fullPath)
+}
+
+func (s *LocalStorage) Stat(key string) (*ObjectInfo, error) {
+	_, fullPath, err := s.resolve(key)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotExist
+		}
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, ErrNotExist
+	}
+	return localObjectInfo(key, info), nil
+}
+
+// Walk 遍历根目录下的全部文件，不跟随符号链接；根目录不存在时视为空。
+func (s *LocalStorage) Walk(fn func(ObjectInfo) error) error {
+	rootAbs, err := filepath.Abs(s.root)
+	if err != nil {
+		return fmt.Errorf("存储目录解析失败: %w", err)
+	}
+	if _, err := os.Lstat(rootAbs); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err := pathpkg.EnsurePathNotSymlink(rootAbs); err != nil {
+		return err
+	}
+	return filepath.WalkDir(rootAbs, func(fullPath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		rel, err := filepath.Rel(rootAbs, fullPath)
+		if err != nil {
+			return err
+		}
+		return fn(*localObjectInfo(filepath.ToSlash(rel), info))
+	})
+}
+
+func (s *LocalStorage) URL(key string) string {
+	return joinURL(s.urlPrefix, key)
+}
+
+// ProxyURL 本地存储始终由服务端提供，与 URL 相同。
+func (s *LocalStorage) ProxyURL(key string) string {
+	return s.URL(key)
+}
+
+// resolve 解析根目录与 key 对应的物理路径，并完成符号链接与越界校验。
+func (s *LocalStorage) resolve(key string) (string, string, error) {
+	cleanKey, err := CleanKey(key)
+	if err != nil {
+		return "", "", err
+	}
+	rootAbs, err := filepath.Abs(s.root)
+	if err != nil {
+		return "", "", fmt.Errorf("存储目录解析失败: %w", err)
+	}
+	// 先校验根目录节点本身，避免根目录被替换为符号链接。
+	if err := pathpkg.EnsurePathNotSymlink(rootAbs); err != nil {
+		return "", "", err
+	}
+	fullPath, err := pathpkg.SecureJoin(rootAbs, filepath.FromSlash(cleanKey))
+	if err != nil {
+		return "", "", err
+	}
+	return rootAbs, fullPath, nil
+}
+
+func localObjectInfo(key string, info os.FileInfo) *ObjectInfo {
+	return &ObjectInfo{
+		Key:          key,
+		Size:         info.Size(),
+		ContentType:  mime.TypeByExtension(path.Ext(key)),
+		LastModified: info.ModTime(),
+	}
+}
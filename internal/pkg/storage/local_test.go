@@ -0,0 +1,122 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// 测试内容：验证本地存储的写入、读取、元信息与删除流程。
+func TestLocalStorage_PutGetStatDelete(t *testing.T) {
+	root := t.TempDir()
+	s := NewLocalStorage(root, "/imgs/")
+
+	if err := s.Put("2026/01/02/a.png", strings.NewReader("hello"), 5, "image/png"); err != nil {
+		t.Fatalf("Put 失败: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "2026", "01", "02", "a.png")); err != nil {
+		t.Fatalf("期望文件已落盘: %v", err)
+	}
+
+	rc, info, err := s.Get("2026/01/02/a.png")
+	if err != nil {
+		t.Fatalf("Get 失败: %v", err)
+	}
+	data, _ := io.ReadAll(rc)
+	_ = rc.Close()
+	if string(data) != "hello" || info.Size != 5 || info.ContentType != "image/png" {
+		t.Fatalf("读取结果不符合预期: data=%q info=%+v", data, info)
+	}
+
+	if _, err := s.Stat("2026/01/02/a.png"); err != nil {
+		t.Fatalf("Stat 失败: %v", err)
+	}
+	if err := s.Delete("2026/01/02/a.png"); err != nil {
+		t.Fatalf("Delete 失败: %v", err)
+	}
+	if _, err := s.Stat("2026/01/02/a.png"); !errors.Is(err, ErrNotExist) {
+		t.Fatalf("期望删除后返回 ErrNotExist，实际为 %v", err)
+	}
+	if err := s.Delete("2026/01/02/a.png"); err != nil {
+		t.Fatalf("期望删除不存在的文件静默成功，实际为 %v", err)
+	}
+}
+
+// 测试内容：验证本地存储拒绝越界 key。
+func TestLocalStorage_RejectsTraversal(t *testing.T) {
+	s := NewLocalStorage(t.TempDir(), "/imgs/")
+
+	if err := s.Put("../escape.png", strings.NewReader("x"), 1, ""); err == nil {
+		t.Fatalf("期望越界 key 被拒绝")
+	}
+	if err := s.Put("/abs.png", strings.NewReader("x"), 1, ""); err == nil {
+		t.Fatalf("期望绝对路径 key 被拒绝")
+	}
+}
+
+// 测试内容：验证 DeletePrefix 删除整个子目录。
+func TestLocalStorage_DeletePrefix(t *testing.T) {
+	root := t.TempDir()
+	s := NewLocalStorage(root, "/avatars/")
+
+	_ = s.Put("7/a.png", strings.NewReader("a"), 1, "")
+	_ = s.Put("7/b.png", strings.NewReader("b"), 1, "")
+	_ = s.Put("8/c.png", strings.NewReader("c"), 1, "")
+
+	if err := s.DeletePrefix("7"); err != nil {
+		t.Fatalf("DeletePrefix 失败: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "7")); !os.IsNotExist(err) {
+		t.Fatalf("期望目录 7 已被删除")
+	}
+	if _, err := s.Stat("8/c.png"); err != nil {
+		t.Fatalf("期望其他用户目录不受影响: %v", err)
+	}
+}
+
+// 测试内容：验证 Walk 返回全部文件的相对 key 与大小，跳过符号链接，根目录不存在时视为空。
+func TestLocalStorage_Walk(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "imgs")
+	s := NewLocalStorage(root, "/imgs/")
+	if err := s.Walk(func(ObjectInfo) error { return errors.New("unexpected") }); err != nil {
+		t.Fatalf("期望根目录不存在时不返回错误，实际为 %v", err)
+	}
+
+	_ = s.Put("2026/01/a.png", strings.NewReader("abc"), 3, "")
+	_ = s.Put("2026/01/a.png.variants/alternate.webp", strings.NewReader("a"), 1, "")
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	_ = os.WriteFile(outside, []byte("x"), 0644)
+	if err := os.Symlink(outside, filepath.Join(root, "link.png")); err != nil {
+		t.Skipf("当前环境不支持符号链接: %v", err)
+	}
+
+	got := map[string]int64{}
+	if err := s.Walk(func(info ObjectInfo) error {
+		got[info.Key] = info.Size
+		return nil
+	}); err != nil {
+		t.Fatalf("Walk 失败: %v", err)
+	}
+	if len(got) != 2 || got["2026/01/a.png"] != 3 || got["2026/01/a.png.variants/alternate.webp"] != 1 {
+		t.Fatalf("遍历结果不符合预期: %v", got)
+	}
+
+	stop := errors.New("stop")
+	if err := s.Walk(func(ObjectInfo) error { return stop }); !errors.Is(err, stop) {
+		t.Fatalf("期望返回回调的错误，实际为 %v", err)
+	}
+}
+
+// 测试内容：验证 URL 拼接前缀时只保留一个分隔符。
+func TestLocalStorage_URL(t *testing.T) {
+	s := NewLocalStorage(t.TempDir(), "/imgs/")
+	if got := s.URL("2026/01/02/a.png"); got != "/imgs/2026/01/02/a.png" {
+		t.Fatalf("期望 /imgs/2026/01/02/a.png，实际为 %q", got)
+	}
+	if got := s.URL(""); got != "/imgs/" {
+		t.Fatalf("期望前缀 /imgs/，实际为 %q", got)
+	}
+}
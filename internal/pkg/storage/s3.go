@@ -0,0 +1,356 @@
+package storage
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3Storage 基于 S3 兼容对象存储的实现。
+//
+// 同一个 bucket 内通过 namespace（如 imgs、avatars）区分不同用途的对象。
+type S3Storage struct {
+	client    *s3Client
+	namespace string
+	// proxyPrefix 未配置 publicURL 时使用的服务端代理访问前缀。
+	proxyPrefix string
+	// publicURL 对象的公开访问地址，为空时经由服务端代理访问。
+	publicURL string
+}
+
+func newS3Storage(client *s3Client, namespace string, proxyPrefix string, publicURL string) *S3Storage {
+	return &S3Storage{client: client, namespace: strings.Trim(namespace, "/"), proxyPrefix: proxyPrefix, publicURL: publicURL}
+}
+
+func (s *S3Storage) Put(key string, r io.Reader, size int64, contentType string) error {
+	objectKey, err := s.objectKey(key)
+	if err != nil {
+		return err
+	}
+	header := http.Header{}
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	resp, err := s.client.do(http.MethodPut, objectKey, nil, header, r, size)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return s3ResponseError("put", resp)
+	}
+	return nil
+}
+
+func (s *S3Storage) Get(key string) (io.ReadCloser, *ObjectInfo, error) {
+	objectKey, err := s.objectKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := s.client.do(http.MethodGet, objectKey, nil, nil, nil, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		_ = resp.Body.Close()
+		return nil, nil, ErrNotExist
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer func() { _ = resp.Body.Close() }()
+		return nil, nil, s3ResponseError("get", resp)
+	}
+	return resp.Body, s3ObjectInfo(key, resp), nil
+}
+
+func (s *S3Storage) Delete(key string) error {
+	objectKey, err := s.objectKey(key)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.do(http.MethodDelete, objectKey, nil, nil, nil, 0)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+		return s3ResponseError("delete", resp)
+	}
+	return nil
+}
+
+func (s *S3Storage) DeletePrefix(prefix string) error {
+	cleanPrefix, err := CleanKey(prefix)
+	if err != nil {
+		return err
+	}
+	keys, err := s.client.listKeys(s.namespace + "/" + cleanPrefix + "/")
+	if err != nil {
+		return err
+	}
+	for _, objectKey := range keys {
+		resp, err := s.client.do(http.MethodDelete, objectKey, nil, nil, nil, 0)
+		if err != nil {
+			return err
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNotFound {
+			return fmt.Errorf("s3 delete %s status code: %d", objectKey, resp.StatusCode)
+		}
+	}
+	return nil
+}
+
+func (s *S3Storage) Stat(key string) (*ObjectInfo, error) {
+	objectKey, err := s.objectKey(key)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.do(http.MethodHead, objectKey, nil, nil, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotExist
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("s3 head status code: %d", resp.StatusCode)
+	}
+	return s3ObjectInfo(key, resp), nil
+}
+
+// Walk 列出命名空间下的全部对象。
+func (s *S3Storage) Walk(fn func(ObjectInfo) error) error {
+	prefix := s.namespace + "/"
+	return s.client.listObjects(prefix, func(info ObjectInfo) error {
+		info.Key = strings.TrimPrefix(info.Key, prefix)
+		if info.Key == "" {
+			return nil
+		}
+		info.ContentType = mime.TypeByExtension(path.Ext(info.Key))
+		return fn(info)
+	})
+}
+
+func (s *S3Storage) URL(key string) string {
+	if s.publicURL == "" {
+		return joinURL(s.proxyPrefix, key)
+	}
+	base := joinURL(s.publicURL, s.client.fullKey(s.namespace))
+	return joinURL(base, key)
+}
+
+func (s *S3Storage) ProxyURL(key string) string {
+	return joinURL(s.proxyPrefix, key)
+}
+
+func (s *S3Storage) objectKey(key string) (string, error) {
+	cleanKey, err := CleanKey(key)
+	if err != nil {
+		return "", err
+	}
+	return s.namespace + "/" + cleanKey, nil
+}
+
+func s3ObjectInfo(key string, resp *http.Response) *ObjectInfo {
+	info := &ObjectInfo{
+		Key:         key,
+		Size:        resp.ContentLength,
+		ContentType: resp.Header.Get("Content-Type"),
+	}
+	if lm, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		info.LastModified = lm
+	}
+	return info
+}
+
+func s3ResponseError(op string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	return fmt.Errorf("s3 %s status code: %d, body: %s", op, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// s3Client 最小化的 S3 REST 客户端，仅实现存储层需要的对象操作。
+type s3Client struct {
+	cfg        S3Config
+	endpoint   *url.URL
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+func newS3Client(cfg S3Config) (*s3Client, error) {
+	if strings.TrimSpace(cfg.Endpoint) == "" {
+		return nil, fmt.Errorf("S3 存储缺少 endpoint 配置")
+	}
+	if strings.TrimSpace(cfg.Bucket) == "" {
+		return nil, fmt.Errorf("S3 存储缺少 bucket 配置")
+	}
+	endpoint := cfg.Endpoint
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("S3 endpoint 无效: %w", err)
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	cfg.Prefix = strings.Trim(cfg.Prefix, "/")
+	return &s3Client{
+		cfg:        cfg,
+		endpoint:   u,
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+		now:        time.Now,
+	}, nil
+}
+
+// fullKey 为对象 key 加上全局前缀。
+func (c *s3Client) fullKey(key string) string {
+	if c.cfg.Prefix == "" {
+		return key
+	}
+	return c.cfg.Prefix + "/" + key
+}
+
+// objectURL 构造对象请求地址（支持 path-style 与 virtual-hosted-style）。
+func (c *s3Client) objectURL(objectKey string, query url.Values) *url.URL {
+	u := *c.endpoint
+	escapedKey := escapeS3Path(objectKey)
+	basePath := strings.TrimRight(u.Path, "/")
+	if c.cfg.UsePathStyle {
+		u.Path = basePath + "/" + c.cfg.Bucket
+		if objectKey != "" {
+			u.Path += "/" + objectKey
+		}
+		u.RawPath = basePath + "/" + escapeS3Path(c.cfg.Bucket)
+		if objectKey != "" {
+			u.RawPath += "/" + escapedKey
+		}
+	} else {
+		u.Host = c.cfg.Bucket + "." + u.Host
+		u.Path = basePath + "/" + objectKey
+		u.RawPath = basePath + "/" + escapedKey
+	}
+	if query != nil {
+		u.RawQuery = canonicalQueryString(query)
+	}
+	return &u
+}
+
+func (c *s3Client) do(method, objectKey string, query url.Values, header http.Header, body io.Reader, size int64) (*http.Response, error) {
+	fullKey := objectKey
+	if objectKey != "" {
+		fullKey = c.fullKey(objectKey)
+	}
+	u := c.objectURL(fullKey, query)
+
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, values := range header {
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+	if body != nil && size >= 0 {
+		req.ContentLength = size
+	}
+	signS3Request(req, c.cfg.AccessKey, c.cfg.SecretKey, c.cfg.Region, c.now())
+	return c.httpClient.Do(req)
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// listKeys 使用 ListObjectsV2 列出前缀下的全部对象 key（不含全局前缀）。
+func (c *s3Client) listKeys(prefix string) ([]string, error) {
+	var keys []string
+	err := c.listObjects(prefix, func(info ObjectInfo) error {
+		keys = append(keys, info.Key)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// listObjects 使用 ListObjectsV2 逐页列出前缀下的对象，Key 不含全局前缀。
+func (c *s3Client) listObjects(prefix string, fn func(ObjectInfo) error) error {
+	token := ""
+	fullPrefix := c.fullKey(prefix)
+	for {
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", fullPrefix)
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := c.do(http.MethodGet, "", query, nil, nil, 0)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			err := s3ResponseError("list", resp)
+			_ = resp.Body.Close()
+			return err
+		}
+		var result listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		_ = resp.Body.Close()
+		if err != nil {
+			return err
+		}
+		for _, item := range result.Contents {
+			key := item.Key
+			if c.cfg.Prefix != "" {
+				key = strings.TrimPrefix(key, c.cfg.Prefix+"/")
+			}
+			if err := fn(ObjectInfo{Key: key, Size: item.Size, LastModified: item.LastModified}); err != nil {
+				return err
+			}
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// escapeS3Path 按 S3 规则对路径逐段编码，保留 "/"。
+func escapeS3Path(p string) string {
+	segments := strings.Split(p, "/")
+	for i, seg := range segments {
+		segments[i] = uriEncode(seg)
+	}
+	return strings.Join(segments, "/")
+}
+
+func uriEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if (ch >= 'A' && ch <= 'Z') || (ch >= 'a' && ch <= 'z') || (ch >= '0' && ch <= '9') ||
+			ch == '-' || ch == '_' || ch == '.' || ch == '~' {
+			b.WriteByte(ch)
+			continue
+		}
+		b.WriteString("%" + strings.ToUpper(strconv.FormatInt(int64(ch)|0x100, 16)[1:]))
+	}
+	return b.String()
+}
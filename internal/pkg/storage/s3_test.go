@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"perfect-pic-server/internal/testutils"
+)
+
+func newTestS3Manager(t *testing.T, fake *testutils.FakeS3, publicURL string) *Manager {
+	t.Helper()
+	m, err := NewManager(&Config{
+		Driver: DriverS3,
+		Local:  LocalConfig{ImageURLPrefix: "/imgs/", AvatarURLPrefix: "/avatars/"},
+		S3: S3Config{
+			Endpoint:     fake.URL(),
+			Bucket:       fake.Bucket,
+			AccessKey:    "test-ak",
+			SecretKey:    "test-sk",
+			Prefix:       "pp",
+			UsePathStyle: true,
+			PublicURL:    publicURL,
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewManager 失败: %v", err)
+	}
+	return m
+}
+
+// 测试内容：验证 S3 存储的写入、读取、元信息与删除流程。
+func TestS3Storage_PutGetStatDelete(t *testing.T) {
+	fake := testutils.NewFakeS3(t, "bucket")
+	m := newTestS3Manager(t, fake, "")
+
+	if err := m.Images.Put("2026/01/02/a b.png", strings.NewReader("hello"), 5, "image/png"); err != nil {
+		t.Fatalf("Put 失败: %v", err)
+	}
+	obj, ok := fake.Object("pp/imgs/2026/01/02/a b.png")
+	if !ok || string(obj.Data) != "hello" || obj.ContentType != "image/png" {
+		t.Fatalf("期望对象写入到 pp/imgs 命名空间，实际 keys=%v", fake.Keys())
+	}
+
+	rc, info, err := m.Images.Get("2026/01/02/a b.png")
+	if err != nil {
+		t.Fatalf("Get 失败: %v", err)
+	}
+	data, _ := io.ReadAll(rc)
+	_ = rc.Close()
+	if string(data) != "hello" || info.Size != 5 {
+		t.Fatalf("读取结果不符合预期: data=%q info=%+v", data, info)
+	}
+
+	if err := m.Images.Delete("2026/01/02/a b.png"); err != nil {
+		t.Fatalf("Delete 失败: %v", err)
+	}
+	if _, err := m.Images.Stat("2026/01/02/a b.png"); !errors.Is(err, ErrNotExist) {
+		t.Fatalf("期望删除后返回 ErrNotExist，实际为 %v", err)
+	}
+	if _, _, err := m.Images.Get("2026/01/02/a b.png"); !errors.Is(err, ErrNotExist) {
+		t.Fatalf("期望 Get 返回 ErrNotExist，实际为 %v", err)
+	}
+}
+
+// 测试内容：验证 DeletePrefix 只删除指定用户的头像对象。
+func TestS3Storage_DeletePrefix(t *testing.T) {
+	fake := testutils.NewFakeS3(t, "bucket")
+	m := newTestS3Manager(t, fake, "")
+
+	_ = m.Avatars.Put("7/a.png", strings.NewReader("a"), 1, "")
+	_ = m.Avatars.Put("7/b.png", strings.NewReader("b"), 1, "")
+	_ = m.Avatars.Put("70/c.png", strings.NewReader("c"), 1, "")
+
+	if err := m.Avatars.DeletePrefix("7"); err != nil {
+		t.Fatalf("DeletePrefix 失败: %v", err)
+	}
+	keys := fake.Keys()
+	if len(keys) != 1 || keys[0] != "pp/avatars/70/c.png" {
+		t.Fatalf("期望仅保留 pp/avatars/70/c.png，实际为 %v", keys)
+	}
+}
+
+// 测试内容：验证 Walk 只列出当前命名空间的对象，返回去除前缀后的 key 与大小。
+func TestS3Storage_Walk(t *testing.T) {
+	fake := testutils.NewFakeS3(t, "bucket")
+	m := newTestS3Manager(t, fake, "")
+
+	_ = m.Images.Put("2026/01/a.png", strings.NewReader("abc"), 3, "image/png")
+	_ = m.Avatars.Put("7/b.png", strings.NewReader("b"), 1, "image/png")
+
+	var got []ObjectInfo
+	if err := m.Images.Walk(func(info ObjectInfo) error {
+		got = append(got, info)
+		return nil
+	}); err != nil {
+		t.Fatalf("Walk 失败: %v", err)
+	}
+	if len(got) != 1 || got[0].Key != "2026/01/a.png" || got[0].Size != 3 || got[0].LastModified.IsZero() {
+		t.Fatalf("遍历结果不符合预期: %+v", got)
+	}
+}
+
+// 测试内容：验证未配置 PublicURL 时回退到代理前缀；配置后仅头像使用公开地址，图片仍经由代理以校验私有权限。
+func TestS3Storage_URL(t *testing.T) {
+	fake := testutils.NewFakeS3(t, "bucket")
+
+	proxied := newTestS3Manager(t, fake, "")
+	if got := proxied.Images.URL("2026/01/02/a.png"); got != "/imgs/2026/01/02/a.png" {
+		t.Fatalf("期望代理地址，实际为 %q", got)
+	}
+
+	public := newTestS3Manager(t, fake, "https://cdn.example.com/")
+	if got := public.Images.URL("2026/01/02/a.png"); got != "/imgs/2026/01/02/a.png" {
+		t.Fatalf("期望图片始终使用代理地址，实际为 %q", got)
+	}
+	if got := public.Avatars.URL(""); got != "https://cdn.example.com/pp/avatars/" {
+		t.Fatalf("期望头像公开前缀，实际为 %q", got)
+	}
+}
+
+// 测试内容：验证缺少必要配置或未知驱动时 NewManager 返回错误。
+func TestNewManager_InvalidConfig(t *testing.T) {
+	if _, err := NewManager(&Config{Driver: DriverS3, S3: S3Config{Bucket: "b"}}); err == nil {
+		t.Fatalf("期望缺少 endpoint 时返回错误")
+	}
+	if _, err := NewManager(&Config{Driver: "ftp"}); err == nil {
+		t.Fatalf("期望未知驱动返回错误")
+	}
+	m, err := NewManager(&Config{})
+	if err != nil || !m.IsLocal() {
+		t.Fatalf("期望默认使用本地存储，err=%v", err)
+	}
+}
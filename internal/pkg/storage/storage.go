@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	// DriverLocal 本地磁盘存储。
+	DriverLocal = "local"
+	// DriverS3 S3 兼容对象存储（AWS S3、MinIO、R2、OSS 等）。
+	DriverS3 = "s3"
+)
+
+// ErrNotExist 表示对象不存在。
+var ErrNotExist = errors.New("storage: object does not exist")
+
+// ObjectInfo 描述存储中的单个对象。
+type ObjectInfo struct {
+	Key          string
+	Size         int64
+	ContentType  string
+	LastModified time.Time
+}
+
+// Storage 是文件存储后端的统一抽象。
+//
+// key 统一使用 "/" 分隔的相对路径（例如 2026/01/02/uuid.png），
+// 由各实现负责映射到具体的物理位置，并拒绝越界路径。
+type Storage interface {
+	// Put 写入对象；size 为 -1 时表示长度未知。
+	Put(key string, r io.Reader, size int64, contentType string) error
+	// Get 读取对象，调用方负责关闭返回的 ReadCloser。
+	Get(key string) (io.ReadCloser, *ObjectInfo, error)
+	// Delete 删除对象；对象不存在时返回 nil。
+	Delete(key string) error
+	// DeletePrefix 删除指定前缀下的全部对象。
+	DeletePrefix(prefix string) error
+	// Stat 获取对象元信息；对象不存在时返回 ErrNotExist。
+	Stat(key string) (*ObjectInfo, error)
+	// URL 返回对象的公开访问地址；key 为空时返回访问前缀。
+	URL(key string) string
+	// ProxyURL 返回经由服务端代理的访问地址；key 为空时返回访问前缀。
+	ProxyURL(key string) string
+	// Walk 遍历全部对象，fn 返回错误时停止遍历并返回该错误。
+	Walk(fn func(ObjectInfo) error) error
+}
+
+// Config 存储后端配置。
+type Config struct {
+	Driver string
+	Local  LocalConfig
+	S3     S3Config
+}
+
+// LocalConfig 本地磁盘存储配置。
+type LocalConfig struct {
+	ImagePath       string
+	ImageURLPrefix  string
+	AvatarPath      string
+	AvatarURLPrefix string
+}
+
+// S3Config S3 兼容对象存储配置。
+type S3Config struct {
+	Endpoint     string
+	Region       string
+	Bucket       string
+	AccessKey    string
+	SecretKey    string
+	Prefix       string
+	UsePathStyle bool
+	// PublicURL 头像的公开访问地址（如 CDN 域名）；为空时回退到服务端代理地址。
+	// 图片需由服务端校验私有权限与签名，始终经由代理访问，不使用该地址。
+	PublicURL string
+}
+
+// Manager 聚合图片与头像两类存储空间。
+type Manager struct {
+	Driver  string
+	Images  Storage
+	Avatars Storage
+}
+
+// NewManager 按配置创建存储后端。
+func NewManager(cfg *Config) (*Manager, error) {
+	driver := strings.ToLower(strings.TrimSpace(cfg.Driver))
+	switch driver {
+	case "", DriverLocal:
+		return &Manager{
+			Driver:  DriverLocal,
+			Images:  NewLocalStorage(defaultString(cfg.Local.ImagePath, "uploads/imgs"), cfg.Local.ImageURLPrefix),
+			Avatars: NewLocalStorage(defaultString(cfg.Local.AvatarPath, "uploads/avatars"), cfg.Local.AvatarURLPrefix),
+		}, nil
+	case DriverS3:
+		client, err := newS3Client(cfg.S3)
+		if err != nil {
+			return nil, err
+		}
+		return &Manager{
+			Driver:  DriverS3,
+			Images:  newS3Storage(client, "imgs", cfg.Local.ImageURLPrefix, ""),
+			Avatars: newS3Storage(client, "avatars", cfg.Local.AvatarURLPrefix, cfg.S3.PublicURL),
+		}, nil
+	default:
+		return nil, fmt.Errorf("不支持的存储驱动: %s", cfg.Driver)
+	}
+}
+
+// IsLocal 判断当前是否为本地磁盘存储。
+func (m *Manager) IsLocal() bool {
+	return m.Driver == DriverLocal
+}
+
+// CleanKey 规范化对象 key，拒绝绝对路径与 ".." 越界。
+func CleanKey(key string) (string, error) {
+	key = strings.ReplaceAll(key, "\\", "/")
+	if strings.HasPrefix(key, "/") {
+		return "", fmt.Errorf("非法路径: 不允许绝对路径")
+	}
+	cleaned := path.Clean(key)
+	if cleaned == "." {
+		return "", fmt.Errorf("非法路径: key 不能为空")
+	}
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("非法路径: 目标超出基目录")
+	}
+	return cleaned, nil
+}
+
+// joinURL 拼接访问前缀与 key，保证两者之间只有一个 "/"。
+func joinURL(prefix, key string) string {
+	return strings.TrimRight(prefix, "/") + "/" + strings.TrimLeft(key, "/")
+}
+
+func defaultString(value, fallback string) string {
+	if strings.TrimSpace(value) == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,32 @@
+package urlsign
+
+import (
+	"testing"
+	"time"
+)
+
+// 测试内容：验证签名往返、过期、篡改 key/过期时间以及不同密钥之间签名互不通用。
+func TestSigner_SignAndVerify(t *testing.T) {
+	signer := NewSigner(&Config{Secret: []byte("secret")})
+	now := time.Unix(1_700_000_000, 0)
+	exp := now.Add(time.Hour).Unix()
+
+	sig := signer.Sign("blobs/ab/cd/x.png", exp)
+	if !signer.Verify("blobs/ab/cd/x.png", exp, sig, now) {
+		t.Fatalf("期望签名校验通过")
+	}
+	if signer.Verify("blobs/ab/cd/x.png", exp, sig, now.Add(2*time.Hour)) {
+		t.Fatalf("期望过期签名校验失败")
+	}
+	if signer.Verify("blobs/ab/cd/y.png", exp, sig, now) {
+		t.Fatalf("期望 key 不一致时校验失败")
+	}
+	if signer.Verify("blobs/ab/cd/x.png", exp+1, sig, now) {
+		t.Fatalf("期望过期时间被篡改时校验失败")
+	}
+
+	other := NewSigner(&Config{Secret: []byte("other")})
+	if other.Verify("blobs/ab/cd/x.png", exp, sig, now) {
+		t.Fatalf("期望不同密钥的签名互不通用")
+	}
+}
@@ -0,0 +1,156 @@
+package watermark
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+	"sync"
+
+	"perfect-pic-server/internal/pkg/imageproc"
+
+	"github.com/golang/freetype/truetype"
+	"github.com/mojocn/base64Captcha"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	PositionTopLeft     = "top-left"
+	PositionTopRight    = "top-right"
+	PositionBottomLeft  = "bottom-left"
+	PositionBottomRight = "bottom-right"
+	PositionCenter      = "center"
+)
+
+// measureFontSize 测量文字宽度时使用的参考字号，实际字号按比例换算。
+const measureFontSize = 100
+
+// Options 水印绘制参数。
+type Options struct {
+	// Position 水印位置，非法取值按右下角处理
+	Position string
+	// Opacity 不透明度 (0-1]
+	Opacity float64
+	// Scale 水印宽度占图片宽度的比例 (0-1]
+	Scale float64
+}
+
+var (
+	defaultFontOnce sync.Once
+	defaultFont     *truetype.Font
+)
+
+// DefaultFont 返回内置字体（文泉驿微米黑，支持中文），首次调用时加载。
+func DefaultFont() *truetype.Font {
+	defaultFontOnce.Do(func() {
+		defaultFont = base64Captcha.DefaultEmbeddedFonts.LoadFontByName("fonts/wqy-microhei.ttc")
+	})
+	return defaultFont
+}
+
+// NormalizePosition 规范化位置参数，空值默认为右下角，非法取值返回空字符串。
+func NormalizePosition(position string) string {
+	switch p := strings.ToLower(strings.TrimSpace(position)); p {
+	case "":
+		return PositionBottomRight
+	case PositionTopLeft, PositionTopRight, PositionBottomLeft, PositionBottomRight, PositionCenter:
+		return p
+	default:
+		return ""
+	}
+}
+
+// DrawText 在 dst 上绘制白色文字水印，并附带半透明阴影以保证浅色背景下的可读性。
+//
+// 字号按 Scale 使文字宽度占图片宽度的对应比例，同时不超过图片高度的一半。
+func DrawText(dst draw.Image, text string, f *truetype.Font, opts Options) {
+	text = strings.TrimSpace(text)
+	b := dst.Bounds()
+	if text == "" || f == nil || b.Empty() {
+		return
+	}
+
+	measured := font.MeasureString(truetype.NewFace(f, &truetype.Options{Size: measureFontSize}), text).Ceil()
+	if measured <= 0 {
+		return
+	}
+	size := float64(measureFontSize) * float64(b.Dx()) * clampUnit(opts.Scale) / float64(measured)
+	size = math.Max(8, math.Min(size, float64(b.Dy())/2))
+
+	face := truetype.NewFace(f, &truetype.Options{Size: size, Hinting: font.HintingFull})
+	defer func() { _ = face.Close() }()
+	textBounds, _ := font.BoundString(face, text)
+	w := (textBounds.Max.X - textBounds.Min.X).Ceil()
+	h := (textBounds.Max.Y - textBounds.Min.Y).Ceil()
+	if w <= 0 || h <= 0 {
+		return
+	}
+
+	mask := image.NewAlpha(image.Rect(0, 0, w, h))
+	drawer := font.Drawer{
+		Dst:  mask,
+		Src:  image.Opaque,
+		Face: face,
+		Dot:  fixed.Point26_6{X: -textBounds.Min.X, Y: -textBounds.Min.Y},
+	}
+	drawer.DrawString(text)
+
+	shadow := max(1, int(size/24))
+	origin := place(b, w+shadow, h+shadow, opts.Position)
+	alpha := uint8(clampUnit(opts.Opacity)*255 + 0.5)
+	draw.DrawMask(dst, image.Rect(origin.X+shadow, origin.Y+shadow, origin.X+shadow+w, origin.Y+shadow+h),
+		image.NewUniform(color.NRGBA{A: alpha / 2}), image.Point{}, mask, image.Point{}, draw.Over)
+	draw.DrawMask(dst, image.Rect(origin.X, origin.Y, origin.X+w, origin.Y+h),
+		image.NewUniform(color.NRGBA{R: 255, G: 255, B: 255, A: alpha}), image.Point{}, mask, image.Point{}, draw.Over)
+}
+
+// DrawImage 在 dst 上叠加图片水印；水印按 Scale 等比缩小（不会放大），并保留自身透明通道。
+func DrawImage(dst draw.Image, mark image.Image, opts Options) {
+	b := dst.Bounds()
+	if mark == nil || b.Empty() || mark.Bounds().Empty() {
+		return
+	}
+	m := margin(b)
+	maxW := max(1, int(float64(b.Dx())*clampUnit(opts.Scale)+0.5))
+	maxH := max(1, b.Dy()-2*m)
+	mark = imageproc.Resize(mark, maxW, maxH, imageproc.FitContain)
+
+	mb := mark.Bounds()
+	origin := place(b, mb.Dx(), mb.Dy(), opts.Position)
+	alpha := uint8(clampUnit(opts.Opacity)*255 + 0.5)
+	draw.DrawMask(dst, image.Rect(origin.X, origin.Y, origin.X+mb.Dx(), origin.Y+mb.Dy()),
+		mark, mb.Min, image.NewUniform(color.Alpha{A: alpha}), image.Point{}, draw.Over)
+}
+
+// place 计算 w x h 的水印在图片中的左上角坐标，四角位置保留与图片尺寸成比例的边距。
+func place(b image.Rectangle, w, h int, position string) image.Point {
+	m := margin(b)
+	left, top := b.Min.X+m, b.Min.Y+m
+	right, bottom := b.Max.X-m-w, b.Max.Y-m-h
+	switch NormalizePosition(position) {
+	case PositionTopLeft:
+		return image.Pt(left, top)
+	case PositionTopRight:
+		return image.Pt(right, top)
+	case PositionBottomLeft:
+		return image.Pt(left, bottom)
+	case PositionCenter:
+		return image.Pt(b.Min.X+(b.Dx()-w)/2, b.Min.Y+(b.Dy()-h)/2)
+	default:
+		return image.Pt(right, bottom)
+	}
+}
+
+func margin(b image.Rectangle) int {
+	return max(2, min(b.Dx(), b.Dy())*3/100)
+}
+
+// clampUnit 将比例限制在 (0, 1]，非法取值按 1 处理。
+func clampUnit(v float64) float64 {
+	if v <= 0 || v > 1 || math.IsNaN(v) {
+		return 1
+	}
+	return v
+}
@@ -0,0 +1,97 @@
+package watermark
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+)
+
+func filled(w, h int, c color.Color) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	return img
+}
+
+// changedBounds 返回与背景色不同的像素所在的最小矩形。
+func changedBounds(img *image.NRGBA, bg color.NRGBA) image.Rectangle {
+	var r image.Rectangle
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if img.NRGBAAt(x, y) != bg {
+				r = r.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return r
+}
+
+// 测试内容：验证文字水印按位置绘制在对应区域，且宽度按比例缩放。
+func TestDrawText_PositionAndScale(t *testing.T) {
+	bg := color.NRGBA{A: 255}
+	for _, position := range []string{PositionTopLeft, PositionBottomRight, PositionCenter} {
+		img := filled(400, 200, bg)
+		DrawText(img, "Perfect Pic 水印", DefaultFont(), Options{Position: position, Opacity: 1, Scale: 0.5})
+
+		r := changedBounds(img, bg)
+		if r.Empty() {
+			t.Fatalf("%s: 期望绘制出水印", position)
+		}
+		if r.Dx() < 150 || r.Dx() > 230 {
+			t.Fatalf("%s: 期望水印宽度约为图片宽度的一半，实际为 %d", position, r.Dx())
+		}
+		center := image.Pt((r.Min.X+r.Max.X)/2, (r.Min.Y+r.Max.Y)/2)
+		switch position {
+		case PositionTopLeft:
+			if center.X > 200 || center.Y > 100 {
+				t.Fatalf("期望水印位于左上角，实际区域为 %v", r)
+			}
+		case PositionBottomRight:
+			if center.X < 200 || center.Y < 100 {
+				t.Fatalf("期望水印位于右下角，实际区域为 %v", r)
+			}
+		case PositionCenter:
+			if center.X < 150 || center.X > 250 || center.Y < 60 || center.Y > 140 {
+				t.Fatalf("期望水印居中，实际区域为 %v", r)
+			}
+		}
+	}
+}
+
+// 测试内容：验证图片水印按比例缩小、按不透明度混合，并保留水印自身的透明区域。
+func TestDrawImage_ScaleAndOpacity(t *testing.T) {
+	bg := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	img := filled(200, 100, bg)
+	mark := filled(100, 50, color.NRGBA{R: 255, A: 255})
+	// 水印左半部分透明
+	draw.Draw(mark, image.Rect(0, 0, 50, 50), image.Transparent, image.Point{}, draw.Src)
+
+	DrawImage(img, mark, Options{Position: PositionTopLeft, Opacity: 0.5, Scale: 0.25})
+
+	r := changedBounds(img, bg)
+	// 缩放插值会在透明边缘外溢约 1 像素
+	if r.Dx() < 25 || r.Dx() > 27 || r.Dy() != 25 || r.Min.X < 25 {
+		t.Fatalf("期望仅水印不透明的右半部分（约 25x25）改变像素，实际区域为 %v", r)
+	}
+	got := img.NRGBAAt(r.Max.X-2, r.Min.Y+2)
+	if got.R != 255 || got.G < 120 || got.G > 135 {
+		t.Fatalf("期望按 50%% 不透明度混合，实际颜色为 %v", got)
+	}
+}
+
+// 测试内容：验证位置参数的规范化。
+func TestNormalizePosition(t *testing.T) {
+	cases := map[string]string{
+		"":             PositionBottomRight,
+		" Top-Left ":   PositionTopLeft,
+		"center":       PositionCenter,
+		"bottom-right": PositionBottomRight,
+		"middle":       "",
+	}
+	for in, want := range cases {
+		if got := NormalizePosition(in); got != want {
+			t.Fatalf("%q: 期望 %q，实际为 %q", in, want, got)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"perfect-pic-server/internal/model"
+	"time"
+)
+
+type AccessTokenStore interface {
+	ListAccessTokensByUserID(userID uint) ([]model.PersonalAccessToken, error)
+	CountAccessTokensByUserID(userID uint) (int64, error)
+	FindAccessTokenByHash(tokenHash string) (*model.PersonalAccessToken, error)
+	CreateAccessToken(token *model.PersonalAccessToken) error
+	DeleteAccessTokenByID(userID uint, tokenID uint) error
+	UpdateAccessTokenLastUsed(tokenID uint, usedAt time.Time, ip string) error
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"perfect-pic-server/internal/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AccessTokenRepository struct {
+	db *gorm.DB
+}
+
+// ListAccessTokensByUserID 返回指定用户的全部个人访问令牌，按创建顺序排列。
+func (r *AccessTokenRepository) ListAccessTokensByUserID(userID uint) ([]model.PersonalAccessToken, error) {
+	var tokens []model.PersonalAccessToken
+	if err := r.db.Where("user_id = ?", userID).Order("id asc").Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// CountAccessTokensByUserID 统计指定用户的个人访问令牌数量。
+func (r *AccessTokenRepository) CountAccessTokensByUserID(userID uint) (int64, error) {
+	var count int64
+	if err := r.db.Model(&model.PersonalAccessToken{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// FindAccessTokenByHash 通过令牌摘要查找令牌，并预加载所属用户。
+func (r *AccessTokenRepository) FindAccessTokenByHash(tokenHash string) (*model.PersonalAccessToken, error) {
+	var token model.PersonalAccessToken
+	if err := r.db.Preload("User").Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// CreateAccessToken 创建个人访问令牌记录。
+func (r *AccessTokenRepository) CreateAccessToken(token *model.PersonalAccessToken) error {
+	return r.db.Create(token).Error
+}
+
+// DeleteAccessTokenByID 删除指定用户下的某个个人访问令牌。
+func (r *AccessTokenRepository) DeleteAccessTokenByID(userID uint, tokenID uint) error {
+	tx := r.db.Where("user_id = ? AND id = ?", userID, tokenID).Delete(&model.PersonalAccessToken{})
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// UpdateAccessTokenLastUsed 记录令牌最近一次使用的时间与来源 IP。
+func (r *AccessTokenRepository) UpdateAccessTokenLastUsed(tokenID uint, usedAt time.Time, ip string) error {
+	return r.db.Model(&model.PersonalAccessToken{}).
+		Where("id = ?", tokenID).
+		UpdateColumns(map[string]interface{}{"last_used_at": usedAt, "last_used_ip": ip}).Error
+}
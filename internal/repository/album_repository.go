@@ -0,0 +1,15 @@
+package repository
+
+import "perfect-pic-server/internal/model"
+
+type AlbumStore interface {
+	CreateAlbum(album *model.Album) error
+	FindAlbumByIDAndUserID(albumID uint, userID uint) (*model.Album, error)
+	ListAlbumsByUserID(userID uint, offset int, limit int) ([]model.Album, int64, error)
+	UpdateAlbum(albumID uint, userID uint, updates map[string]interface{}) error
+	DeleteAlbum(albumID uint, userID uint) error
+	CountAlbumImages(albumIDs []uint) (map[uint]int64, error)
+	HasAlbumImage(albumID uint, imageID uint) (bool, error)
+	AddAlbumImages(albumID uint, imageIDs []uint, addedAt int64) (int64, error)
+	RemoveAlbumImages(albumID uint, imageIDs []uint) (int64, error)
+}
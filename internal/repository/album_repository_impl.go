@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"perfect-pic-server/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AlbumRepository struct {
+	db *gorm.DB
+}
+
+// CreateAlbum 创建相册记录。
+func (r *AlbumRepository) CreateAlbum(album *model.Album) error {
+	return r.db.Create(album).Error
+}
+
+// FindAlbumByIDAndUserID 查找指定用户的相册。
+func (r *AlbumRepository) FindAlbumByIDAndUserID(albumID uint, userID uint) (*model.Album, error) {
+	var album model.Album
+	if err := r.db.Where("id = ? AND user_id = ?", albumID, userID).First(&album).Error; err != nil {
+		return nil, err
+	}
+	return &album, nil
+}
+
+// ListAlbumsByUserID 按 sort_order 升序、创建时间倒序分页返回用户的相册。
+func (r *AlbumRepository) ListAlbumsByUserID(userID uint, offset int, limit int) ([]model.Album, int64, error) {
+	var albums []model.Album
+	var total int64
+
+	query := r.db.Model(&model.Album{}).Where("user_id = ?", userID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if err := query.Order("sort_order asc").Order("id desc").Offset(offset).Limit(limit).Find(&albums).Error; err != nil {
+		return nil, 0, err
+	}
+	return albums, total, nil
+}
+
+// UpdateAlbum 更新指定用户相册的字段。
+func (r *AlbumRepository) UpdateAlbum(albumID uint, userID uint, updates map[string]interface{}) error {
+	tx := r.db.Model(&model.Album{}).Where("id = ? AND user_id = ?", albumID, userID).Updates(updates)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// DeleteAlbum 删除相册及其图片关联，图片本身不受影响。
+func (r *AlbumRepository) DeleteAlbum(albumID uint, userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND user_id = ?", albumID, userID).Delete(&model.Album{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("album_id = ?", albumID).Delete(&model.AlbumImage{}).Error
+	})
+}
+
+// CountAlbumImages 统计各相册内的图片数量。
+func (r *AlbumRepository) CountAlbumImages(albumIDs []uint) (map[uint]int64, error) {
+	counts := make(map[uint]int64, len(albumIDs))
+	if len(albumIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		AlbumID uint
+		Count   int64
+	}
+	if err := r.db.Model(&model.AlbumImage{}).
+		Select("album_id, COUNT(*) AS count").
+		Joins("JOIN images ON images.id = album_images.image_id AND images.deleted_at IS NULL").
+		Where("album_id IN ?", albumIDs).
+		Group("album_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.AlbumID] = row.Count
+	}
+	return counts, nil
+}
+
+// HasAlbumImage 判断图片是否在相册内。
+func (r *AlbumRepository) HasAlbumImage(albumID uint, imageID uint) (bool, error) {
+	var count int64
+	if err := r.db.Model(&model.AlbumImage{}).Where("album_id = ? AND image_id = ?", albumID, imageID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// AddAlbumImages 将图片加入相册，已在相册内的图片会被忽略，返回实际新增的数量。
+func (r *AlbumRepository) AddAlbumImages(albumID uint, imageIDs []uint, addedAt int64) (int64, error) {
+	if len(imageIDs) == 0 {
+		return 0, nil
+	}
+	links := make([]model.AlbumImage, 0, len(imageIDs))
+	for _, id := range imageIDs {
+		links = append(links, model.AlbumImage{AlbumID: albumID, ImageID: id, AddedAt: addedAt})
+	}
+	tx := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&links)
+	if tx.Error != nil {
+		return 0, tx.Error
+	}
+	return tx.RowsAffected, nil
+}
+
+// RemoveAlbumImages 将图片移出相册；封面被移出时一并清空封面，返回实际移除的数量。
+func (r *AlbumRepository) RemoveAlbumImages(albumID uint, imageIDs []uint) (int64, error) {
+	if len(imageIDs) == 0 {
+		return 0, nil
+	}
+	var removed int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("album_id = ? AND image_id IN ?", albumID, imageIDs).Delete(&model.AlbumImage{})
+		if result.Error != nil {
+			return result.Error
+		}
+		removed = result.RowsAffected
+		return tx.Model(&model.Album{}).
+			Where("id = ? AND cover_image_id IN ?", albumID, imageIDs).
+			UpdateColumn("cover_image_id", nil).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return removed, nil
+}
+
+// detachImagesFromAlbums 在删除图片的事务内移除其相册关联，并清空以其为封面的相册封面。
+func detachImagesFromAlbums(tx *gorm.DB, imageIDs []uint) error {
+	if err := tx.Where("image_id IN ?", imageIDs).Delete(&model.AlbumImage{}).Error; err != nil {
+		return err
+	}
+	return tx.Model(&model.Album{}).
+		Where("cover_image_id IN ?", imageIDs).
+		UpdateColumn("cover_image_id", nil).Error
+}
@@ -1,28 +1,116 @@
 package repository
 
-import "perfect-pic-server/internal/model"
+import (
+	"fmt"
+	"perfect-pic-server/internal/model"
+	"time"
+)
+
+const (
+	// ImageSortUploadedAt 按上传时间排序（默认）。
+	ImageSortUploadedAt = "uploaded_at"
+	// ImageSortTakenAt 按 EXIF 拍摄时间排序，无拍摄时间的图片排在最后。
+	ImageSortTakenAt = "taken_at"
+	// ImageSortViews 按累计访问次数排序。
+	ImageSortViews = "views"
+)
 
 type ListImagesParams struct {
-	UserID      *uint
+	UserID   *uint
+	Username string
+	Filename string
+	ID       *uint
+	AlbumID  *uint
+	// Search 全文检索关键词，Tag 按标签名精确过滤
+	Search string
+	Tag    string
+	// TakenFrom/TakenTo 按拍摄时间过滤，区间为 [TakenFrom, TakenTo)
+	TakenFrom       *time.Time
+	TakenTo         *time.Time
+	SortBy          string
+	SortAsc         bool
+	Offset          int
+	Limit           int
+	PreloadUser     bool
+	PreloadMetadata bool
+	PreloadTags     bool
+}
+
+// TagUsage 标签及其关联的图片数量。
+type TagUsage struct {
+	ID    uint   `json:"id"`
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+// ImageViewCount 图片在某天来自某来源站点的访问次数增量，Host 为空表示没有 Referer。
+type ImageViewCount struct {
+	ImageID uint
+	Day     string
+	Host    string
+	Views   int64
+}
+
+// StorageQuotaExceededError 写入图片时用户的剩余空间不足以容纳本次占用。
+type StorageQuotaExceededError struct {
+	// Used 写入时用户的已用空间
+	Used  int64
+	Quota int64
+	Size  int64
+}
+
+func (e *StorageQuotaExceededError) Error() string {
+	return fmt.Sprintf("storage quota exceeded: used %d + size %d > quota %d", e.Used, e.Size, e.Quota)
+}
+
+// UserStorageUsage 用户记录的已用空间，以及按图片记录（含回收站）统计的实际占用。
+type UserStorageUsage struct {
+	ID          uint
 	Username    string
-	Filename    string
-	ID          *uint
-	Offset      int
-	Limit       int
-	PreloadUser bool
+	Avatar      string
+	StorageUsed int64
+	ActualUsed  int64
 }
 
 type ImageStore interface {
-	CreateAndIncreaseUserStorage(image *model.Image, userID uint, size int64) error
-	DeleteAndDecreaseUserStorage(image *model.Image) error
-	BatchDeleteAndDecreaseUserStorage(imageIDs []uint, userSizeMap map[uint]int64) error
+	CreateAndIncreaseUserStorage(image *model.Image, userID uint, size int64, quota int64) error
+	DeleteAndDecreaseUserStorage(image *model.Image) ([]string, error)
+	BatchDeleteAndDecreaseUserStorage(imageIDs []uint, userSizeMap map[uint]int64) ([]string, error)
 	ListImages(params ListImagesParams) ([]model.Image, int64, error)
 	CountByUserID(userID uint) (int64, error)
 	FindByIDAndUserID(imageID uint, userID uint) (*model.Image, error)
+	FindWithMetadataByIDAndUserID(imageID uint, userID uint) (*model.Image, error)
 	FindByIDsAndUserID(ids []uint, userID uint) ([]model.Image, error)
 	FindByID(id uint) (*model.Image, error)
 	FindByIDs(ids []uint) ([]model.Image, error)
 	FindUnscopedByUserID(userID uint) ([]model.Image, error)
+	FindByUserIDAndSHA256(userID uint, sha256 string) (*model.Image, error)
+	FindBlobByHash(hash string) (*model.ImageBlob, error)
+	UpdateImageInfo(imageID uint, userID uint, updates map[string]interface{}) error
+	FindOriginalNamesByPath(path string) ([]string, error)
+	FindAccessByPath(path string) ([]model.Image, error)
 	CountAll() (int64, error)
 	SumAllSize() (int64, error)
+	SetImageTags(imageID uint, userID uint, names []string) error
+	ListUserTags(userID uint, prefix string, limit int) ([]TagUsage, error)
+	FindTagNamesByImageIDs(imageIDs []uint) (map[uint][]string, error)
+	FindByShortCode(code string) (*model.Image, error)
+	ShortCodeExists(code string) (bool, error)
+	SetShortCodeIfEmpty(imageID uint, code string) (bool, error)
+	UpdateShortCode(imageID uint, code string) error
+	FindExpired(now int64, limit int) ([]model.Image, error)
+	TrashImages(imageIDs []uint) error
+	ListTrashedByUserID(userID uint, offset int, limit int) ([]model.Image, int64, error)
+	FindTrashedByIDsAndUserID(ids []uint, userID uint) ([]model.Image, error)
+	FindTrashedBefore(cutoff time.Time, limit int) ([]model.Image, error)
+	RestoreImages(imageIDs []uint, userID uint) (int64, error)
+	ListFileRefs() ([]model.Image, error)
+	ListBlobPaths() ([]string, error)
+	ListUserStorageUsage() ([]UserStorageUsage, error)
+	RecalculateUserStorage(userIDs []uint) error
+	ClearAlternates(imageIDs []uint) error
+	ClearUserAvatars(userIDs []uint) error
+	AddImageViews(counts []ImageViewCount) error
+	ListImageDailyViews(imageID uint, fromDay string) ([]model.ImageViewStat, error)
+	ListImageTopReferrers(imageID uint, fromDay string, limit int) ([]model.ImageReferrerStat, error)
 }
@@ -0,0 +1,17 @@
+package repository
+
+import (
+	"perfect-pic-server/internal/model"
+)
+
+// FindExpired 按过期时间先后查找在 now（Unix 秒）时已过期的图片（含回收站中的图片），最多返回 limit 条。
+func (r *ImageRepository) FindExpired(now int64, limit int) ([]model.Image, error) {
+	var images []model.Image
+	if err := r.db.Unscoped().Where("expires_at IS NOT NULL AND expires_at <= ?", now).
+		Order("expires_at ASC, id ASC").
+		Limit(limit).
+		Find(&images).Error; err != nil {
+		return nil, err
+	}
+	return images, nil
+}
@@ -4,45 +4,103 @@ import (
 	"perfect-pic-server/internal/model"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ImageRepository struct {
 	db *gorm.DB
 }
 
-func (r *ImageRepository) CreateAndIncreaseUserStorage(image *model.Image, userID uint, size int64) error {
+// CreateAndIncreaseUserStorage 创建图片记录并增加用户已用空间。
+//
+// 配额检查与扣减合并为一条条件更新（storage_used + size <= quota），并作为事务中的第一条写操作，
+// 由数据库行锁保证并发上传不会共同超出配额；空间不足时返回 *StorageQuotaExceededError 并回滚。
+func (r *ImageRepository) CreateAndIncreaseUserStorage(image *model.Image, userID uint, size int64, quota int64) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Create(image).Error; err != nil {
-			return err
+		result := tx.Model(&model.User{}).Where("id = ? AND storage_used + ? <= ?", userID, size, quota).
+			UpdateColumn("storage_used", gorm.Expr("storage_used + ?", size))
+		if result.Error != nil {
+			return result.Error
 		}
-		if err := tx.Model(&model.User{}).Where("id = ?", userID).
-			UpdateColumn("storage_used", gorm.Expr("storage_used + ?", size)).Error; err != nil {
+		if result.RowsAffected == 0 {
+			var user model.User
+			if err := tx.Select("storage_used").First(&user, userID).Error; err != nil {
+				return err
+			}
+			return &StorageQuotaExceededError{Used: user.StorageUsed, Quota: quota, Size: size}
+		}
+
+		if image.SHA256 != "" {
+			if err := acquireBlob(tx, image); err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(image).Error; err != nil {
 			return err
 		}
-		return nil
+		return refreshSearchDocuments(tx, []uint{image.ID})
 	})
 }
 
-func (r *ImageRepository) DeleteAndDecreaseUserStorage(image *model.Image) error {
-	return r.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Delete(image).Error; err != nil {
+func (r *ImageRepository) DeleteAndDecreaseUserStorage(image *model.Image) ([]string, error) {
+	var released []string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("image_id = ?", image.ID).Delete(&model.ImageMetadata{}).Error; err != nil {
+			return err
+		}
+		if err := detachImagesFromAlbums(tx, []uint{image.ID}); err != nil {
+			return err
+		}
+		if err := detachImagesFromTags(tx, []uint{image.ID}); err != nil {
+			return err
+		}
+		if err := deleteImageStats(tx, []uint{image.ID}); err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Delete(image).Error; err != nil {
 			return err
 		}
 		if err := tx.Model(&model.User{}).Where("id = ?", image.UserID).
-			UpdateColumn("storage_used", gorm.Expr("storage_used - ?", image.Size)).Error; err != nil {
+			UpdateColumn("storage_used", gorm.Expr("storage_used - ?", image.StorageSize())).Error; err != nil {
 			return err
 		}
+		paths, err := releaseImageFiles(tx, []model.Image{*image})
+		if err != nil {
+			return err
+		}
+		released = paths
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	return released, nil
 }
 
-func (r *ImageRepository) BatchDeleteAndDecreaseUserStorage(imageIDs []uint, userSizeMap map[uint]int64) error {
+func (r *ImageRepository) BatchDeleteAndDecreaseUserStorage(imageIDs []uint, userSizeMap map[uint]int64) ([]string, error) {
 	if len(imageIDs) == 0 {
-		return nil
+		return nil, nil
 	}
 
-	return r.db.Transaction(func(tx *gorm.DB) error {
-		if err := tx.Where("id IN ?", imageIDs).Delete(&model.Image{}).Error; err != nil {
+	var released []string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var images []model.Image
+		if err := tx.Unscoped().Select("id", "path", "sha256").Where("id IN ?", imageIDs).Find(&images).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("image_id IN ?", imageIDs).Delete(&model.ImageMetadata{}).Error; err != nil {
+			return err
+		}
+		if err := detachImagesFromAlbums(tx, imageIDs); err != nil {
+			return err
+		}
+		if err := detachImagesFromTags(tx, imageIDs); err != nil {
+			return err
+		}
+		if err := deleteImageStats(tx, imageIDs); err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("id IN ?", imageIDs).Delete(&model.Image{}).Error; err != nil {
 			return err
 		}
 		for uid, size := range userSizeMap {
@@ -51,8 +109,121 @@ func (r *ImageRepository) BatchDeleteAndDecreaseUserStorage(imageIDs []uint, use
 				return err
 			}
 		}
+		paths, err := releaseImageFiles(tx, images)
+		if err != nil {
+			return err
+		}
+		released = paths
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+	return released, nil
+}
+
+func (r *ImageRepository) FindByUserIDAndSHA256(userID uint, sha256 string) (*model.Image, error) {
+	var image model.Image
+	if err := r.db.Where("user_id = ? AND sha256 = ?", userID, sha256).Order("id asc").First(&image).Error; err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+func (r *ImageRepository) UpdateImageInfo(imageID uint, userID uint, updates map[string]interface{}) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Image{}).Where("id = ? AND user_id = ?", imageID, userID).Updates(updates).Error; err != nil {
+			return err
+		}
+		return refreshSearchDocuments(tx, []uint{imageID})
+	})
+}
+
+func (r *ImageRepository) FindOriginalNamesByPath(path string) ([]string, error) {
+	var names []string
+	if err := r.db.Model(&model.Image{}).Distinct("original_name").Where("path = ?", path).Pluck("original_name", &names).Error; err != nil {
+		return nil, err
+	}
+	return names, nil
+}
+
+// FindAccessByPath 按上传先后查找引用该文件的全部记录（含回收站中的记录），仅读取访问控制所需字段。
+func (r *ImageRepository) FindAccessByPath(path string) ([]model.Image, error) {
+	var images []model.Image
+	if err := r.db.Unscoped().Select("id", "user_id", "visibility", "expires_at", "deleted_at").Where("path = ?", path).Order("id").Find(&images).Error; err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+func (r *ImageRepository) FindBlobByHash(hash string) (*model.ImageBlob, error) {
+	var blob model.ImageBlob
+	if err := r.db.Where("hash = ?", hash).First(&blob).Error; err != nil {
+		return nil, err
+	}
+	return &blob, nil
+}
+
+// acquireBlob 为图片引用的内容寻址文件增加引用计数（不存在时创建），并将图片路径对齐到该文件。
+func acquireBlob(tx *gorm.DB, image *model.Image) error {
+	blob := model.ImageBlob{Hash: image.SHA256, Path: image.Path, Size: image.Size, RefCount: 1}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "hash"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"ref_count": gorm.Expr("image_blobs.ref_count + 1")}),
+	}).Create(&blob).Error; err != nil {
+		return err
+	}
+
+	var current model.ImageBlob
+	if err := tx.Where("hash = ?", image.SHA256).First(&current).Error; err != nil {
+		return err
+	}
+	image.Path = current.Path
+	return nil
+}
+
+// releaseImageFiles 释放已删除图片对物理文件的引用，返回不再被任何记录引用、需要删除的文件路径。
+//
+// 未记录哈希的旧图片独占自己的文件，直接返回其路径；去重后的图片扣减引用计数，归零时删除 blob 记录。
+func releaseImageFiles(tx *gorm.DB, images []model.Image) ([]string, error) {
+	var paths []string
+	hashCounts := make(map[string]int64)
+	for _, img := range images {
+		if img.SHA256 == "" {
+			paths = append(paths, img.Path)
+			continue
+		}
+		hashCounts[img.SHA256]++
+	}
+	if len(hashCounts) == 0 {
+		return paths, nil
+	}
+
+	hashes := make([]string, 0, len(hashCounts))
+	for hash, count := range hashCounts {
+		if err := tx.Model(&model.ImageBlob{}).Where("hash = ?", hash).
+			UpdateColumn("ref_count", gorm.Expr("ref_count - ?", count)).Error; err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+
+	var orphaned []model.ImageBlob
+	if err := tx.Where("hash IN ? AND ref_count <= 0", hashes).Find(&orphaned).Error; err != nil {
+		return nil, err
+	}
+	if len(orphaned) == 0 {
+		return paths, nil
+	}
+	ids := make([]uint, 0, len(orphaned))
+	for _, blob := range orphaned {
+		ids = append(ids, blob.ID)
+		paths = append(paths, blob.Path)
+	}
+	if err := tx.Delete(&model.ImageBlob{}, ids).Error; err != nil {
+		return nil, err
+	}
+	return paths, nil
 }
 
 func (r *ImageRepository) ListImages(params ListImagesParams) ([]model.Image, int64, error) {
@@ -73,6 +244,28 @@ func (r *ImageRepository) ListImages(params ListImagesParams) ([]model.Image, in
 	if params.ID != nil {
 		query = query.Where("images.id = ?", *params.ID)
 	}
+	if params.AlbumID != nil {
+		query = query.Joins("JOIN album_images ON album_images.image_id = images.id").
+			Where("album_images.album_id = ?", *params.AlbumID)
+	}
+	if params.Search != "" {
+		query = applyImageSearch(query, params.Search)
+	}
+	if params.Tag != "" {
+		query = query.Where("images.id IN (?)", r.db.Table("image_tags").
+			Select("image_tags.image_id").
+			Joins("JOIN tags ON tags.id = image_tags.tag_id").
+			Where("tags.name = ?", params.Tag))
+	}
+	if params.TakenFrom != nil || params.TakenTo != nil || params.SortBy == ImageSortTakenAt {
+		query = query.Joins("LEFT JOIN image_metadata ON image_metadata.image_id = images.id")
+	}
+	if params.TakenFrom != nil {
+		query = query.Where("image_metadata.taken_at >= ?", *params.TakenFrom)
+	}
+	if params.TakenTo != nil {
+		query = query.Where("image_metadata.taken_at < ?", *params.TakenTo)
+	}
 
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
@@ -81,10 +274,30 @@ func (r *ImageRepository) ListImages(params ListImagesParams) ([]model.Image, in
 	if params.PreloadUser {
 		query = query.Preload("User")
 	}
+	if params.PreloadMetadata {
+		query = query.Preload("Metadata")
+	}
 
-	if err := query.Order("images.id desc").Offset(params.Offset).Limit(params.Limit).Find(&images).Error; err != nil {
+	direction := "desc"
+	if params.SortAsc {
+		direction = "asc"
+	}
+	switch params.SortBy {
+	case ImageSortTakenAt:
+		// 无拍摄时间的记录无论升降序都排在最后
+		query = query.Order("image_metadata.taken_at IS NULL").Order("image_metadata.taken_at " + direction)
+	case ImageSortViews:
+		query = query.Order("images.views " + direction)
+	}
+
+	if err := query.Order("images.id " + direction).Offset(params.Offset).Limit(params.Limit).Find(&images).Error; err != nil {
 		return nil, 0, err
 	}
+	if params.PreloadTags {
+		if err := attachTags(r.db, images); err != nil {
+			return nil, 0, err
+		}
+	}
 
 	return images, total, nil
 }
@@ -105,6 +318,14 @@ func (r *ImageRepository) FindByIDAndUserID(imageID uint, userID uint) (*model.I
 	return &image, nil
 }
 
+func (r *ImageRepository) FindWithMetadataByIDAndUserID(imageID uint, userID uint) (*model.Image, error) {
+	var image model.Image
+	if err := r.db.Preload("Metadata").Where("id = ? AND user_id = ?", imageID, userID).First(&image).Error; err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
 func (r *ImageRepository) FindByIDsAndUserID(ids []uint, userID uint) ([]model.Image, error) {
 	var images []model.Image
 	if err := r.db.Where("id IN ? AND user_id = ?", ids, userID).Find(&images).Error; err != nil {
@@ -147,7 +368,7 @@ func (r *ImageRepository) CountAll() (int64, error) {
 
 func (r *ImageRepository) SumAllSize() (int64, error) {
 	var total int64
-	if err := r.db.Model(&model.Image{}).Select("COALESCE(SUM(size), 0)").Scan(&total).Error; err != nil {
+	if err := r.db.Model(&model.Image{}).Select("COALESCE(SUM(size + alt_size), 0)").Scan(&total).Error; err != nil {
 		return 0, err
 	}
 	return total, nil
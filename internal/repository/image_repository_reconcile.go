@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"perfect-pic-server/internal/model"
+
+	"gorm.io/gorm"
+)
+
+// userActualStorageExpr 按图片记录（含回收站中的图片）统计用户实际占用空间的子查询。
+const userActualStorageExpr = "(SELECT COALESCE(SUM(images.size + images.alt_size), 0) FROM images WHERE images.user_id = users.id)"
+
+// ListFileRefs 列出全部图片记录（含回收站）引用的文件信息，仅加载对账所需的字段。
+func (r *ImageRepository) ListFileRefs() ([]model.Image, error) {
+	var images []model.Image
+	if err := r.db.Unscoped().
+		Select("id", "user_id", "path", "sha256", "size", "alt_type", "alt_size").
+		Order("id ASC").
+		Find(&images).Error; err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+// ListBlobPaths 列出去重文件表中登记的全部文件路径。
+func (r *ImageRepository) ListBlobPaths() ([]string, error) {
+	var paths []string
+	if err := r.db.Model(&model.ImageBlob{}).Pluck("path", &paths).Error; err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// ListUserStorageUsage 列出全部用户（含已注销）的头像、记录的已用空间与实际占用。
+func (r *ImageRepository) ListUserStorageUsage() ([]UserStorageUsage, error) {
+	var rows []UserStorageUsage
+	if err := r.db.Table("users").
+		Select("users.id, users.username, users.avatar, users.storage_used, " + userActualStorageExpr + " AS actual_used").
+		Order("users.id ASC").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// RecalculateUserStorage 按图片记录重新计算用户的已用空间，在单条语句内完成以免覆盖并发上传的增量。
+func (r *ImageRepository) RecalculateUserStorage(userIDs []uint) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	return r.db.Unscoped().Model(&model.User{}).Where("id IN ?", userIDs).
+		UpdateColumn("storage_used", gorm.Expr(userActualStorageExpr)).Error
+}
+
+// ClearAlternates 清除图片的备用格式记录（备用文件已丢失时使用）。
+func (r *ImageRepository) ClearAlternates(imageIDs []uint) error {
+	if len(imageIDs) == 0 {
+		return nil
+	}
+	return r.db.Unscoped().Model(&model.Image{}).Where("id IN ?", imageIDs).
+		UpdateColumns(map[string]interface{}{"alt_type": "", "alt_size": 0}).Error
+}
+
+// ClearUserAvatars 清除用户的头像记录（头像文件已丢失时使用）。
+func (r *ImageRepository) ClearUserAvatars(userIDs []uint) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	return r.db.Unscoped().Model(&model.User{}).Where("id IN ?", userIDs).UpdateColumn("avatar", "").Error
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// maxSearchTerms 单次检索最多使用的关键词数量。
+const maxSearchTerms = 8
+
+// searchTerms 将检索输入拆分为仅由字母与数字组成的关键词（统一为小写并去重），
+// 避免用户输入被解释为各数据库的全文检索语法。
+func searchTerms(search string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(search), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	seen := make(map[string]struct{}, len(fields))
+	terms := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if _, ok := seen[field]; ok {
+			continue
+		}
+		seen[field] = struct{}{}
+		terms = append(terms, field)
+		if len(terms) == maxSearchTerms {
+			break
+		}
+	}
+	return terms
+}
+
+// applyImageSearch 追加全文检索条件：所有关键词均需命中图片的检索文档。
+// SQLite 与 PostgreSQL 按子串匹配（分别由 trigram 与 pg_trgm 索引加速，少于 3 个字符的关键词或未启用 pg_trgm 时退化为扫描），
+// 以便检索中文标题或描述中的任意片段；MySQL 由 ngram 全文索引匹配。
+func applyImageSearch(query *gorm.DB, search string) *gorm.DB {
+	terms := searchTerms(search)
+	if len(terms) == 0 {
+		// 输入中没有可检索的字符，不应退化为返回全部图片
+		return query.Where("1 = 0")
+	}
+
+	switch query.Dialector.Name() {
+	case "sqlite":
+		// 关键词仅由字母与数字组成，无需转义 LIKE 通配符
+		for _, term := range terms {
+			query = query.Where("images.id IN (SELECT rowid FROM image_search_fts WHERE content LIKE ?)", "%"+term+"%")
+		}
+		return query
+	case "postgres":
+		for _, term := range terms {
+			query = query.Where("images.id IN (SELECT image_id FROM image_search_documents WHERE content ILIKE ?)", "%"+term+"%")
+		}
+		return query
+	case "mysql":
+		parts := make([]string, 0, len(terms))
+		for _, term := range terms {
+			parts = append(parts, "+"+term+"*")
+		}
+		return query.Where("images.id IN (SELECT image_id FROM image_search_documents WHERE MATCH(content) AGAINST(? IN BOOLEAN MODE))",
+			strings.Join(parts, " "))
+	default:
+		for _, term := range terms {
+			query = query.Where("images.id IN (SELECT image_id FROM image_search_documents WHERE content LIKE ?)", "%"+term+"%")
+		}
+		return query
+	}
+}
@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"perfect-pic-server/internal/model"
+
+	"gorm.io/gorm"
+)
+
+func (r *ImageRepository) FindByShortCode(code string) (*model.Image, error) {
+	var image model.Image
+	if err := r.db.Where("short_code = ?", code).First(&image).Error; err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+// ShortCodeExists 判断短码是否已被占用，回收站中的图片同样占用短码（恢复后短链接仍然有效）。
+func (r *ImageRepository) ShortCodeExists(code string) (bool, error) {
+	var count int64
+	if err := r.db.Unscoped().Model(&model.Image{}).Where("short_code = ?", code).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// SetShortCodeIfEmpty 仅在图片尚无短码时写入，返回是否写入成功；短码已被占用时由唯一索引报错。
+func (r *ImageRepository) SetShortCodeIfEmpty(imageID uint, code string) (bool, error) {
+	tx := r.db.Model(&model.Image{}).Where("id = ? AND short_code IS NULL", imageID).UpdateColumn("short_code", code)
+	if tx.Error != nil {
+		return false, tx.Error
+	}
+	return tx.RowsAffected > 0, nil
+}
+
+func (r *ImageRepository) UpdateShortCode(imageID uint, code string) error {
+	tx := r.db.Model(&model.Image{}).Where("id = ?", imageID).UpdateColumn("short_code", code)
+	if tx.Error != nil {
+		return tx.Error
+	}
+	if tx.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"cmp"
+	"slices"
+
+	"perfect-pic-server/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// AddImageViews 在一个事务中累加图片的总访问次数、按天访问次数与按来源站点的访问次数。
+// 已被永久删除的图片会被跳过；按图片与日期排序写入，避免多个实例同时写入时互相死锁。
+func (r *ImageRepository) AddImageViews(counts []ImageViewCount) error {
+	if len(counts) == 0 {
+		return nil
+	}
+	counts = slices.Clone(counts)
+	slices.SortFunc(counts, func(a, b ImageViewCount) int {
+		return cmp.Or(cmp.Compare(a.ImageID, b.ImageID), cmp.Compare(a.Day, b.Day), cmp.Compare(a.Host, b.Host))
+	})
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		exists := make(map[uint]bool)
+		for i := 0; i < len(counts); {
+			imageID := counts[i].ImageID
+			var total int64
+			for ; i < len(counts) && counts[i].ImageID == imageID; i++ {
+				total += counts[i].Views
+			}
+			result := tx.Unscoped().Model(&model.Image{}).Where("id = ?", imageID).
+				UpdateColumn("views", gorm.Expr("views + ?", total))
+			if result.Error != nil {
+				return result.Error
+			}
+			exists[imageID] = result.RowsAffected > 0
+		}
+
+		for i := 0; i < len(counts); {
+			day := model.ImageViewStat{ImageID: counts[i].ImageID, Day: counts[i].Day}
+			for ; i < len(counts) && counts[i].ImageID == day.ImageID && counts[i].Day == day.Day; i++ {
+				if !exists[day.ImageID] {
+					continue
+				}
+				day.Views += counts[i].Views
+				if counts[i].Host == "" {
+					continue
+				}
+				referrer := model.ImageReferrerStat{ImageID: day.ImageID, Day: day.Day, Host: counts[i].Host, Views: counts[i].Views}
+				if err := tx.Clauses(clause.OnConflict{
+					Columns:   []clause.Column{{Name: "image_id"}, {Name: "day"}, {Name: "host"}},
+					DoUpdates: clause.Assignments(map[string]interface{}{"views": gorm.Expr("image_referrer_stats.views + ?", referrer.Views)}),
+				}).Create(&referrer).Error; err != nil {
+					return err
+				}
+			}
+			if day.Views == 0 {
+				continue
+			}
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "image_id"}, {Name: "day"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{"views": gorm.Expr("image_view_stats.views + ?", day.Views)}),
+			}).Create(&day).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListImageDailyViews 按日期升序返回图片自 fromDay（含）起有访问记录的每日访问次数。
+func (r *ImageRepository) ListImageDailyViews(imageID uint, fromDay string) ([]model.ImageViewStat, error) {
+	var stats []model.ImageViewStat
+	if err := r.db.Where("image_id = ? AND day >= ?", imageID, fromDay).Order("day ASC").Find(&stats).Error; err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// ListImageTopReferrers 汇总图片自 fromDay（含）起各来源站点的访问次数，按次数降序返回前 limit 个。
+func (r *ImageRepository) ListImageTopReferrers(imageID uint, fromDay string, limit int) ([]model.ImageReferrerStat, error) {
+	var stats []model.ImageReferrerStat
+	if err := r.db.Model(&model.ImageReferrerStat{}).
+		Select("host, SUM(views) AS views").
+		Where("image_id = ? AND day >= ?", imageID, fromDay).
+		Group("host").
+		Order("SUM(views) DESC").Order("host ASC").
+		Limit(limit).
+		Scan(&stats).Error; err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// deleteImageStats 删除图片的访问统计，在永久删除图片的事务中调用。
+func deleteImageStats(tx *gorm.DB, imageIDs []uint) error {
+	if err := tx.Where("image_id IN ?", imageIDs).Delete(&model.ImageViewStat{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("image_id IN ?", imageIDs).Delete(&model.ImageReferrerStat{}).Error
+}
@@ -0,0 +1,192 @@
+package repository
+
+import (
+	"strings"
+
+	"perfect-pic-server/internal/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SetImageTags 以 names 整体替换图片的标签，不存在的标签按用户自动创建，不再被引用的标签随之删除。
+func (r *ImageRepository) SetImageTags(imageID uint, userID uint, names []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var previous []uint
+		if err := tx.Model(&model.ImageTag{}).Where("image_id = ?", imageID).Pluck("tag_id", &previous).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("image_id = ?", imageID).Delete(&model.ImageTag{}).Error; err != nil {
+			return err
+		}
+
+		if len(names) > 0 {
+			tags := make([]model.Tag, 0, len(names))
+			for _, name := range names {
+				tags = append(tags, model.Tag{UserID: userID, Name: name})
+			}
+			if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error; err != nil {
+				return err
+			}
+
+			var tagIDs []uint
+			if err := tx.Model(&model.Tag{}).Where("user_id = ? AND name IN ?", userID, names).Pluck("id", &tagIDs).Error; err != nil {
+				return err
+			}
+			links := make([]model.ImageTag, 0, len(tagIDs))
+			for _, tagID := range tagIDs {
+				links = append(links, model.ImageTag{ImageID: imageID, TagID: tagID})
+			}
+			if err := tx.Create(&links).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := pruneOrphanTags(tx, previous); err != nil {
+			return err
+		}
+		return refreshSearchDocuments(tx, []uint{imageID})
+	})
+}
+
+// likePrefixEscaper 转义 LIKE 通配符，使标签前缀按字面匹配。
+var likePrefixEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// ListUserTags 按使用次数降序列出用户的标签，prefix 非空时只返回以其开头的标签。
+func (r *ImageRepository) ListUserTags(userID uint, prefix string, limit int) ([]TagUsage, error) {
+	query := r.db.Table("tags").
+		Select("tags.id, tags.name, COUNT(image_tags.image_id) AS count").
+		Joins("JOIN image_tags ON image_tags.tag_id = tags.id").
+		Joins("JOIN images ON images.id = image_tags.image_id AND images.deleted_at IS NULL").
+		Where("tags.user_id = ?", userID)
+	if prefix != "" {
+		query = query.Where("tags.name LIKE ? ESCAPE '!'", likePrefixEscaper.Replace(prefix)+"%")
+	}
+
+	var tags []TagUsage
+	if err := query.Group("tags.id, tags.name").
+		Order("COUNT(image_tags.image_id) DESC").Order("tags.name ASC").
+		Limit(limit).Scan(&tags).Error; err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// FindTagNamesByImageIDs 返回每张图片按名称排序的标签名。
+func (r *ImageRepository) FindTagNamesByImageIDs(imageIDs []uint) (map[uint][]string, error) {
+	return findTagNames(r.db, imageIDs)
+}
+
+type imageTagName struct {
+	ImageID uint
+	Name    string
+}
+
+func findTagNames(db *gorm.DB, imageIDs []uint) (map[uint][]string, error) {
+	result := make(map[uint][]string)
+	if len(imageIDs) == 0 {
+		return result, nil
+	}
+
+	var rows []imageTagName
+	if err := db.Table("image_tags").
+		Select("image_tags.image_id, tags.name").
+		Joins("JOIN tags ON tags.id = image_tags.tag_id").
+		Where("image_tags.image_id IN ?", imageIDs).
+		Order("tags.name ASC").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		result[row.ImageID] = append(result[row.ImageID], row.Name)
+	}
+	return result, nil
+}
+
+// attachTags 为图片列表填充标签名。
+func attachTags(db *gorm.DB, images []model.Image) error {
+	if len(images) == 0 {
+		return nil
+	}
+	ids := make([]uint, 0, len(images))
+	for _, img := range images {
+		ids = append(ids, img.ID)
+	}
+	names, err := findTagNames(db, ids)
+	if err != nil {
+		return err
+	}
+	for i := range images {
+		images[i].Tags = names[images[i].ID]
+	}
+	return nil
+}
+
+// pruneOrphanTags 删除 tagIDs 中已没有任何图片引用的标签。
+func pruneOrphanTags(tx *gorm.DB, tagIDs []uint) error {
+	if len(tagIDs) == 0 {
+		return nil
+	}
+	return tx.Where("id IN ? AND id NOT IN (?)", tagIDs, tx.Model(&model.ImageTag{}).Select("tag_id")).
+		Delete(&model.Tag{}).Error
+}
+
+// detachImagesFromTags 在删除图片的事务内移除其标签关联与检索文档，并清理因此失去引用的标签。
+func detachImagesFromTags(tx *gorm.DB, imageIDs []uint) error {
+	var tagIDs []uint
+	if err := tx.Model(&model.ImageTag{}).Distinct("tag_id").Where("image_id IN ?", imageIDs).Pluck("tag_id", &tagIDs).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("image_id IN ?", imageIDs).Delete(&model.ImageTag{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("image_id IN ?", imageIDs).Delete(&model.ImageSearchDocument{}).Error; err != nil {
+		return err
+	}
+	return pruneOrphanTags(tx, tagIDs)
+}
+
+// refreshSearchDocuments 按图片当前的标题、描述、原始文件名与标签重建检索文档，没有可检索内容时删除文档。
+func refreshSearchDocuments(tx *gorm.DB, imageIDs []uint) error {
+	if len(imageIDs) == 0 {
+		return nil
+	}
+	var images []model.Image
+	if err := tx.Select("id", "original_name", "title", "description").Where("id IN ?", imageIDs).Find(&images).Error; err != nil {
+		return err
+	}
+	tagNames, err := findTagNames(tx, imageIDs)
+	if err != nil {
+		return err
+	}
+
+	var empty []uint
+	docs := make([]model.ImageSearchDocument, 0, len(images))
+	for _, img := range images {
+		var parts []string
+		for _, field := range []string{img.Title, img.Description, img.OriginalName} {
+			if field != "" {
+				parts = append(parts, field)
+			}
+		}
+		parts = append(parts, tagNames[img.ID]...)
+		if len(parts) == 0 {
+			empty = append(empty, img.ID)
+			continue
+		}
+		docs = append(docs, model.ImageSearchDocument{ImageID: img.ID, Content: strings.Join(parts, " ")})
+	}
+
+	if len(empty) > 0 {
+		if err := tx.Where("image_id IN ?", empty).Delete(&model.ImageSearchDocument{}).Error; err != nil {
+			return err
+		}
+	}
+	if len(docs) == 0 {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "image_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"content"}),
+	}).Create(&docs).Error
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"time"
+
+	"perfect-pic-server/internal/model"
+)
+
+// TrashImages 将图片移入回收站（软删除）。文件、配额、标签与相册关系均保持不变，以便恢复。
+func (r *ImageRepository) TrashImages(imageIDs []uint) error {
+	if len(imageIDs) == 0 {
+		return nil
+	}
+	return r.db.Where("id IN ?", imageIDs).Delete(&model.Image{}).Error
+}
+
+// ListTrashedByUserID 按删除时间倒序分页列出用户回收站中的图片。
+func (r *ImageRepository) ListTrashedByUserID(userID uint, offset int, limit int) ([]model.Image, int64, error) {
+	query := r.db.Unscoped().Model(&model.Image{}).Where("user_id = ? AND deleted_at IS NOT NULL", userID)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var images []model.Image
+	if err := query.Order("deleted_at DESC, id DESC").Offset(offset).Limit(limit).Find(&images).Error; err != nil {
+		return nil, 0, err
+	}
+	return images, total, nil
+}
+
+// FindTrashedByIDsAndUserID 查找用户回收站中指定 ID 的图片。
+func (r *ImageRepository) FindTrashedByIDsAndUserID(ids []uint, userID uint) ([]model.Image, error) {
+	var images []model.Image
+	if err := r.db.Unscoped().Where("id IN ? AND user_id = ? AND deleted_at IS NOT NULL", ids, userID).Find(&images).Error; err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+// FindTrashedBefore 查找在 cutoff 之前移入回收站的图片，最多返回 limit 条。
+func (r *ImageRepository) FindTrashedBefore(cutoff time.Time, limit int) ([]model.Image, error) {
+	var images []model.Image
+	if err := r.db.Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Order("deleted_at ASC, id ASC").
+		Limit(limit).
+		Find(&images).Error; err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+// RestoreImages 将用户回收站中的图片恢复，返回实际恢复的数量。
+func (r *ImageRepository) RestoreImages(imageIDs []uint, userID uint) (int64, error) {
+	if len(imageIDs) == 0 {
+		return 0, nil
+	}
+	tx := r.db.Unscoped().Model(&model.Image{}).
+		Where("id IN ? AND user_id = ? AND deleted_at IS NOT NULL", imageIDs, userID).
+		UpdateColumn("deleted_at", nil)
+	return tx.RowsAffected, tx.Error
+}
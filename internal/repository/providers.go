@@ -25,10 +25,20 @@ func NewPasskeyRepository(db *gorm.DB) PasskeyStore {
 	return &PasskeyRepository{db: db}
 }
 
+func NewAlbumRepository(db *gorm.DB) AlbumStore {
+	return &AlbumRepository{db: db}
+}
+
+func NewAccessTokenRepository(db *gorm.DB) AccessTokenStore {
+	return &AccessTokenRepository{db: db}
+}
+
 var RepoSet = wire.NewSet(
 	NewUserRepository,
 	NewImageRepository,
 	NewSettingRepository,
 	NewSystemRepository,
 	NewPasskeyRepository,
+	NewAlbumRepository,
+	NewAccessTokenRepository,
 )
@@ -129,9 +129,36 @@ func (r *UserRepository) HardDeleteUserWithImages(userID uint) error {
 		if err := tx.Unscoped().First(&user, userID).Error; err != nil {
 			return err
 		}
+		if err := tx.Where("image_id IN (?)", tx.Model(&model.Image{}).Unscoped().Select("id").Where("user_id = ?", userID)).
+			Delete(&model.ImageSearchDocument{}).Error; err != nil {
+			return err
+		}
+		for _, stat := range []interface{}{&model.ImageViewStat{}, &model.ImageReferrerStat{}} {
+			if err := tx.Where("image_id IN (?)", tx.Model(&model.Image{}).Unscoped().Select("id").Where("user_id = ?", userID)).
+				Delete(stat).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("tag_id IN (?)", tx.Model(&model.Tag{}).Select("id").Where("user_id = ?", userID)).
+			Delete(&model.ImageTag{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&model.Tag{}).Error; err != nil {
+			return err
+		}
 		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&model.Image{}).Error; err != nil {
 			return err
 		}
+		if err := tx.Where("album_id IN (?)", tx.Model(&model.Album{}).Select("id").Where("user_id = ?", userID)).
+			Delete(&model.AlbumImage{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&model.Album{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&model.PersonalAccessToken{}).Error; err != nil {
+			return err
+		}
 		return tx.Unscoped().Delete(&user).Error
 	})
 }
@@ -24,6 +24,7 @@ func registerAdminRoutes(
 	uploadBodyLimit := bodyLimitMiddleware.UploadBodyLimitMiddleware()
 
 	adminGroup.GET("/stats", systemHandler.GetServerStats)
+	adminGroup.POST("/storage/reconcile", systemHandler.ReconcileStorage)
 
 	adminGroup.GET("/settings", settingsHandler.GetSettings)
 	adminGroup.PATCH("/settings", bodyLimit, settingsHandler.UpdateSettings)
@@ -41,4 +42,5 @@ func registerAdminRoutes(
 	adminGroup.GET("/images", imageHandler.GetImageList)
 	adminGroup.DELETE("/images/batch", bodyLimit, imageHandler.BatchDeleteImages)
 	adminGroup.DELETE("/images/:id", imageHandler.DeleteImage)
+	adminGroup.PUT("/images/:id/short-code", bodyLimit, imageHandler.SetImageShortCode)
 }
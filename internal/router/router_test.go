@@ -10,6 +10,8 @@ import (
 	pkgmail "perfect-pic-server/internal/pkg/email"
 	jwtpkg "perfect-pic-server/internal/pkg/jwt"
 	"perfect-pic-server/internal/pkg/ratelimit"
+	"perfect-pic-server/internal/pkg/storage"
+	"perfect-pic-server/internal/pkg/urlsign"
 	"perfect-pic-server/internal/repository"
 	"perfect-pic-server/internal/service"
 	"perfect-pic-server/internal/testutils"
@@ -41,26 +43,32 @@ func TestInitRouter_RegistersCoreRoutes(t *testing.T) {
 	authService := service.NewAuthService(dbConfig, tokenService)
 	captchaService := service.NewCaptchaService(dbConfig)
 	userService := service.NewUserService(userStore, dbConfig, cacheStore, tokenService)
-	imageService := service.NewImageService(imageStore, dbConfig, staticConfig)
+	storages, err := storage.NewManager(config.NewStorageConfig(staticConfig))
+	if err != nil {
+		t.Fatalf("init storage: %v", err)
+	}
+	imageService := service.NewImageService(imageStore, dbConfig, staticConfig, storages, urlsign.NewSigner(config.NewURLSignConfig(staticConfig)), nil)
 	emailService := service.NewEmailService(dbConfig, pkgmail.NewMailer(), staticConfig)
 	initService := service.NewInitService(systemStore, dbConfig)
 	passkeyService := service.NewPasskeyService(passkeyStore, dbConfig, cacheStore)
 	settingsService := service.NewSettingsService(settingStore, dbConfig)
+	accessTokenService := service.NewAccessTokenService(repository.NewAccessTokenRepository(gdb))
+	albumService := service.NewAlbumService(repository.NewAlbumRepository(gdb), imageStore)
 
 	authUseCase := appuc.NewAuthUseCase(authService, userStore, userService, emailService, initService, dbConfig)
 	userUseCase := appuc.NewUserUseCase(authService, userService, userStore, emailService, dbConfig)
-	imageUseCase := appuc.NewImageUseCase(imageService, userService, userStore, staticConfig, dbConfig)
+	imageUseCase := appuc.NewImageUseCase(imageService, service.NewTusService(dbConfig, staticConfig, cacheStore), userService, userStore, staticConfig, dbConfig)
 	passkeyUseCase := appuc.NewPasskeyUseCase(passkeyService, passkeyStore, authService, userStore)
 	userManageUseCase := adminuc.NewUserManageUseCase(userService, imageService, passkeyService)
 	settingsUseCase := adminuc.NewSettingsUseCase(emailService)
-	statUseCase := adminuc.NewStatUseCase(imageStore, userStore)
+	statUseCase := adminuc.NewStatUseCase(imageStore, userStore, imageService)
 
 	authHandler := handler.NewAuthHandler(authService, captchaService, authUseCase, initService, dbConfig, passkeyUseCase)
-	systemHandler := handler.NewSystemHandler(initService, statUseCase, dbConfig, staticConfig, userService)
+	systemHandler := handler.NewSystemHandler(initService, statUseCase, dbConfig, staticConfig, storages, userService, imageService)
 	settingsHandler := handler.NewSettingsHandler(settingsService, settingsUseCase)
-	userHandler := handler.NewUserHandler(userService, userUseCase, userManageUseCase, imageService, imageUseCase, authService, passkeyService, passkeyUseCase)
-	imageHandler := handler.NewImageHandler(imageService, imageUseCase)
-	authMiddleware := middleware.NewAuthMiddleware(tokenService, userService)
+	userHandler := handler.NewUserHandler(userService, userUseCase, userManageUseCase, imageService, imageUseCase, authService, passkeyService, passkeyUseCase, accessTokenService, appuc.NewIntegrationUseCase(accessTokenService, dbConfig))
+	imageHandler := handler.NewImageHandler(imageService, imageUseCase, albumService)
+	authMiddleware := middleware.NewAuthMiddleware(tokenService, userService, accessTokenService)
 	rateLimitMiddleware := middleware.NewRateLimitMiddleware(
 		dbConfig,
 		ratelimit.NewTokenBucketLimiter(nil),
@@ -99,7 +107,40 @@ func TestInitRouter_RegistersCoreRoutes(t *testing.T) {
 		{method: "POST", path: "/api/user/passkeys/register/start"},
 		{method: "POST", path: "/api/user/passkeys/register/finish"},
 		{method: "GET", path: "/api/user/ping"},
+		{method: "GET", path: "/api/user/tokens"},
+		{method: "POST", path: "/api/user/tokens"},
+		{method: "DELETE", path: "/api/user/tokens/:id"},
+		{method: "POST", path: "/api/user/upload/batch"},
+		{method: "POST", path: "/api/user/upload/url"},
+		{method: "POST", path: "/api/user/integrations/upload"},
+		{method: "GET", path: "/api/user/integrations/sharex.sxcu"},
+		{method: "GET", path: "/api/user/integrations/picgo.json"},
+		{method: "GET", path: "/api/integrations/delete/:id"},
+		{method: "POST", path: "/api/integrations/delete/:id"},
+		{method: "DELETE", path: "/api/integrations/delete/:id"},
+		{method: "OPTIONS", path: "/api/user/uploads/tus"},
+		{method: "POST", path: "/api/user/uploads/tus"},
+		{method: "HEAD", path: "/api/user/uploads/tus/:id"},
+		{method: "PATCH", path: "/api/user/uploads/tus/:id"},
+		{method: "DELETE", path: "/api/user/uploads/tus/:id"},
+		{method: "PATCH", path: "/api/user/images/:id"},
+		{method: "PUT", path: "/api/user/images/:id/tags"},
+		{method: "POST", path: "/api/user/images/:id/signed-url"},
+		{method: "GET", path: "/api/user/images/:id/stats"},
+		{method: "GET", path: "/api/user/tags"},
+		{method: "GET", path: "/api/user/trash"},
+		{method: "POST", path: "/api/user/trash/restore"},
+		{method: "DELETE", path: "/api/user/trash/batch"},
+		{method: "DELETE", path: "/api/user/trash"},
+		{method: "GET", path: "/api/user/albums"},
+		{method: "POST", path: "/api/user/albums"},
+		{method: "PATCH", path: "/api/user/albums/:id"},
+		{method: "DELETE", path: "/api/user/albums/:id"},
+		{method: "POST", path: "/api/user/albums/:id/images"},
+		{method: "DELETE", path: "/api/user/albums/:id/images"},
 		{method: "GET", path: "/api/admin/stats"},
+		{method: "POST", path: "/api/admin/storage/reconcile"},
+		{method: "PUT", path: "/api/admin/images/:id/short-code"},
 	}
 
 	have := make(map[string]bool)
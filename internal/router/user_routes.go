@@ -19,6 +19,13 @@ func registerUserRoutes(
 	userGroup := api.Group("/user")
 	userGroup.Use(authMiddleware.JWTAuth())
 	userGroup.Use(authMiddleware.UserStatusCheck())
+	// 以下分组额外接受具备对应权限范围的个人访问令牌，路径前缀与 userGroup 相同
+	scopedGroup := func(scope string) *gin.RouterGroup {
+		return api.Group("/user", authMiddleware.JWTAuth(scope), authMiddleware.UserStatusCheck())
+	}
+	uploadGroup := scopedGroup(consts.AccessTokenScopeUpload)
+	readGroup := scopedGroup(consts.AccessTokenScopeRead)
+	deleteGroup := scopedGroup(consts.AccessTokenScopeDelete)
 	bodyLimit := bodyLimitMiddleware.BodyLimitMiddleware()
 
 	// 修改用户名请求间隔：读取配置（秒）
@@ -28,8 +35,13 @@ func registerUserRoutes(
 	// 上传限流：读取配置
 	uploadLimiter := rateLimitMiddleware.RateLimit(consts.ConfigRateLimitUploadRPS, consts.ConfigRateLimitUploadBurst)
 	uploadBodyLimit := bodyLimitMiddleware.UploadBodyLimitMiddleware()
+	encodedUploadBodyLimit := bodyLimitMiddleware.EncodedUploadBodyLimitMiddleware()
+	batchUploadBodyLimit := bodyLimitMiddleware.BatchUploadBodyLimitMiddleware()
 
-	userGroup.GET("/profile", userHandler.GetSelfInfo)
+	readGroup.GET("/profile", userHandler.GetSelfInfo)
+	userGroup.GET("/tokens", userHandler.ListSelfAccessTokens)
+	userGroup.POST("/tokens", bodyLimit, userHandler.CreateSelfAccessToken)
+	userGroup.DELETE("/tokens/:id", userHandler.RevokeSelfAccessToken)
 	userGroup.GET("/passkeys", userHandler.ListSelfPasskeys)
 	userGroup.DELETE("/passkeys/:id", userHandler.DeleteSelfPasskey)
 	userGroup.PATCH("/passkeys/:id/name", bodyLimit, userHandler.UpdateSelfPasskeyName)
@@ -40,12 +52,51 @@ func registerUserRoutes(
 	userGroup.POST("/email", bodyLimit, emailLimiter, userHandler.RequestUpdateEmail)
 
 	userGroup.PATCH("/avatar", uploadBodyLimit, uploadLimiter, userHandler.UpdateSelfAvatar)
-	userGroup.POST("/upload", uploadBodyLimit, uploadLimiter, imageHandler.UploadImage)
+	uploadGroup.POST("/upload", uploadBodyLimit, uploadLimiter, imageHandler.UploadImage)
+	uploadGroup.POST("/upload/batch", batchUploadBodyLimit, uploadLimiter, imageHandler.BatchUploadImages)
+	uploadGroup.POST("/upload/url", encodedUploadBodyLimit, uploadLimiter, imageHandler.UploadImageByURL)
 
-	userGroup.GET("/images", imageHandler.GetMyImages)
-	userGroup.DELETE("/images/batch", bodyLimit, imageHandler.BatchDeleteMyImages)
-	userGroup.DELETE("/images/:id", imageHandler.DeleteMyImage)
-	userGroup.GET("/images/count", userHandler.GetSelfImagesCount)
+	// PicGo / ShareX / Typora 等客户端：兼容上传接口接受 upload 权限的访问令牌，配置文件生成仅限登录令牌
+	uploadGroup.POST("/integrations/upload", uploadBodyLimit, uploadLimiter, imageHandler.IntegrationUploadImage)
+	userGroup.GET("/integrations/sharex.sxcu", userHandler.GetShareXConfig)
+	userGroup.GET("/integrations/picgo.json", userHandler.GetPicGoConfig)
+	// 兼容上传响应中的删除链接自带签名与过期时间，无需登录；GET 仅展示确认页，POST/DELETE 执行删除
+	api.GET("/integrations/delete/:id", imageHandler.ConfirmDeleteImageBySignedLink)
+	api.POST("/integrations/delete/:id", imageHandler.DeleteImageBySignedLink)
+	api.DELETE("/integrations/delete/:id", imageHandler.DeleteImageBySignedLink)
+
+	// 可续传上传（tus 1.0）：分片大小由 Upload-Length 约束，不经过整体上传请求体限制
+	tusGroup := uploadGroup.Group("/uploads/tus")
+	tusGroup.OPTIONS("", imageHandler.TusOptions)
+	tusGroup.POST("", bodyLimit, uploadLimiter, imageHandler.CreateTusUpload)
+	tusGroup.HEAD("/:id", imageHandler.HeadTusUpload)
+	tusGroup.PATCH("/:id", imageHandler.PatchTusUpload)
+	tusGroup.DELETE("/:id", imageHandler.DeleteTusUpload)
+
+	readGroup.GET("/images", imageHandler.GetMyImages)
+	deleteGroup.DELETE("/images/batch", bodyLimit, imageHandler.BatchDeleteMyImages)
+	deleteGroup.DELETE("/images/:id", imageHandler.DeleteMyImage)
+	readGroup.GET("/images/count", userHandler.GetSelfImagesCount)
+	readGroup.GET("/images/:id", imageHandler.GetMyImageDetail)
+	readGroup.GET("/images/:id/stats", imageHandler.GetMyImageStats)
+	userGroup.PATCH("/images/:id", bodyLimit, imageHandler.UpdateMyImage)
+	userGroup.PUT("/images/:id/tags", bodyLimit, imageHandler.SetMyImageTags)
+	userGroup.POST("/images/:id/signed-url", bodyLimit, imageHandler.CreateMyImageSignedURL)
+	readGroup.GET("/tags", imageHandler.ListMyTags)
+
+	// 回收站：删除的图片在保留期内可恢复，永久删除后释放存储空间
+	readGroup.GET("/trash", imageHandler.ListMyTrash)
+	deleteGroup.POST("/trash/restore", bodyLimit, imageHandler.RestoreMyTrash)
+	deleteGroup.DELETE("/trash/batch", bodyLimit, imageHandler.DeleteMyTrash)
+	deleteGroup.DELETE("/trash", imageHandler.EmptyMyTrash)
+
+	readGroup.GET("/albums", imageHandler.ListMyAlbums)
+	userGroup.POST("/albums", bodyLimit, imageHandler.CreateMyAlbum)
+	readGroup.GET("/albums/:id", imageHandler.GetMyAlbum)
+	userGroup.PATCH("/albums/:id", bodyLimit, imageHandler.UpdateMyAlbum)
+	userGroup.DELETE("/albums/:id", imageHandler.DeleteMyAlbum)
+	userGroup.POST("/albums/:id/images", bodyLimit, imageHandler.AddMyAlbumImages)
+	userGroup.DELETE("/albums/:id/images", bodyLimit, imageHandler.RemoveMyAlbumImages)
 
 	userGroup.GET("/ping", func(c *gin.Context) {
 		c.JSON(200, gin.H{"message": "pong with auth"})
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	commonpkg "perfect-pic-server/internal/common"
+	"perfect-pic-server/internal/consts"
+	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/model"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// accessTokenBytes 令牌明文的随机字节数。
+const accessTokenBytes = 32
+
+// accessTokenHintLength 列表中展示的令牌开头长度（含 ppt_ 前缀）。
+const accessTokenHintLength = 12
+
+// accessTokenTouchInterval 同一来源 IP 重复使用令牌时，最近使用时间的最小更新间隔，避免每个请求都写库。
+const accessTokenTouchInterval = time.Minute
+
+// ListUserAccessTokens 获取用户的个人访问令牌列表。
+func (s *AccessTokenService) ListUserAccessTokens(userID uint) ([]moduledto.AccessTokenResponse, error) {
+	tokens, err := s.tokenStore.ListAccessTokensByUserID(userID)
+	if err != nil {
+		log.Printf("List access tokens error: %v\n", err)
+		return nil, commonpkg.NewInternalError("读取访问令牌列表失败")
+	}
+
+	items := make([]moduledto.AccessTokenResponse, 0, len(tokens))
+	for i := range tokens {
+		items = append(items, buildAccessTokenResponse(&tokens[i]))
+	}
+	return items, nil
+}
+
+// CreateUserAccessToken 创建个人访问令牌，返回的明文令牌只在此时可见。
+func (s *AccessTokenService) CreateUserAccessToken(userID uint, req moduledto.CreateAccessTokenRequest) (*moduledto.CreatedAccessTokenResponse, error) {
+	return s.createAccessToken(userID, req, "")
+}
+
+// RotateSourceAccessToken 为客户端配置签发来源为 source 的令牌，并吊销该用户此前同来源的令牌，
+// 每个用户每个来源只保留一个；手动创建的令牌（来源为空）不受影响。
+func (s *AccessTokenService) RotateSourceAccessToken(userID uint, source string, req moduledto.CreateAccessTokenRequest) (*moduledto.CreatedAccessTokenResponse, error) {
+	if source == "" {
+		return nil, commonpkg.NewValidationError("令牌来源不能为空")
+	}
+	tokens, err := s.tokenStore.ListAccessTokensByUserID(userID)
+	if err != nil {
+		log.Printf("List access tokens error: %v\n", err)
+		return nil, commonpkg.NewInternalError("签发访问令牌失败")
+	}
+	for _, token := range tokens {
+		if token.Source != source {
+			continue
+		}
+		if err := s.RevokeUserAccessToken(userID, token.ID); err != nil {
+			return nil, err
+		}
+	}
+	return s.createAccessToken(userID, req, source)
+}
+
+func (s *AccessTokenService) createAccessToken(userID uint, req moduledto.CreateAccessTokenRequest, source string) (*moduledto.CreatedAccessTokenResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > consts.AccessTokenNameMaxRunes {
+		return nil, commonpkg.NewValidationError(fmt.Sprintf("令牌名称长度必须为 1-%d 个字符", consts.AccessTokenNameMaxRunes))
+	}
+	scopes, err := normalizeAccessTokenScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if req.ExpiresInDays < 0 || req.ExpiresInDays > consts.AccessTokenMaxExpireDays {
+		return nil, commonpkg.NewValidationError(fmt.Sprintf("有效期必须为 0-%d 天（0 表示永不过期）", consts.AccessTokenMaxExpireDays))
+	}
+
+	count, err := s.tokenStore.CountAccessTokensByUserID(userID)
+	if err != nil {
+		log.Printf("Count access tokens error: %v\n", err)
+		return nil, commonpkg.NewInternalError("创建访问令牌失败")
+	}
+	if count >= consts.MaxUserAccessTokenCount {
+		return nil, commonpkg.NewValidationError(fmt.Sprintf("最多只能创建 %d 个访问令牌", consts.MaxUserAccessTokenCount))
+	}
+
+	b := make([]byte, accessTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return nil, commonpkg.NewInternalError("生成访问令牌失败")
+	}
+	plain := consts.AccessTokenPrefix + hex.EncodeToString(b)
+
+	token := model.PersonalAccessToken{
+		UserID:    userID,
+		Name:      name,
+		TokenHash: hashAccessToken(plain),
+		TokenHint: plain[:accessTokenHintLength],
+		Scopes:    strings.Join(scopes, ","),
+		Source:    source,
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+	if err := s.tokenStore.CreateAccessToken(&token); err != nil {
+		log.Printf("Create access token error: %v\n", err)
+		return nil, commonpkg.NewInternalError("创建访问令牌失败")
+	}
+
+	return &moduledto.CreatedAccessTokenResponse{
+		AccessTokenResponse: buildAccessTokenResponse(&token),
+		Token:               plain,
+	}, nil
+}
+
+// RevokeUserAccessToken 吊销（删除）用户的某个个人访问令牌，立即生效。
+func (s *AccessTokenService) RevokeUserAccessToken(userID uint, tokenID uint) error {
+	if tokenID == 0 {
+		return commonpkg.NewValidationError("无效的令牌 ID")
+	}
+	if err := s.tokenStore.DeleteAccessTokenByID(userID, tokenID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return commonpkg.NewNotFoundError("访问令牌不存在")
+		}
+		log.Printf("Delete access token error: %v\n", err)
+		return commonpkg.NewInternalError("吊销访问令牌失败")
+	}
+	return nil
+}
+
+// AuthenticateAccessToken 校验 ppt_ 开头的令牌明文，返回未过期的令牌记录（已预加载所属用户）。
+func (s *AccessTokenService) AuthenticateAccessToken(plain string) (*model.PersonalAccessToken, error) {
+	if !strings.HasPrefix(plain, consts.AccessTokenPrefix) || len(plain) != len(consts.AccessTokenPrefix)+accessTokenBytes*2 {
+		return nil, commonpkg.NewUnauthorizedError("访问令牌无效")
+	}
+	token, err := s.tokenStore.FindAccessTokenByHash(hashAccessToken(plain))
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Find access token error: %v\n", err)
+		}
+		return nil, commonpkg.NewUnauthorizedError("访问令牌无效")
+	}
+	if token.ExpiresAt != nil && time.Now().After(*token.ExpiresAt) {
+		return nil, commonpkg.NewUnauthorizedError("访问令牌已过期")
+	}
+	return token, nil
+}
+
+// TouchAccessToken 异步记录令牌的最近使用时间与来源 IP，不阻塞当前请求。
+func (s *AccessTokenService) TouchAccessToken(token *model.PersonalAccessToken, ip string) {
+	now := time.Now()
+	if token.LastUsedAt != nil && token.LastUsedIP == ip && now.Sub(*token.LastUsedAt) < accessTokenTouchInterval {
+		return
+	}
+	tokenID := token.ID
+	go func() {
+		if err := s.tokenStore.UpdateAccessTokenLastUsed(tokenID, now, ip); err != nil {
+			log.Printf("Update access token last used error: %v\n", err)
+		}
+	}()
+}
+
+// normalizeAccessTokenScopes 校验并去重权限范围，按固定顺序返回。
+func normalizeAccessTokenScopes(scopes []string) ([]string, error) {
+	requested := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		if !slices.Contains(consts.AccessTokenScopes, scope) {
+			return nil, commonpkg.NewValidationError(fmt.Sprintf("不支持的权限范围: %s，可选值为 %s", scope, strings.Join(consts.AccessTokenScopes, "、")))
+		}
+		requested[scope] = true
+	}
+	if len(requested) == 0 {
+		return nil, commonpkg.NewValidationError("至少需要选择一个权限范围")
+	}
+
+	normalized := make([]string, 0, len(requested))
+	for _, scope := range consts.AccessTokenScopes {
+		if requested[scope] {
+			normalized = append(normalized, scope)
+		}
+	}
+	return normalized, nil
+}
+
+func hashAccessToken(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+func buildAccessTokenResponse(token *model.PersonalAccessToken) moduledto.AccessTokenResponse {
+	resp := moduledto.AccessTokenResponse{
+		ID:         token.ID,
+		Name:       token.Name,
+		TokenHint:  token.TokenHint,
+		Scopes:     strings.Split(token.Scopes, ","),
+		Source:     token.Source,
+		CreatedAt:  token.CreatedAt.Unix(),
+		LastUsedIP: token.LastUsedIP,
+	}
+	if token.ExpiresAt != nil {
+		expiresAt := token.ExpiresAt.Unix()
+		resp.ExpiresAt = &expiresAt
+	}
+	if token.LastUsedAt != nil {
+		lastUsedAt := token.LastUsedAt.Unix()
+		resp.LastUsedAt = &lastUsedAt
+	}
+	return resp
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"perfect-pic-server/internal/common"
+	"perfect-pic-server/internal/consts"
+	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/model"
+	"perfect-pic-server/internal/repository"
+)
+
+// 测试内容：验证创建访问令牌只保存摘要、权限范围去重排序，认证成功后可列出并吊销，吊销后立即失效。
+func TestAccessTokenService_CreateAuthenticateRevoke(t *testing.T) {
+	setupTestDB(t)
+	s := NewAccessTokenService(repository.NewAccessTokenRepository(testGormDB))
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+
+	created, err := s.CreateUserAccessToken(u.ID, moduledto.CreateAccessTokenRequest{
+		Name:          " PicGo ",
+		Scopes:        []string{"read", "UPLOAD", "read"},
+		ExpiresInDays: 30,
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if !strings.HasPrefix(created.Token, consts.AccessTokenPrefix) || !strings.HasPrefix(created.Token, created.TokenHint) {
+		t.Fatalf("令牌格式不符: %+v", created)
+	}
+	if created.Name != "PicGo" || strings.Join(created.Scopes, ",") != "upload,read" || created.ExpiresAt == nil {
+		t.Fatalf("创建结果不符: %+v", created)
+	}
+
+	var stored model.PersonalAccessToken
+	_ = testGormDB.First(&stored, created.ID).Error
+	if stored.TokenHash == created.Token || stored.TokenHash != hashAccessToken(created.Token) {
+		t.Fatalf("期望仅保存令牌摘要")
+	}
+
+	token, err := s.AuthenticateAccessToken(created.Token)
+	if err != nil {
+		t.Fatalf("authenticate: %v", err)
+	}
+	if token.UserID != u.ID || token.User.Username != "alice" || !token.HasAnyScope(consts.AccessTokenScopeUpload) || token.HasAnyScope(consts.AccessTokenScopeDelete) {
+		t.Fatalf("认证结果不符: %+v", token)
+	}
+
+	list, err := s.ListUserAccessTokens(u.ID)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("期望 1 个令牌，实际为 %v %v", list, err)
+	}
+
+	assertServiceErrorCode(t, s.RevokeUserAccessToken(u.ID+1, created.ID), common.ErrorCodeNotFound)
+	if err := s.RevokeUserAccessToken(u.ID, created.ID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	_, err = s.AuthenticateAccessToken(created.Token)
+	assertServiceErrorCode(t, err, common.ErrorCodeUnauthorized)
+}
+
+// 测试内容：验证名称、权限范围、有效期的校验，以及过期令牌与格式错误的令牌无法认证。
+func TestAccessTokenService_Validation(t *testing.T) {
+	setupTestDB(t)
+	s := NewAccessTokenService(repository.NewAccessTokenRepository(testGormDB))
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+
+	invalid := []moduledto.CreateAccessTokenRequest{
+		{Name: "  ", Scopes: []string{"read"}},
+		{Name: strings.Repeat("名", consts.AccessTokenNameMaxRunes+1), Scopes: []string{"read"}},
+		{Name: "x", Scopes: nil},
+		{Name: "x", Scopes: []string{"admin"}},
+		{Name: "x", Scopes: []string{"read"}, ExpiresInDays: -1},
+		{Name: "x", Scopes: []string{"read"}, ExpiresInDays: consts.AccessTokenMaxExpireDays + 1},
+	}
+	for _, req := range invalid {
+		_, err := s.CreateUserAccessToken(u.ID, req)
+		assertServiceErrorCode(t, err, common.ErrorCodeValidation)
+	}
+
+	created, err := s.CreateUserAccessToken(u.ID, moduledto.CreateAccessTokenRequest{Name: "x", Scopes: []string{"read"}})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if created.ExpiresAt != nil {
+		t.Fatalf("期望有效期为 0 时永不过期")
+	}
+	_ = testGormDB.Model(&model.PersonalAccessToken{}).Where("id = ?", created.ID).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error
+	_, err = s.AuthenticateAccessToken(created.Token)
+	assertServiceErrorCode(t, err, common.ErrorCodeUnauthorized)
+
+	for _, raw := range []string{"", "ppt_short", strings.Repeat("a", len(created.Token))} {
+		_, err := s.AuthenticateAccessToken(raw)
+		assertServiceErrorCode(t, err, common.ErrorCodeUnauthorized)
+	}
+}
+
+// 测试内容：验证按来源轮换令牌只吊销同来源的旧令牌，同名的手动令牌与其它来源的令牌保持可用。
+func TestAccessTokenService_RotateSourceAccessToken(t *testing.T) {
+	setupTestDB(t)
+	s := NewAccessTokenService(repository.NewAccessTokenRepository(testGormDB))
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+
+	req := moduledto.CreateAccessTokenRequest{Name: "ShareX（客户端配置）", Scopes: []string{consts.AccessTokenScopeUpload}}
+	manual, err := s.CreateUserAccessToken(u.ID, req)
+	if err != nil || manual.Source != "" {
+		t.Fatalf("期望手动令牌来源为空，实际为 %+v %v", manual, err)
+	}
+	picgo, _ := s.RotateSourceAccessToken(u.ID, consts.AccessTokenSourcePicGo, req)
+	first, err := s.RotateSourceAccessToken(u.ID, consts.AccessTokenSourceShareX, req)
+	if err != nil || first.Source != consts.AccessTokenSourceShareX {
+		t.Fatalf("签发结果不符: %+v %v", first, err)
+	}
+	second, err := s.RotateSourceAccessToken(u.ID, consts.AccessTokenSourceShareX, req)
+	if err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+
+	_, err = s.AuthenticateAccessToken(first.Token)
+	assertServiceErrorCode(t, err, common.ErrorCodeUnauthorized)
+	for _, token := range []string{manual.Token, picgo.Token, second.Token} {
+		if _, err := s.AuthenticateAccessToken(token); err != nil {
+			t.Fatalf("期望令牌仍可用: %v", err)
+		}
+	}
+
+	_, err = s.RotateSourceAccessToken(u.ID, "", req)
+	assertServiceErrorCode(t, err, common.ErrorCodeValidation)
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"errors"
+	"log"
+	commonpkg "perfect-pic-server/internal/common"
+	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/model"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+const (
+	maxAlbumTitleLength       = 100
+	maxAlbumDescriptionLength = 1000
+)
+
+// ListAlbums 分页获取用户的相册列表。
+func (s *AlbumService) ListAlbums(userID uint, page, pageSize int) ([]moduledto.AlbumResponse, int64, int, int, error) {
+	page, pageSize = normalizePagination(page, pageSize)
+	albums, total, err := s.albumStore.ListAlbumsByUserID(userID, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, 0, page, pageSize, commonpkg.NewInternalError("获取相册列表失败")
+	}
+
+	items, err := s.buildAlbumResponses(albums)
+	if err != nil {
+		return nil, 0, page, pageSize, err
+	}
+	return items, total, page, pageSize, nil
+}
+
+// GetAlbum 获取用户自己的相册详情。
+func (s *AlbumService) GetAlbum(userID uint, albumID uint) (*moduledto.AlbumResponse, error) {
+	album, err := s.findAlbum(userID, albumID)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.buildAlbumResponses([]model.Album{*album})
+	if err != nil {
+		return nil, err
+	}
+	return &items[0], nil
+}
+
+// CreateAlbum 创建相册。
+func (s *AlbumService) CreateAlbum(userID uint, req moduledto.CreateAlbumRequest) (*moduledto.AlbumResponse, error) {
+	title, err := normalizeAlbumTitle(req.Title)
+	if err != nil {
+		return nil, err
+	}
+	description, err := normalizeAlbumDescription(req.Description)
+	if err != nil {
+		return nil, err
+	}
+	visibility := model.AlbumVisibilityPrivate
+	if req.Visibility != "" {
+		if visibility, err = normalizeAlbumVisibility(req.Visibility); err != nil {
+			return nil, err
+		}
+	}
+
+	album := model.Album{
+		UserID:      userID,
+		Title:       title,
+		Description: description,
+		SortOrder:   req.SortOrder,
+		Visibility:  visibility,
+	}
+	if err := s.albumStore.CreateAlbum(&album); err != nil {
+		log.Printf("Create album error: %v\n", err)
+		return nil, commonpkg.NewInternalError("创建相册失败")
+	}
+	return &moduledto.AlbumResponse{Album: album}, nil
+}
+
+// UpdateAlbum 更新相册信息，封面必须是相册内的图片。
+func (s *AlbumService) UpdateAlbum(userID uint, albumID uint, req moduledto.UpdateAlbumRequest) (*moduledto.AlbumResponse, error) {
+	if _, err := s.findAlbum(userID, albumID); err != nil {
+		return nil, err
+	}
+
+	updates := make(map[string]interface{})
+	if req.Title != nil {
+		title, err := normalizeAlbumTitle(*req.Title)
+		if err != nil {
+			return nil, err
+		}
+		updates["title"] = title
+	}
+	if req.Description != nil {
+		description, err := normalizeAlbumDescription(*req.Description)
+		if err != nil {
+			return nil, err
+		}
+		updates["description"] = description
+	}
+	if req.Visibility != nil {
+		visibility, err := normalizeAlbumVisibility(*req.Visibility)
+		if err != nil {
+			return nil, err
+		}
+		updates["visibility"] = visibility
+	}
+	if req.SortOrder != nil {
+		updates["sort_order"] = *req.SortOrder
+	}
+	if req.CoverImageID != nil {
+		if *req.CoverImageID == 0 {
+			updates["cover_image_id"] = nil
+		} else {
+			ok, err := s.albumStore.HasAlbumImage(albumID, *req.CoverImageID)
+			if err != nil {
+				return nil, commonpkg.NewInternalError("更新相册失败")
+			}
+			if !ok {
+				return nil, commonpkg.NewValidationError("封面必须是相册内的图片")
+			}
+			updates["cover_image_id"] = *req.CoverImageID
+		}
+	}
+
+	if len(updates) > 0 {
+		if err := s.albumStore.UpdateAlbum(albumID, userID, updates); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, commonpkg.NewNotFoundError("相册不存在")
+			}
+			log.Printf("Update album error: %v\n", err)
+			return nil, commonpkg.NewInternalError("更新相册失败")
+		}
+	}
+	return s.GetAlbum(userID, albumID)
+}
+
+// DeleteAlbum 删除相册，相册内的图片不会被删除。
+func (s *AlbumService) DeleteAlbum(userID uint, albumID uint) error {
+	if err := s.albumStore.DeleteAlbum(albumID, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return commonpkg.NewNotFoundError("相册不存在")
+		}
+		log.Printf("Delete album error: %v\n", err)
+		return commonpkg.NewInternalError("删除相册失败")
+	}
+	return nil
+}
+
+// AddAlbumImages 将用户自己的图片加入相册，不属于该用户的 ID 会被忽略；返回实际新增的数量。
+func (s *AlbumService) AddAlbumImages(userID uint, albumID uint, imageIDs []uint) (int64, error) {
+	if _, err := s.findAlbum(userID, albumID); err != nil {
+		return 0, err
+	}
+	images, err := s.imageStore.FindByIDsAndUserID(imageIDs, userID)
+	if err != nil {
+		return 0, commonpkg.NewInternalError("查找图片失败")
+	}
+	if len(images) == 0 {
+		return 0, commonpkg.NewNotFoundError("未找到指定图片或无权操作")
+	}
+
+	ids := make([]uint, 0, len(images))
+	for _, img := range images {
+		ids = append(ids, img.ID)
+	}
+	added, err := s.albumStore.AddAlbumImages(albumID, ids, time.Now().Unix())
+	if err != nil {
+		log.Printf("Add album images error: %v\n", err)
+		return 0, commonpkg.NewInternalError("加入相册失败")
+	}
+	return added, nil
+}
+
+// RemoveAlbumImages 将图片移出相册，返回实际移除的数量。
+func (s *AlbumService) RemoveAlbumImages(userID uint, albumID uint, imageIDs []uint) (int64, error) {
+	if _, err := s.findAlbum(userID, albumID); err != nil {
+		return 0, err
+	}
+	removed, err := s.albumStore.RemoveAlbumImages(albumID, imageIDs)
+	if err != nil {
+		log.Printf("Remove album images error: %v\n", err)
+		return 0, commonpkg.NewInternalError("移出相册失败")
+	}
+	return removed, nil
+}
+
+func (s *AlbumService) findAlbum(userID uint, albumID uint) (*model.Album, error) {
+	album, err := s.albumStore.FindAlbumByIDAndUserID(albumID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, commonpkg.NewNotFoundError("相册不存在")
+		}
+		return nil, commonpkg.NewInternalError("获取相册失败")
+	}
+	return album, nil
+}
+
+// buildAlbumResponses 为相册补充图片数量与封面图片。
+func (s *AlbumService) buildAlbumResponses(albums []model.Album) ([]moduledto.AlbumResponse, error) {
+	albumIDs := make([]uint, 0, len(albums))
+	var coverIDs []uint
+	for _, album := range albums {
+		albumIDs = append(albumIDs, album.ID)
+		if album.CoverImageID != nil {
+			coverIDs = append(coverIDs, *album.CoverImageID)
+		}
+	}
+
+	counts, err := s.albumStore.CountAlbumImages(albumIDs)
+	if err != nil {
+		return nil, commonpkg.NewInternalError("获取相册信息失败")
+	}
+	covers := make(map[uint]*model.Image, len(coverIDs))
+	if len(coverIDs) > 0 {
+		images, err := s.imageStore.FindByIDs(coverIDs)
+		if err != nil {
+			return nil, commonpkg.NewInternalError("获取相册信息失败")
+		}
+		for i := range images {
+			covers[images[i].ID] = &images[i]
+		}
+	}
+
+	items := make([]moduledto.AlbumResponse, 0, len(albums))
+	for _, album := range albums {
+		item := moduledto.AlbumResponse{Album: album, ImageCount: counts[album.ID]}
+		if album.CoverImageID != nil {
+			item.Cover = covers[*album.CoverImageID]
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func normalizeAlbumTitle(title string) (string, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return "", commonpkg.NewValidationError("相册标题不能为空")
+	}
+	if utf8.RuneCountInString(title) > maxAlbumTitleLength {
+		return "", commonpkg.NewValidationError("相册标题不能超过 100 个字符")
+	}
+	return title, nil
+}
+
+func normalizeAlbumDescription(description string) (string, error) {
+	description = strings.TrimSpace(description)
+	if utf8.RuneCountInString(description) > maxAlbumDescriptionLength {
+		return "", commonpkg.NewValidationError("相册描述不能超过 1000 个字符")
+	}
+	return description, nil
+}
+
+func normalizeAlbumVisibility(visibility string) (string, error) {
+	switch v := strings.ToLower(strings.TrimSpace(visibility)); v {
+	case model.AlbumVisibilityPrivate, model.AlbumVisibilityPublic:
+		return v, nil
+	default:
+		return "", commonpkg.NewValidationError("相册可见性只能为 private 或 public")
+	}
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"os"
+	"testing"
+
+	"perfect-pic-server/internal/common"
+	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/model"
+)
+
+func createAlbumTestImages(t *testing.T, userID uint, names ...string) []model.Image {
+	t.Helper()
+	images := make([]model.Image, 0, len(names))
+	for _, name := range names {
+		img := model.Image{Filename: name, Path: name, Size: 1, Width: 1, Height: 1, MimeType: ".png", UploadedAt: 1, UserID: userID}
+		if err := testGormDB.Create(&img).Error; err != nil {
+			t.Fatalf("create image: %v", err)
+		}
+		images = append(images, img)
+	}
+	return images
+}
+
+// 测试内容：验证相册的创建、更新、列表排序与删除，以及标题、可见性与封面的校验。
+func TestAlbumService_CRUD(t *testing.T) {
+	setupTestDB(t)
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+	imgs := createAlbumTestImages(t, u.ID, "a.png")
+
+	if _, err := testService.albumService.CreateAlbum(u.ID, moduledto.CreateAlbumRequest{Title: "  "}); err == nil {
+		t.Fatalf("期望空标题返回错误")
+	}
+	if _, err := testService.albumService.CreateAlbum(u.ID, moduledto.CreateAlbumRequest{Title: "x", Visibility: "friends"}); err == nil {
+		t.Fatalf("期望非法可见性返回错误")
+	}
+
+	first, err := testService.albumService.CreateAlbum(u.ID, moduledto.CreateAlbumRequest{Title: " 旅行 ", Description: "2024"})
+	if err != nil {
+		t.Fatalf("CreateAlbum: %v", err)
+	}
+	if first.Title != "旅行" || first.Visibility != model.AlbumVisibilityPrivate {
+		t.Fatalf("非预期相册: %+v", first.Album)
+	}
+	second, _ := testService.albumService.CreateAlbum(u.ID, moduledto.CreateAlbumRequest{Title: "置顶", SortOrder: -1, Visibility: "PUBLIC"})
+	if second.Visibility != model.AlbumVisibilityPublic {
+		t.Fatalf("期望可见性为 public，实际为 %q", second.Visibility)
+	}
+
+	list, total, _, _, err := testService.albumService.ListAlbums(u.ID, 1, 10)
+	if err != nil || total != 2 || list[0].ID != second.ID {
+		t.Fatalf("期望按 sort_order 排序且置顶相册在前: total=%d err=%v", total, err)
+	}
+
+	// 封面必须是相册内的图片
+	cover := imgs[0].ID
+	if _, err := testService.albumService.UpdateAlbum(u.ID, first.ID, moduledto.UpdateAlbumRequest{CoverImageID: &cover}); err == nil {
+		t.Fatalf("期望相册外的图片不能设为封面")
+	}
+	if _, err := testService.albumService.AddAlbumImages(u.ID, first.ID, []uint{cover}); err != nil {
+		t.Fatalf("AddAlbumImages: %v", err)
+	}
+	title := "旅行 2024"
+	updated, err := testService.albumService.UpdateAlbum(u.ID, first.ID, moduledto.UpdateAlbumRequest{Title: &title, CoverImageID: &cover})
+	if err != nil {
+		t.Fatalf("UpdateAlbum: %v", err)
+	}
+	if updated.Title != title || updated.Cover == nil || updated.Cover.ID != cover || updated.ImageCount != 1 {
+		t.Fatalf("非预期更新结果: %+v", updated)
+	}
+
+	// 其他用户无法访问
+	_, err = testService.albumService.GetAlbum(u.ID+100, first.ID)
+	if se, ok := common.AsServiceError(err); !ok || se.Code != common.ErrorCodeNotFound {
+		t.Fatalf("期望其他用户访问返回 NotFound，实际为 %v", err)
+	}
+
+	if err := testService.albumService.DeleteAlbum(u.ID, first.ID); err != nil {
+		t.Fatalf("DeleteAlbum: %v", err)
+	}
+	var links int64
+	testGormDB.Model(&model.AlbumImage{}).Where("album_id = ?", first.ID).Count(&links)
+	var images int64
+	testGormDB.Model(&model.Image{}).Where("user_id = ?", u.ID).Count(&images)
+	if links != 0 || images != 1 {
+		t.Fatalf("期望删除相册后关联被清理且图片保留，实际 links=%d images=%d", links, images)
+	}
+}
+
+// 测试内容：验证批量加入/移出只作用于用户自己的图片，重复加入被忽略，移出封面时清空封面，并可按相册过滤图片列表。
+func TestAlbumService_AddRemoveImagesAndFilter(t *testing.T) {
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	other := model.User{Username: "bob", Password: "x", Status: 1, Email: "b@example.com"}
+	_ = testGormDB.Create(&u).Error
+	_ = testGormDB.Create(&other).Error
+	imgs := createAlbumTestImages(t, u.ID, "a.png", "b.png", "c.png")
+	foreign := createAlbumTestImages(t, other.ID, "x.png")
+
+	album, _ := testService.albumService.CreateAlbum(u.ID, moduledto.CreateAlbumRequest{Title: "相册"})
+	added, err := testService.albumService.AddAlbumImages(u.ID, album.ID, []uint{imgs[0].ID, imgs[1].ID, foreign[0].ID})
+	if err != nil || added != 2 {
+		t.Fatalf("期望只加入自己的 2 张图片，实际 added=%d err=%v", added, err)
+	}
+	added, _ = testService.albumService.AddAlbumImages(u.ID, album.ID, []uint{imgs[0].ID})
+	if added != 0 {
+		t.Fatalf("期望重复加入被忽略，实际 added=%d", added)
+	}
+	if _, err := testService.albumService.AddAlbumImages(u.ID, album.ID, []uint{foreign[0].ID}); err == nil {
+		t.Fatalf("期望只包含他人图片时返回错误")
+	}
+	if _, err := testService.albumService.AddAlbumImages(other.ID, album.ID, []uint{foreign[0].ID}); err == nil {
+		t.Fatalf("期望不能操作他人相册")
+	}
+
+	albumID := album.ID
+	listed, total, _, _, err := testService.imageService.ListImages(moduledto.ListImagesRequest{
+		PaginationRequest: moduledto.PaginationRequest{Page: 1, PageSize: 10},
+		UserID:            &u.ID,
+		AlbumID:           &albumID,
+	})
+	if err != nil || total != 2 || len(listed) != 2 {
+		t.Fatalf("期望相册过滤返回 2 张图片，实际 total=%d err=%v", total, err)
+	}
+
+	cover := imgs[0].ID
+	if _, err := testService.albumService.UpdateAlbum(u.ID, album.ID, moduledto.UpdateAlbumRequest{CoverImageID: &cover}); err != nil {
+		t.Fatalf("UpdateAlbum: %v", err)
+	}
+	removed, err := testService.albumService.RemoveAlbumImages(u.ID, album.ID, []uint{imgs[0].ID, imgs[2].ID})
+	if err != nil || removed != 1 {
+		t.Fatalf("期望移出 1 张图片，实际 removed=%d err=%v", removed, err)
+	}
+	got, _ := testService.albumService.GetAlbum(u.ID, album.ID)
+	if got.CoverImageID != nil || got.ImageCount != 1 {
+		t.Fatalf("期望封面被清空且剩余 1 张图片，实际 %+v", got)
+	}
+
+	// 删除图片时同步移出相册并清空封面
+	cover = imgs[1].ID
+	_, _ = testService.albumService.UpdateAlbum(u.ID, album.ID, moduledto.UpdateAlbumRequest{CoverImageID: &cover})
+	if err := testService.imageService.BatchDeleteImages([]model.Image{imgs[1]}); err != nil {
+		t.Fatalf("BatchDeleteImages: %v", err)
+	}
+	got, _ = testService.albumService.GetAlbum(u.ID, album.ID)
+	if got.CoverImageID != nil || got.ImageCount != 0 {
+		t.Fatalf("期望删除图片后相册为空且无封面，实际 %+v", got)
+	}
+}
@@ -1,6 +1,9 @@
 package service
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"image"
@@ -9,18 +12,21 @@ import (
 	_ "image/png"
 	"io"
 	"log"
+	"mime"
 	"mime/multipart"
-	"os"
+	"path"
 	"path/filepath"
 	commonpkg "perfect-pic-server/internal/common"
 	"perfect-pic-server/internal/consts"
 	moduledto "perfect-pic-server/internal/dto"
 	"perfect-pic-server/internal/model"
-	"perfect-pic-server/internal/pkg/pathpkg"
+	"perfect-pic-server/internal/pkg/storage"
 	"perfect-pic-server/internal/pkg/validator"
 	repo "perfect-pic-server/internal/repository"
+	"strconv"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	_ "golang.org/x/image/bmp"
@@ -34,28 +40,9 @@ import (
 //   - string: 文件扩展名 (小写, 如 .jpg)
 //   - error: 错误信息或原因
 func (s *ImageService) ValidateImageFile(file *multipart.FileHeader) (bool, string, error) {
-	// 检查文件大小
-	maxSizeMB := s.dbConfig.GetInt(consts.ConfigMaxUploadSize) // 默认 10MB
-	if file.Size > int64(maxSizeMB*1024*1024) {
-		return false, "", commonpkg.NewValidationError(fmt.Sprintf("文件大小不能超过 %dMB", maxSizeMB))
-	}
-
-	// 检查文件扩展名
-	allowExtsStr := s.dbConfig.GetString(consts.ConfigAllowFileExtensions)
-	ext := strings.ToLower(filepath.Ext(file.Filename))
-	if ext == "" {
-		return false, "", commonpkg.NewValidationError("无法识别文件类型")
-	}
-
-	allowed := false
-	for _, allowExt := range strings.Split(allowExtsStr, ",") {
-		if strings.TrimSpace(strings.ToLower(allowExt)) == ext {
-			allowed = true
-			break
-		}
-	}
-	if !allowed {
-		return false, ext, commonpkg.NewValidationError(fmt.Sprintf("不支持的文件类型: %s", ext))
+	ext, err := s.ValidateImageName(file.Filename, file.Size)
+	if err != nil {
+		return false, ext, err
 	}
 
 	// 检查文件内容 (Magic Bytes)
@@ -72,41 +59,39 @@ func (s *ImageService) ValidateImageFile(file *multipart.FileHeader) (bool, stri
 	return true, ext, nil
 }
 
-// DeleteImage 删除图片文件和数据库记录
-func (s *ImageService) DeleteImage(image *model.Image) error {
-	cfg := s.staticConfig
-	uploadRoot := cfg.Upload.Path
-	if uploadRoot == "" {
-		uploadRoot = "uploads/imgs"
-	}
-	uploadRootAbs, err := filepath.Abs(uploadRoot)
-	if err != nil {
-		return commonpkg.NewInternalError("系统错误: 上传目录解析失败")
+// ValidateImageName 校验文件大小与扩展名，返回小写扩展名。
+func (s *ImageService) ValidateImageName(filename string, size int64) (string, error) {
+	// 检查文件大小
+	maxSizeMB := s.dbConfig.GetInt(consts.ConfigMaxUploadSize) // 默认 10MB
+	if size > int64(maxSizeMB*1024*1024) {
+		return "", commonpkg.NewValidationError(fmt.Sprintf("文件大小不能超过 %dMB", maxSizeMB))
 	}
-	// 删除前先校验上传根目录节点本身，避免根目录被替换为符号链接。
-	if err := pathpkg.EnsurePathNotSymlink(uploadRootAbs); err != nil {
-		log.Printf("DeleteImage upload root security check failed: %v\n", err)
-		return commonpkg.NewInternalError("系统错误: 上传目录存在符号链接风险")
+
+	// 检查文件扩展名
+	allowExtsStr := s.dbConfig.GetString(consts.ConfigAllowFileExtensions)
+	ext := strings.ToLower(filepath.Ext(filename))
+	if ext == "" {
+		return "", commonpkg.NewValidationError("无法识别文件类型")
 	}
 
-	// 拼接完整物理路径
-	fullPath, err := pathpkg.SecureJoin(uploadRootAbs, image.Path)
-	if err != nil {
-		log.Printf("DeleteImage secure path error: %v\n", err)
-		return commonpkg.NewInternalError("系统错误: 非法文件路径")
+	for _, allowExt := range strings.Split(allowExtsStr, ",") {
+		if strings.TrimSpace(strings.ToLower(allowExt)) == ext {
+			return ext, nil
+		}
 	}
+	return ext, commonpkg.NewValidationError(fmt.Sprintf("不支持的文件类型: %s", ext))
+}
 
-	// 使用事务确保数据库操作原子性
-	if err := s.imageStore.DeleteAndDecreaseUserStorage(image); err != nil {
+// DeleteImage 删除图片文件和数据库记录
+func (s *ImageService) DeleteImage(image *model.Image) error {
+	// 使用事务确保数据库操作原子性，返回不再被引用的文件
+	released, err := s.imageStore.DeleteAndDecreaseUserStorage(image)
+	if err != nil {
 		return err
 	}
 
-	// 事务提交后，删除物理文件
-	if err := os.Remove(fullPath); err != nil {
-		if !os.IsNotExist(err) {
-			log.Printf("Delete file error: %v, path: %s\n", err, fullPath)
-		}
-	}
+	// 事务提交后，删除存储中的文件
+	s.deleteReleasedFiles(released)
 
 	return nil
 }
@@ -121,63 +106,76 @@ func (s *ImageService) BatchDeleteImages(images []model.Image) error {
 	// key: UserID, value: TotalSizeToFree
 	userSizeMap := make(map[uint]int64)
 	var imageIDs []uint
-	var pathsToDelete []string
-
-	uploadRoot := s.staticConfig.Upload.Path
-	if uploadRoot == "" {
-		uploadRoot = "uploads/imgs"
-	}
-	uploadRootAbs, err := filepath.Abs(uploadRoot)
-	if err != nil {
-		return commonpkg.NewInternalError("系统错误: 上传目录解析失败")
-	}
-	// 批量删除前先校验上传根目录节点本身，避免根目录被替换为符号链接。
-	if err := pathpkg.EnsurePathNotSymlink(uploadRootAbs); err != nil {
-		log.Printf("BatchDeleteImages upload root security check failed: %v\n", err)
-		return commonpkg.NewInternalError("系统错误: 上传目录存在符号链接风险")
-	}
 
 	for _, img := range images {
-		userSizeMap[img.UserID] += img.Size
+		userSizeMap[img.UserID] += img.StorageSize()
 		imageIDs = append(imageIDs, img.ID)
-		fullPath, secureErr := pathpkg.SecureJoin(uploadRootAbs, img.Path)
-		if secureErr != nil {
-			log.Printf("BatchDeleteImages secure path error: %v\n", secureErr)
-			continue
-		}
-		pathsToDelete = append(pathsToDelete, fullPath)
 	}
 
 	// 开启单一事务处理所有数据库变更
-	if err := s.imageStore.BatchDeleteAndDecreaseUserStorage(imageIDs, userSizeMap); err != nil {
+	released, err := s.imageStore.BatchDeleteAndDecreaseUserStorage(imageIDs, userSizeMap)
+	if err != nil {
 		return err
 	}
 
-	// 事务成功提交后，清理物理文件
-	for _, path := range pathsToDelete {
-		if err := os.Remove(path); err != nil {
-			if !os.IsNotExist(err) {
-				log.Printf("Batch delete file error: %v, path: %s\n", err, path)
-			}
-		}
-	}
+	// 事务成功提交后，清理存储中的文件
+	s.deleteReleasedFiles(released)
 
 	return nil
 }
 
+// deleteReleasedFiles 删除已无记录引用的文件及其变体缓存。
+func (s *ImageService) deleteReleasedFiles(keys []string) {
+	for _, key := range keys {
+		if _, err := storage.CleanKey(key); err != nil {
+			log.Printf("Delete file secure path error: %v\n", err)
+			continue
+		}
+		if err := s.storage.Images.Delete(key); err != nil {
+			log.Printf("Delete file error: %v, key: %s\n", err, key)
+		}
+		s.deleteImageVariants(key)
+	}
+}
+
 // ListImages 分页查询图片列表；查询范围与过滤条件由上层传参决定。
 func (s *ImageService) ListImages(params moduledto.ListImagesRequest) ([]model.Image, int64, int, int, error) {
 	page, pageSize := normalizePagination(params.Page, params.PageSize)
 	offset := (page - 1) * pageSize
 
+	sortBy := strings.ToLower(strings.TrimSpace(params.SortBy))
+	switch sortBy {
+	case "", repo.ImageSortUploadedAt, repo.ImageSortTakenAt, repo.ImageSortViews:
+	default:
+		return nil, 0, page, pageSize, commonpkg.NewValidationError("sort 参数仅支持 uploaded_at、taken_at 或 views")
+	}
+	sortOrder := strings.ToLower(strings.TrimSpace(params.SortOrder))
+	if sortOrder != "" && sortOrder != "asc" && sortOrder != "desc" {
+		return nil, 0, page, pageSize, commonpkg.NewValidationError("order 参数仅支持 asc 或 desc")
+	}
+
+	search := strings.TrimSpace(params.Search)
+	if utf8.RuneCountInString(search) > maxSearchLength {
+		return nil, 0, page, pageSize, commonpkg.NewValidationError(fmt.Sprintf("搜索关键词不能超过 %d 个字符", maxSearchLength))
+	}
+
 	images, total, err := s.imageStore.ListImages(repo.ListImagesParams{
-		UserID:      params.UserID,
-		Username:    params.Username,
-		Filename:    params.Filename,
-		ID:          params.ID,
-		Offset:      offset,
-		Limit:       pageSize,
-		PreloadUser: params.PreloadUser,
+		UserID:          params.UserID,
+		Username:        params.Username,
+		Filename:        params.Filename,
+		ID:              params.ID,
+		AlbumID:         params.AlbumID,
+		Search:          search,
+		Tag:             normalizeTagName(params.Tag),
+		TakenFrom:       params.TakenFrom,
+		TakenTo:         params.TakenTo,
+		SortBy:          sortBy,
+		SortAsc:         sortOrder == "asc",
+		Offset:          offset,
+		Limit:           pageSize,
+		PreloadUser:     params.PreloadUser,
+		PreloadMetadata: params.PreloadMetadata,
+		PreloadTags:     params.PreloadTags,
 	})
 	if err != nil {
 		return nil, 0, page, pageSize, commonpkg.NewInternalError("获取图片列表失败")
@@ -215,6 +213,24 @@ func (s *ImageService) GetImageByID(imageID uint, userID *uint) (*model.Image, e
 	return image, nil
 }
 
+// GetImageDetail 获取用户自己图片的详情（含拍摄信息与 GPS）。
+func (s *ImageService) GetImageDetail(imageID uint, userID uint) (*moduledto.ImageDetailResponse, error) {
+	image, err := s.imageStore.FindWithMetadataByIDAndUserID(imageID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, commonpkg.NewNotFoundError("图片不存在或无权访问")
+		}
+		return nil, commonpkg.NewInternalError("查找图片失败")
+	}
+	tags, err := s.imageStore.FindTagNamesByImageIDs([]uint{image.ID})
+	if err != nil {
+		return nil, commonpkg.NewInternalError("查找图片标签失败")
+	}
+	image.Tags = tags[image.ID]
+	s.EnsureShortCode(image)
+	return &moduledto.ImageDetailResponse{Image: *image, URL: s.ImageRecordURL(image), ShortURL: ShortURL(image)}, nil
+}
+
 // GetImagesByIDs 按 ID 列表获取图片；当 userID 非空时只在该用户范围内查询。
 func (s *ImageService) GetImagesByIDs(ids []uint, userID *uint) ([]model.Image, error) {
 	var (
@@ -233,37 +249,12 @@ func (s *ImageService) GetImagesByIDs(ids []uint, userID *uint) ([]model.Image,
 }
 
 // DeleteUserFiles 删除指定用户的所有关联文件（头像、上传的照片）
-// 此函数只负责删除物理文件，不处理数据库记录的清理
-//
-//nolint:gocyclo
+// 图片记录会随之删除以释放共享文件的引用计数；用户记录本身由调用方负责清理
 func (s *ImageService) DeleteUserFiles(userID uint) error {
 
 	// 1. 删除头像目录
-	// 头像存储结构: data/avatars/{userID}/filename
-	avatarRoot := s.staticConfig.Upload.AvatarPath
-	if avatarRoot == "" {
-		avatarRoot = "uploads/avatars"
-	}
-	avatarRootAbs, err := filepath.Abs(avatarRoot)
-	if err != nil {
-		return fmt.Errorf("failed to resolve avatar root: %w", err)
-	}
-	// 先校验头像根目录节点本身，避免根目录直接是符号链接。
-	if err := pathpkg.EnsurePathNotSymlink(avatarRootAbs); err != nil {
-		return fmt.Errorf("avatar root symlink risk: %w", err)
-	}
-
-	userAvatarDir, err := pathpkg.SecureJoin(avatarRootAbs, fmt.Sprintf("%d", userID))
-	if err != nil {
-		return fmt.Errorf("failed to build avatar dir: %w", err)
-	}
-	// 在执行 RemoveAll 前再做一次链路检查，确保目标目录链路未被并发替换为符号链接。
-	if err := pathpkg.EnsureNoSymlinkBetween(avatarRootAbs, userAvatarDir); err != nil {
-		return fmt.Errorf("avatar dir symlink risk: %w", err)
-	}
-
-	// RemoveAll 删除路径及其包含的任何子项。如果路径不存在，RemoveAll 返回 nil（无错误）。
-	if err := os.RemoveAll(userAvatarDir); err != nil {
+	// 头像存储结构: avatars/{userID}/filename
+	if err := s.storage.Avatars.DeletePrefix(fmt.Sprintf("%d", userID)); err != nil {
 		// 记录日志或打印错误，但不中断后续操作
 		log.Printf("Warning: Failed to delete avatar directory for user %d: %v\n", userID, err)
 	}
@@ -274,133 +265,283 @@ func (s *ImageService) DeleteUserFiles(userID uint) error {
 	if err != nil {
 		return fmt.Errorf("failed to retrieve user images: %w", err)
 	}
-
-	uploadRoot := s.staticConfig.Upload.Path
-	if uploadRoot == "" {
-		uploadRoot = "uploads/imgs"
+	if err := s.BatchDeleteImages(images); err != nil {
+		return fmt.Errorf("failed to release user images: %w", err)
 	}
-	uploadRootAbs, err := filepath.Abs(uploadRoot)
+
+	return nil
+}
+
+// uploadFields 上传时随文件提交、已校验的描述信息。
+type uploadFields struct {
+	title       string
+	description string
+	visibility  string
+	expiresAt   *int64
+}
+
+// ValidateUploadInfo 在接收文件内容之前校验上传附带的描述信息、可见性、有效期与水印选项。
+func (s *ImageService) ValidateUploadInfo(info moduledto.ImageUploadInfo) error {
+	_, err := s.normalizeUploadInfo(info)
+	return err
+}
+
+// normalizeUploadInfo 校验上传附带的描述信息与水印选项。
+func (s *ImageService) normalizeUploadInfo(info moduledto.ImageUploadInfo) (*uploadFields, error) {
+	title, err := normalizeImageTitle(info.Title)
 	if err != nil {
-		return fmt.Errorf("failed to resolve upload root: %w", err)
+		return nil, err
 	}
-	// 先校验上传根目录节点本身，避免根目录直接是符号链接。
-	if err := pathpkg.EnsurePathNotSymlink(uploadRootAbs); err != nil {
-		return fmt.Errorf("upload root symlink risk: %w", err)
+	description, err := normalizeImageDescription(info.Description)
+	if err != nil {
+		return nil, err
 	}
-
-	for _, img := range images {
-		// 转换路径分隔符以适配当前系统 (DB中存储的是 web 格式 '/')
-		localPath := filepath.FromSlash(img.Path)
-		fullPath, secureErr := pathpkg.SecureJoin(uploadRootAbs, localPath)
-		if secureErr != nil {
-			log.Printf("Warning: Skip unsafe image path for user %d (%s): %v\n", userID, img.Path, secureErr)
-			continue
-		}
-
-		if err := os.Remove(fullPath); err != nil {
-			if !os.IsNotExist(err) {
-				log.Printf("Warning: Failed to delete image file %s: %v\n", fullPath, err)
-			}
+	visibility := model.ImageVisibilityPublic
+	if strings.TrimSpace(info.Visibility) != "" {
+		if visibility, err = normalizeImageVisibility(info.Visibility); err != nil {
+			return nil, err
 		}
 	}
-
-	return nil
+	if err := s.checkWatermarkOptOut(info); err != nil {
+		return nil, err
+	}
+	expiresAt, err := s.resolveImageExpiry(info, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &uploadFields{title: title, description: description, visibility: visibility, expiresAt: expiresAt}, nil
 }
 
-// ProcessImageUpload 处理图片上传核心业务：校验、配额检查、入库。
+// ProcessImageUpload 处理图片上传核心业务：校验、元数据清理、水印、格式转换、去重、配额检查、入库。
+// 原始文件名取自上传文件头，标题与描述为可选的附加信息。
 //
-//nolint:gocyclo
-func (s *ImageService) ProcessImageUpload(file *multipart.FileHeader, uid uint, usedSize int64, quota int64) (*model.Image, string, error) {
+// 相同内容只保存一份物理文件（按 SHA-256 内容寻址），但每条记录都会计入所属用户的存储占用；
+// 用户重复上传自己已有的内容时直接返回已有记录，并将 Duplicate 置为 true。
+func (s *ImageService) ProcessImageUpload(file *multipart.FileHeader, uid uint, usedSize int64, quota int64, info moduledto.ImageUploadInfo) (*moduledto.ImageUploadResult, error) {
+	fields, err := s.normalizeUploadInfo(info)
+	if err != nil {
+		return nil, err
+	}
+
 	valid, ext, err := s.ValidateImageFile(file)
 	if !valid {
-		return nil, "", err
+		return nil, err
 	}
 
-	if usedSize+file.Size > quota {
-		return nil, "", commonpkg.NewForbiddenError(fmt.Sprintf("存储空间不足，上传失败。当前已用: %d B, 剩余: %d B", usedSize, quota-usedSize))
+	src, err := file.Open()
+	if err != nil {
+		return nil, commonpkg.NewInternalError("无法读取上传文件")
 	}
+	// 文件大小已在校验阶段受 max_upload_size 约束，整体读入内存以便在写入存储前处理内容
+	content, err := io.ReadAll(src)
+	_ = src.Close()
+	if err != nil {
+		return nil, commonpkg.NewInternalError("无法读取上传文件")
+	}
+	return s.storeImageUpload(file.Filename, ext, content, uid, usedSize, quota, info, fields)
+}
 
-	now := time.Now()
-	datePath := filepath.Join(now.Format("2006"), now.Format("01"), now.Format("02"))
-
-	cfg := s.staticConfig
-	uploadRoot := cfg.Upload.Path
-	if uploadRoot == "" {
-		uploadRoot = "uploads/imgs"
+// ProcessImageData 处理已在内存中的图片内容（远程抓取、data URI），与 ProcessImageUpload 共用校验、配额与入库流程。
+func (s *ImageService) ProcessImageData(filename string, content []byte, uid uint, usedSize int64, quota int64, info moduledto.ImageUploadInfo) (*moduledto.ImageUploadResult, error) {
+	fields, err := s.normalizeUploadInfo(info)
+	if err != nil {
+		return nil, err
 	}
-	uploadRootAbs, err := filepath.Abs(uploadRoot)
+
+	ext, err := s.ValidateImageName(filename, int64(len(content)))
 	if err != nil {
-		return nil, "", commonpkg.NewInternalError("系统错误: 上传目录解析失败")
+		return nil, err
 	}
-	if err := pathpkg.EnsurePathNotSymlink(uploadRootAbs); err != nil {
-		log.Printf("Upload root security check failed: %v\n", err)
-		return nil, "", commonpkg.NewInternalError("系统错误: 上传目录存在符号链接风险")
+	if valid, msg := validator.ValidateImageContent(bytes.NewReader(content), ext); !valid {
+		return nil, commonpkg.NewValidationError(msg)
 	}
-	fullDir, err := pathpkg.SecureJoin(uploadRootAbs, datePath)
+	return s.storeImageUpload(filename, ext, content, uid, usedSize, quota, info, fields)
+}
+
+// storeImageUpload 对已通过类型校验的上传内容执行解析、元数据清理、水印、转码、去重、配额检查与入库。
+//
+//nolint:gocyclo
+func (s *ImageService) storeImageUpload(filename string, ext string, content []byte, uid uint, usedSize int64, quota int64, info moduledto.ImageUploadInfo, fields *uploadFields) (*moduledto.ImageUploadResult, error) {
+	imgCfg, _, err := image.DecodeConfig(bytes.NewReader(content))
 	if err != nil {
-		log.Printf("SecureJoin dir error: %v\n", err)
-		return nil, "", commonpkg.NewInternalError("系统错误: 非法存储目录")
+		return nil, commonpkg.NewValidationError("无法解析图片尺寸，请上传有效图片")
 	}
 
-	if err := os.MkdirAll(fullDir, 0755); err != nil {
-		log.Printf("MkdirAll error: %v\n", err)
-		return nil, "", commonpkg.NewInternalError("系统错误: 无法创建存储目录")
+	// 拍摄信息须在清理元数据之前解析
+	metadata := s.extractImageMetadata(content)
+
+	// 元数据须在落盘前清理，哈希、大小与配额均以清理后的内容为准
+	content, err = s.stripImageMetadata(content)
+	if err != nil {
+		return nil, err
 	}
-	if err := pathpkg.EnsureNoSymlinkBetween(uploadRootAbs, fullDir); err != nil {
-		log.Printf("Upload dir security check failed: %v\n", err)
-		return nil, "", commonpkg.NewInternalError("系统错误: 存储目录存在符号链接风险")
+
+	width, height := imgCfg.Width, imgCfg.Height
+	orientation := 0
+	if metadata != nil {
+		orientation = metadata.Orientation
 	}
 
-	newFilename := uuid.New().String() + ext
-	dst, err := pathpkg.SecureJoin(fullDir, newFilename)
-	if err != nil {
-		log.Printf("SecureJoin dst error: %v\n", err)
-		return nil, "", commonpkg.NewInternalError("系统错误: 非法文件路径")
+	// 水印须在转码之前添加，使原图与备用格式带有相同的水印
+	if marked := s.watermarkImageUpload(content, ext, width, height, orientation, info); marked != nil {
+		content = marked.data
+		width, height = marked.width, marked.height
+		// 重新编码后的像素已按 EXIF 方向旋转
+		orientation = 0
 	}
 
-	src, err := file.Open()
-	if err != nil {
-		return nil, "", commonpkg.NewInternalError("无法读取上传文件")
+	// 按设置转码：replace 模式下以转码结果作为原图保存，alternate 模式下作为备用格式与原图并存
+	var alternate *convertedImage
+	if converted := s.convertImageUpload(content, ext, width, height, orientation); converted != nil {
+		if s.imageConvertMode() == convertModeAlternate {
+			alternate = converted
+		} else {
+			content, ext = converted.data, converted.ext
+			width, height = converted.width, converted.height
+		}
 	}
-	defer func() { _ = src.Close() }()
+	size := int64(len(content))
+	chargeSize := size
+	if alternate != nil {
+		chargeSize += int64(len(alternate.data))
+	}
+	hashSum := sha256.Sum256(content)
+	contentHash := hex.EncodeToString(hashSum[:])
 
-	imgCfg, _, err := image.DecodeConfig(src)
-	if err != nil {
-		return nil, "", commonpkg.NewValidationError("无法解析图片尺寸，请上传有效图片")
+	existing, err := s.imageStore.FindByUserIDAndSHA256(uid, contentHash)
+	if err == nil && existing.Expired(time.Now().Unix()) {
+		// 已过期但尚未被清理的记录不作为重复返回：先行删除，再按新上传处理
+		if err := s.BatchDeleteImages([]model.Image{*existing}); err != nil {
+			log.Printf("Delete expired duplicate image error: %v\n", err)
+			return nil, commonpkg.NewInternalError("系统错误: 数据库记录失败")
+		}
+		usedSize = max(usedSize-existing.StorageSize(), 0)
+		existing, err = nil, gorm.ErrRecordNotFound
 	}
-	if _, err := src.Seek(0, io.SeekStart); err != nil {
-		return nil, "", commonpkg.NewInternalError("系统错误: 无法重置文件读取位置")
+	if err == nil {
+		s.EnsureShortCode(existing)
+		return &moduledto.ImageUploadResult{Image: existing, URL: s.ImageRecordURL(existing), ShortURL: ShortURL(existing), Duplicate: true}, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("Find duplicate image error: %v\n", err)
+		return nil, commonpkg.NewInternalError("系统错误: 数据库查询失败")
 	}
 
-	out, err := os.Create(dst)
-	if err != nil {
-		return nil, "", commonpkg.NewInternalError("系统错误: 无法创建文件")
+	// 预检查可在写入文件前拒绝明显超额的上传；并发上传的最终判定以入库时的条件更新为准
+	if usedSize+chargeSize > quota {
+		return nil, quotaExceededError(usedSize, quota)
 	}
-	defer func() { _ = out.Close() }()
 
-	if _, err = io.Copy(out, src); err != nil {
-		return nil, "", commonpkg.NewInternalError("文件保存失败")
+	blobPath := blobKey(contentHash, ext)
+	blobExists := false
+	if blob, err := s.imageStore.FindBlobByHash(contentHash); err == nil {
+		blobPath = blob.Path
+		blobExists = true
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("Find image blob error: %v\n", err)
+		return nil, commonpkg.NewInternalError("系统错误: 数据库查询失败")
 	}
 
-	relativePath := filepath.ToSlash(filepath.Join(
-		now.Format("2006"), now.Format("01"), now.Format("02"), newFilename))
+	if !blobExists {
+		if err := s.putUploadedFile(content, blobPath, ext); err != nil {
+			return nil, err
+		}
+		if alternate != nil {
+			if err := s.putUploadedFile(alternate.data, alternateKey(blobPath, alternate.ext), alternate.ext); err != nil {
+				return nil, err
+			}
+		}
+	}
 
+	now := time.Now()
+	newFilename := uuid.New().String() + ext
 	imageRecord := model.Image{
-		Filename:   newFilename,
-		Path:       relativePath,
-		Size:       file.Size,
-		Width:      imgCfg.Width,
-		Height:     imgCfg.Height,
-		UserID:     uid,
-		UploadedAt: now.Unix(),
-		MimeType:   ext,
-	}
-
-	if err := s.imageStore.CreateAndIncreaseUserStorage(&imageRecord, uid, file.Size); err != nil {
-		_ = os.Remove(dst)
+		Filename:     newFilename,
+		Path:         blobPath,
+		Size:         size,
+		Width:        width,
+		Height:       height,
+		UserID:       uid,
+		UploadedAt:   now.Unix(),
+		MimeType:     ext,
+		SHA256:       contentHash,
+		Metadata:     metadata,
+		OriginalName: sanitizeOriginalName(filename),
+		Title:        fields.title,
+		Description:  fields.description,
+		Visibility:   fields.visibility,
+		ExpiresAt:    fields.expiresAt,
+	}
+	if alternate != nil {
+		imageRecord.AltType = alternate.ext
+		imageRecord.AltSize = int64(len(alternate.data))
+	}
+
+	// 入库失败时不删除已写入的文件：同内容的并发上传可能已复用该 key 且稍后才提交，
+	// 未被引用的文件由存储对账在宽限期后作为孤立文件清理
+	if err := s.imageStore.CreateAndIncreaseUserStorage(&imageRecord, uid, chargeSize, quota); err != nil {
+		var quotaErr *repo.StorageQuotaExceededError
+		if errors.As(err, &quotaErr) {
+			return nil, quotaExceededError(quotaErr.Used, quotaErr.Quota)
+		}
 		log.Printf("Process upload DB error: %v\n", err)
-		return nil, "", commonpkg.NewInternalError("系统错误: 数据库记录失败")
+		return nil, commonpkg.NewInternalError("系统错误: 数据库记录失败")
 	}
 
-	return &imageRecord, cfg.Upload.URLPrefix + relativePath, nil
+	if blobExists {
+		// 复用已有文件时，该文件可能恰好被并发的删除操作清理，此时用本次上传内容补写。
+		if _, err := s.storage.Images.Stat(imageRecord.Path); errors.Is(err, storage.ErrNotExist) {
+			if err := s.putUploadedFile(content, imageRecord.Path, ext); err != nil {
+				log.Printf("Restore shared file error: %v\n", err)
+			}
+		}
+		if alternate != nil {
+			altKey := alternateKey(imageRecord.Path, alternate.ext)
+			if _, err := s.storage.Images.Stat(altKey); errors.Is(err, storage.ErrNotExist) {
+				if err := s.putUploadedFile(alternate.data, altKey, alternate.ext); err != nil {
+					log.Printf("Store alternate file error: %v\n", err)
+				}
+			}
+		}
+	}
+
+	s.EnsureShortCode(&imageRecord)
+	return &moduledto.ImageUploadResult{Image: &imageRecord, URL: s.ImageRecordURL(&imageRecord), ShortURL: ShortURL(&imageRecord)}, nil
+}
+
+// quotaExceededError 返回存储空间不足的上传错误。
+func quotaExceededError(usedSize int64, quota int64) error {
+	return commonpkg.NewForbiddenError(fmt.Sprintf("存储空间不足，上传失败。当前已用: %d B, 剩余: %d B", usedSize, max(quota-usedSize, 0)))
+}
+
+// putUploadedFile 将处理后的上传内容写入存储。
+func (s *ImageService) putUploadedFile(content []byte, key string, ext string) error {
+	if err := s.storage.Images.Put(key, bytes.NewReader(content), int64(len(content)), mime.TypeByExtension(ext)); err != nil {
+		log.Printf("Storage put error: %v\n", err)
+		return commonpkg.NewInternalError("文件保存失败")
+	}
+	return nil
+}
+
+// blobKey 返回内容寻址文件的存储 key：blobs/{hash[0:2]}/{hash[2:4]}/{hash}{ext}
+func blobKey(hash string, ext string) string {
+	return path.Join("blobs", hash[0:2], hash[2:4], hash+ext)
+}
+
+// ImageURL 返回图片的公开访问地址；path 为空时返回访问前缀。
+func (s *ImageService) ImageURL(path string) string {
+	return s.storage.Images.URL(path)
+}
+
+// ImageRecordURL 返回图片记录的访问地址，附带记录 ID，使去重共享同一文件的不同记录分别计入各自的流量与访问统计。
+func (s *ImageService) ImageRecordURL(image *model.Image) string {
+	return s.storage.Images.URL(image.Path) + "?" + ImageRecordQueryParam + "=" + strconv.FormatUint(uint64(image.ID), 10)
+}
+
+// AvatarURL 返回头像的公开访问地址；key 为空时返回访问前缀。
+// 开启防盗链时始终经由服务端代理，对象存储的公开地址不会校验来源。
+func (s *ImageService) AvatarURL(key string) string {
+	if s.dbConfig.GetBool(consts.ConfigHotlinkEnabled) {
+		return s.storage.Avatars.ProxyURL(key)
+	}
+	return s.storage.Avatars.URL(key)
 }
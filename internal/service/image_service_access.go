@@ -0,0 +1,180 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	commonpkg "perfect-pic-server/internal/common"
+	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/model"
+	"perfect-pic-server/internal/pkg/storage"
+
+	"gorm.io/gorm"
+)
+
+// ImageRecordQueryParam 图片访问地址中标识具体图片记录的查询参数。去重后不同用户的相同内容共享同一文件地址，
+// 该参数决定访问流量与访问统计计入哪条记录。
+const ImageRecordQueryParam = "id"
+
+const (
+	// defaultSignedURLTTL/maxSignedURLTTL 签名链接的默认有效期与最长有效期。
+	defaultSignedURLTTL = time.Hour
+	maxSignedURLTTL     = 7 * 24 * time.Hour
+)
+
+// ImageViewer 图片访问请求中已认证的用户身份。
+type ImageViewer struct {
+	UserID uint
+	Admin  bool
+}
+
+// AuthorizeImageAccess 校验对图片访问前缀下 key 的访问权限，变体与备用格式文件按其原图判断。
+//
+// 只要引用该文件的记录中有一条不是 private，文件即可公开访问（去重后相同内容共享同一地址）；
+// 否则仅允许所有者、管理员或持有效签名（expires/signature）的请求访问。
+// 引用该文件的记录均已过期或位于回收站时视为图片不存在。
+// restricted 表示命中了私有图片，调用方应禁止共享缓存保存响应。
+// record 为本次访问计入流量与访问统计的记录（仅含访问控制所需字段）：imageID（来自访问地址的 id 参数或短链接）
+// 指向引用该文件的有效记录时取该记录，但公开访问时被指明的记录须为非私有；否则公开访问时取公开记录，
+// 所有者访问时取其自己的记录，其余情况取最早上传该内容的记录；文件未被任何记录引用时为 nil。
+func (s *ImageService) AuthorizeImageAccess(key string, imageID uint, viewer *ImageViewer, expires string, signature string) (record *model.Image, restricted bool, err error) {
+	cleanKey, err := storage.CleanKey(key)
+	if err != nil {
+		// 非法路径交由后续处理返回 404
+		return nil, false, nil
+	}
+	baseKey := accessBaseKey(cleanKey)
+
+	records, err := s.imageStore.FindAccessByPath(baseKey)
+	if err != nil {
+		log.Printf("Find image access error: %v\n", err)
+		return nil, false, commonpkg.NewInternalError("读取图片失败")
+	}
+	if len(records) == 0 {
+		return nil, false, nil
+	}
+	now := time.Now().Unix()
+	live := records[:0]
+	for _, r := range records {
+		if !r.Expired(now) && !r.DeletedAt.Valid {
+			live = append(live, r)
+		}
+	}
+	if len(live) == 0 {
+		return nil, false, commonpkg.NewNotFoundError("图片不存在或已过期")
+	}
+	records = live
+	var identified *model.Image
+	for i := range records {
+		if imageID != 0 && records[i].ID == imageID {
+			identified = &records[i]
+			break
+		}
+	}
+
+	for i := range records {
+		if records[i].Visibility != model.ImageVisibilityPrivate {
+			// 经由公开记录放行时不计入被指明的私有记录
+			if identified != nil && identified.Visibility != model.ImageVisibilityPrivate {
+				return identified, false, nil
+			}
+			return &records[i], false, nil
+		}
+	}
+
+	record = &records[0]
+	if identified != nil {
+		record = identified
+	}
+	if viewer != nil {
+		if viewer.Admin {
+			return record, true, nil
+		}
+		for i := range records {
+			if records[i].UserID == viewer.UserID {
+				if identified != nil {
+					return identified, true, nil
+				}
+				return &records[i], true, nil
+			}
+		}
+	}
+	if signature != "" {
+		if s.verifySignature(baseKey, expires, signature) {
+			return record, true, nil
+		}
+		return record, true, commonpkg.NewForbiddenError("签名无效或链接已过期")
+	}
+	return record, true, commonpkg.NewForbiddenError("无权访问该图片")
+}
+
+// VerifySignedURL 判断图片访问前缀下 key 携带的 exp/sig 是否为有效且未过期的签名，变体与备用格式文件按其原图判断。
+func (s *ImageService) VerifySignedURL(key string, expires string, signature string) bool {
+	if signature == "" {
+		return false
+	}
+	cleanKey, err := storage.CleanKey(key)
+	if err != nil {
+		return false
+	}
+	return s.verifySignature(accessBaseKey(cleanKey), expires, signature)
+}
+
+func (s *ImageService) verifySignature(baseKey string, expires string, signature string) bool {
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	return err == nil && s.signer.Verify(baseKey, exp, signature, time.Now())
+}
+
+// accessBaseKey 返回变体文件对应的原图 key；签名与可见性均按原图计算。
+func accessBaseKey(cleanKey string) string {
+	if i := strings.Index(cleanKey, variantDirSuffix+"/"); i >= 0 {
+		return cleanKey[:i]
+	}
+	return cleanKey
+}
+
+// CreateSignedURL 为用户自己的图片生成带过期时间的签名访问地址；expiresIn 为有效期（秒），0 表示默认值。
+func (s *ImageService) CreateSignedURL(imageID uint, userID uint, expiresIn int64) (*moduledto.SignedURLResponse, error) {
+	ttl := defaultSignedURLTTL
+	if expiresIn != 0 {
+		if expiresIn < 0 || expiresIn > int64(maxSignedURLTTL/time.Second) {
+			return nil, commonpkg.NewValidationError(fmt.Sprintf("有效期需在 1 到 %d 秒之间", int64(maxSignedURLTTL/time.Second)))
+		}
+		ttl = time.Duration(expiresIn) * time.Second
+	}
+
+	image, err := s.imageStore.FindByIDAndUserID(imageID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, commonpkg.NewNotFoundError("图片不存在或无权访问")
+		}
+		return nil, commonpkg.NewInternalError("查找图片失败")
+	}
+
+	expiresAt := time.Now().Add(ttl).Unix()
+	query := url.Values{}
+	query.Set(ImageRecordQueryParam, strconv.FormatUint(uint64(image.ID), 10))
+	query.Set("exp", strconv.FormatInt(expiresAt, 10))
+	query.Set("sig", s.signer.Sign(image.Path, expiresAt))
+
+	// 签名链接始终经由服务端的图片访问前缀，以便校验签名（对象存储的公开地址不做访问控制）
+	prefix := strings.TrimSuffix(s.staticConfig.Upload.URLPrefix, "/")
+	return &moduledto.SignedURLResponse{
+		URL:       prefix + "/" + image.Path + "?" + query.Encode(),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+func normalizeImageVisibility(visibility string) (string, error) {
+	switch v := strings.ToLower(strings.TrimSpace(visibility)); v {
+	case model.ImageVisibilityPublic, model.ImageVisibilityUnlisted, model.ImageVisibilityPrivate:
+		return v, nil
+	default:
+		return "", commonpkg.NewValidationError("图片可见性只能为 public、unlisted 或 private")
+	}
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"perfect-pic-server/internal/common"
+	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/model"
+	"perfect-pic-server/internal/testutils"
+)
+
+// 测试内容：验证上传与修改时的可见性校验，以及私有图片在去重共享时只要有公开记录即可公开访问。
+func TestImageService_VisibilityAndSharedAccess(t *testing.T) {
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	alice := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&alice).Error
+	bob := model.User{Username: "bob", Password: "x", Status: 1, Email: "b@example.com"}
+	_ = testGormDB.Create(&bob).Error
+
+	if _, err := testService.imageService.ProcessImageUpload(mustFileHeader(t, "a.png", testutils.MinimalPNG()), alice.ID, 0, 1<<20, moduledto.ImageUploadInfo{Visibility: "secret"}); err == nil {
+		t.Fatalf("期望非法可见性返回错误")
+	}
+	res, err := testService.imageService.ProcessImageUpload(mustFileHeader(t, "a.png", testutils.MinimalPNG()), alice.ID, 0, 1<<20, moduledto.ImageUploadInfo{Visibility: " Private "})
+	if err != nil {
+		t.Fatalf("ProcessImageUpload: %v", err)
+	}
+	if res.Image.Visibility != model.ImageVisibilityPrivate {
+		t.Fatalf("期望可见性为 private，实际为 %q", res.Image.Visibility)
+	}
+
+	key := res.Image.Path
+	if _, restricted, err := testService.imageService.AuthorizeImageAccess(key, 0, nil, "", ""); !restricted || err == nil {
+		t.Fatalf("期望匿名访问私有图片被拒绝: restricted=%v err=%v", restricted, err)
+	}
+	if record, _, err := testService.imageService.AuthorizeImageAccess(key, 0, &ImageViewer{UserID: alice.ID}, "", ""); err != nil || record == nil || record.ID != res.Image.ID {
+		t.Fatalf("期望所有者可以访问且按其自己的记录统计: record=%+v err=%v", record, err)
+	}
+
+	// bob 以默认可见性上传相同内容后，共享文件可被公开访问
+	bobRes, err := testService.imageService.ProcessImageUpload(mustFileHeader(t, "b.png", testutils.MinimalPNG()), bob.ID, 0, 1<<20, moduledto.ImageUploadInfo{})
+	if err != nil {
+		t.Fatalf("ProcessImageUpload: %v", err)
+	}
+	if record, restricted, err := testService.imageService.AuthorizeImageAccess(key, 0, nil, "", ""); restricted || err != nil || record == nil || record.UserID != bob.ID {
+		t.Fatalf("期望共享文件存在公开记录时可公开访问并按公开记录的所有者计费: record=%+v restricted=%v err=%v", record, restricted, err)
+	}
+	if record, _, err := testService.imageService.AuthorizeImageAccess(key, res.Image.ID, nil, "", ""); err != nil || record == nil || record.ID != bobRes.Image.ID {
+		t.Fatalf("期望匿名访问时不计入被指明的私有记录: record=%+v err=%v", record, err)
+	}
+	if record, _, err := testService.imageService.AuthorizeImageAccess(key, res.Image.ID, &ImageViewer{UserID: alice.ID}, "", ""); err != nil || record == nil || record.ID != bobRes.Image.ID {
+		t.Fatalf("期望共享文件公开时按公开记录统计: record=%+v err=%v", record, err)
+	}
+
+	empty := ""
+	if _, err := testService.imageService.UpdateImageInfo(res.Image.ID, alice.ID, moduledto.UpdateImageInfoRequest{Visibility: &empty}); err == nil {
+		t.Fatalf("期望修改为空可见性返回错误")
+	}
+	unlisted := "unlisted"
+	detail, err := testService.imageService.UpdateImageInfo(res.Image.ID, alice.ID, moduledto.UpdateImageInfoRequest{Visibility: &unlisted})
+	if err != nil || detail.Visibility != model.ImageVisibilityUnlisted {
+		t.Fatalf("期望可见性修改为 unlisted: detail=%+v err=%v", detail, err)
+	}
+}
+
+// 测试内容：验证签名链接的有效期校验、归属校验，以及生成的链接可通过访问校验。
+func TestImageService_CreateSignedURL(t *testing.T) {
+	setupTestDB(t)
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+	img := model.Image{Filename: "a.png", Path: "2026/10/16/a.png", Size: 1, Width: 1, Height: 1, MimeType: ".png", UploadedAt: 1, UserID: u.ID, Visibility: model.ImageVisibilityPrivate}
+	_ = testGormDB.Create(&img).Error
+
+	for _, expiresIn := range []int64{-1, int64(maxSignedURLTTL/time.Second) + 1} {
+		_, err := testService.imageService.CreateSignedURL(img.ID, u.ID, expiresIn)
+		assertServiceErrorCode(t, err, common.ErrorCodeValidation)
+	}
+	_, err := testService.imageService.CreateSignedURL(img.ID, u.ID+1, 0)
+	assertServiceErrorCode(t, err, common.ErrorCodeNotFound)
+
+	signed, err := testService.imageService.CreateSignedURL(img.ID, u.ID, 0)
+	if err != nil {
+		t.Fatalf("CreateSignedURL: %v", err)
+	}
+	if want := time.Now().Add(defaultSignedURLTTL).Unix(); signed.ExpiresAt < want-5 || signed.ExpiresAt > want+5 {
+		t.Fatalf("期望默认有效期为 1 小时，实际过期时间为 %d", signed.ExpiresAt)
+	}
+	parsed, err := url.Parse(signed.URL)
+	if err != nil || !strings.HasSuffix(parsed.Path, "/"+img.Path) {
+		t.Fatalf("非预期签名链接: %s", signed.URL)
+	}
+	query := parsed.Query()
+	if _, _, err := testService.imageService.AuthorizeImageAccess(img.Path, 0, nil, query.Get("exp"), query.Get("sig")); err != nil {
+		t.Fatalf("期望签名链接可以访问: %v", err)
+	}
+	if _, _, err := testService.imageService.AuthorizeImageAccess(img.Path, 0, nil, query.Get("exp"), query.Get("sig")+"x"); err == nil {
+		t.Fatalf("期望篡改后的签名被拒绝")
+	}
+}
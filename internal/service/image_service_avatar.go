@@ -2,13 +2,11 @@ package service
 
 import (
 	"fmt"
-	"io"
 	"log"
+	"mime"
 	"mime/multipart"
-	"os"
 	"path/filepath"
 	commonpkg "perfect-pic-server/internal/common"
-	"perfect-pic-server/internal/pkg/pathpkg"
 	"strings"
 
 	"github.com/google/uuid"
@@ -16,27 +14,8 @@ import (
 
 // SaveUserAvatarFile 保存用户头像文件并返回新文件名。
 func (s *ImageService) SaveUserAvatarFile(userID uint, file *multipart.FileHeader) (string, error) {
-	avatarRootAbs, storageDir, err := s.resolveUserAvatarDir(userID)
-	if err != nil {
-		return "", err
-	}
-
-	if err := os.MkdirAll(storageDir, 0755); err != nil {
-		log.Printf("MkdirAll error: %v\n", err)
-		return "", commonpkg.NewInternalError("系统错误: 无法创建存储目录")
-	}
-	if err := pathpkg.EnsureNoSymlinkBetween(avatarRootAbs, storageDir); err != nil {
-		log.Printf("Avatar storage security check failed: %v\n", err)
-		return "", commonpkg.NewInternalError("系统错误: 头像目录存在符号链接风险")
-	}
-
 	ext := strings.ToLower(filepath.Ext(file.Filename))
 	newFilename := uuid.New().String() + ext
-	dstPath, err := pathpkg.SecureJoin(storageDir, newFilename)
-	if err != nil {
-		log.Printf("Avatar dst secure join error: %v\n", err)
-		return "", commonpkg.NewInternalError("系统错误: 非法头像文件路径")
-	}
 
 	src, err := file.Open()
 	if err != nil {
@@ -45,15 +24,8 @@ func (s *ImageService) SaveUserAvatarFile(userID uint, file *multipart.FileHeade
 	}
 	defer func() { _ = src.Close() }()
 
-	out, err := os.Create(dstPath)
-	if err != nil {
-		log.Printf("File create error: %v\n", err)
-		return "", commonpkg.NewInternalError("系统错误: 无法创建文件")
-	}
-	defer func() { _ = out.Close() }()
-
-	if _, err = io.Copy(out, src); err != nil {
-		log.Printf("File save error: %v\n", err)
+	if err := s.storage.Avatars.Put(avatarKey(userID, newFilename), src, file.Size, mime.TypeByExtension(ext)); err != nil {
+		log.Printf("Avatar save error: %v\n", err)
 		return "", commonpkg.NewInternalError("文件保存失败")
 	}
 
@@ -66,42 +38,14 @@ func (s *ImageService) DeleteUserAvatarFile(userID uint, filename string) error
 		return nil
 	}
 
-	_, storageDir, err := s.resolveUserAvatarDir(userID)
-	if err != nil {
-		return err
-	}
-
-	avatarPath, err := pathpkg.SecureJoin(storageDir, filename)
-	if err != nil {
-		log.Printf("Avatar file secure join error: %v\n", err)
-		return commonpkg.NewInternalError("系统错误: 非法头像文件路径")
-	}
-
-	if err := os.Remove(avatarPath); err != nil && !os.IsNotExist(err) {
+	if err := s.storage.Avatars.Delete(avatarKey(userID, filename)); err != nil {
+		log.Printf("Avatar delete error: %v\n", err)
 		return commonpkg.NewInternalError("系统错误: 删除头像文件失败")
 	}
 	return nil
 }
 
-func (s *ImageService) resolveUserAvatarDir(userID uint) (string, string, error) {
-	avatarRoot := s.staticConfig.Upload.AvatarPath
-	if avatarRoot == "" {
-		avatarRoot = "uploads/avatars"
-	}
-	avatarRootAbs, err := filepath.Abs(avatarRoot)
-	if err != nil {
-		return "", "", commonpkg.NewInternalError("系统错误: 头像目录解析失败")
-	}
-	if err := pathpkg.EnsurePathNotSymlink(avatarRootAbs); err != nil {
-		log.Printf("Avatar root security check failed: %v\n", err)
-		return "", "", commonpkg.NewInternalError("系统错误: 头像目录存在符号链接风险")
-	}
-
-	storageDir, err := pathpkg.SecureJoin(avatarRootAbs, fmt.Sprintf("%v", userID))
-	if err != nil {
-		log.Printf("Avatar storage dir error: %v\n", err)
-		return "", "", commonpkg.NewInternalError("系统错误: 非法头像目录")
-	}
-
-	return avatarRootAbs, storageDir, nil
+// avatarKey 头像存储结构: {userID}/{filename}
+func avatarKey(userID uint, filename string) string {
+	return fmt.Sprintf("%d/%s", userID, filename)
 }
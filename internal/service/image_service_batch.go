@@ -0,0 +1,15 @@
+package service
+
+import "perfect-pic-server/internal/consts"
+
+// maxBatchUploadFiles 批量上传文件数允许配置的最大值。
+const maxBatchUploadFiles = 100
+
+// MaxBatchUploadFiles 返回单次批量上传允许的最大文件数。
+func (s *ImageService) MaxBatchUploadFiles() int {
+	count := s.dbConfig.GetInt(consts.ConfigMaxBatchUploadFiles)
+	if count <= 0 {
+		return 1
+	}
+	return min(count, maxBatchUploadFiles)
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"bytes"
+	"log"
+	"path"
+	"perfect-pic-server/internal/consts"
+	"perfect-pic-server/internal/pkg/imageproc"
+	"strings"
+)
+
+const (
+	// convertModeReplace 转码结果替换原图保存。
+	convertModeReplace = "replace"
+	// convertModeAlternate 原图与转码结果并存，转码结果作为备用格式。
+	convertModeAlternate = "alternate"
+)
+
+// convertFormatWebPLossless 转码格式设置中的无损 WebP：内置 WebP 编码器仅支持无损编码，只转码 PNG/BMP。
+const convertFormatWebPLossless = "webp-lossless"
+
+// alternateFileName 备用格式文件名，与变体一起存放在 {原图}.variants/ 下，随原图一起删除。
+const alternateFileName = "alternate"
+
+// convertedImage 转码结果。
+type convertedImage struct {
+	data   []byte
+	ext    string
+	width  int
+	height int
+}
+
+// imageConvertFormat 读取转码目标格式，返回空字符串表示不转码。
+func (s *ImageService) imageConvertFormat() string {
+	raw := strings.ToLower(strings.TrimSpace(s.dbConfig.GetString(consts.ConfigImageConvertFormat)))
+	if raw == convertFormatWebPLossless {
+		return imageproc.FormatWebP
+	}
+	if format := imageproc.NormalizeFormat(raw); format == imageproc.FormatJPEG {
+		return format
+	}
+	return ""
+}
+
+// imageConvertMode 读取转码结果保存方式，非法配置回退为替换原图。
+func (s *ImageService) imageConvertMode() string {
+	if strings.ToLower(strings.TrimSpace(s.dbConfig.GetString(consts.ConfigImageConvertMode))) == convertModeAlternate {
+		return convertModeAlternate
+	}
+	return convertModeReplace
+}
+
+// convertImageUpload 按系统设置将 PNG/BMP 上传内容转码为目标格式（JPEG 既不重复转码为 JPEG，也不转为无损 WebP）。
+//
+// 解码时会按 EXIF 方向旋转像素，因为转码输出不携带 EXIF。
+// 未开启转码、源格式不适用、转码失败或（开启“仅当更小”时）结果不比原文件小时返回 nil，调用方按原文件保存。
+func (s *ImageService) convertImageUpload(content []byte, ext string, width, height, orientation int) *convertedImage {
+	format := s.imageConvertFormat()
+	if format == "" {
+		return nil
+	}
+	source := imageproc.NormalizeFormat(ext)
+	if !convertibleSource(source) || source == format || lossyToLossless(source, format) || int64(width)*int64(height) > maxVariantSourcePixels {
+		return nil
+	}
+
+	img, _, err := imageproc.Decode(bytes.NewReader(content))
+	if err != nil {
+		log.Printf("Convert upload decode error: %v\n", err)
+		return nil
+	}
+	img = imageproc.ApplyOrientation(img, orientation)
+
+	var buf bytes.Buffer
+	if err := imageproc.Encode(&buf, img, format, s.dbConfig.GetInt(consts.ConfigImageConvertQuality)); err != nil {
+		log.Printf("Convert upload encode error: %v\n", err)
+		return nil
+	}
+	if s.dbConfig.GetBool(consts.ConfigImageConvertOnlyIfSmaller) && buf.Len() >= len(content) {
+		return nil
+	}
+
+	b := img.Bounds()
+	return &convertedImage{data: buf.Bytes(), ext: imageproc.Ext(format), width: b.Dx(), height: b.Dy()}
+}
+
+// convertibleSource 判断源格式是否支持转码（也即是否可能存在备用格式）。
+func convertibleSource(format string) bool {
+	switch format {
+	case imageproc.FormatJPEG, imageproc.FormatPNG, imageproc.FormatBMP:
+		return true
+	default:
+		return false
+	}
+}
+
+// lossyToLossless 判断是否为将有损格式转为无损编码：WebP 目标（webp-lossless）仅支持无损编码，
+// JPEG 照片转为无损 WebP 通常会成倍增大，因此只转码 PNG/BMP。
+func lossyToLossless(source string, target string) bool {
+	return source == imageproc.FormatJPEG && target == imageproc.FormatWebP
+}
+
+// alternateKey 返回原图 key 对应的备用格式文件 key：{原图}.variants/alternate{ext}
+func alternateKey(key string, ext string) string {
+	return path.Join(variantDir(key), alternateFileName+ext)
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"perfect-pic-server/internal/consts"
+	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/model"
+	"perfect-pic-server/internal/pkg/imageproc"
+)
+
+// newSolidPNG 生成一张纯色 PNG，转码为 WebP 后体积明显更小。
+func newSolidPNG(t *testing.T, w, h int, c color.NRGBA) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	// 不压缩，保证 PNG 比转码结果大
+	enc := png.Encoder{CompressionLevel: png.NoCompression}
+	if err := enc.Encode(&buf, img); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// newPhotoJPEG 生成一张带渐变与噪点的高质量 JPEG，近似照片内容。
+func newPhotoJPEG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	rng := rand.New(rand.NewSource(1))
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			noise := rng.Intn(32)
+			img.Set(x, y, color.NRGBA{R: uint8(x * 200 / w), G: uint8(y * 200 / h), B: uint8(100 + noise), A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatalf("encode jpeg: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func setTestSetting(t *testing.T, key, value string) {
+	t.Helper()
+	if err := testGormDB.Save(&model.Setting{Key: key, Value: value}).Error; err != nil {
+		t.Fatalf("save setting %s: %v", key, err)
+	}
+	testService.ClearCache()
+}
+
+// 测试内容：验证 replace 模式下 PNG 上传被转码为 WebP 保存，记录格式、大小与配额以转码结果为准。
+func TestProcessImageUpload_ConvertReplace(t *testing.T) {
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+
+	setTestSetting(t, consts.ConfigImageConvertFormat, "webp-lossless")
+
+	content := newSolidPNG(t, 64, 32, color.NRGBA{R: 10, G: 20, B: 30, A: 255})
+	res, err := testService.imageService.ProcessImageUpload(mustFileHeader(t, "a.png", content), u.ID, 0, 1<<20, moduledto.ImageUploadInfo{})
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if res.Image.MimeType != ".webp" || filepath.Ext(res.Image.Path) != ".webp" {
+		t.Fatalf("期望转码为 .webp，实际为 %q %q", res.Image.MimeType, res.Image.Path)
+	}
+	if res.Image.Width != 64 || res.Image.Height != 32 || res.Image.AltType != "" {
+		t.Fatalf("记录不符: %+v", res.Image)
+	}
+	stored, err := os.ReadFile(filepath.Join("uploads", "imgs", filepath.FromSlash(res.Image.Path)))
+	if err != nil {
+		t.Fatalf("read stored: %v", err)
+	}
+	if string(stored[:4]) != "RIFF" || string(stored[8:12]) != "WEBP" {
+		t.Fatalf("期望存储内容为 WebP")
+	}
+	if res.Image.Size != int64(len(stored)) || res.Image.Size >= int64(len(content)) {
+		t.Fatalf("期望记录大小为转码后的 %d，实际为 %d（原始 %d）", len(stored), res.Image.Size, len(content))
+	}
+	var got model.User
+	_ = testGormDB.First(&got, u.ID).Error
+	if got.StorageUsed != res.Image.Size {
+		t.Fatalf("期望配额按转码后大小计算 %d，实际为 %d", res.Image.Size, got.StorageUsed)
+	}
+}
+
+// 测试内容：验证开启“仅当更小”时转码结果不更小则保留原图，关闭后总是转码。
+func TestProcessImageUpload_ConvertOnlyIfSmaller(t *testing.T) {
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+
+	// 压缩后的纯色 PNG 非常小，转码为 JPEG 不会更小
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img)
+	content := buf.Bytes()
+
+	setTestSetting(t, consts.ConfigImageConvertFormat, "jpeg")
+	res, err := testService.imageService.ProcessImageUpload(mustFileHeader(t, "a.png", content), u.ID, 0, 1<<20, moduledto.ImageUploadInfo{})
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if res.Image.MimeType != ".png" || res.Image.Size != int64(len(content)) {
+		t.Fatalf("期望保留原图，实际为 %q %d", res.Image.MimeType, res.Image.Size)
+	}
+
+	setTestSetting(t, consts.ConfigImageConvertOnlyIfSmaller, "false")
+	other := newSolidPNG(t, 4, 4, color.NRGBA{R: 1, A: 255})
+	res, err = testService.imageService.ProcessImageUpload(mustFileHeader(t, "b.png", other), u.ID, 0, 1<<20, moduledto.ImageUploadInfo{})
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if res.Image.MimeType != ".jpg" {
+		t.Fatalf("期望关闭“仅当更小”后总是转码，实际为 %q", res.Image.MimeType)
+	}
+}
+
+// 测试内容：验证 alternate 模式下原图与备用格式并存，配额包含两者，删除图片时一并删除备用文件。
+func TestProcessImageUpload_ConvertAlternate(t *testing.T) {
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+
+	setTestSetting(t, consts.ConfigImageConvertFormat, "webp-lossless")
+	setTestSetting(t, consts.ConfigImageConvertMode, "alternate")
+
+	content := newSolidPNG(t, 64, 32, color.NRGBA{R: 10, G: 20, B: 30, A: 255})
+	res, err := testService.imageService.ProcessImageUpload(mustFileHeader(t, "a.png", content), u.ID, 0, 1<<20, moduledto.ImageUploadInfo{})
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if res.Image.MimeType != ".png" || res.Image.Size != int64(len(content)) {
+		t.Fatalf("期望保留原图，实际为 %q %d", res.Image.MimeType, res.Image.Size)
+	}
+	altPath := filepath.Join("uploads", "imgs", filepath.FromSlash(alternateKey(res.Image.Path, ".webp")))
+	alt, err := os.ReadFile(altPath)
+	if err != nil {
+		t.Fatalf("期望备用格式文件存在: %v", err)
+	}
+	if res.Image.AltType != ".webp" || res.Image.AltSize != int64(len(alt)) {
+		t.Fatalf("期望记录备用格式 .webp/%d，实际为 %q/%d", len(alt), res.Image.AltType, res.Image.AltSize)
+	}
+	var got model.User
+	_ = testGormDB.First(&got, u.ID).Error
+	if got.StorageUsed != res.Image.Size+res.Image.AltSize {
+		t.Fatalf("期望配额包含备用格式 %d，实际为 %d", res.Image.Size+res.Image.AltSize, got.StorageUsed)
+	}
+
+	if err := testService.imageService.DeleteImage(res.Image); err != nil {
+		t.Fatalf("DeleteImage: %v", err)
+	}
+	if _, err := os.Stat(altPath); !os.IsNotExist(err) {
+		t.Fatalf("期望备用格式文件随图片删除，实际 err=%v", err)
+	}
+	_ = testGormDB.First(&got, u.ID).Error
+	if got.StorageUsed != 0 {
+		t.Fatalf("期望删除后配额归零，实际为 %d", got.StorageUsed)
+	}
+}
+
+// 测试内容：验证 JPEG 照片在目标格式为无损 WebP 时（无论替换还是并存模式）都保持原样，不会因转为无损 WebP 而增大。
+func TestProcessImageUpload_ConvertPhotoJPEGToWebP(t *testing.T) {
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+
+	content := newPhotoJPEG(t, 128, 96)
+	img, _, err := image.Decode(bytes.NewReader(content))
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	var lossless bytes.Buffer
+	if err := imageproc.Encode(&lossless, img, imageproc.FormatWebP, 80); err != nil {
+		t.Fatalf("encode webp: %v", err)
+	}
+	if lossless.Len() <= len(content) {
+		t.Fatalf("前提不成立：期望无损 WebP（%d）大于原 JPEG（%d）", lossless.Len(), len(content))
+	}
+
+	setTestSetting(t, consts.ConfigImageConvertFormat, "webp-lossless")
+	setTestSetting(t, consts.ConfigImageConvertOnlyIfSmaller, "false")
+	for _, mode := range []string{"replace", "alternate"} {
+		setTestSetting(t, consts.ConfigImageConvertMode, mode)
+		res, err := testService.imageService.ProcessImageUpload(mustFileHeader(t, mode+".jpg", content), u.ID, 0, 1<<20, moduledto.ImageUploadInfo{})
+		if err != nil {
+			t.Fatalf("upload: %v", err)
+		}
+		if res.Image.MimeType != ".jpg" || res.Image.AltType != "" || res.Image.StorageSize() > int64(len(content)) {
+			t.Fatalf("%s: 期望 JPEG 保持原样且不增大，实际为 %q/%q 共 %d 字节（原始 %d）", mode, res.Image.MimeType, res.Image.AltType, res.Image.StorageSize(), len(content))
+		}
+		// 相同内容第二次上传会命中去重，清理后继续
+		_ = testService.imageService.DeleteImage(res.Image)
+	}
+}
+
+// 测试内容：验证转码相关设置的取值校验，不支持按质量压缩的 webp 目标被拒绝。
+func TestValidateSettingUpdate_ImageConvert(t *testing.T) {
+	valid := map[string][]string{
+		consts.ConfigImageConvertFormat:  {"none", "webp-lossless", "JPEG"},
+		consts.ConfigImageConvertMode:    {"replace", "alternate"},
+		consts.ConfigImageConvertQuality: {"1", "80", "100"},
+	}
+	for key, values := range valid {
+		for _, value := range values {
+			if err := validateSettingUpdate(moduledto.UpdateSettingRequest{Key: key, Value: value}); err != nil {
+				t.Fatalf("%s=%q: 期望合法，实际为 %v", key, value, err)
+			}
+		}
+	}
+	invalid := map[string][]string{
+		consts.ConfigImageConvertFormat:  {"gif", "", "webp"},
+		consts.ConfigImageConvertMode:    {"both"},
+		consts.ConfigImageConvertQuality: {"0", "101", "abc"},
+	}
+	for key, values := range invalid {
+		for _, value := range values {
+			if err := validateSettingUpdate(moduledto.UpdateSettingRequest{Key: key, Value: value}); err == nil {
+				t.Fatalf("%s=%q: 期望非法取值返回错误", key, value)
+			}
+		}
+	}
+}
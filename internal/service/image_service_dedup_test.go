@@ -0,0 +1,119 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/model"
+	"perfect-pic-server/internal/testutils"
+)
+
+// 测试内容：验证相同内容只保存一份文件、按用户分别计费，重复上传返回已有记录，删除时按引用计数清理文件。
+func TestProcessImageUpload_DeduplicatesByContentHash(t *testing.T) {
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	alice := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	bob := model.User{Username: "bob", Password: "x", Status: 1, Email: "b@example.com"}
+	_ = testGormDB.Create(&alice).Error
+	_ = testGormDB.Create(&bob).Error
+
+	content := testutils.MinimalPNG()
+	size := int64(len(content))
+
+	first, err := testService.imageService.ProcessImageUpload(mustFileHeader(t, "a.png", content), alice.ID, 0, 1<<20, moduledto.ImageUploadInfo{})
+	if err != nil {
+		t.Fatalf("alice upload: %v", err)
+	}
+	if first.Duplicate || first.Image.SHA256 == "" {
+		t.Fatalf("期望首次上传不是重复且记录哈希，实际为 %+v", first)
+	}
+
+	again, err := testService.imageService.ProcessImageUpload(mustFileHeader(t, "copy.png", content), alice.ID, size, size, moduledto.ImageUploadInfo{})
+	if err != nil {
+		t.Fatalf("alice re-upload: %v", err)
+	}
+	if !again.Duplicate || again.Image.ID != first.Image.ID {
+		t.Fatalf("期望重复上传返回已有记录，实际为 %+v", again)
+	}
+
+	second, err := testService.imageService.ProcessImageUpload(mustFileHeader(t, "b.png", content), bob.ID, 0, 1<<20, moduledto.ImageUploadInfo{})
+	if err != nil {
+		t.Fatalf("bob upload: %v", err)
+	}
+	if second.Duplicate || second.Image.ID == first.Image.ID {
+		t.Fatalf("期望其他用户获得独立记录，实际为 %+v", second)
+	}
+	if second.Image.Path != first.Image.Path {
+		t.Fatalf("期望共享同一物理文件，实际为 %q / %q", first.Image.Path, second.Image.Path)
+	}
+
+	var blob model.ImageBlob
+	if err := testGormDB.Where("hash = ?", first.Image.SHA256).First(&blob).Error; err != nil || blob.RefCount != 2 {
+		t.Fatalf("期望引用计数为 2，实际为 %d err=%v", blob.RefCount, err)
+	}
+	for _, u := range []model.User{alice, bob} {
+		var got model.User
+		_ = testGormDB.First(&got, u.ID).Error
+		if got.StorageUsed != size {
+			t.Fatalf("期望用户 %s 计费 %d，实际为 %d", u.Username, size, got.StorageUsed)
+		}
+	}
+
+	full := filepath.Join("uploads", "imgs", filepath.FromSlash(first.Image.Path))
+	if err := testService.DeleteImage(first.Image); err != nil {
+		t.Fatalf("DeleteImage: %v", err)
+	}
+	if _, err := os.Stat(full); err != nil {
+		t.Fatalf("期望仍被引用的文件保留: %v", err)
+	}
+
+	if err := testService.BatchDeleteImages([]model.Image{*second.Image}); err != nil {
+		t.Fatalf("BatchDeleteImages: %v", err)
+	}
+	if _, err := os.Stat(full); !os.IsNotExist(err) {
+		t.Fatalf("期望引用归零后删除文件, err=%v", err)
+	}
+	var count int64
+	_ = testGormDB.Model(&model.ImageBlob{}).Count(&count).Error
+	if count != 0 {
+		t.Fatalf("期望 blob 记录被清理，实际剩余 %d", count)
+	}
+}
+
+// 测试内容：验证删除用户文件时只释放该用户的引用，不影响其他用户共享的文件。
+func TestDeleteUserFiles_KeepsSharedBlobs(t *testing.T) {
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	alice := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	bob := model.User{Username: "bob", Password: "x", Status: 1, Email: "b@example.com"}
+	_ = testGormDB.Create(&alice).Error
+	_ = testGormDB.Create(&bob).Error
+
+	content := testutils.MinimalPNG()
+	a, _ := testService.imageService.ProcessImageUpload(mustFileHeader(t, "a.png", content), alice.ID, 0, 1<<20, moduledto.ImageUploadInfo{})
+	_, _ = testService.imageService.ProcessImageUpload(mustFileHeader(t, "b.png", content), bob.ID, 0, 1<<20, moduledto.ImageUploadInfo{})
+
+	if err := testService.DeleteUserFiles(alice.ID); err != nil {
+		t.Fatalf("DeleteUserFiles: %v", err)
+	}
+	full := filepath.Join("uploads", "imgs", filepath.FromSlash(a.Image.Path))
+	if _, err := os.Stat(full); err != nil {
+		t.Fatalf("期望其他用户仍引用的文件保留: %v", err)
+	}
+	var blob model.ImageBlob
+	if err := testGormDB.Where("hash = ?", a.Image.SHA256).First(&blob).Error; err != nil || blob.RefCount != 1 {
+		t.Fatalf("期望引用计数为 1，实际为 %d err=%v", blob.RefCount, err)
+	}
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	commonpkg "perfect-pic-server/internal/common"
+	"perfect-pic-server/internal/consts"
+	moduledto "perfect-pic-server/internal/dto"
+)
+
+// maxImageTTLSeconds 图片有效期上限（10 年），同时用于校验系统默认值与用户设置。
+const maxImageTTLSeconds int64 = 10 * 365 * 24 * 3600
+
+// expiredImageBatchSize 清理任务每批删除的过期图片数。
+const expiredImageBatchSize = 100
+
+// ParseUploadExpiry 解析表单或 tus 元数据中以字符串提交的 expires_in 与 expires_at，未提交的字段返回 0。
+func ParseUploadExpiry(expiresIn string, expiresAt string) (int64, int64, error) {
+	var in, at int64
+	var err error
+	if raw := strings.TrimSpace(expiresIn); raw != "" {
+		if in, err = strconv.ParseInt(raw, 10, 64); err != nil {
+			return 0, 0, commonpkg.NewValidationError("expires_in 必须为整数（单位：秒）")
+		}
+	}
+	if raw := strings.TrimSpace(expiresAt); raw != "" {
+		if at, err = strconv.ParseInt(raw, 10, 64); err != nil {
+			return 0, 0, commonpkg.NewValidationError("expires_at 必须为 Unix 时间戳（单位：秒）")
+		}
+	}
+	return in, at, nil
+}
+
+// resolveImageExpiry 计算上传图片的过期时间（Unix 秒）：优先使用请求中的 expires_in/expires_at，
+// 否则依次使用用户与系统的默认有效期；返回 nil 表示永久保存。
+func (s *ImageService) resolveImageExpiry(info moduledto.ImageUploadInfo, now time.Time) (*int64, error) {
+	if info.ExpiresIn != 0 && info.ExpiresAt != 0 {
+		return nil, commonpkg.NewValidationError("expires_in 与 expires_at 只能提供一个")
+	}
+
+	var ttl int64
+	switch {
+	case info.ExpiresIn != 0:
+		if info.ExpiresIn < 0 || info.ExpiresIn > maxImageTTLSeconds {
+			return nil, commonpkg.NewValidationError(fmt.Sprintf("有效期需在 1 到 %d 秒之间", maxImageTTLSeconds))
+		}
+		ttl = info.ExpiresIn
+	case info.ExpiresAt != 0:
+		if info.ExpiresAt <= now.Unix() || info.ExpiresAt-now.Unix() > maxImageTTLSeconds {
+			return nil, commonpkg.NewValidationError("过期时间必须晚于当前时间且不超过 10 年")
+		}
+		expiresAt := info.ExpiresAt
+		return &expiresAt, nil
+	case info.DefaultTTL != nil:
+		ttl = *info.DefaultTTL
+	default:
+		ttl = s.dbConfig.GetInt64(consts.ConfigDefaultImageTTL)
+	}
+
+	if ttl <= 0 {
+		return nil, nil
+	}
+	expiresAt := now.Unix() + min(ttl, maxImageTTLSeconds)
+	return &expiresAt, nil
+}
+
+// DeleteExpiredImages 分批删除已过期的图片并释放所属用户的存储空间，返回删除的记录数。
+func (s *ImageService) DeleteExpiredImages() (int, error) {
+	now := time.Now().Unix()
+	deleted := 0
+	for {
+		images, err := s.imageStore.FindExpired(now, expiredImageBatchSize)
+		if err != nil {
+			return deleted, fmt.Errorf("find expired images: %w", err)
+		}
+		if len(images) == 0 {
+			return deleted, nil
+		}
+		if err := s.BatchDeleteImages(images); err != nil {
+			return deleted, fmt.Errorf("delete expired images: %w", err)
+		}
+		deleted += len(images)
+		if len(images) < expiredImageBatchSize {
+			return deleted, nil
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"perfect-pic-server/internal/common"
+	"perfect-pic-server/internal/consts"
+	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/model"
+)
+
+// 测试内容：验证上传有效期的计算顺序：请求参数优先，其次为用户默认值与系统默认值，并校验非法取值。
+func TestResolveImageExpiry(t *testing.T) {
+	setupTestDB(t)
+	s := testService.imageService
+	now := time.Unix(1_800_000_000, 0)
+
+	expiry := func(info moduledto.ImageUploadInfo) *int64 {
+		t.Helper()
+		got, err := s.resolveImageExpiry(info, now)
+		if err != nil {
+			t.Fatalf("resolve: %v", err)
+		}
+		return got
+	}
+
+	if got := expiry(moduledto.ImageUploadInfo{}); got != nil {
+		t.Fatalf("期望默认永久保存，实际为 %d", *got)
+	}
+	if got := expiry(moduledto.ImageUploadInfo{ExpiresIn: 60}); got == nil || *got != now.Unix()+60 {
+		t.Fatalf("expires_in 结果不符: %v", got)
+	}
+	if got := expiry(moduledto.ImageUploadInfo{ExpiresAt: now.Unix() + 3600}); got == nil || *got != now.Unix()+3600 {
+		t.Fatalf("expires_at 结果不符: %v", got)
+	}
+
+	setTestSetting(t, consts.ConfigDefaultImageTTL, "86400")
+	if got := expiry(moduledto.ImageUploadInfo{}); got == nil || *got != now.Unix()+86400 {
+		t.Fatalf("期望使用系统默认有效期，实际为 %v", got)
+	}
+	userTTL := int64(600)
+	if got := expiry(moduledto.ImageUploadInfo{DefaultTTL: &userTTL}); got == nil || *got != now.Unix()+600 {
+		t.Fatalf("期望使用用户默认有效期，实际为 %v", got)
+	}
+	forever := int64(0)
+	if got := expiry(moduledto.ImageUploadInfo{DefaultTTL: &forever}); got != nil {
+		t.Fatalf("期望用户设置为永久保存时不过期，实际为 %d", *got)
+	}
+
+	for _, info := range []moduledto.ImageUploadInfo{
+		{ExpiresIn: 60, ExpiresAt: now.Unix() + 60},
+		{ExpiresIn: -1},
+		{ExpiresIn: maxImageTTLSeconds + 1},
+		{ExpiresAt: now.Unix()},
+	} {
+		_, err := s.resolveImageExpiry(info, now)
+		assertServiceErrorCode(t, err, common.ErrorCodeValidation)
+	}
+
+	if in, at, err := ParseUploadExpiry(" 60 ", ""); err != nil || in != 60 || at != 0 {
+		t.Fatalf("解析结果不符: %d %d %v", in, at, err)
+	}
+	_, _, err := ParseUploadExpiry("1h", "")
+	assertServiceErrorCode(t, err, common.ErrorCodeValidation)
+}
+
+// 测试内容：验证上传时写入过期时间，过期图片不可访问、不作为重复上传返回，并由清理任务删除文件与释放配额。
+func TestDeleteExpiredImages(t *testing.T) {
+	setupTestDB(t)
+	s := testService.imageService
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+
+	result, err := s.ProcessImageData("a.png", newSolidPNG(t, 4, 4, color.NRGBA{R: 255, A: 255}), u.ID, 0, 1<<20, moduledto.ImageUploadInfo{ExpiresIn: 3600})
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if result.Image.ExpiresAt == nil || *result.Image.ExpiresAt <= time.Now().Unix() {
+		t.Fatalf("期望写入过期时间，实际为 %v", result.Image.ExpiresAt)
+	}
+	other := newSolidPNG(t, 4, 4, color.NRGBA{B: 255, A: 255})
+	keep, err := s.ProcessImageData("b.png", other, u.ID, 0, 1<<20, moduledto.ImageUploadInfo{})
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	// 将第一张图片改为已过期
+	past := time.Now().Add(-time.Minute).Unix()
+	_ = testGormDB.Model(&model.Image{}).Where("id = ?", result.Image.ID).Update("expires_at", past).Error
+	full := filepath.Join("uploads", "imgs", filepath.FromSlash(result.Image.Path))
+
+	_, _, err = s.AuthorizeImageAccess(result.Image.Path, 0, nil, "", "")
+	assertServiceErrorCode(t, err, common.ErrorCodeNotFound)
+	if _, restricted, err := s.AuthorizeImageAccess(keep.Image.Path, 0, nil, "", ""); err != nil || restricted {
+		t.Fatalf("未过期图片应可访问: %v %v", restricted, err)
+	}
+	code := "expired"
+	_ = testGormDB.Model(&model.Image{}).Where("id = ?", result.Image.ID).Update("short_code", code).Error
+	_, err = s.FindImageByShortCode(code)
+	assertServiceErrorCode(t, err, common.ErrorCodeNotFound)
+
+	deleted, err := s.DeleteExpiredImages()
+	if err != nil || deleted != 1 {
+		t.Fatalf("期望删除 1 张过期图片，实际为 %d %v", deleted, err)
+	}
+	if _, err := os.Stat(full); !os.IsNotExist(err) {
+		t.Fatalf("期望删除过期图片文件，err=%v", err)
+	}
+	var got model.User
+	_ = testGormDB.First(&got, u.ID).Error
+	if got.StorageUsed != keep.Image.StorageSize() {
+		t.Fatalf("期望释放过期图片的配额，storage_used=%d", got.StorageUsed)
+	}
+	if err := testGormDB.First(&model.Image{}, keep.Image.ID).Error; err != nil {
+		t.Fatalf("未过期图片不应被删除: %v", err)
+	}
+
+	// 清理前重复上传已过期内容时，删除旧记录并按新上传处理
+	_ = testGormDB.Model(&model.Image{}).Where("id = ?", keep.Image.ID).Update("expires_at", past).Error
+	again, err := s.ProcessImageData("b.png", other, u.ID, got.StorageUsed, got.StorageUsed, moduledto.ImageUploadInfo{})
+	if err != nil || again.Duplicate || again.Image.ID == keep.Image.ID {
+		t.Fatalf("期望过期内容按新上传处理，实际为 %+v %v", again, err)
+	}
+	_ = testGormDB.First(&got, u.ID).Error
+	if got.StorageUsed != again.Image.StorageSize() {
+		t.Fatalf("期望配额只计入新记录，storage_used=%d", got.StorageUsed)
+	}
+}
+
+// 测试内容：验证后台清理任务启动后立即执行一次，stop 等待清理完成并可重复调用。
+func TestStartImageJanitor(t *testing.T) {
+	setupTestDB(t)
+	s := testService.imageService
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com", StorageUsed: 4}
+	_ = testGormDB.Create(&u).Error
+	past := time.Now().Add(-time.Minute).Unix()
+	img := model.Image{Filename: "a.png", Path: "2026/a.png", Size: 4, MimeType: ".png", UploadedAt: 1, UserID: u.ID, ExpiresAt: &past}
+	_ = testGormDB.Create(&img).Error
+
+	stop := s.StartImageJanitor(time.Hour)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		var count int64
+		_ = testGormDB.Model(&model.Image{}).Count(&count).Error
+		if count == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("期望清理任务删除过期图片")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	stop()
+	stop()
+}
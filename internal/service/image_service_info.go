@@ -0,0 +1,158 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	commonpkg "perfect-pic-server/internal/common"
+	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/pkg/imageproc"
+	"perfect-pic-server/internal/pkg/pathpkg"
+	"perfect-pic-server/internal/pkg/storage"
+
+	"gorm.io/gorm"
+)
+
+const (
+	maxImageTitleLength       = 100
+	maxImageDescriptionLength = 1000
+	// maxOriginalNameBytes 原始文件名的最大字节数，与多数文件系统的文件名上限一致。
+	maxOriginalNameBytes = 255
+)
+
+// UpdateImageInfo 修改用户图片的原始文件名、标题、描述与可见性，返回更新后的图片详情。
+func (s *ImageService) UpdateImageInfo(imageID uint, userID uint, req moduledto.UpdateImageInfoRequest) (*moduledto.ImageDetailResponse, error) {
+	updates := make(map[string]interface{})
+	if req.OriginalName != nil {
+		updates["original_name"] = sanitizeOriginalName(*req.OriginalName)
+	}
+	if req.Title != nil {
+		title, err := normalizeImageTitle(*req.Title)
+		if err != nil {
+			return nil, err
+		}
+		updates["title"] = title
+	}
+	if req.Description != nil {
+		description, err := normalizeImageDescription(*req.Description)
+		if err != nil {
+			return nil, err
+		}
+		updates["description"] = description
+	}
+	if req.Visibility != nil {
+		visibility, err := normalizeImageVisibility(*req.Visibility)
+		if err != nil {
+			return nil, err
+		}
+		updates["visibility"] = visibility
+	}
+
+	if _, err := s.imageStore.FindByIDAndUserID(imageID, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, commonpkg.NewNotFoundError("图片不存在或无权访问")
+		}
+		return nil, commonpkg.NewInternalError("查找图片失败")
+	}
+
+	if len(updates) > 0 {
+		if err := s.imageStore.UpdateImageInfo(imageID, userID, updates); err != nil {
+			log.Printf("Update image info error: %v\n", err)
+			return nil, commonpkg.NewInternalError("更新图片信息失败")
+		}
+	}
+	return s.GetImageDetail(imageID, userID)
+}
+
+// OpenImageDownload 打开原图用于下载（不做格式协商），并返回 Content-Disposition 使用的文件名。
+//
+// 去重后同一文件可能被多条记录共享，仅当这些记录的原始文件名一致时才使用原始文件名，
+// 否则退回存储文件名，避免把其他用户的文件名暴露给下载者。
+func (s *ImageService) OpenImageDownload(key string) (io.ReadCloser, *storage.ObjectInfo, string, error) {
+	cleanKey, err := storage.CleanKey(key)
+	if err != nil {
+		return nil, nil, "", commonpkg.NewNotFoundError("图片不存在")
+	}
+
+	reader, info, err := s.storage.Images.Get(cleanKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotExist) || errors.Is(err, pathpkg.ErrSymlink) {
+			return nil, nil, "", commonpkg.NewNotFoundError("图片不存在")
+		}
+		log.Printf("Open image error: %v\n", err)
+		return nil, nil, "", commonpkg.NewInternalError("读取图片失败")
+	}
+
+	filename := path.Base(cleanKey)
+	if names, err := s.imageStore.FindOriginalNamesByPath(cleanKey); err != nil {
+		log.Printf("Find original name error: %v\n", err)
+	} else if len(names) == 1 && names[0] != "" {
+		filename = downloadFilename(names[0], path.Ext(cleanKey))
+	}
+	return reader, info, filename, nil
+}
+
+// downloadFilename 使原始文件名的扩展名与实际存储格式一致（例如上传 PNG 后被转码为 WebP）。
+func downloadFilename(original string, ext string) string {
+	originalExt := path.Ext(original)
+	if originalExt != "" && imageproc.NormalizeFormat(originalExt) == imageproc.NormalizeFormat(ext) {
+		return original
+	}
+	return strings.TrimSuffix(original, originalExt) + ext
+}
+
+// sanitizeOriginalName 清理客户端提交的文件名：去除路径部分与控制字符，并限制长度。
+func sanitizeOriginalName(name string) string {
+	// 部分客户端会提交完整路径（包括 Windows 风格的反斜杠）
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "." || name == ".." {
+		return ""
+	}
+
+	if len(name) > maxOriginalNameBytes {
+		// 保留扩展名，按字符边界截断主体部分
+		ext := path.Ext(name)
+		if len(ext) > 16 {
+			ext = ""
+		}
+		base := name[:len(name)-len(ext)]
+		limit := maxOriginalNameBytes - len(ext)
+		for len(base) > limit {
+			_, size := utf8.DecodeLastRuneInString(base)
+			base = base[:len(base)-size]
+		}
+		name = base + ext
+	}
+	return name
+}
+
+func normalizeImageTitle(title string) (string, error) {
+	title = strings.TrimSpace(title)
+	if utf8.RuneCountInString(title) > maxImageTitleLength {
+		return "", commonpkg.NewValidationError(fmt.Sprintf("图片标题不能超过 %d 个字符", maxImageTitleLength))
+	}
+	return title, nil
+}
+
+func normalizeImageDescription(description string) (string, error) {
+	description = strings.TrimSpace(description)
+	if utf8.RuneCountInString(description) > maxImageDescriptionLength {
+		return "", commonpkg.NewValidationError(fmt.Sprintf("图片描述不能超过 %d 个字符", maxImageDescriptionLength))
+	}
+	return description, nil
+}
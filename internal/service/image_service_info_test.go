@@ -0,0 +1,159 @@
+package service
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/model"
+	"perfect-pic-server/internal/testutils"
+)
+
+// 测试内容：验证上传时保存清理后的原始文件名、标题与描述，并可通过全文检索命中。
+func TestProcessImageUpload_StoresOriginalNameAndInfo(t *testing.T) {
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+
+	info := moduledto.ImageUploadInfo{Title: " 海边日落 ", Description: "sunset at the beach"}
+	res, err := testService.imageService.ProcessImageUpload(mustFileHeader(t, `C:\fakepath\IMG_0001.png`, testutils.MinimalPNG()), u.ID, 0, 1<<20, info)
+	if err != nil {
+		t.Fatalf("ProcessImageUpload: %v", err)
+	}
+	if res.Image.OriginalName != "IMG_0001.png" || res.Image.Title != "海边日落" || res.Image.Description != "sunset at the beach" {
+		t.Fatalf("非预期图片信息: %+v", res.Image)
+	}
+
+	uid := u.ID
+	for _, q := range []string{"img_0001", "海边", "beach"} {
+		images, _, _, _, err := testService.imageService.ListImages(moduledto.ListImagesRequest{
+			PaginationRequest: moduledto.PaginationRequest{Page: 1, PageSize: 10},
+			UserID:            &uid,
+			Search:            q,
+		})
+		if err != nil || len(images) != 1 {
+			t.Fatalf("期望 %q 命中上传的图片: images=%d err=%v", q, len(images), err)
+		}
+	}
+
+	long := moduledto.ImageUploadInfo{Title: strings.Repeat("长", maxImageTitleLength+1)}
+	if _, err := testService.imageService.ProcessImageUpload(mustFileHeader(t, "b.png", testutils.MinimalPNG()), u.ID, 0, 1<<20, long); err == nil {
+		t.Fatalf("期望超长标题返回错误")
+	}
+}
+
+// 测试内容：验证修改图片信息只更新提交的字段、校验长度与归属，并同步更新检索文档。
+func TestImageService_UpdateImageInfo(t *testing.T) {
+	setupTestDB(t)
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+	other := model.User{Username: "bob", Password: "x", Status: 1, Email: "b@example.com"}
+	_ = testGormDB.Create(&other).Error
+	img := model.Image{Filename: "a.png", Path: "a.png", Size: 1, Width: 1, Height: 1, MimeType: ".png", UploadedAt: 1, UserID: u.ID, OriginalName: "a.png", Description: "keep"}
+	_ = testGormDB.Create(&img).Error
+
+	title := "mountain"
+	name := "../../etc/passwd\n"
+	detail, err := testService.imageService.UpdateImageInfo(img.ID, u.ID, moduledto.UpdateImageInfoRequest{Title: &title, OriginalName: &name})
+	if err != nil {
+		t.Fatalf("UpdateImageInfo: %v", err)
+	}
+	if detail.Title != "mountain" || detail.OriginalName != "passwd" || detail.Description != "keep" {
+		t.Fatalf("非预期图片信息: %+v", detail.Image)
+	}
+
+	var doc model.ImageSearchDocument
+	if err := testGormDB.Where("image_id = ?", img.ID).First(&doc).Error; err != nil || !strings.Contains(doc.Content, "mountain") {
+		t.Fatalf("期望检索文档包含新标题: doc=%+v err=%v", doc, err)
+	}
+
+	description := strings.Repeat("x", maxImageDescriptionLength+1)
+	if _, err := testService.imageService.UpdateImageInfo(img.ID, u.ID, moduledto.UpdateImageInfoRequest{Description: &description}); err == nil {
+		t.Fatalf("期望超长描述返回错误")
+	}
+	if _, err := testService.imageService.UpdateImageInfo(img.ID, other.ID, moduledto.UpdateImageInfoRequest{Title: &title}); err == nil {
+		t.Fatalf("期望不能修改他人的图片")
+	}
+}
+
+// 测试内容：验证下载文件名使用原始文件名并与存储格式对齐，共享文件的原始文件名不一致时退回存储文件名。
+func TestImageService_OpenImageDownload(t *testing.T) {
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	alice := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&alice).Error
+	bob := model.User{Username: "bob", Password: "x", Status: 1, Email: "b@example.com"}
+	_ = testGormDB.Create(&bob).Error
+
+	res, err := testService.imageService.ProcessImageUpload(mustFileHeader(t, "旅行.PNG", testutils.MinimalPNG()), alice.ID, 0, 1<<20, moduledto.ImageUploadInfo{})
+	if err != nil {
+		t.Fatalf("ProcessImageUpload: %v", err)
+	}
+
+	reader, _, filename, err := testService.imageService.OpenImageDownload(res.Image.Path)
+	if err != nil {
+		t.Fatalf("OpenImageDownload: %v", err)
+	}
+	data, _ := io.ReadAll(reader)
+	_ = reader.Close()
+	if filename != "旅行.PNG" || len(data) == 0 {
+		t.Fatalf("期望下载名为 旅行.PNG，实际为 %q（%d 字节）", filename, len(data))
+	}
+
+	// 其他用户以不同文件名上传相同内容后，不再暴露任何一方的文件名
+	if _, err := testService.imageService.ProcessImageUpload(mustFileHeader(t, "secret.png", testutils.MinimalPNG()), bob.ID, 0, 1<<20, moduledto.ImageUploadInfo{}); err != nil {
+		t.Fatalf("ProcessImageUpload: %v", err)
+	}
+	reader, _, filename, err = testService.imageService.OpenImageDownload(res.Image.Path)
+	if err != nil {
+		t.Fatalf("OpenImageDownload: %v", err)
+	}
+	_ = reader.Close()
+	if strings.Contains(filename, "旅行") || strings.Contains(filename, "secret") {
+		t.Fatalf("期望退回存储文件名，实际为 %q", filename)
+	}
+
+	if _, _, _, err := testService.imageService.OpenImageDownload("../etc/passwd"); err == nil {
+		t.Fatalf("期望非法路径返回错误")
+	}
+}
+
+// 测试内容：验证原始文件名的路径、控制字符清理与按字节截断，以及下载名扩展名对齐。
+func TestSanitizeOriginalNameAndDownloadFilename(t *testing.T) {
+	if got := sanitizeOriginalName(` /tmp/a\b\ photo.jpg `); got != "photo.jpg" {
+		t.Fatalf("期望 photo.jpg，实际为 %q", got)
+	}
+	if got := sanitizeOriginalName(".."); got != "" {
+		t.Fatalf("期望 .. 被清空，实际为 %q", got)
+	}
+	long := sanitizeOriginalName(strings.Repeat("图", 200) + ".png")
+	if len(long) > maxOriginalNameBytes || !strings.HasSuffix(long, "图.png") {
+		t.Fatalf("期望按字符边界截断并保留扩展名，实际长度 %d", len(long))
+	}
+
+	cases := []struct{ original, ext, want string }{
+		{"a.jpg", ".jpg", "a.jpg"},
+		{"a.JPEG", ".jpg", "a.JPEG"},
+		{"a.png", ".webp", "a.webp"},
+		{"noext", ".webp", "noext.webp"},
+	}
+	for _, tc := range cases {
+		if got := downloadFilename(tc.original, tc.ext); got != tc.want {
+			t.Fatalf("%q: 期望 %q，实际为 %q", tc.original, tc.want, got)
+		}
+	}
+}
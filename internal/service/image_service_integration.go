@@ -0,0 +1,121 @@
+package service
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	commonpkg "perfect-pic-server/internal/common"
+	"perfect-pic-server/internal/consts"
+	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/model"
+)
+
+const (
+	// integrationThumbnailMaxSize 兼容上传响应中缩略图的期望边长上限，实际取允许尺寸中不超过该值的最大者。
+	integrationThumbnailMaxSize = 320
+	// defaultIntegrationDeleteLinkHours/maxIntegrationDeleteLinkHours 删除链接有效期（小时）的默认值与上限。
+	defaultIntegrationDeleteLinkHours = 24
+	maxIntegrationDeleteLinkHours     = 8760
+)
+
+// BuildIntegrationUploadResponse 将上传结果转换为第三方客户端使用的响应，附带缩略图与免登录的删除链接。
+func (s *ImageService) BuildIntegrationUploadResponse(result *moduledto.ImageUploadResult) *moduledto.IntegrationUploadResponse {
+	image := result.Image
+	expires := time.Now().Add(s.integrationDeleteLinkTTL()).Unix()
+	resp := &moduledto.IntegrationUploadResponse{
+		ID:           image.ID,
+		Filename:     image.OriginalName,
+		URL:          s.absoluteURL(result.URL),
+		ThumbnailURL: s.absoluteURL(result.URL),
+		DeleteURL:    s.absoluteURL(fmt.Sprintf("/api/integrations/delete/%d?exp=%d&sig=%s", image.ID, expires, url.QueryEscape(s.imageDeleteSignature(image, expires)))),
+		Duplicate:    result.Duplicate,
+		ExpiresAt:    image.ExpiresAt,
+	}
+	if result.ShortURL != "" {
+		resp.ShortURL = s.absoluteURL(result.ShortURL)
+	}
+	// 缩略图由服务端按需生成，始终经由服务端的图片访问前缀
+	if size := s.integrationThumbnailSize(); size > 0 && s.IsImageVariantEnabled() {
+		prefix := strings.TrimSuffix(s.staticConfig.Upload.URLPrefix, "/")
+		resp.ThumbnailURL = s.absoluteURL(fmt.Sprintf("%s/%s?%s=%d&w=%d&h=%d&fit=contain", prefix, image.Path, ImageRecordQueryParam, image.ID, size, size))
+	}
+	return resp
+}
+
+// VerifyImageDeleteLink 校验兼容上传响应中的删除链接，返回待删除的图片。链接过期或签名不匹配时返回 Forbidden。
+func (s *ImageService) VerifyImageDeleteLink(imageID uint, expires string, signature string) (*model.Image, error) {
+	image, err := s.GetImageByID(imageID, nil)
+	if err != nil {
+		return nil, err
+	}
+	exp, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || signature == "" || !s.signer.Verify(imageDeleteSignatureKey(image), exp, signature, time.Now()) {
+		return nil, commonpkg.NewForbiddenError("删除链接无效或已过期")
+	}
+	return image, nil
+}
+
+// DeleteImageWithSignature 通过兼容上传响应中的删除链接删除图片（移入回收站），无需登录，返回被删除的图片。
+func (s *ImageService) DeleteImageWithSignature(imageID uint, expires string, signature string) (*model.Image, error) {
+	image, err := s.VerifyImageDeleteLink(imageID, expires, signature)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.TrashImages([]model.Image{*image}); err != nil {
+		return nil, err
+	}
+	return image, nil
+}
+
+// integrationThumbnailSize 从允许的缩略图尺寸中选取兼容上传响应使用的边长：优先取不超过 integrationThumbnailMaxSize 的最大者，
+// 都超过时取最小者；未配置任何尺寸时返回 0，缩略图地址退回原图。
+func (s *ImageService) integrationThumbnailSize() int {
+	best, smallest := 0, 0
+	for size := range parseVariantSizes(s.dbConfig.GetString(consts.ConfigImageVariantAllowedSizes)) {
+		if size <= integrationThumbnailMaxSize && size > best {
+			best = size
+		}
+		if smallest == 0 || size < smallest {
+			smallest = size
+		}
+	}
+	if best == 0 {
+		return smallest
+	}
+	return best
+}
+
+// integrationDeleteLinkTTL 返回删除链接的有效期。
+func (s *ImageService) integrationDeleteLinkTTL() time.Duration {
+	hours := s.dbConfig.GetInt(consts.ConfigIntegrationDeleteLinkHours)
+	if hours <= 0 {
+		hours = defaultIntegrationDeleteLinkHours
+	}
+	return time.Duration(min(hours, maxIntegrationDeleteLinkHours)) * time.Hour
+}
+
+// imageDeleteSignature 计算图片删除链接在 expires（Unix 秒）之前有效的签名。
+func (s *ImageService) imageDeleteSignature(image *model.Image, expires int64) string {
+	return s.signer.Sign(imageDeleteSignatureKey(image), expires)
+}
+
+// imageDeleteSignatureKey 删除链接的签名 key，带 delete/ 前缀并包含图片 ID 与存储路径，
+// 与图片访问签名互不通用，图片删除后自然失效。
+func imageDeleteSignatureKey(image *model.Image) string {
+	return "delete/" + strconv.FormatUint(uint64(image.ID), 10) + "/" + image.Path
+}
+
+// absoluteURL 为站内相对地址补全网站基础 URL，对象存储等已是绝对地址的保持不变。
+func (s *ImageService) absoluteURL(u string) string {
+	if !strings.HasPrefix(u, "/") || strings.HasPrefix(u, "//") {
+		return u
+	}
+	baseURL := strings.TrimSuffix(s.dbConfig.GetString(consts.ConfigBaseURL), "/")
+	if baseURL == "" {
+		baseURL = "http://localhost"
+	}
+	return baseURL + u
+}
@@ -0,0 +1,51 @@
+package service
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// StartImageJanitor 启动后台清理任务，立即执行一次后每隔 interval 删除过期图片并清理超过保留天数的回收站图片。
+// 返回的 stop 函数会等待正在进行的清理完成后再返回，可安全地重复调用。
+func (s *ImageService) StartImageJanitor(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			s.runImageJanitor()
+
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+		})
+	}
+}
+
+func (s *ImageService) runImageJanitor() {
+	if deleted, err := s.DeleteExpiredImages(); err != nil {
+		log.Printf("Delete expired images error: %v\n", err)
+	} else if deleted > 0 {
+		log.Printf("🧹 已清理 %d 张过期图片\n", deleted)
+	}
+	if purged, err := s.PurgeTrashedImages(); err != nil {
+		log.Printf("Purge trashed images error: %v\n", err)
+	} else if purged > 0 {
+		log.Printf("🧹 已永久删除 %d 张超过保留期的回收站图片\n", purged)
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"log"
+	commonpkg "perfect-pic-server/internal/common"
+	"perfect-pic-server/internal/consts"
+	"perfect-pic-server/internal/model"
+	"perfect-pic-server/internal/pkg/imagemeta"
+)
+
+// imageStripMode 读取元数据清理模式，非法配置回退为全部清理。
+func (s *ImageService) imageStripMode() imagemeta.StripMode {
+	mode, ok := imagemeta.ParseStripMode(s.dbConfig.GetString(consts.ConfigImageStripMetadata))
+	if !ok {
+		return imagemeta.StripAll
+	}
+	return mode
+}
+
+// stripImageMetadata 按系统设置清理上传内容中的 EXIF/XMP/IPTC 元数据，像素数据不会被重新编码。
+func (s *ImageService) stripImageMetadata(data []byte) ([]byte, error) {
+	stripped, err := imagemeta.Strip(data, s.imageStripMode())
+	if err != nil {
+		log.Printf("Strip image metadata error: %v\n", err)
+		return nil, commonpkg.NewValidationError("图片结构异常，无法处理元数据，请上传有效图片")
+	}
+	return stripped, nil
+}
+
+// extractImageMetadata 解析上传内容中的 EXIF 拍摄信息；无 EXIF 或解析失败时返回 nil，不影响上传。
+func (s *ImageService) extractImageMetadata(data []byte) *model.ImageMetadata {
+	exif, err := imagemeta.ParseEXIF(data)
+	if err != nil {
+		log.Printf("Parse image EXIF error: %v\n", err)
+		return nil
+	}
+	if exif == nil {
+		return nil
+	}
+
+	meta := &model.ImageMetadata{
+		Make:         truncateRunes(exif.Make, 128),
+		Model:        truncateRunes(exif.Model, 128),
+		LensModel:    truncateRunes(exif.LensModel, 128),
+		ExposureTime: truncateRunes(exif.ExposureTime, 32),
+		FNumber:      exif.FNumber,
+		ISO:          exif.ISO,
+		FocalLength:  exif.FocalLength,
+		TakenAt:      exif.DateTimeOriginal,
+		Orientation:  exif.Orientation,
+	}
+	if exif.GPS != nil {
+		lat, lon := exif.GPS.Latitude, exif.GPS.Longitude
+		meta.GPSLatitude = &lat
+		meta.GPSLongitude = &lon
+		meta.GPSAltitude = exif.GPS.Altitude
+	}
+	return meta
+}
+
+// truncateRunes 按字符数截断字符串，避免超出数据库列长度。
+func truncateRunes(value string, limit int) string {
+	runes := []rune(value)
+	if len(runes) <= limit {
+		return value
+	}
+	return string(runes[:limit])
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"perfect-pic-server/internal/common"
+	"perfect-pic-server/internal/consts"
+	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/model"
+	"perfect-pic-server/internal/testutils"
+)
+
+// 测试内容：验证上传时按设置清理 EXIF，记录大小与配额以清理后的内容为准；keep 模式原样保存。
+func TestProcessImageUpload_StripsMetadata(t *testing.T) {
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+
+	content := testutils.JPEGWithEXIF(testutils.EXIFFixture{Make: "SECRET-CAMERA"})
+	res, err := testService.imageService.ProcessImageUpload(mustFileHeader(t, "a.jpg", content), u.ID, 0, 1<<20, moduledto.ImageUploadInfo{})
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	stored, err := os.ReadFile(filepath.Join("uploads", "imgs", filepath.FromSlash(res.Image.Path)))
+	if err != nil {
+		t.Fatalf("read stored: %v", err)
+	}
+	if bytes.Contains(stored, []byte("SECRET-CAMERA")) {
+		t.Fatalf("期望存储内容已移除 EXIF")
+	}
+	if res.Image.Size != int64(len(stored)) || res.Image.Size >= int64(len(content)) {
+		t.Fatalf("期望记录大小为清理后的 %d，实际为 %d（原始 %d）", len(stored), res.Image.Size, len(content))
+	}
+	var got model.User
+	_ = testGormDB.First(&got, u.ID).Error
+	if got.StorageUsed != res.Image.Size {
+		t.Fatalf("期望配额按清理后大小计算 %d，实际为 %d", res.Image.Size, got.StorageUsed)
+	}
+
+	_ = testGormDB.Save(&model.Setting{Key: consts.ConfigImageStripMetadata, Value: "keep"}).Error
+	testService.ClearCache()
+	kept := testutils.JPEGWithEXIF(testutils.EXIFFixture{Make: "KEPT-CAMERA"})
+	res, err = testService.imageService.ProcessImageUpload(mustFileHeader(t, "b.jpg", kept), u.ID, got.StorageUsed, 1<<20, moduledto.ImageUploadInfo{})
+	if err != nil {
+		t.Fatalf("upload keep: %v", err)
+	}
+	stored, _ = os.ReadFile(filepath.Join("uploads", "imgs", filepath.FromSlash(res.Image.Path)))
+	if !bytes.Equal(stored, kept) || res.Image.Size != int64(len(kept)) {
+		t.Fatalf("期望 keep 模式原样保存")
+	}
+}
+
+// 测试内容：验证元数据清理模式设置的取值校验。
+func TestValidateSettingUpdate_ImageStripMetadata(t *testing.T) {
+	for _, value := range []string{"all", "gps", "keep"} {
+		if err := validateSettingUpdate(moduledto.UpdateSettingRequest{Key: consts.ConfigImageStripMetadata, Value: value}); err != nil {
+			t.Fatalf("%q: 期望合法，实际为 %v", value, err)
+		}
+	}
+	if err := validateSettingUpdate(moduledto.UpdateSettingRequest{Key: consts.ConfigImageStripMetadata, Value: "none"}); err == nil {
+		t.Fatalf("期望非法取值返回错误")
+	}
+}
+
+// 测试内容：验证上传时在清理前解析 EXIF 并保存拍摄信息，详情接口仅对所有者返回含 GPS 的数据，删除图片时一并删除。
+func TestProcessImageUpload_ExtractsMetadata(t *testing.T) {
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	other := model.User{Username: "bob", Password: "x", Status: 1, Email: "b@example.com"}
+	_ = testGormDB.Create(&u).Error
+	_ = testGormDB.Create(&other).Error
+
+	content := testutils.JPEGWithEXIF(testutils.EXIFFixture{
+		Make:             "TestMaker",
+		Model:            "TestCam",
+		DateTimeOriginal: "2024:05:06 07:08:09",
+		GPS:              &[2]uint32{31, 121},
+	})
+	res, err := testService.imageService.ProcessImageUpload(mustFileHeader(t, "a.jpg", content), u.ID, 0, 1<<20, moduledto.ImageUploadInfo{})
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	detail, err := testService.imageService.GetImageDetail(res.Image.ID, u.ID)
+	if err != nil {
+		t.Fatalf("GetImageDetail: %v", err)
+	}
+	meta := detail.Metadata
+	if meta == nil || meta.Make != "TestMaker" || meta.Model != "TestCam" {
+		t.Fatalf("期望保存相机信息，实际为 %+v", meta)
+	}
+	if meta.TakenAt == nil || !meta.TakenAt.Equal(time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)) {
+		t.Fatalf("期望拍摄时间被保存，实际为 %v", meta.TakenAt)
+	}
+	if meta.GPSLatitude == nil || *meta.GPSLatitude != 31 || meta.GPSLongitude == nil || *meta.GPSLongitude != 121 {
+		t.Fatalf("期望所有者可见 GPS，实际为 %+v", meta)
+	}
+	if detail.URL == "" {
+		t.Fatalf("期望详情包含访问地址")
+	}
+
+	if _, err := testService.imageService.GetImageDetail(res.Image.ID, other.ID); err == nil {
+		t.Fatalf("期望其他用户无法查看详情")
+	} else if se, ok := common.AsServiceError(err); !ok || se.Code != common.ErrorCodeNotFound {
+		t.Fatalf("期望 NotFound，实际为 %v", err)
+	}
+
+	// 管理端列表不预加载拍摄信息
+	images, _, _, _, err := testService.imageService.ListImages(moduledto.ListImagesRequest{PreloadUser: true})
+	if err != nil || len(images) != 1 || images[0].Metadata != nil {
+		t.Fatalf("期望管理端列表不含拍摄信息，实际为 %+v err=%v", images, err)
+	}
+
+	if err := testService.DeleteImage(res.Image); err != nil {
+		t.Fatalf("DeleteImage: %v", err)
+	}
+	var count int64
+	_ = testGormDB.Model(&model.ImageMetadata{}).Count(&count).Error
+	if count != 0 {
+		t.Fatalf("期望删除图片时删除拍摄信息，剩余 %d 条", count)
+	}
+}
+
+// 测试内容：验证按拍摄时间过滤与排序，无拍摄时间的图片排在最后，非法排序参数返回校验错误。
+func TestListImages_FilterAndSortByTakenAt(t *testing.T) {
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+	// 清理元数据后像素相同的图片会被去重，这里保留元数据以得到四条独立记录
+	_ = testGormDB.Save(&model.Setting{Key: consts.ConfigImageStripMetadata, Value: "keep"}).Error
+	testService.ClearCache()
+
+	upload := func(name string, fx testutils.EXIFFixture) uint {
+		res, err := testService.imageService.ProcessImageUpload(mustFileHeader(t, name, testutils.JPEGWithEXIF(fx)), u.ID, 0, 1<<30, moduledto.ImageUploadInfo{})
+		if err != nil {
+			t.Fatalf("upload %s: %v", name, err)
+		}
+		return res.Image.ID
+	}
+	mid := upload("mid.jpg", testutils.EXIFFixture{Model: "mid", DateTimeOriginal: "2023:06:01 12:00:00"})
+	none := upload("none.jpg", testutils.EXIFFixture{Model: "none"})
+	old := upload("old.jpg", testutils.EXIFFixture{Model: "old", DateTimeOriginal: "2020:01:01 00:00:00"})
+	recent := upload("recent.jpg", testutils.EXIFFixture{Model: "recent", DateTimeOriginal: "2024:12:31 23:59:59"})
+
+	ids := func(images []model.Image) []uint {
+		res := make([]uint, 0, len(images))
+		for _, img := range images {
+			res = append(res, img.ID)
+		}
+		return res
+	}
+	equal := func(a, b []uint) bool {
+		if len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if a[i] != b[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	desc, _, _, _, err := testService.imageService.ListImages(moduledto.ListImagesRequest{UserID: &u.ID, SortBy: "taken_at", PaginationRequest: moduledto.PaginationRequest{PageSize: 10}})
+	if err != nil {
+		t.Fatalf("ListImages: %v", err)
+	}
+	if got, want := ids(desc), []uint{recent, mid, old, none}; !equal(got, want) {
+		t.Fatalf("降序期望 %v，实际为 %v", want, got)
+	}
+
+	asc, _, _, _, _ := testService.imageService.ListImages(moduledto.ListImagesRequest{UserID: &u.ID, SortBy: "taken_at", SortOrder: "asc", PaginationRequest: moduledto.PaginationRequest{PageSize: 10}})
+	if got, want := ids(asc), []uint{old, mid, recent, none}; !equal(got, want) {
+		t.Fatalf("升序期望 %v，实际为 %v", want, got)
+	}
+
+	from := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	filtered, total, _, _, _ := testService.imageService.ListImages(moduledto.ListImagesRequest{UserID: &u.ID, TakenFrom: &from, TakenTo: &to})
+	if total != 2 || !equal(ids(filtered), []uint{recent, mid}) {
+		t.Fatalf("期望过滤出 2 张，实际为 total=%d ids=%v", total, ids(filtered))
+	}
+
+	if _, _, _, _, err := testService.imageService.ListImages(moduledto.ListImagesRequest{SortBy: "size"}); err == nil {
+		t.Fatalf("期望非法排序字段返回错误")
+	}
+}
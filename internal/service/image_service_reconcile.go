@@ -0,0 +1,223 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"path"
+	"strings"
+	"time"
+
+	commonpkg "perfect-pic-server/internal/common"
+	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/model"
+	"perfect-pic-server/internal/pkg/storage"
+	repo "perfect-pic-server/internal/repository"
+)
+
+// reconcileGracePeriod 最近修改时间在此之内的文件不视为孤立文件，避免误删刚写入、记录尚未提交的上传。
+const reconcileGracePeriod = time.Hour
+
+const (
+	reconcileStorageImages  = "images"
+	reconcileStorageAvatars = "avatars"
+
+	missingKindImage     = "image"
+	missingKindAlternate = "alternate"
+	missingKindAvatar    = "avatar"
+)
+
+// ReconcileStorage 对账存储中的文件与数据库记录：列出没有记录引用的孤立文件、记录存在但文件缺失的项，
+// 以及用户 storage_used 与图片记录合计不一致的情况。dryRun 为 false 时同时修复：删除孤立文件，
+// 删除原图缺失的图片记录，清除缺失的备用格式与头像记录，并按图片记录重新计算用户的已用空间。
+func (s *ImageService) ReconcileStorage(dryRun bool) (*moduledto.StorageReconcileReport, error) {
+	if !s.reconciling.CompareAndSwap(false, true) {
+		return nil, commonpkg.NewConflictError("存储对账任务正在进行中")
+	}
+	defer s.reconciling.Store(false)
+
+	report := &moduledto.StorageReconcileReport{
+		DryRun:       dryRun,
+		OrphanFiles:  []moduledto.StorageOrphanFile{},
+		MissingFiles: []moduledto.StorageMissingFile{},
+		StorageDrift: []moduledto.StorageDrift{},
+	}
+
+	// 先读取记录再遍历文件：遍历期间新上传的文件写入早于记录提交，只会落入宽限期而不会被误判为缺失
+	images, err := s.imageStore.ListFileRefs()
+	if err != nil {
+		log.Printf("Reconcile list images error: %v\n", err)
+		return nil, commonpkg.NewInternalError("读取图片记录失败")
+	}
+	blobPaths, err := s.imageStore.ListBlobPaths()
+	if err != nil {
+		log.Printf("Reconcile list blobs error: %v\n", err)
+		return nil, commonpkg.NewInternalError("读取图片记录失败")
+	}
+	users, err := s.imageStore.ListUserStorageUsage()
+	if err != nil {
+		log.Printf("Reconcile list users error: %v\n", err)
+		return nil, commonpkg.NewInternalError("读取用户记录失败")
+	}
+	report.ScannedImages = len(images)
+
+	imageFiles, err := s.reconcileImageFiles(report, images, blobPaths)
+	if err != nil {
+		log.Printf("Reconcile walk images error: %v\n", err)
+		return nil, commonpkg.NewInternalError("遍历图片存储失败")
+	}
+	avatarFiles, err := s.reconcileAvatarFiles(report, users)
+	if err != nil {
+		log.Printf("Reconcile walk avatars error: %v\n", err)
+		return nil, commonpkg.NewInternalError("遍历头像存储失败")
+	}
+
+	var missingImages []model.Image
+	var missingAlternates []uint
+	for _, img := range images {
+		if !hasKey(imageFiles, img.Path) {
+			report.MissingFiles = append(report.MissingFiles, moduledto.StorageMissingFile{Kind: missingKindImage, Key: img.Path, ImageID: img.ID, UserID: img.UserID})
+			missingImages = append(missingImages, img)
+			continue
+		}
+		if img.AltType == "" {
+			continue
+		}
+		if key := alternateKey(img.Path, img.AltType); !hasKey(imageFiles, key) {
+			report.MissingFiles = append(report.MissingFiles, moduledto.StorageMissingFile{Kind: missingKindAlternate, Key: key, ImageID: img.ID, UserID: img.UserID})
+			missingAlternates = append(missingAlternates, img.ID)
+		}
+	}
+	var missingAvatars []uint
+	for _, u := range users {
+		if u.Avatar == "" {
+			continue
+		}
+		if key := avatarKey(u.ID, u.Avatar); !hasKey(avatarFiles, key) {
+			report.MissingFiles = append(report.MissingFiles, moduledto.StorageMissingFile{Kind: missingKindAvatar, Key: key, UserID: u.ID})
+			missingAvatars = append(missingAvatars, u.ID)
+		}
+	}
+
+	if !dryRun {
+		s.deleteOrphanFiles(report)
+		if err := s.repairMissingFiles(missingImages, missingAlternates, missingAvatars); err != nil {
+			log.Printf("Reconcile repair records error: %v\n", err)
+			return nil, commonpkg.NewInternalError("修复缺失文件的记录失败")
+		}
+		// 记录修复会改变实际占用，重新读取后再对账配额
+		if users, err = s.imageStore.ListUserStorageUsage(); err != nil {
+			log.Printf("Reconcile list users error: %v\n", err)
+			return nil, commonpkg.NewInternalError("读取用户记录失败")
+		}
+	}
+
+	var drifted []uint
+	for _, u := range users {
+		if u.StorageUsed != u.ActualUsed {
+			report.StorageDrift = append(report.StorageDrift, moduledto.StorageDrift{UserID: u.ID, Username: u.Username, Recorded: u.StorageUsed, Actual: u.ActualUsed})
+			drifted = append(drifted, u.ID)
+		}
+	}
+	if !dryRun {
+		if err := s.imageStore.RecalculateUserStorage(drifted); err != nil {
+			log.Printf("Reconcile recalculate storage error: %v\n", err)
+			return nil, commonpkg.NewInternalError("重新计算用户已用空间失败")
+		}
+	}
+	return report, nil
+}
+
+// reconcileImageFiles 遍历图片存储，记录孤立文件并返回存在的全部 key。
+// 原图被引用时，其变体缓存同样视为被引用；备用格式文件仅在记录的格式与之一致时视为被引用。
+func (s *ImageService) reconcileImageFiles(report *moduledto.StorageReconcileReport, images []model.Image, blobPaths []string) (map[string]struct{}, error) {
+	referenced := make(map[string]struct{}, len(images)+len(blobPaths))
+	alternates := make(map[string]struct{})
+	for _, img := range images {
+		referenced[img.Path] = struct{}{}
+		if img.AltType != "" {
+			alternates[alternateKey(img.Path, img.AltType)] = struct{}{}
+		}
+	}
+	for _, p := range blobPaths {
+		referenced[p] = struct{}{}
+	}
+
+	isReferenced := func(key string) bool {
+		if hasKey(referenced, key) {
+			return true
+		}
+		base := accessBaseKey(key)
+		if base == key || !hasKey(referenced, base) {
+			return false
+		}
+		if strings.HasPrefix(path.Base(key), alternateFileName+".") {
+			return hasKey(alternates, key)
+		}
+		return true
+	}
+	return s.walkStorage(report, s.storage.Images, reconcileStorageImages, isReferenced)
+}
+
+// reconcileAvatarFiles 遍历头像存储，记录孤立文件并返回存在的全部 key。
+func (s *ImageService) reconcileAvatarFiles(report *moduledto.StorageReconcileReport, users []repo.UserStorageUsage) (map[string]struct{}, error) {
+	referenced := make(map[string]struct{}, len(users))
+	for _, u := range users {
+		if u.Avatar != "" {
+			referenced[avatarKey(u.ID, u.Avatar)] = struct{}{}
+		}
+	}
+	return s.walkStorage(report, s.storage.Avatars, reconcileStorageAvatars, func(key string) bool {
+		return hasKey(referenced, key)
+	})
+}
+
+func (s *ImageService) walkStorage(report *moduledto.StorageReconcileReport, store storage.Storage, name string, isReferenced func(string) bool) (map[string]struct{}, error) {
+	cutoff := time.Now().Add(-reconcileGracePeriod)
+	existing := make(map[string]struct{})
+	err := store.Walk(func(info storage.ObjectInfo) error {
+		existing[info.Key] = struct{}{}
+		report.ScannedFiles++
+		if isReferenced(info.Key) || info.LastModified.After(cutoff) {
+			return nil
+		}
+		report.OrphanFiles = append(report.OrphanFiles, moduledto.StorageOrphanFile{
+			Storage:    name,
+			Key:        info.Key,
+			Size:       info.Size,
+			ModifiedAt: info.LastModified.Unix(),
+		})
+		report.OrphanSize += info.Size
+		return nil
+	})
+	return existing, err
+}
+
+// deleteOrphanFiles 删除报告中的孤立文件，失败项记录到报告中而不中断对账。
+func (s *ImageService) deleteOrphanFiles(report *moduledto.StorageReconcileReport) {
+	for _, orphan := range report.OrphanFiles {
+		store := s.storage.Images
+		if orphan.Storage == reconcileStorageAvatars {
+			store = s.storage.Avatars
+		}
+		if err := store.Delete(orphan.Key); err != nil {
+			log.Printf("Reconcile delete orphan file error: %v, key: %s\n", err, orphan.Key)
+			report.Errors = append(report.Errors, fmt.Sprintf("删除孤立文件 %s/%s 失败: %v", orphan.Storage, orphan.Key, err))
+		}
+	}
+}
+
+// repairMissingFiles 删除原图缺失的图片记录，并清除缺失的备用格式与头像记录。
+func (s *ImageService) repairMissingFiles(images []model.Image, alternateIDs []uint, avatarUserIDs []uint) error {
+	if err := s.BatchDeleteImages(images); err != nil {
+		return err
+	}
+	if err := s.imageStore.ClearAlternates(alternateIDs); err != nil {
+		return err
+	}
+	return s.imageStore.ClearUserAvatars(avatarUserIDs)
+}
+
+func hasKey(set map[string]struct{}, key string) bool {
+	_, ok := set[key]
+	return ok
+}
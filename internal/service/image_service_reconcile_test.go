@@ -0,0 +1,122 @@
+package service
+
+import (
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"perfect-pic-server/internal/common"
+	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/model"
+)
+
+// 测试内容：验证存储对账在预演模式下只报告孤立文件、缺失文件与配额偏差，修复模式下删除孤立文件、清理失效记录并重新计算配额。
+func TestReconcileStorage(t *testing.T) {
+	setupTestDB(t)
+	s := testService.imageService
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	alice := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com", Avatar: "a.png"}
+	bob := model.User{Username: "bob", Password: "x", Status: 1, Email: "b@example.com", Avatar: "gone.png"}
+	_ = testGormDB.Create(&alice).Error
+	_ = testGormDB.Create(&bob).Error
+
+	result, err := s.ProcessImageData("a.png", newSolidPNG(t, 4, 4, color.NRGBA{R: 255, A: 255}), alice.ID, 0, 1<<20, moduledto.ImageUploadInfo{})
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	kept := result.Image
+	missing := model.Image{Filename: "m.png", Path: "2026/missing.png", Size: 5, MimeType: ".png", UploadedAt: 1, UserID: bob.ID}
+	_ = testGormDB.Create(&missing).Error
+	// bob 的 storage_used 未计入 missing，alice 的多记了 100 字节
+	_ = testGormDB.Model(&model.User{}).Where("id = ?", alice.ID).Update("storage_used", kept.StorageSize()+100).Error
+
+	old := time.Now().Add(-2 * reconcileGracePeriod)
+	writeFile := func(store, key string, aged bool) {
+		t.Helper()
+		full := filepath.Join("uploads", store, filepath.FromSlash(key))
+		_ = os.MkdirAll(filepath.Dir(full), 0755)
+		if err := os.WriteFile(full, []byte("data"), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		if aged {
+			_ = os.Chtimes(full, old, old)
+		}
+	}
+	writeFile("imgs", "2026/orphan.png", true)
+	writeFile("imgs", "2026/fresh.png", false)
+	writeFile("imgs", variantKey(kept.Path, 10, 10, "cover", "webp"), true)
+	writeFile("imgs", alternateKey(kept.Path, ".webp"), true)
+	writeFile("avatars", avatarKey(alice.ID, "a.png"), true)
+	writeFile("avatars", "99/x.png", true)
+
+	report, err := s.ReconcileStorage(true)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	orphans := map[string]bool{}
+	for _, f := range report.OrphanFiles {
+		orphans[f.Storage+":"+f.Key] = true
+	}
+	wantOrphans := []string{"images:2026/orphan.png", "images:" + alternateKey(kept.Path, ".webp"), "avatars:99/x.png"}
+	if len(orphans) != len(wantOrphans) || report.OrphanSize != int64(4*len(wantOrphans)) {
+		t.Fatalf("孤立文件不符: %+v", report.OrphanFiles)
+	}
+	for _, key := range wantOrphans {
+		if !orphans[key] {
+			t.Fatalf("期望报告孤立文件 %s，实际为 %+v", key, report.OrphanFiles)
+		}
+	}
+	missingKinds := map[string]string{}
+	for _, m := range report.MissingFiles {
+		missingKinds[m.Kind] = m.Key
+	}
+	if len(report.MissingFiles) != 2 || missingKinds["image"] != missing.Path || missingKinds["avatar"] != avatarKey(bob.ID, "gone.png") {
+		t.Fatalf("缺失文件不符: %+v", report.MissingFiles)
+	}
+	if len(report.StorageDrift) != 2 {
+		t.Fatalf("期望两个用户的配额存在偏差，实际为 %+v", report.StorageDrift)
+	}
+	if _, err := os.Stat(filepath.Join("uploads", "imgs", "2026", "orphan.png")); err != nil {
+		t.Fatalf("期望预演模式不删除文件: %v", err)
+	}
+
+	report, err = s.ReconcileStorage(false)
+	if err != nil || len(report.Errors) != 0 {
+		t.Fatalf("repair: %v %v", err, report)
+	}
+	if _, err := os.Stat(filepath.Join("uploads", "imgs", "2026", "orphan.png")); !os.IsNotExist(err) {
+		t.Fatalf("期望修复后删除孤立文件")
+	}
+	if _, err := os.Stat(filepath.Join("uploads", "imgs", "2026", "fresh.png")); err != nil {
+		t.Fatalf("期望保留宽限期内的文件: %v", err)
+	}
+	if err := testGormDB.Unscoped().First(&model.Image{}, missing.ID).Error; err == nil {
+		t.Fatalf("期望删除原图缺失的图片记录")
+	}
+	var gotAlice, gotBob model.User
+	_ = testGormDB.First(&gotAlice, alice.ID).Error
+	_ = testGormDB.First(&gotBob, bob.ID).Error
+	if gotAlice.StorageUsed != kept.StorageSize() || gotBob.StorageUsed != 0 || gotBob.Avatar != "" || gotAlice.Avatar != "a.png" {
+		t.Fatalf("修复结果不符: alice=%d bob=%d avatar=%q", gotAlice.StorageUsed, gotBob.StorageUsed, gotBob.Avatar)
+	}
+
+	report, err = s.ReconcileStorage(true)
+	if err != nil || len(report.OrphanFiles)+len(report.MissingFiles)+len(report.StorageDrift) != 0 {
+		t.Fatalf("期望修复后对账结果为空，实际为 %+v %v", report, err)
+	}
+	if report.ScannedImages != 1 {
+		t.Fatalf("期望扫描 1 条图片记录，实际为 %d", report.ScannedImages)
+	}
+
+	s.reconciling.Store(true)
+	defer s.reconciling.Store(false)
+	_, err = s.ReconcileStorage(true)
+	assertServiceErrorCode(t, err, common.ErrorCodeConflict)
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	commonpkg "perfect-pic-server/internal/common"
+	"perfect-pic-server/internal/consts"
+	"perfect-pic-server/internal/pkg/imageproc"
+	"perfect-pic-server/internal/pkg/remotefetch"
+	"strings"
+)
+
+// dataURIPrefix data URI 的协议前缀。
+const dataURIPrefix = "data:"
+
+// LoadUploadSource 读取按地址上传的图片内容：source 可以是 http/https 地址或 data:image/...;base64, 形式的 data URI。
+//
+// 返回的文件名用于扩展名校验与保存原始文件名：远程图片取地址中的文件名，data URI 使用调用方提供的 filename；
+// 扩展名与实际格式不一致或缺失时按实际格式修正。
+func (s *ImageService) LoadUploadSource(ctx context.Context, source string, filename string) (string, []byte, error) {
+	source = strings.TrimSpace(source)
+	if len(source) >= len(dataURIPrefix) && strings.EqualFold(source[:len(dataURIPrefix)], dataURIPrefix) {
+		return s.decodeDataURI(source, filename)
+	}
+	return s.fetchRemoteImage(ctx, source)
+}
+
+// fetchRemoteImage 经 SSRF 防护的抓取器下载远程图片，响应体大小受 max_upload_size 限制。
+func (s *ImageService) fetchRemoteImage(ctx context.Context, rawURL string) (string, []byte, error) {
+	if s.fetcher == nil {
+		return "", nil, commonpkg.NewInternalError("未启用远程图片上传")
+	}
+	maxSizeMB := s.dbConfig.GetInt(consts.ConfigMaxUploadSize)
+	result, err := s.fetcher.Fetch(ctx, rawURL, int64(maxSizeMB)*1024*1024)
+	if err != nil {
+		switch {
+		case errors.Is(err, remotefetch.ErrInvalidURL):
+			return "", nil, commonpkg.NewValidationError("图片地址无效，仅支持 http/https 地址")
+		case errors.Is(err, remotefetch.ErrBlockedAddress):
+			return "", nil, commonpkg.NewValidationError("不允许从该地址获取图片")
+		case errors.Is(err, remotefetch.ErrTooLarge):
+			return "", nil, commonpkg.NewValidationError(fmt.Sprintf("文件大小不能超过 %dMB", maxSizeMB))
+		case errors.Is(err, remotefetch.ErrTooManyRedirects):
+			return "", nil, commonpkg.NewValidationError("远程地址重定向次数过多")
+		case errors.Is(err, remotefetch.ErrBadStatus):
+			return "", nil, commonpkg.NewValidationError("远程服务器未返回图片")
+		}
+		log.Printf("Fetch remote image error: %v\n", err)
+		return "", nil, commonpkg.NewValidationError("获取远程图片失败，请检查地址是否可访问")
+	}
+
+	// 以内容嗅探结果为准，不信任远端声明的 Content-Type 与地址中的扩展名
+	format := imageproc.NormalizeFormat(strings.TrimPrefix(http.DetectContentType(result.Data), "image/"))
+	if format == "" {
+		return "", nil, commonpkg.NewValidationError("远程文件不是支持的图片格式")
+	}
+	return uploadFilename(path.Base(result.FinalURL.Path), format), result.Data, nil
+}
+
+// decodeDataURI 解析 data:image/...;base64, 形式的图片内容，解码前按编码长度预估大小以避免过大的内存分配。
+func (s *ImageService) decodeDataURI(dataURI string, filename string) (string, []byte, error) {
+	meta, payload, ok := strings.Cut(dataURI[len(dataURIPrefix):], ",")
+	params := strings.Split(meta, ";")
+	if !ok || len(params) < 2 || !strings.EqualFold(strings.TrimSpace(params[len(params)-1]), "base64") {
+		return "", nil, commonpkg.NewValidationError("仅支持 base64 编码的 data URI")
+	}
+	mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+	format := ""
+	if subtype, ok := strings.CutPrefix(mediaType, "image/"); ok {
+		format = imageproc.NormalizeFormat(subtype)
+	}
+	if format == "" {
+		return "", nil, commonpkg.NewValidationError("data URI 不是支持的图片格式")
+	}
+
+	payload = strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\n' || r == '\r' || r == '\t' {
+			return -1
+		}
+		return r
+	}, payload)
+	maxSizeMB := s.dbConfig.GetInt(consts.ConfigMaxUploadSize)
+	if int64(base64.RawStdEncoding.DecodedLen(len(strings.TrimRight(payload, "=")))) > int64(maxSizeMB)*1024*1024 {
+		return "", nil, commonpkg.NewValidationError(fmt.Sprintf("文件大小不能超过 %dMB", maxSizeMB))
+	}
+	content, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(payload, "="))
+	if err != nil || len(content) == 0 {
+		return "", nil, commonpkg.NewValidationError("data URI 内容不是有效的 base64 编码")
+	}
+	return uploadFilename(filename, format), content, nil
+}
+
+// uploadFilename 返回与实际格式一致的文件名：扩展名匹配时保留原名，否则替换或补全扩展名，缺少文件名时使用 image。
+func uploadFilename(name string, format string) string {
+	base := path.Base(strings.ReplaceAll(strings.TrimSpace(name), "\\", "/"))
+	if base == "." || base == "/" || base == "" {
+		base = "image"
+	}
+	ext := path.Ext(base)
+	if imageproc.NormalizeFormat(ext) == format {
+		return base
+	}
+	if stem := strings.TrimSuffix(base, ext); stem != "" {
+		base = stem
+	}
+	return base + imageproc.Ext(format)
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"image/color"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"perfect-pic-server/internal/common"
+	"perfect-pic-server/internal/consts"
+	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/model"
+)
+
+// 测试内容：验证 data URI 解码成功时按媒体类型修正文件名，并拒绝非 base64、非图片类型、非法编码与超限内容。
+func TestLoadUploadSource_DataURI(t *testing.T) {
+	setupTestDB(t)
+
+	content := newSolidPNG(t, 4, 4, color.NRGBA{R: 1, A: 255})
+	encoded := base64.StdEncoding.EncodeToString(content)
+
+	name, data, err := testService.imageService.LoadUploadSource(context.Background(), "data:image/png;base64,"+encoded, "shot.jpg")
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if name != "shot.png" || string(data) != string(content) {
+		t.Fatalf("解码结果不符: %q len=%d", name, len(data))
+	}
+	// 允许大小写不同的前缀、省略填充与换行
+	wrapped := encoded[:10] + "\n" + strings.TrimRight(encoded[10:], "=")
+	if name, _, err := testService.imageService.LoadUploadSource(context.Background(), "DATA:image/PNG;base64,"+wrapped, ""); err != nil || name != "image.png" {
+		t.Fatalf("期望解码成功且文件名为 image.png，实际为 %q %v", name, err)
+	}
+
+	invalid := []string{
+		"data:image/png," + encoded,
+		"data:text/plain;base64," + encoded,
+		"data:image/png;base64,@@@",
+		"data:image/png;base64,",
+	}
+	for _, source := range invalid {
+		_, _, err := testService.imageService.LoadUploadSource(context.Background(), source, "")
+		assertServiceErrorCode(t, err, common.ErrorCodeValidation)
+	}
+
+	setTestSetting(t, consts.ConfigMaxUploadSize, "1")
+	large := base64.StdEncoding.EncodeToString(make([]byte, 1024*1024+1))
+	_, _, err = testService.imageService.LoadUploadSource(context.Background(), "data:image/png;base64,"+large, "")
+	assertServiceErrorCode(t, err, common.ErrorCodeValidation)
+}
+
+// 测试内容：验证远程抓取以内容嗅探的格式修正文件名，非图片内容、错误状态码与非法地址返回参数错误。
+func TestLoadUploadSource_RemoteURL(t *testing.T) {
+	setupTestDB(t)
+
+	content := newSolidPNG(t, 4, 4, color.NRGBA{G: 1, A: 255})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/pics/cat.jpg", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/jpeg")
+		_, _ = w.Write(content)
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html><body>hello</body></html>"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	name, data, err := testService.imageService.LoadUploadSource(context.Background(), srv.URL+"/pics/cat.jpg?x=1", "")
+	if err != nil {
+		t.Fatalf("fetch: %v", err)
+	}
+	if name != "cat.png" || string(data) != string(content) {
+		t.Fatalf("抓取结果不符: %q len=%d", name, len(data))
+	}
+
+	for _, source := range []string{srv.URL + "/page", srv.URL + "/missing", "ftp://example.com/a.png"} {
+		_, _, err := testService.imageService.LoadUploadSource(context.Background(), source, "")
+		assertServiceErrorCode(t, err, common.ErrorCodeValidation)
+	}
+}
+
+// 测试内容：验证按内容上传时同样校验扩展名与图片内容，并正常入库。
+func TestProcessImageData(t *testing.T) {
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+
+	content := newSolidPNG(t, 4, 4, color.NRGBA{B: 1, A: 255})
+	res, err := testService.imageService.ProcessImageData("a.png", content, u.ID, 0, 1<<20, moduledto.ImageUploadInfo{})
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if res.Image.OriginalName != "a.png" || res.Image.Size != int64(len(content)) {
+		t.Fatalf("记录不符: %+v", res.Image)
+	}
+
+	_, err = testService.imageService.ProcessImageData("a.png", []byte("not an image"), u.ID, 0, 1<<20, moduledto.ImageUploadInfo{})
+	assertServiceErrorCode(t, err, common.ErrorCodeValidation)
+	_, err = testService.imageService.ProcessImageData("a.exe", content, u.ID, 0, 1<<20, moduledto.ImageUploadInfo{})
+	assertServiceErrorCode(t, err, common.ErrorCodeValidation)
+}
+
+// 测试内容：验证上传文件名按实际格式补全或替换扩展名。
+func TestUploadFilename(t *testing.T) {
+	cases := []struct {
+		name   string
+		format string
+		want   string
+	}{
+		{"a.png", "png", "a.png"},
+		{"a.JPG", "jpeg", "a.JPG"},
+		{"a.jpeg", "png", "a.png"},
+		{"photo", "webp", "photo.webp"},
+		{"", "gif", "image.gif"},
+		{"dir\\b.gif", "gif", "b.gif"},
+	}
+	for _, tc := range cases {
+		if got := uploadFilename(tc.name, tc.format); got != tc.want {
+			t.Fatalf("%q/%s: 期望 %q，实际为 %q", tc.name, tc.format, tc.want, got)
+		}
+	}
+}
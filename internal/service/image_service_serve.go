@@ -0,0 +1,77 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"log"
+	"path"
+	commonpkg "perfect-pic-server/internal/common"
+	"perfect-pic-server/internal/common/httpx"
+	"perfect-pic-server/internal/pkg/imageproc"
+	"perfect-pic-server/internal/pkg/pathpkg"
+	"perfect-pic-server/internal/pkg/storage"
+	"strings"
+)
+
+// alternateFormats 按优先级排列的备用格式候选。
+var alternateFormats = []string{imageproc.FormatWebP, imageproc.FormatJPEG}
+
+// OpenImage 按 Accept 头打开图片：存在客户端接受的备用格式时返回备用格式，否则返回原图。
+//
+// negotiable 表示该图片的响应内容可能随 Accept 变化，调用方应据此设置 Vary: Accept。
+func (s *ImageService) OpenImage(key string, accept string) (reader io.ReadCloser, info *storage.ObjectInfo, negotiable bool, err error) {
+	cleanKey, err := storage.CleanKey(key)
+	if err != nil {
+		return nil, nil, false, commonpkg.NewNotFoundError("图片不存在")
+	}
+
+	source := imageproc.NormalizeFormat(path.Ext(cleanKey))
+	negotiable = convertibleSource(source) && !strings.Contains(cleanKey, variantDirSuffix+"/")
+	if negotiable {
+		if reader, info := s.openAlternate(cleanKey, source, accept); reader != nil {
+			return reader, info, true, nil
+		}
+	}
+
+	reader, info, err = s.storage.Images.Get(cleanKey)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotExist) {
+			return nil, nil, false, commonpkg.NewNotFoundError("图片不存在")
+		}
+		log.Printf("Open image error: %v\n", err)
+		if errors.Is(err, pathpkg.ErrSymlink) {
+			// 不向客户端暴露符号链接的存在
+			return nil, nil, false, commonpkg.NewNotFoundError("图片不存在")
+		}
+		return nil, nil, false, commonpkg.NewInternalError("读取图片失败")
+	}
+	return reader, info, negotiable, nil
+}
+
+// openAlternate 选择并打开客户端接受的备用格式，没有可用的备用格式时返回 nil。
+//
+// WebP 要求客户端在 Accept 中显式声明（image/* 等通配不足以说明支持 WebP）；
+// 其余格式只要 q 值不低于原格式即优先返回体积更小的备用格式。
+func (s *ImageService) openAlternate(key, source, accept string) (io.ReadCloser, *storage.ObjectInfo) {
+	sourceQ, _ := httpx.AcceptQuality(accept, imageproc.ContentType(source))
+	for _, format := range alternateFormats {
+		if format == source {
+			continue
+		}
+		q, explicit := httpx.AcceptQuality(accept, imageproc.ContentType(format))
+		if q <= 0 || q < sourceQ || (format == imageproc.FormatWebP && !explicit) {
+			continue
+		}
+
+		reader, info, err := s.storage.Images.Get(alternateKey(key, imageproc.Ext(format)))
+		if err != nil {
+			if !errors.Is(err, storage.ErrNotExist) {
+				log.Printf("Open alternate image error: %v\n", err)
+			}
+			continue
+		}
+		info.ContentType = imageproc.ContentType(format)
+		return reader, info
+	}
+	return nil, nil
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	commonpkg "perfect-pic-server/internal/common"
+	"perfect-pic-server/internal/consts"
+	"perfect-pic-server/internal/model"
+
+	"gorm.io/gorm"
+)
+
+const base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// maxShortCodeAttempts 生成随机短码的最大尝试次数，超过后放弃本次生成（下次访问详情时会重试）。
+const maxShortCodeAttempts = 5
+
+var customShortCodePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// randomShortCode 生成随机短码，测试中可替换以构造冲突。
+var randomShortCode = randomBase62
+
+// ShortURL 返回图片的短链接（站内相对地址），尚无短码时返回空字符串。
+func ShortURL(image *model.Image) string {
+	if image == nil || image.ShortCode == nil {
+		return ""
+	}
+	return consts.ShortLinkPathPrefix + *image.ShortCode
+}
+
+// ShortCodeCollisions 返回自进程启动以来生成随机短码时的冲突次数。
+func (s *ImageService) ShortCodeCollisions() int64 {
+	return s.shortCodeCollisions.Load()
+}
+
+// EnsureShortCode 为尚无短码的图片生成随机 base62 短码并写回 image；失败时仅记录日志，不影响调用方。
+func (s *ImageService) EnsureShortCode(image *model.Image) {
+	if image == nil || image.ShortCode != nil {
+		return
+	}
+	code, err := s.claimRandomShortCode(image.ID, true)
+	if err != nil {
+		log.Printf("Assign short code error: %v\n", err)
+		return
+	}
+	if code == "" {
+		// 并发请求已为该图片生成短码，读取已有的值
+		if current, err := s.imageStore.FindByID(image.ID); err == nil {
+			image.ShortCode = current.ShortCode
+		}
+		return
+	}
+	image.ShortCode = &code
+}
+
+// FindImageByShortCode 按短码查找图片，已过期的图片视为不存在。
+func (s *ImageService) FindImageByShortCode(code string) (*model.Image, error) {
+	if code == "" || len(code) > consts.ShortCodeMaxLength || !customShortCodePattern.MatchString(code) {
+		return nil, commonpkg.NewNotFoundError("短链接不存在")
+	}
+	image, err := s.imageStore.FindByShortCode(code)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, commonpkg.NewNotFoundError("短链接不存在")
+		}
+		log.Printf("Find image by short code error: %v\n", err)
+		return nil, commonpkg.NewInternalError("读取短链接失败")
+	}
+	if image.Expired(time.Now().Unix()) {
+		return nil, commonpkg.NewNotFoundError("短链接不存在")
+	}
+	return image, nil
+}
+
+// SetImageShortCode 管理员为图片设置自定义短码；code 为空时重新生成随机短码，原短链接随即失效。
+func (s *ImageService) SetImageShortCode(imageID uint, code string) (*model.Image, error) {
+	image, err := s.GetImageByID(imageID, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	code = strings.TrimSpace(code)
+	if code == "" {
+		code, err = s.claimRandomShortCode(image.ID, false)
+		if err != nil {
+			log.Printf("Regenerate short code error: %v\n", err)
+			return nil, commonpkg.NewInternalError("生成短链接失败")
+		}
+		image.ShortCode = &code
+		return image, nil
+	}
+
+	if len(code) < consts.ShortCodeMinLength || len(code) > consts.ShortCodeMaxLength || !customShortCodePattern.MatchString(code) {
+		return nil, commonpkg.NewValidationError(fmt.Sprintf("短码只能包含字母、数字、下划线与连字符，长度为 %d-%d", consts.ShortCodeMinLength, consts.ShortCodeMaxLength))
+	}
+	if image.ShortCode != nil && *image.ShortCode == code {
+		return image, nil
+	}
+	if exists, err := s.imageStore.ShortCodeExists(code); err != nil {
+		return nil, commonpkg.NewInternalError("设置短链接失败")
+	} else if exists {
+		return nil, commonpkg.NewConflictError("该短码已被占用")
+	}
+	if err := s.imageStore.UpdateShortCode(image.ID, code); err != nil {
+		// 检查与写入之间被并发占用时由唯一索引拒绝
+		if exists, _ := s.imageStore.ShortCodeExists(code); exists {
+			return nil, commonpkg.NewConflictError("该短码已被占用")
+		}
+		log.Printf("Update short code error: %v\n", err)
+		return nil, commonpkg.NewInternalError("设置短链接失败")
+	}
+	image.ShortCode = &code
+	return image, nil
+}
+
+// claimRandomShortCode 生成随机短码并写入图片，冲突时重新生成并计数。
+// onlyIfEmpty 为 true 时仅在图片尚无短码时写入，图片已有短码则返回空字符串。
+func (s *ImageService) claimRandomShortCode(imageID uint, onlyIfEmpty bool) (string, error) {
+	for attempt := 0; attempt < maxShortCodeAttempts; attempt++ {
+		code, err := randomShortCode(consts.ShortCodeLength)
+		if err != nil {
+			return "", err
+		}
+		exists, err := s.imageStore.ShortCodeExists(code)
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			s.shortCodeCollisions.Add(1)
+			continue
+		}
+
+		if onlyIfEmpty {
+			var ok bool
+			ok, err = s.imageStore.SetShortCodeIfEmpty(imageID, code)
+			if err == nil && !ok {
+				return "", nil
+			}
+		} else {
+			err = s.imageStore.UpdateShortCode(imageID, code)
+		}
+		if err == nil {
+			return code, nil
+		}
+		// 检查与写入之间被并发占用时由唯一索引拒绝，同样视为冲突
+		if exists, _ := s.imageStore.ShortCodeExists(code); !exists {
+			return "", err
+		}
+		s.shortCodeCollisions.Add(1)
+	}
+	return "", fmt.Errorf("short code collided %d times", maxShortCodeAttempts)
+}
+
+// randomBase62 生成长度为 n 的均匀分布 base62 随机串（拒绝采样避免取模偏差）。
+func randomBase62(n int) (string, error) {
+	const limit = 256 - 256%len(base62Alphabet)
+	out := make([]byte, 0, n)
+	buf := make([]byte, n*2)
+	for len(out) < n {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		for _, b := range buf {
+			if int(b) >= limit {
+				continue
+			}
+			out = append(out, base62Alphabet[int(b)%len(base62Alphabet)])
+			if len(out) == n {
+				break
+			}
+		}
+	}
+	return string(out), nil
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"perfect-pic-server/internal/common"
+	"perfect-pic-server/internal/consts"
+	"perfect-pic-server/internal/model"
+)
+
+func createShortLinkTestImage(t *testing.T, name string) *model.Image {
+	t.Helper()
+	var u model.User
+	if err := testGormDB.Where(model.User{Username: "alice"}).Attrs(model.User{Password: "x", Status: 1, Email: "a@example.com"}).FirstOrCreate(&u).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	img := model.Image{Filename: name, Path: "2026/" + name, Size: 1, MimeType: "image/png", UserID: u.ID}
+	if err := testGormDB.Create(&img).Error; err != nil {
+		t.Fatalf("create image: %v", err)
+	}
+	return &img
+}
+
+// 测试内容：验证随机短码为指定长度的 base62 字符串，且已有短码的图片不会被重新生成。
+func TestEnsureShortCode(t *testing.T) {
+	setupTestDB(t)
+	s := testService.imageService
+
+	code, err := randomBase62(consts.ShortCodeLength)
+	if err != nil || len(code) != consts.ShortCodeLength || strings.Trim(code, base62Alphabet) != "" {
+		t.Fatalf("随机短码不符: %q %v", code, err)
+	}
+
+	img := createShortLinkTestImage(t, "a.png")
+	s.EnsureShortCode(img)
+	if img.ShortCode == nil || len(*img.ShortCode) != consts.ShortCodeLength {
+		t.Fatalf("期望生成短码，实际为 %v", img.ShortCode)
+	}
+	first := *img.ShortCode
+	if ShortURL(img) != "/s/"+first {
+		t.Fatalf("短链接不符: %s", ShortURL(img))
+	}
+
+	// 其它请求已写入短码时，读取已有值而不是覆盖
+	stale := &model.Image{ID: img.ID}
+	s.EnsureShortCode(stale)
+	if stale.ShortCode == nil || *stale.ShortCode != first {
+		t.Fatalf("期望沿用已有短码 %s，实际为 %v", first, stale.ShortCode)
+	}
+
+	found, err := s.FindImageByShortCode(first)
+	if err != nil || found.ID != img.ID {
+		t.Fatalf("按短码查找失败: %v", err)
+	}
+	for _, code := range []string{"", "nope", "../x", strings.Repeat("a", consts.ShortCodeMaxLength+1)} {
+		_, err := s.FindImageByShortCode(code)
+		assertServiceErrorCode(t, err, common.ErrorCodeNotFound)
+	}
+}
+
+// 测试内容：验证随机短码冲突时重新生成并计数，连续冲突达到上限时放弃生成。
+func TestEnsureShortCode_Collisions(t *testing.T) {
+	setupTestDB(t)
+	s := testService.imageService
+
+	taken := "TAKEN01"
+	occupied := createShortLinkTestImage(t, "a.png")
+	occupied.ShortCode = &taken
+	_ = testGormDB.Save(occupied).Error
+
+	old := randomShortCode
+	defer func() { randomShortCode = old }()
+	sequence := []string{taken, taken, "FRESH01"}
+	randomShortCode = func(int) (string, error) {
+		code := sequence[0]
+		if len(sequence) > 1 {
+			sequence = sequence[1:]
+		}
+		return code, nil
+	}
+
+	before := s.ShortCodeCollisions()
+	img := createShortLinkTestImage(t, "b.png")
+	s.EnsureShortCode(img)
+	if img.ShortCode == nil || *img.ShortCode != "FRESH01" {
+		t.Fatalf("期望冲突后使用新短码，实际为 %v", img.ShortCode)
+	}
+	if got := s.ShortCodeCollisions() - before; got != 2 {
+		t.Fatalf("期望记录 2 次冲突，实际为 %d", got)
+	}
+
+	randomShortCode = func(int) (string, error) { return taken, nil }
+	other := createShortLinkTestImage(t, "c.png")
+	s.EnsureShortCode(other)
+	if other.ShortCode != nil {
+		t.Fatalf("期望连续冲突后放弃生成，实际为 %v", *other.ShortCode)
+	}
+}
+
+// 测试内容：验证管理员自定义短码的格式校验、占用冲突，以及留空时重新生成随机短码使旧短码失效。
+func TestSetImageShortCode(t *testing.T) {
+	setupTestDB(t)
+	s := testService.imageService
+
+	a := createShortLinkTestImage(t, "a.png")
+	b := createShortLinkTestImage(t, "b.png")
+
+	img, err := s.SetImageShortCode(a.ID, " my-slug ")
+	if err != nil || *img.ShortCode != "my-slug" {
+		t.Fatalf("设置短码失败: %v", err)
+	}
+	if _, err := s.SetImageShortCode(a.ID, "my-slug"); err != nil {
+		t.Fatalf("重复设置相同短码应成功: %v", err)
+	}
+
+	_, err = s.SetImageShortCode(b.ID, "my-slug")
+	assertServiceErrorCode(t, err, common.ErrorCodeConflict)
+	for _, code := range []string{"ab", "有中文", "a/b", strings.Repeat("a", consts.ShortCodeMaxLength+1)} {
+		_, err := s.SetImageShortCode(b.ID, code)
+		assertServiceErrorCode(t, err, common.ErrorCodeValidation)
+	}
+	_, err = s.SetImageShortCode(9999, "other")
+	assertServiceErrorCode(t, err, common.ErrorCodeNotFound)
+
+	img, err = s.SetImageShortCode(a.ID, "")
+	if err != nil || *img.ShortCode == "my-slug" || len(*img.ShortCode) != consts.ShortCodeLength {
+		t.Fatalf("期望重新生成随机短码，实际为 %v %v", img.ShortCode, err)
+	}
+	_, err = s.FindImageByShortCode("my-slug")
+	assertServiceErrorCode(t, err, common.ErrorCodeNotFound)
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	commonpkg "perfect-pic-server/internal/common"
+	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/model"
+	repo "perfect-pic-server/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// statsDayLayout 访问统计的日期格式（服务器本地时区）。
+	statsDayLayout = "2006-01-02"
+	// defaultImageStatsDays/maxImageStatsDays 访问统计的默认与最长查询天数。
+	defaultImageStatsDays = 30
+	maxImageStatsDays     = 365
+	// imageStatsTopReferrers 访问统计返回的来源站点数量。
+	imageStatsTopReferrers = 10
+	// maxPendingImageViewKeys 内存中待写入的计数条目上限，超过后新的来源站点只计入当天总数，避免伪造 Referer 耗尽内存。
+	maxPendingImageViewKeys = 100000
+	// maxReferrerHostLength 来源站点主机名的最大长度，超出时不记录来源。
+	maxReferrerHostLength = 255
+)
+
+// imageViewKey 内存中访问计数的汇总维度，host 为空表示没有 Referer。
+type imageViewKey struct {
+	imageID uint
+	day     string
+	host    string
+}
+
+// RecordImageView 在内存中记录一次图片访问，由 FlushImageViews 定期批量写入数据库。
+func (s *ImageService) RecordImageView(imageID uint, referer string) {
+	if imageID == 0 {
+		return
+	}
+	key := imageViewKey{imageID: imageID, day: time.Now().Format(statsDayLayout), host: referrerHost(referer)}
+
+	s.viewsMu.Lock()
+	defer s.viewsMu.Unlock()
+	if s.pendingViews == nil {
+		s.pendingViews = make(map[imageViewKey]int64)
+	}
+	if _, ok := s.pendingViews[key]; !ok && key.host != "" && len(s.pendingViews) >= maxPendingImageViewKeys {
+		key.host = ""
+	}
+	s.pendingViews[key]++
+}
+
+// FlushImageViews 将内存中的访问计数写入数据库；写入失败时计数放回内存，在下次写入时重试。
+func (s *ImageService) FlushImageViews() error {
+	s.viewsMu.Lock()
+	pending := s.pendingViews
+	s.pendingViews = nil
+	s.viewsMu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	counts := make([]repo.ImageViewCount, 0, len(pending))
+	for key, views := range pending {
+		counts = append(counts, repo.ImageViewCount{ImageID: key.imageID, Day: key.day, Host: key.host, Views: views})
+	}
+	if err := s.imageStore.AddImageViews(counts); err != nil {
+		s.viewsMu.Lock()
+		if s.pendingViews == nil {
+			s.pendingViews = make(map[imageViewKey]int64, len(pending))
+		}
+		for key, views := range pending {
+			s.pendingViews[key] += views
+		}
+		s.viewsMu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// StartImageViewFlusher 启动后台任务，每隔 interval 将内存中的访问计数写入数据库。
+// 返回的 stop 函数会在停止前写入剩余的计数，可安全地重复调用。
+func (s *ImageService) StartImageViewFlusher(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				s.flushImageViewsAndLog()
+				return
+			case <-ticker.C:
+				s.flushImageViewsAndLog()
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+		})
+	}
+}
+
+func (s *ImageService) flushImageViewsAndLog() {
+	if err := s.FlushImageViews(); err != nil {
+		log.Printf("Flush image views error: %v\n", err)
+	}
+}
+
+// GetImageStats 获取用户自己图片最近 days 天（含今天）的访问统计，days 为 0 时使用默认值。
+// 统计数据定期批量写入，尚未写入的最近访问不包含在结果中。
+func (s *ImageService) GetImageStats(imageID uint, userID uint, days int) (*moduledto.ImageStatsResponse, error) {
+	if days == 0 {
+		days = defaultImageStatsDays
+	}
+	if days < 1 || days > maxImageStatsDays {
+		return nil, commonpkg.NewValidationError(fmt.Sprintf("days 参数需在 1 到 %d 之间", maxImageStatsDays))
+	}
+
+	image, err := s.imageStore.FindByIDAndUserID(imageID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, commonpkg.NewNotFoundError("图片不存在或无权访问")
+		}
+		return nil, commonpkg.NewInternalError("查找图片失败")
+	}
+
+	today := time.Now()
+	fromDay := today.AddDate(0, 0, -(days - 1)).Format(statsDayLayout)
+	stats, err := s.imageStore.ListImageDailyViews(image.ID, fromDay)
+	if err != nil {
+		log.Printf("List image daily views error: %v\n", err)
+		return nil, commonpkg.NewInternalError("获取访问统计失败")
+	}
+	referrers, err := s.imageStore.ListImageTopReferrers(image.ID, fromDay, imageStatsTopReferrers)
+	if err != nil {
+		log.Printf("List image referrers error: %v\n", err)
+		return nil, commonpkg.NewInternalError("获取访问统计失败")
+	}
+
+	viewsByDay := make(map[string]int64, len(stats))
+	for _, stat := range stats {
+		viewsByDay[stat.Day] = stat.Views
+	}
+	daily := make([]model.ImageViewStat, 0, days)
+	for i := days - 1; i >= 0; i-- {
+		day := today.AddDate(0, 0, -i).Format(statsDayLayout)
+		daily = append(daily, model.ImageViewStat{ImageID: image.ID, Day: day, Views: viewsByDay[day]})
+	}
+	if referrers == nil {
+		referrers = []model.ImageReferrerStat{}
+	}
+
+	return &moduledto.ImageStatsResponse{
+		ImageID:    image.ID,
+		TotalViews: image.Views,
+		Days:       days,
+		Daily:      daily,
+		Referrers:  referrers,
+	}, nil
+}
+
+// referrerHost 提取 Referer 中的主机名（小写），无法解析或过长时返回空字符串。
+func referrerHost(referer string) string {
+	if referer == "" {
+		return ""
+	}
+	u, err := url.Parse(referer)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	host := strings.ToLower(u.Hostname())
+	if len(host) > maxReferrerHostLength {
+		return ""
+	}
+	return host
+}
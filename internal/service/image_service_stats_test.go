@@ -0,0 +1,104 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"perfect-pic-server/internal/common"
+	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/model"
+)
+
+// 测试内容：验证访问计数在内存中汇总后批量写入数据库，统计接口按天补零并按访问次数返回来源站点，列表可按访问次数排序。
+func TestImageService_ViewStats(t *testing.T) {
+	setupTestDB(t)
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+	hot := model.Image{Filename: "hot.png", Path: "2024/01/01/hot.png", Size: 1, UploadedAt: 1, UserID: u.ID}
+	cold := model.Image{Filename: "cold.png", Path: "2024/01/01/cold.png", Size: 1, UploadedAt: 2, UserID: u.ID}
+	_ = testGormDB.Create(&hot).Error
+	_ = testGormDB.Create(&cold).Error
+
+	// 两天前的历史数据
+	twoDaysAgo := time.Now().AddDate(0, 0, -2).Format("2006-01-02")
+	_ = testGormDB.Create(&model.ImageViewStat{ImageID: hot.ID, Day: twoDaysAgo, Views: 5}).Error
+	_ = testGormDB.Create(&model.ImageReferrerStat{ImageID: hot.ID, Day: twoDaysAgo, Host: "b.example.com", Views: 5}).Error
+	_ = testGormDB.Model(&hot).Update("views", 5).Error
+
+	s := testService.imageService
+	for _, referer := range []string{"https://a.example.com/1", "https://A.example.com/2", "https://b.example.com/", "", "javascript:alert(1)"} {
+		s.RecordImageView(hot.ID, referer)
+	}
+	s.RecordImageView(cold.ID, "")
+	if err := s.FlushImageViews(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if err := s.FlushImageViews(); err != nil {
+		t.Fatalf("期望没有待写入计数时直接返回: %v", err)
+	}
+
+	stats, err := s.GetImageStats(hot.ID, u.ID, 3)
+	if err != nil {
+		t.Fatalf("GetImageStats: %v", err)
+	}
+	if stats.TotalViews != 10 || len(stats.Daily) != 3 {
+		t.Fatalf("统计结果不符: %+v", stats)
+	}
+	if stats.Daily[0].Day != twoDaysAgo || stats.Daily[0].Views != 5 || stats.Daily[1].Views != 0 || stats.Daily[2].Views != 5 {
+		t.Fatalf("每日访问次数不符: %+v", stats.Daily)
+	}
+	if len(stats.Referrers) != 2 || stats.Referrers[0].Host != "b.example.com" || stats.Referrers[0].Views != 6 || stats.Referrers[1].Host != "a.example.com" || stats.Referrers[1].Views != 2 {
+		t.Fatalf("来源站点统计不符: %+v", stats.Referrers)
+	}
+
+	// 仅统计今天时不包含历史来源
+	today, _ := s.GetImageStats(hot.ID, u.ID, 1)
+	if len(today.Daily) != 1 || today.Daily[0].Views != 5 || len(today.Referrers) != 2 || today.Referrers[0].Views != 2 {
+		t.Fatalf("今日统计不符: %+v", today)
+	}
+
+	_, err = s.GetImageStats(hot.ID, u.ID, 366)
+	assertServiceErrorCode(t, err, common.ErrorCodeValidation)
+	_, err = s.GetImageStats(hot.ID, u.ID+1, 0)
+	assertServiceErrorCode(t, err, common.ErrorCodeNotFound)
+
+	list, _, _, _, err := s.ListImages(moduledto.ListImagesRequest{UserID: &u.ID, SortBy: "views", PaginationRequest: moduledto.PaginationRequest{PageSize: 10}})
+	if err != nil {
+		t.Fatalf("ListImages: %v", err)
+	}
+	if len(list) != 2 || list[0].ID != hot.ID || list[0].Views != 10 || list[1].Views != 1 {
+		t.Fatalf("按访问次数排序结果不符: %+v", list)
+	}
+}
+
+// 测试内容：验证图片删除后清理其访问统计，已删除图片的待写入计数被丢弃而不会写入统计表。
+func TestImageService_ViewStatsRemovedWithImage(t *testing.T) {
+	setupTestDB(t)
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+	img := model.Image{Filename: "a.png", Path: "2024/01/01/a.png", Size: 1, UploadedAt: 1, UserID: u.ID}
+	_ = testGormDB.Create(&img).Error
+
+	s := testService.imageService
+	s.RecordImageView(img.ID, "https://a.example.com/")
+	if err := s.FlushImageViews(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	s.RecordImageView(img.ID, "https://b.example.com/")
+
+	if err := s.DeleteImage(&img); err != nil {
+		t.Fatalf("DeleteImage: %v", err)
+	}
+	if err := s.FlushImageViews(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+
+	var days, referrers int64
+	testGormDB.Model(&model.ImageViewStat{}).Count(&days)
+	testGormDB.Model(&model.ImageReferrerStat{}).Count(&referrers)
+	if days != 0 || referrers != 0 {
+		t.Fatalf("期望删除图片后统计被清理，实际为 days=%d referrers=%d", days, referrers)
+	}
+}
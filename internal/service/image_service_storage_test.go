@@ -0,0 +1,108 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"perfect-pic-server/internal/config"
+	"perfect-pic-server/internal/consts"
+	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/model"
+	"perfect-pic-server/internal/pkg/storage"
+	"perfect-pic-server/internal/pkg/urlsign"
+	"perfect-pic-server/internal/repository"
+	"perfect-pic-server/internal/testutils"
+)
+
+func newS3ImageService(t *testing.T, fake *testutils.FakeS3) *ImageService {
+	t.Helper()
+	storages, err := storage.NewManager(&storage.Config{
+		Driver: storage.DriverS3,
+		Local:  storage.LocalConfig{ImageURLPrefix: "/imgs/", AvatarURLPrefix: "/avatars/"},
+		S3: storage.S3Config{
+			Endpoint:     fake.URL(),
+			Bucket:       fake.Bucket,
+			AccessKey:    "ak",
+			SecretKey:    "sk",
+			UsePathStyle: true,
+			PublicURL:    "https://cdn.example.com",
+		},
+	})
+	if err != nil {
+		t.Fatalf("init storage: %v", err)
+	}
+	return NewImageService(repository.NewImageRepository(testGormDB), testService.dbConfig, config.NewStaticConfig(), storages, urlsign.NewSigner(config.NewURLSignConfig(config.NewStaticConfig())), nil)
+}
+
+// 测试内容：验证使用 S3 存储时上传写入对象存储、返回经由服务端代理的地址（即使配置了 PublicURL），删除时同步清理对象。
+func TestProcessImageUpload_S3Storage(t *testing.T) {
+	setupTestDB(t)
+	fake := testutils.NewFakeS3(t, "pics")
+	imageService := newS3ImageService(t, fake)
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+
+	fh := mustFileHeader(t, "a.png", testutils.MinimalPNG())
+	result, err := imageService.ProcessImageUpload(fh, u.ID, 0, 1<<20, moduledto.ImageUploadInfo{})
+	if err != nil {
+		t.Fatalf("ProcessImageUpload: %v", err)
+	}
+	img, url := result.Image, result.URL
+	if url != fmt.Sprintf("/imgs/%s?id=%d", img.Path, img.ID) {
+		t.Fatalf("期望返回代理地址，实际为 %q", url)
+	}
+	if _, ok := fake.Object("imgs/" + img.Path); !ok {
+		t.Fatalf("期望对象已写入 S3，实际 keys=%v", fake.Keys())
+	}
+
+	if err := imageService.DeleteImage(img); err != nil {
+		t.Fatalf("DeleteImage: %v", err)
+	}
+	if len(fake.Keys()) != 0 {
+		t.Fatalf("期望对象已删除，实际 keys=%v", fake.Keys())
+	}
+}
+
+// 测试内容：验证使用 S3 存储时头像写入与用户文件清理。
+func TestDeleteUserFiles_S3Storage(t *testing.T) {
+	setupTestDB(t)
+	fake := testutils.NewFakeS3(t, "pics")
+	imageService := newS3ImageService(t, fake)
+
+	filename, err := imageService.SaveUserAvatarFile(9, mustFileHeader(t, "avatar.png", testutils.MinimalPNG()))
+	if err != nil {
+		t.Fatalf("SaveUserAvatarFile: %v", err)
+	}
+	if _, ok := fake.Object("avatars/9/" + filename); !ok {
+		t.Fatalf("期望头像已写入 S3，实际 keys=%v", fake.Keys())
+	}
+
+	if err := imageService.DeleteUserFiles(9); err != nil {
+		t.Fatalf("DeleteUserFiles: %v", err)
+	}
+	for _, key := range fake.Keys() {
+		if strings.HasPrefix(key, "avatars/9/") {
+			t.Fatalf("期望头像已清理，实际 keys=%v", fake.Keys())
+		}
+	}
+}
+
+// 测试内容：验证配置 PublicURL 时头像使用公开地址，开启防盗链后改为经由服务端代理，以便校验来源。
+func TestImageService_AvatarURLWithHotlink(t *testing.T) {
+	setupTestDB(t)
+	fake := testutils.NewFakeS3(t, "pics")
+	imageService := newS3ImageService(t, fake)
+
+	if got := imageService.AvatarURL("1/a.png"); got != "https://cdn.example.com/avatars/1/a.png" {
+		t.Fatalf("期望未开启防盗链时使用公开地址，实际为 %q", got)
+	}
+	setTestSetting(t, consts.ConfigHotlinkEnabled, "true")
+	if got := imageService.AvatarURL("1/a.png"); got != "/avatars/1/a.png" {
+		t.Fatalf("期望开启防盗链后使用代理地址，实际为 %q", got)
+	}
+	if got := imageService.ImageURL(""); got != "/imgs/" {
+		t.Fatalf("期望图片前缀为代理地址，实际为 %q", got)
+	}
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	commonpkg "perfect-pic-server/internal/common"
+	repo "perfect-pic-server/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// maxImageTags 单张图片最多可设置的标签数量。
+	maxImageTags = 20
+	// maxTagNameLength 标签名最大字符数，与 tags.name 列宽一致。
+	maxTagNameLength = 32
+	// maxSearchLength 图片列表检索关键词的最大字符数。
+	maxSearchLength = 100
+	// defaultTagSuggestLimit/maxTagSuggestLimit 标签补全返回数量的默认值与上限。
+	defaultTagSuggestLimit = 10
+	maxTagSuggestLimit     = 50
+)
+
+// SetImageTags 整体替换用户图片的标签，返回规范化后的标签名（小写、去重、按名称排序）。
+func (s *ImageService) SetImageTags(imageID uint, userID uint, names []string) ([]string, error) {
+	tags, err := normalizeTagNames(names)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.imageStore.FindByIDAndUserID(imageID, userID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, commonpkg.NewNotFoundError("图片不存在或无权访问")
+		}
+		return nil, commonpkg.NewInternalError("查找图片失败")
+	}
+
+	if err := s.imageStore.SetImageTags(imageID, userID, tags); err != nil {
+		return nil, commonpkg.NewInternalError("保存图片标签失败")
+	}
+	return tags, nil
+}
+
+// ListTags 按使用次数列出用户的标签，用于输入时的自动补全。
+func (s *ImageService) ListTags(userID uint, prefix string, limit int) ([]repo.TagUsage, error) {
+	if limit <= 0 {
+		limit = defaultTagSuggestLimit
+	}
+	if limit > maxTagSuggestLimit {
+		limit = maxTagSuggestLimit
+	}
+
+	tags, err := s.imageStore.ListUserTags(userID, normalizeTagName(prefix), limit)
+	if err != nil {
+		return nil, commonpkg.NewInternalError("获取标签列表失败")
+	}
+	if tags == nil {
+		tags = []repo.TagUsage{}
+	}
+	return tags, nil
+}
+
+// normalizeTagName 统一标签名格式：去除首尾空白、合并连续空白并转为小写。
+func normalizeTagName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// normalizeTagNames 规范化并校验一组标签名，空标签被忽略，重复标签只保留一个。
+func normalizeTagNames(names []string) ([]string, error) {
+	seen := make(map[string]struct{}, len(names))
+	tags := make([]string, 0, len(names))
+	for _, raw := range names {
+		name := normalizeTagName(raw)
+		if name == "" {
+			continue
+		}
+		if utf8.RuneCountInString(name) > maxTagNameLength {
+			return nil, commonpkg.NewValidationError(fmt.Sprintf("标签长度不能超过 %d 个字符", maxTagNameLength))
+		}
+		if strings.IndexFunc(name, unicode.IsControl) >= 0 {
+			return nil, commonpkg.NewValidationError("标签包含非法字符")
+		}
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		seen[name] = struct{}{}
+		tags = append(tags, name)
+	}
+	if len(tags) > maxImageTags {
+		return nil, commonpkg.NewValidationError(fmt.Sprintf("每张图片最多设置 %d 个标签", maxImageTags))
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
@@ -0,0 +1,178 @@
+package service
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/model"
+)
+
+// 测试内容：验证标签规范化、替换与孤立标签清理，以及按前缀补全与使用次数排序。
+func TestImageService_SetImageTagsAndListTags(t *testing.T) {
+	setupTestDB(t)
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+	imgs := createAlbumTestImages(t, u.ID, "a.png", "b.png")
+
+	tags, err := testService.imageService.SetImageTags(imgs[0].ID, u.ID, []string{" Travel ", "travel", "", "海边  日落", "my_trip"})
+	if err != nil {
+		t.Fatalf("SetImageTags: %v", err)
+	}
+	if want := []string{"my_trip", "travel", "海边 日落"}; !reflect.DeepEqual(tags, want) {
+		t.Fatalf("期望标签为 %v，实际为 %v", want, tags)
+	}
+	if _, err := testService.imageService.SetImageTags(imgs[1].ID, u.ID, []string{"travel"}); err != nil {
+		t.Fatalf("SetImageTags: %v", err)
+	}
+
+	list, err := testService.imageService.ListTags(u.ID, "", 0)
+	if err != nil || len(list) != 3 || list[0].Name != "travel" || list[0].Count != 2 {
+		t.Fatalf("期望 travel 使用次数最多且排在首位: list=%+v err=%v", list, err)
+	}
+	// 前缀中的 LIKE 通配符按字面匹配
+	list, _ = testService.imageService.ListTags(u.ID, "MY_", 10)
+	if len(list) != 1 || list[0].Name != "my_trip" {
+		t.Fatalf("期望前缀 my_ 只匹配 my_trip，实际为 %+v", list)
+	}
+	list, _ = testService.imageService.ListTags(u.ID, "m%", 10)
+	if len(list) != 0 {
+		t.Fatalf("期望 %% 不作为通配符，实际为 %+v", list)
+	}
+
+	// 替换后不再被引用的标签被删除
+	if _, err := testService.imageService.SetImageTags(imgs[0].ID, u.ID, []string{}); err != nil {
+		t.Fatalf("SetImageTags clear: %v", err)
+	}
+	var count int64
+	testGormDB.Model(&model.Tag{}).Where("user_id = ?", u.ID).Count(&count)
+	if count != 1 {
+		t.Fatalf("期望只剩 1 个标签，实际为 %d", count)
+	}
+}
+
+// 测试内容：验证标签数量、长度与图片归属的校验。
+func TestImageService_SetImageTagsValidation(t *testing.T) {
+	setupTestDB(t)
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+	other := model.User{Username: "bob", Password: "x", Status: 1, Email: "b@example.com"}
+	_ = testGormDB.Create(&other).Error
+	imgs := createAlbumTestImages(t, u.ID, "a.png")
+
+	if _, err := testService.imageService.SetImageTags(imgs[0].ID, u.ID, []string{strings.Repeat("长", maxTagNameLength+1)}); err == nil {
+		t.Fatalf("期望超长标签返回错误")
+	}
+	many := make([]string, 0, maxImageTags+1)
+	for i := 0; i <= maxImageTags; i++ {
+		many = append(many, strings.Repeat("t", i+1))
+	}
+	if _, err := testService.imageService.SetImageTags(imgs[0].ID, u.ID, many); err == nil {
+		t.Fatalf("期望标签数量超限返回错误")
+	}
+	if _, err := testService.imageService.SetImageTags(imgs[0].ID, other.ID, []string{"x"}); err == nil {
+		t.Fatalf("期望不能为他人的图片设置标签")
+	}
+}
+
+// 测试内容：验证 q 全文检索（前缀、多关键词与中文子串）与 tag 过滤，以及删除图片后检索文档与孤立标签被清理。
+func TestImageService_ListImagesSearch(t *testing.T) {
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+	other := model.User{Username: "bob", Password: "x", Status: 1, Email: "b@example.com"}
+	_ = testGormDB.Create(&other).Error
+	imgs := createAlbumTestImages(t, u.ID, "a.png", "b.png", "c.png")
+	otherImgs := createAlbumTestImages(t, other.ID, "d.png")
+
+	_, _ = testService.imageService.SetImageTags(imgs[0].ID, u.ID, []string{"sunset", "旅行"})
+	_, _ = testService.imageService.SetImageTags(imgs[1].ID, u.ID, []string{"sunrise"})
+	_, _ = testService.imageService.SetImageTags(otherImgs[0].ID, other.ID, []string{"sunset"})
+
+	search := func(userID *uint, q, tag string) []uint {
+		t.Helper()
+		images, _, _, _, err := testService.imageService.ListImages(moduledto.ListImagesRequest{
+			PaginationRequest: moduledto.PaginationRequest{Page: 1, PageSize: 10},
+			UserID:            userID,
+			Search:            q,
+			Tag:               tag,
+			SortOrder:         "asc",
+			PreloadTags:       true,
+		})
+		if err != nil {
+			t.Fatalf("ListImages: %v", err)
+		}
+		ids := make([]uint, 0, len(images))
+		for _, img := range images {
+			ids = append(ids, img.ID)
+		}
+		return ids
+	}
+
+	uid := u.ID
+	if got := search(&uid, "sun", ""); !reflect.DeepEqual(got, []uint{imgs[0].ID, imgs[1].ID}) {
+		t.Fatalf("期望前缀 sun 命中两张图片，实际为 %v", got)
+	}
+	if got := search(&uid, "SUN 旅", ""); !reflect.DeepEqual(got, []uint{imgs[0].ID}) {
+		t.Fatalf("期望多关键词同时命中，实际为 %v", got)
+	}
+	title, desc := "黄昏时分的海边灯塔", "Weekend trip"
+	if _, err := testService.imageService.UpdateImageInfo(imgs[2].ID, u.ID, moduledto.UpdateImageInfoRequest{Title: &title, Description: &desc}); err != nil {
+		t.Fatalf("UpdateImageInfo: %v", err)
+	}
+	for _, q := range []string{"海边灯", "灯塔", "边", "ekend", "海边 trip"} {
+		if got := search(&uid, q, ""); !reflect.DeepEqual(got, []uint{imgs[2].ID}) {
+			t.Fatalf("期望按子串 %q 命中中文标题或描述，实际为 %v", q, got)
+		}
+	}
+	if got := search(&uid, "海边 山", ""); len(got) != 0 {
+		t.Fatalf("期望所有关键词均需命中，实际为 %v", got)
+	}
+	if got := search(&uid, `"*)(`, ""); len(got) != 0 {
+		t.Fatalf("期望没有可检索字符时不返回图片，实际为 %v", got)
+	}
+	if got := search(nil, "sunset", ""); !reflect.DeepEqual(got, []uint{imgs[0].ID, otherImgs[0].ID}) {
+		t.Fatalf("期望管理员检索覆盖所有用户，实际为 %v", got)
+	}
+	if got := search(&uid, "", "Sunrise"); !reflect.DeepEqual(got, []uint{imgs[1].ID}) {
+		t.Fatalf("期望按标签过滤，实际为 %v", got)
+	}
+
+	images, _, _, _, _ := testService.imageService.ListImages(moduledto.ListImagesRequest{
+		PaginationRequest: moduledto.PaginationRequest{Page: 1, PageSize: 10},
+		ID:                &imgs[0].ID,
+		PreloadTags:       true,
+	})
+	if len(images) != 1 || !reflect.DeepEqual(images[0].Tags, []string{"sunset", "旅行"}) {
+		t.Fatalf("期望列表返回图片标签，实际为 %+v", images)
+	}
+
+	// 重新设置标签后旧内容不再命中
+	_, _ = testService.imageService.SetImageTags(imgs[1].ID, u.ID, []string{"night"})
+	if got := search(&uid, "sunrise", ""); len(got) != 0 {
+		t.Fatalf("期望旧标签不再命中，实际为 %v", got)
+	}
+
+	if err := testService.imageService.DeleteImage(&imgs[1]); err != nil {
+		t.Fatalf("DeleteImage: %v", err)
+	}
+	var docs, tags int64
+	testGormDB.Model(&model.ImageSearchDocument{}).Where("image_id = ?", imgs[1].ID).Count(&docs)
+	testGormDB.Model(&model.Tag{}).Where("name = ?", "night").Count(&tags)
+	if docs != 0 || tags != 0 {
+		t.Fatalf("期望删除图片后清理检索文档与孤立标签: docs=%d tags=%d", docs, tags)
+	}
+	if got := search(&uid, "night", ""); len(got) != 0 {
+		t.Fatalf("期望已删除图片不再命中，实际为 %v", got)
+	}
+}
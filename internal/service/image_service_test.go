@@ -2,13 +2,17 @@ package service
 
 import (
 	"bytes"
+	"image/color"
 	"mime/multipart"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	moduledto "perfect-pic-server/internal/dto"
+	"sync"
 	"testing"
+	"time"
 
+	platformservice "perfect-pic-server/internal/common"
 	"perfect-pic-server/internal/model"
 	"perfect-pic-server/internal/testutils"
 )
@@ -301,3 +305,95 @@ func mustFileHeader(t *testing.T, filename string, content []byte) *multipart.Fi
 	}
 	return fhs[0]
 }
+
+// 测试内容：验证并发上传即使都基于过期的已用空间通过了预检查，入库时的条件更新也只允许配额内的上传成功，
+// 被拒绝的上传返回 forbidden 且不残留记录；其已写入的文件不在请求中删除，由存储对账在宽限期后清理。
+func TestProcessImageData_ConcurrentUploadsRespectQuota(t *testing.T) {
+	setupTestDB(t)
+	s := testService.imageService
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	const uploads = 6
+	contents := make([][]byte, uploads)
+	for i := range contents {
+		contents[i] = newSolidPNG(t, 8, 8, color.NRGBA{R: uint8(i * 40), G: 255, A: 255})
+	}
+	size := int64(len(contents[0]))
+	quota := 2*size + size/2
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com", StorageQuota: &quota}
+	_ = testGormDB.Create(&u).Error
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, uploads)
+	for i, content := range contents {
+		wg.Add(1)
+		go func(i int, content []byte) {
+			defer wg.Done()
+			// 所有请求都以已用空间为 0 通过预检查，模拟并发读取到相同的 storage_used
+			_, err := s.ProcessImageData("a.png", content, u.ID, 0, quota, moduledto.ImageUploadInfo{})
+			errCh <- err
+		}(i, content)
+	}
+	wg.Wait()
+	close(errCh)
+
+	successCount, forbiddenCount, otherErrCount := 0, 0, 0
+	for err := range errCh {
+		if err == nil {
+			successCount++
+			continue
+		}
+		if serviceErr, ok := platformservice.AsServiceError(err); ok && serviceErr.Code == platformservice.ErrorCodeForbidden {
+			forbiddenCount++
+			continue
+		}
+		otherErrCount++
+		t.Logf("非预期错误: %v", err)
+	}
+	if successCount != 2 || forbiddenCount != uploads-2 || otherErrCount != 0 {
+		t.Fatalf("期望 2 成功 + %d forbidden；实际 success=%d forbidden=%d other=%d", uploads-2, successCount, forbiddenCount, otherErrCount)
+	}
+
+	var got model.User
+	_ = testGormDB.First(&got, u.ID).Error
+	if got.StorageUsed != 2*size {
+		t.Fatalf("期望 storage_used=%d，实际为 %d", 2*size, got.StorageUsed)
+	}
+	var imageCount, blobCount int64
+	_ = testGormDB.Model(&model.Image{}).Where("user_id = ?", u.ID).Count(&imageCount).Error
+	_ = testGormDB.Model(&model.ImageBlob{}).Count(&blobCount).Error
+	if imageCount != 2 || blobCount != 2 {
+		t.Fatalf("期望仅保留 2 条图片与 blob 记录，实际为 %d %d", imageCount, blobCount)
+	}
+	countFiles := func() int {
+		files := 0
+		_ = filepath.WalkDir(filepath.Join("uploads", "imgs"), func(p string, d os.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				files++
+				old := time.Now().Add(-2 * reconcileGracePeriod)
+				_ = os.Chtimes(p, old, old)
+			}
+			return nil
+		})
+		return files
+	}
+	if files := countFiles(); files != uploads {
+		t.Fatalf("期望被拒绝的上传保留文件等待对账，实际文件数为 %d", files)
+	}
+
+	report, err := s.ReconcileStorage(false)
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if len(report.OrphanFiles) != uploads-2 || len(report.MissingFiles) != 0 {
+		t.Fatalf("期望对账清理 %d 个孤立文件，实际为 %+v", uploads-2, report)
+	}
+	if files := countFiles(); files != 2 {
+		t.Fatalf("期望对账后仅保留 2 个文件，实际为 %d", files)
+	}
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	commonpkg "perfect-pic-server/internal/common"
+	"perfect-pic-server/internal/consts"
+	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/model"
+)
+
+const (
+	// defaultTrashRetentionDays/maxTrashRetentionDays 回收站保留天数的默认值与上限。
+	defaultTrashRetentionDays = 30
+	maxTrashRetentionDays     = 3650
+	// trashBatchSize 清空回收站与定期清理时每批永久删除的图片数。
+	trashBatchSize = 100
+)
+
+// trashRetention 返回回收站中图片的保留时长。
+func (s *ImageService) trashRetention() time.Duration {
+	days := s.dbConfig.GetInt(consts.ConfigImageTrashRetentionDays)
+	if days <= 0 {
+		days = defaultTrashRetentionDays
+	}
+	return time.Duration(min(days, maxTrashRetentionDays)) * 24 * time.Hour
+}
+
+// TrashImages 将图片移入回收站。文件与配额在永久删除前保持占用，恢复后图片地址与短链接不变。
+func (s *ImageService) TrashImages(images []model.Image) error {
+	if len(images) == 0 {
+		return nil
+	}
+	ids := make([]uint, 0, len(images))
+	for _, img := range images {
+		ids = append(ids, img.ID)
+	}
+	return s.imageStore.TrashImages(ids)
+}
+
+// ListTrashedImages 分页获取用户回收站中的图片。
+func (s *ImageService) ListTrashedImages(userID uint, page int, pageSize int) ([]moduledto.TrashedImageResponse, int64, int, int, error) {
+	page, pageSize = normalizePagination(page, pageSize)
+	images, total, err := s.imageStore.ListTrashedByUserID(userID, (page-1)*pageSize, pageSize)
+	if err != nil {
+		log.Printf("List trashed images error: %v\n", err)
+		return nil, 0, page, pageSize, commonpkg.NewInternalError("获取回收站列表失败")
+	}
+
+	retention := s.trashRetention()
+	items := make([]moduledto.TrashedImageResponse, 0, len(images))
+	for _, img := range images {
+		items = append(items, moduledto.TrashedImageResponse{
+			Image:     img,
+			URL:       s.ImageRecordURL(&img),
+			DeletedAt: img.DeletedAt.Time.Unix(),
+			PurgeAt:   img.DeletedAt.Time.Add(retention).Unix(),
+		})
+	}
+	return items, total, page, pageSize, nil
+}
+
+// RestoreTrashedImages 从回收站恢复用户的图片，返回恢复的数量。
+func (s *ImageService) RestoreTrashedImages(userID uint, ids []uint) (int64, error) {
+	if len(ids) == 0 {
+		return 0, commonpkg.NewValidationError("请选择要恢复的图片")
+	}
+	restored, err := s.imageStore.RestoreImages(ids, userID)
+	if err != nil {
+		log.Printf("Restore images error: %v\n", err)
+		return 0, commonpkg.NewInternalError("恢复图片失败")
+	}
+	if restored == 0 {
+		return 0, commonpkg.NewNotFoundError("回收站中未找到指定图片")
+	}
+	return restored, nil
+}
+
+// DeleteTrashedImages 永久删除用户回收站中的指定图片并释放配额，返回删除的数量。
+func (s *ImageService) DeleteTrashedImages(userID uint, ids []uint) (int, error) {
+	if len(ids) == 0 {
+		return 0, commonpkg.NewValidationError("请选择要删除的图片")
+	}
+	images, err := s.imageStore.FindTrashedByIDsAndUserID(ids, userID)
+	if err != nil {
+		return 0, commonpkg.NewInternalError("查找图片失败")
+	}
+	if len(images) == 0 {
+		return 0, commonpkg.NewNotFoundError("回收站中未找到指定图片")
+	}
+	if err := s.BatchDeleteImages(images); err != nil {
+		return 0, err
+	}
+	return len(images), nil
+}
+
+// EmptyTrash 清空用户的回收站，返回永久删除的数量。
+func (s *ImageService) EmptyTrash(userID uint) (int, error) {
+	deleted := 0
+	for {
+		images, _, err := s.imageStore.ListTrashedByUserID(userID, 0, trashBatchSize)
+		if err != nil {
+			return deleted, commonpkg.NewInternalError("查找图片失败")
+		}
+		if len(images) == 0 {
+			return deleted, nil
+		}
+		if err := s.BatchDeleteImages(images); err != nil {
+			return deleted, err
+		}
+		deleted += len(images)
+	}
+}
+
+// PurgeTrashedImages 永久删除在回收站中超过保留天数的图片并释放配额，返回删除的数量。
+func (s *ImageService) PurgeTrashedImages() (int, error) {
+	cutoff := time.Now().Add(-s.trashRetention())
+	deleted := 0
+	for {
+		images, err := s.imageStore.FindTrashedBefore(cutoff, trashBatchSize)
+		if err != nil {
+			return deleted, fmt.Errorf("find trashed images: %w", err)
+		}
+		if len(images) == 0 {
+			return deleted, nil
+		}
+		if err := s.BatchDeleteImages(images); err != nil {
+			return deleted, fmt.Errorf("purge trashed images: %w", err)
+		}
+		deleted += len(images)
+		if len(images) < trashBatchSize {
+			return deleted, nil
+		}
+	}
+}
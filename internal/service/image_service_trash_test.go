@@ -0,0 +1,145 @@
+package service
+
+import (
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"perfect-pic-server/internal/common"
+	"perfect-pic-server/internal/consts"
+	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/model"
+)
+
+// 测试内容：验证移入回收站的图片保留文件与配额但不可访问，可恢复原状态，永久删除与清空回收站时释放文件与配额。
+func TestTrashImages_RestoreAndDelete(t *testing.T) {
+	setupTestDB(t)
+	s := testService.imageService
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+	upload := func(c color.NRGBA) *model.Image {
+		t.Helper()
+		result, err := s.ProcessImageData("a.png", newSolidPNG(t, 4, 4, c), u.ID, 0, 1<<20, moduledto.ImageUploadInfo{})
+		if err != nil {
+			t.Fatalf("upload: %v", err)
+		}
+		return result.Image
+	}
+	storageUsed := func() int64 {
+		var got model.User
+		_ = testGormDB.First(&got, u.ID).Error
+		return got.StorageUsed
+	}
+
+	a := upload(color.NRGBA{R: 255, A: 255})
+	b := upload(color.NRGBA{G: 255, A: 255})
+	used := storageUsed()
+	s.EnsureShortCode(a)
+
+	if err := s.TrashImages([]model.Image{*a, *b}); err != nil {
+		t.Fatalf("trash: %v", err)
+	}
+	if storageUsed() != used {
+		t.Fatalf("期望回收站中的图片继续占用配额，storage_used=%d", storageUsed())
+	}
+	fullA := filepath.Join("uploads", "imgs", filepath.FromSlash(a.Path))
+	if _, err := os.Stat(fullA); err != nil {
+		t.Fatalf("期望保留回收站中的图片文件: %v", err)
+	}
+	_, _, err := s.AuthorizeImageAccess(a.Path, 0, nil, "", "")
+	assertServiceErrorCode(t, err, common.ErrorCodeNotFound)
+	_, err = s.FindImageByShortCode(*a.ShortCode)
+	assertServiceErrorCode(t, err, common.ErrorCodeNotFound)
+	if count, _ := s.GetUserImageCount(u.ID); count != 0 {
+		t.Fatalf("期望回收站中的图片不计入图片数量，实际为 %d", count)
+	}
+
+	list, total, _, _, err := s.ListTrashedImages(u.ID, 1, 10)
+	if err != nil || total != 2 || len(list) != 2 {
+		t.Fatalf("回收站列表不符: %d %v", total, err)
+	}
+	if list[0].DeletedAt == 0 || list[0].PurgeAt-list[0].DeletedAt != 30*24*3600 || list[0].URL == "" {
+		t.Fatalf("回收站条目不符: %+v", list[0])
+	}
+
+	// 恢复后短链接与访问地址不变
+	if restored, err := s.RestoreTrashedImages(u.ID, []uint{a.ID}); err != nil || restored != 1 {
+		t.Fatalf("restore: %d %v", restored, err)
+	}
+	if found, err := s.FindImageByShortCode(*a.ShortCode); err != nil || found.ID != a.ID {
+		t.Fatalf("期望恢复后短链接可用: %v", err)
+	}
+	_, err = s.RestoreTrashedImages(u.ID, []uint{a.ID})
+	assertServiceErrorCode(t, err, common.ErrorCodeNotFound)
+	_, err = s.RestoreTrashedImages(u.ID+1, []uint{b.ID})
+	assertServiceErrorCode(t, err, common.ErrorCodeNotFound)
+
+	// 未在回收站中的图片不能通过回收站永久删除
+	_, err = s.DeleteTrashedImages(u.ID, []uint{a.ID})
+	assertServiceErrorCode(t, err, common.ErrorCodeNotFound)
+	if deleted, err := s.DeleteTrashedImages(u.ID, []uint{b.ID}); err != nil || deleted != 1 {
+		t.Fatalf("delete trashed: %d %v", deleted, err)
+	}
+	if storageUsed() != a.StorageSize() {
+		t.Fatalf("期望永久删除后释放配额，storage_used=%d", storageUsed())
+	}
+
+	if err := s.TrashImages([]model.Image{*a}); err != nil {
+		t.Fatalf("trash: %v", err)
+	}
+	if deleted, err := s.EmptyTrash(u.ID); err != nil || deleted != 1 {
+		t.Fatalf("empty trash: %d %v", deleted, err)
+	}
+	if _, err := os.Stat(fullA); !os.IsNotExist(err) {
+		t.Fatalf("期望清空回收站后删除文件, err=%v", err)
+	}
+	if storageUsed() != 0 {
+		t.Fatalf("期望清空回收站后释放全部配额，storage_used=%d", storageUsed())
+	}
+}
+
+// 测试内容：验证定期清理只永久删除超过保留天数的回收站图片，回收站中的图片仍占用短码。
+func TestPurgeTrashedImages(t *testing.T) {
+	setupTestDB(t)
+	s := testService.imageService
+	setTestSetting(t, consts.ConfigImageTrashRetentionDays, "7")
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com", StorageUsed: 8}
+	_ = testGormDB.Create(&u).Error
+	oldCode := "oldcode"
+	old := model.Image{Filename: "a.png", Path: "2026/a.png", Size: 4, MimeType: ".png", UploadedAt: 1, UserID: u.ID, ShortCode: &oldCode}
+	recent := model.Image{Filename: "b.png", Path: "2026/b.png", Size: 4, MimeType: ".png", UploadedAt: 1, UserID: u.ID}
+	_ = testGormDB.Create(&old).Error
+	_ = testGormDB.Create(&recent).Error
+	if err := s.TrashImages([]model.Image{old, recent}); err != nil {
+		t.Fatalf("trash: %v", err)
+	}
+	_ = testGormDB.Unscoped().Model(&model.Image{}).Where("id = ?", old.ID).Update("deleted_at", time.Now().Add(-8*24*time.Hour)).Error
+
+	other := createShortLinkTestImage(t, "c.png")
+	_, err := s.SetImageShortCode(other.ID, oldCode)
+	assertServiceErrorCode(t, err, common.ErrorCodeConflict)
+
+	purged, err := s.PurgeTrashedImages()
+	if err != nil || purged != 1 {
+		t.Fatalf("期望清理 1 张图片，实际为 %d %v", purged, err)
+	}
+	var remaining []model.Image
+	_ = testGormDB.Unscoped().Where("user_id = ? AND deleted_at IS NOT NULL", u.ID).Find(&remaining).Error
+	if len(remaining) != 1 || remaining[0].ID != recent.ID {
+		t.Fatalf("期望仅保留未到期的回收站图片，实际为 %+v", remaining)
+	}
+	var got model.User
+	_ = testGormDB.First(&got, u.ID).Error
+	if got.StorageUsed != 4 {
+		t.Fatalf("期望释放已清理图片的配额，storage_used=%d", got.StorageUsed)
+	}
+}
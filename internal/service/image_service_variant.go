@@ -0,0 +1,183 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	commonpkg "perfect-pic-server/internal/common"
+	"perfect-pic-server/internal/consts"
+	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/pkg/imageproc"
+	"perfect-pic-server/internal/pkg/storage"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// variantDirSuffix 变体缓存目录后缀，变体与原图存放在同一目录下：{原图}.variants/{变体文件}
+const variantDirSuffix = ".variants"
+
+// maxVariantSourcePixels 生成变体时允许解码的原图最大像素数，防止解压炸弹耗尽内存。
+const maxVariantSourcePixels = 50_000_000
+
+// maxVariantSize 变体边长允许配置的最大值。
+const maxVariantSize = 8192
+
+var variantGroup singleflight.Group
+
+// IsImageVariantEnabled 是否允许按需生成图片变体。
+func (s *ImageService) IsImageVariantEnabled() bool {
+	return s.dbConfig.GetBool(consts.ConfigImageVariantEnabled)
+}
+
+// GetImageVariant 获取原图 key 对应的变体；首次请求时生成并缓存到原图旁边。
+//
+//nolint:gocyclo
+func (s *ImageService) GetImageVariant(key string, req moduledto.ImageVariantRequest) (io.ReadCloser, *storage.ObjectInfo, error) {
+	cleanKey, err := storage.CleanKey(key)
+	if err != nil || strings.Contains(cleanKey, variantDirSuffix+"/") {
+		return nil, nil, commonpkg.NewNotFoundError("图片不存在")
+	}
+
+	fit := imageproc.NormalizeFit(req.Fit)
+	if fit == "" {
+		return nil, nil, commonpkg.NewValidationError("fit 参数仅支持 contain、cover、fill")
+	}
+
+	sourceFormat := imageproc.NormalizeFormat(path.Ext(cleanKey))
+	if sourceFormat == "" {
+		return nil, nil, commonpkg.NewValidationError("该文件不支持生成缩略图")
+	}
+	format := sourceFormat
+	if req.Format != "" {
+		format = imageproc.NormalizeFormat(req.Format)
+	}
+	if !imageproc.CanEncode(format) {
+		if req.Format != "" {
+			return nil, nil, commonpkg.NewValidationError("fmt 参数仅支持 jpeg、png、webp")
+		}
+		// GIF/BMP 等原格式仅取首帧，统一输出为 PNG。
+		format = imageproc.FormatPNG
+	}
+
+	if err := s.validateVariantSize(req.Width, req.Height); err != nil {
+		return nil, nil, err
+	}
+
+	cacheKey := variantKey(cleanKey, req.Width, req.Height, fit, format)
+	if reader, info, err := s.storage.Images.Get(cacheKey); err == nil {
+		return reader, info, nil
+	} else if !errors.Is(err, storage.ErrNotExist) {
+		log.Printf("Get image variant error: %v\n", err)
+	}
+
+	// 同一变体的并发请求只生成一次。
+	result, err, _ := variantGroup.Do(cacheKey, func() (interface{}, error) {
+		return s.generateImageVariant(cleanKey, cacheKey, req.Width, req.Height, fit, format)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	data := result.([]byte)
+	info := &storage.ObjectInfo{
+		Key:          cacheKey,
+		Size:         int64(len(data)),
+		ContentType:  imageproc.ContentType(format),
+		LastModified: time.Now(),
+	}
+	return io.NopCloser(bytes.NewReader(data)), info, nil
+}
+
+func (s *ImageService) generateImageVariant(key, cacheKey string, width, height int, fit, format string) ([]byte, error) {
+	original, _, err := s.storage.Images.Get(key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotExist) {
+			return nil, commonpkg.NewNotFoundError("图片不存在")
+		}
+		log.Printf("Read original image error: %v\n", err)
+		return nil, commonpkg.NewInternalError("读取原图失败")
+	}
+	raw, err := io.ReadAll(original)
+	_ = original.Close()
+	if err != nil {
+		log.Printf("Read original image error: %v\n", err)
+		return nil, commonpkg.NewInternalError("读取原图失败")
+	}
+
+	cfg, _, err := imageproc.DecodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		return nil, commonpkg.NewValidationError("无法解析原图")
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > maxVariantSourcePixels {
+		return nil, commonpkg.NewValidationError("原图尺寸过大，无法生成缩略图")
+	}
+
+	img, _, err := imageproc.Decode(bytes.NewReader(raw))
+	if err != nil {
+		return nil, commonpkg.NewValidationError("无法解析原图")
+	}
+
+	var buf bytes.Buffer
+	if err := imageproc.Encode(&buf, imageproc.Resize(img, width, height, fit), format, 0); err != nil {
+		log.Printf("Encode image variant error: %v\n", err)
+		return nil, commonpkg.NewInternalError("生成缩略图失败")
+	}
+
+	data := buf.Bytes()
+	if err := s.storage.Images.Put(cacheKey, bytes.NewReader(data), int64(len(data)), imageproc.ContentType(format)); err != nil {
+		// 缓存写入失败不影响本次响应。
+		log.Printf("Cache image variant error: %v\n", err)
+	}
+	return data, nil
+}
+
+func (s *ImageService) validateVariantSize(width, height int) error {
+	if width < 0 || height < 0 {
+		return commonpkg.NewValidationError("宽高参数不能为负数")
+	}
+	allowed := parseVariantSizes(s.dbConfig.GetString(consts.ConfigImageVariantAllowedSizes))
+	for _, size := range []int{width, height} {
+		if size == 0 {
+			continue
+		}
+		if _, ok := allowed[size]; !ok {
+			return commonpkg.NewValidationError(fmt.Sprintf("不支持的尺寸: %d", size))
+		}
+	}
+	return nil
+}
+
+// deleteImageVariants 删除原图对应的全部变体缓存。
+func (s *ImageService) deleteImageVariants(key string) {
+	if err := s.storage.Images.DeletePrefix(variantDir(key)); err != nil {
+		log.Printf("Delete image variants error: %v, key: %s\n", err, key)
+	}
+}
+
+// variantDir 返回原图变体缓存所在目录。
+func variantDir(key string) string {
+	return key + variantDirSuffix
+}
+
+// variantKey 返回指定参数对应的变体对象 key。
+func variantKey(key string, width, height int, fit, format string) string {
+	return path.Join(variantDir(key), fmt.Sprintf("w%d_h%d_%s%s", width, height, fit, imageproc.Ext(format)))
+}
+
+// parseVariantSizes 解析逗号分隔的变体边长列表，忽略非法项。
+func parseVariantSizes(raw string) map[int]struct{} {
+	sizes := make(map[int]struct{})
+	for _, part := range strings.Split(raw, ",") {
+		size, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || size <= 0 || size > maxVariantSize {
+			continue
+		}
+		sizes[size] = struct{}{}
+	}
+	return sizes
+}
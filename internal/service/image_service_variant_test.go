@@ -0,0 +1,133 @@
+package service
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"perfect-pic-server/internal/common"
+	"perfect-pic-server/internal/consts"
+	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/model"
+)
+
+func writeTestPNG(t *testing.T, full string, w, h int) {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("encode png: %v", err)
+	}
+	_ = os.MkdirAll(filepath.Dir(full), 0755)
+	if err := os.WriteFile(full, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("write png: %v", err)
+	}
+}
+
+// 测试内容：验证按需生成变体并缓存到原图旁边，删除原图时一并清理变体。
+func TestGetImageVariant_GeneratesCachesAndDeletes(t *testing.T) {
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com", StorageUsed: 1}
+	_ = testGormDB.Create(&u).Error
+
+	imgRel := "2026/10/16/a.png"
+	writeTestPNG(t, filepath.Join("uploads", "imgs", filepath.FromSlash(imgRel)), 400, 200)
+	img := model.Image{Filename: "a.png", Path: imgRel, Size: 1, Width: 400, Height: 200, MimeType: ".png", UploadedAt: 1, UserID: u.ID}
+	_ = testGormDB.Create(&img).Error
+
+	reader, info, err := testService.imageService.GetImageVariant(imgRel, moduledto.ImageVariantRequest{Width: 320, Height: 320, Fit: "cover", Format: "webp"})
+	if err != nil {
+		t.Fatalf("GetImageVariant: %v", err)
+	}
+	data, _ := io.ReadAll(reader)
+	_ = reader.Close()
+	if info.ContentType != "image/webp" {
+		t.Fatalf("期望 image/webp，实际为 %q", info.ContentType)
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || format != "webp" || cfg.Width != 200 || cfg.Height != 200 {
+		t.Fatalf("期望 200x200 webp，实际为 %dx%d %s err=%v", cfg.Width, cfg.Height, format, err)
+	}
+
+	cached := filepath.Join("uploads", "imgs", "2026", "10", "16", "a.png.variants", "w320_h320_cover.webp")
+	if _, err := os.Stat(cached); err != nil {
+		t.Fatalf("期望变体缓存到原图旁边: %v", err)
+	}
+
+	// 第二次请求直接命中缓存
+	reader, _, err = testService.imageService.GetImageVariant(imgRel, moduledto.ImageVariantRequest{Width: 320, Height: 320, Fit: "cover", Format: "webp"})
+	if err != nil {
+		t.Fatalf("GetImageVariant cached: %v", err)
+	}
+	_ = reader.Close()
+
+	if err := testService.DeleteImage(&img); err != nil {
+		t.Fatalf("DeleteImage: %v", err)
+	}
+	if _, err := os.Stat(filepath.Dir(cached)); !os.IsNotExist(err) {
+		t.Fatalf("期望删除原图时清理变体目录, err=%v", err)
+	}
+}
+
+// 测试内容：验证不在允许列表中的尺寸与非法参数被拒绝。
+func TestGetImageVariant_RejectsInvalidParams(t *testing.T) {
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	imgRel := "2026/10/16/b.png"
+	writeTestPNG(t, filepath.Join("uploads", "imgs", filepath.FromSlash(imgRel)), 50, 50)
+
+	cases := []moduledto.ImageVariantRequest{
+		{Width: 333},
+		{Width: 320, Fit: "stretch"},
+		{Width: 320, Format: "tiff"},
+		{Height: -1},
+	}
+	for _, req := range cases {
+		if _, _, err := testService.imageService.GetImageVariant(imgRel, req); err == nil {
+			t.Fatalf("期望参数 %+v 被拒绝", req)
+		}
+	}
+
+	_, _, err := testService.imageService.GetImageVariant("2026/10/16/missing.png", moduledto.ImageVariantRequest{Width: 320})
+	if se, ok := common.AsServiceError(err); !ok || se.Code != common.ErrorCodeNotFound {
+		t.Fatalf("期望原图不存在时返回 NotFound，实际为 %v", err)
+	}
+
+	_, _, err = testService.imageService.GetImageVariant("2026/10/16/b.png.variants/w320_h0_contain.png", moduledto.ImageVariantRequest{Width: 320})
+	if se, ok := common.AsServiceError(err); !ok || se.Code != common.ErrorCodeNotFound {
+		t.Fatalf("期望不允许对变体再次生成变体，实际为 %v", err)
+	}
+}
+
+// 测试内容：验证缩略图尺寸配置项的更新校验。
+func TestValidateSettingUpdate_ImageVariantAllowedSizes(t *testing.T) {
+	if err := validateSettingUpdate(moduledto.UpdateSettingRequest{Key: consts.ConfigImageVariantAllowedSizes, Value: "64, 128,320"}); err != nil {
+		t.Fatalf("期望合法配置通过校验，实际为 %v", err)
+	}
+	for _, value := range []string{"", "64,abc", "0", "99999"} {
+		if err := validateSettingUpdate(moduledto.UpdateSettingRequest{Key: consts.ConfigImageVariantAllowedSizes, Value: value}); err == nil {
+			t.Fatalf("期望非法配置 %q 被拒绝", value)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	"image/png"
+	"log"
+	"os"
+	commonpkg "perfect-pic-server/internal/common"
+	"perfect-pic-server/internal/consts"
+	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/pkg/imageproc"
+	"perfect-pic-server/internal/pkg/watermark"
+	"strings"
+)
+
+const (
+	// watermarkTypeText 文字水印。
+	watermarkTypeText = "text"
+	// watermarkTypeImage PNG 图片水印。
+	watermarkTypeImage = "image"
+)
+
+// maxWatermarkTextLength 文字水印内容的最大字符数。
+const maxWatermarkTextLength = 100
+
+// checkWatermarkOptOut 校验用户关闭水印的请求：水印开启且管理员未允许关闭时拒绝。
+func (s *ImageService) checkWatermarkOptOut(info moduledto.ImageUploadInfo) error {
+	if info.DisableWatermark && s.dbConfig.GetBool(consts.ConfigWatermarkEnabled) && !s.dbConfig.GetBool(consts.ConfigWatermarkAllowUserDisable) {
+		return commonpkg.NewForbiddenError("管理员未允许关闭水印")
+	}
+	return nil
+}
+
+// watermarkImageUpload 按系统设置为上传内容添加水印，并按原格式重新编码。
+//
+// 解码时会按 EXIF 方向旋转像素，因为重新编码的输出不携带 EXIF。
+// 未开启水印、用户关闭水印、源格式不适用（GIF/BMP）、图片过小、水印配置无效或处理失败时返回 nil，调用方按原内容保存。
+func (s *ImageService) watermarkImageUpload(content []byte, ext string, width, height, orientation int, info moduledto.ImageUploadInfo) *convertedImage {
+	if !s.dbConfig.GetBool(consts.ConfigWatermarkEnabled) || info.DisableWatermark {
+		return nil
+	}
+	format := imageproc.NormalizeFormat(ext)
+	if !imageproc.CanEncode(format) || int64(width)*int64(height) > maxVariantSourcePixels {
+		return nil
+	}
+	if minSize := s.dbConfig.GetInt(consts.ConfigWatermarkMinSize); minSize > 0 && (width < minSize || height < minSize) {
+		return nil
+	}
+
+	opts := watermark.Options{
+		Position: s.dbConfig.GetString(consts.ConfigWatermarkPosition),
+		Opacity:  float64(s.dbConfig.GetInt(consts.ConfigWatermarkOpacity)) / 100,
+		Scale:    float64(s.dbConfig.GetInt(consts.ConfigWatermarkScale)) / 100,
+	}
+	var (
+		mark image.Image
+		text string
+	)
+	if strings.ToLower(strings.TrimSpace(s.dbConfig.GetString(consts.ConfigWatermarkType))) == watermarkTypeImage {
+		if mark = s.loadWatermarkImage(); mark == nil {
+			return nil
+		}
+	} else if text = s.watermarkText(info.Username); text == "" {
+		return nil
+	}
+
+	src, _, err := imageproc.Decode(bytes.NewReader(content))
+	if err != nil {
+		log.Printf("Watermark upload decode error: %v\n", err)
+		return nil
+	}
+	src = imageproc.ApplyOrientation(src, orientation)
+	b := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Src)
+	if mark != nil {
+		watermark.DrawImage(dst, mark, opts)
+	} else {
+		watermark.DrawText(dst, text, watermark.DefaultFont(), opts)
+	}
+
+	var buf bytes.Buffer
+	if err := imageproc.Encode(&buf, dst, format, 0); err != nil {
+		log.Printf("Watermark upload encode error: %v\n", err)
+		return nil
+	}
+	return &convertedImage{data: buf.Bytes(), ext: ext, width: b.Dx(), height: b.Dy()}
+}
+
+// watermarkText 展开文字水印中的占位符。
+func (s *ImageService) watermarkText(username string) string {
+	replacer := strings.NewReplacer(
+		"{site_name}", s.dbConfig.GetString(consts.ConfigSiteName),
+		"{username}", username,
+	)
+	return strings.TrimSpace(replacer.Replace(s.dbConfig.GetString(consts.ConfigWatermarkText)))
+}
+
+// loadWatermarkImage 读取配置的 PNG 水印文件，未配置或读取失败时返回 nil。
+func (s *ImageService) loadWatermarkImage() image.Image {
+	imagePath := strings.TrimSpace(s.dbConfig.GetString(consts.ConfigWatermarkImagePath))
+	if imagePath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(imagePath)
+	if err != nil {
+		log.Printf("Read watermark image error: %v\n", err)
+		return nil
+	}
+	mark, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		log.Printf("Decode watermark image error: %v\n", err)
+		return nil
+	}
+	return mark
+}
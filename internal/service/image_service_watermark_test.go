@@ -0,0 +1,199 @@
+package service
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"perfect-pic-server/internal/common"
+	"perfect-pic-server/internal/consts"
+	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/model"
+)
+
+// readStoredPNG 读取上传后存储的 PNG 并解码。
+func readStoredPNG(t *testing.T, key string) image.Image {
+	t.Helper()
+	stored, err := os.ReadFile(filepath.Join("uploads", "imgs", filepath.FromSlash(key)))
+	if err != nil {
+		t.Fatalf("read stored: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(stored))
+	if err != nil {
+		t.Fatalf("decode stored: %v", err)
+	}
+	return img
+}
+
+// countChangedPixels 统计与背景色不同的像素数量。
+func countChangedPixels(img image.Image, bg color.NRGBA) int {
+	changed := 0
+	b := img.Bounds()
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			if color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA) != bg {
+				changed++
+			}
+		}
+	}
+	return changed
+}
+
+// 测试内容：验证开启文字水印后上传内容被添加水印，过小的图片不处理，占位符展开为空时跳过。
+func TestProcessImageUpload_TextWatermark(t *testing.T) {
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+
+	setTestSetting(t, consts.ConfigWatermarkEnabled, "true")
+	setTestSetting(t, consts.ConfigWatermarkText, "@{username}")
+	setTestSetting(t, consts.ConfigWatermarkMinSize, "100")
+
+	bg := color.NRGBA{R: 20, G: 40, B: 60, A: 255}
+	content := newSolidPNG(t, 200, 120, bg)
+	res, err := testService.imageService.ProcessImageUpload(mustFileHeader(t, "a.png", content), u.ID, 0, 1<<20, moduledto.ImageUploadInfo{Username: u.Username})
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	img := readStoredPNG(t, res.Image.Path)
+	if countChangedPixels(img, bg) == 0 {
+		t.Fatalf("期望图片被添加水印")
+	}
+	if res.Image.Width != 200 || res.Image.Height != 120 || res.Image.Size == int64(len(content)) {
+		t.Fatalf("记录不符: %+v", res.Image)
+	}
+	// 默认位置为右下角，左上角保持原样
+	if c := color.NRGBAModel.Convert(img.At(5, 5)).(color.NRGBA); c != bg {
+		t.Fatalf("期望左上角不受影响，实际为 %v", c)
+	}
+
+	small := newSolidPNG(t, 80, 200, bg)
+	res, err = testService.imageService.ProcessImageUpload(mustFileHeader(t, "b.png", small), u.ID, 0, 1<<20, moduledto.ImageUploadInfo{Username: u.Username})
+	if err != nil {
+		t.Fatalf("upload small: %v", err)
+	}
+	if res.Image.Size != int64(len(small)) {
+		t.Fatalf("期望宽度小于最小边长的图片不添加水印")
+	}
+
+	setTestSetting(t, consts.ConfigWatermarkText, "{username}")
+	if text := testService.imageService.watermarkText(""); text != "" {
+		t.Fatalf("期望占位符展开为空时跳过水印，实际为 %q", text)
+	}
+}
+
+// 测试内容：验证图片水印读取配置的 PNG 文件；配置的文件不存在时按原内容保存。
+func TestProcessImageUpload_ImageWatermark(t *testing.T) {
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+
+	_ = os.WriteFile("logo.png", newSolidPNG(t, 40, 20, color.NRGBA{R: 255, A: 255}), 0644)
+	setTestSetting(t, consts.ConfigWatermarkEnabled, "true")
+	setTestSetting(t, consts.ConfigWatermarkType, "image")
+	setTestSetting(t, consts.ConfigWatermarkImagePath, "missing.png")
+	setTestSetting(t, consts.ConfigWatermarkPosition, "top-left")
+	setTestSetting(t, consts.ConfigWatermarkOpacity, "100")
+
+	bg := color.NRGBA{R: 255, G: 255, B: 255, A: 255}
+	content := newSolidPNG(t, 400, 300, bg)
+	res, err := testService.imageService.ProcessImageUpload(mustFileHeader(t, "a.png", content), u.ID, 0, 1<<20, moduledto.ImageUploadInfo{})
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if res.Image.Size != int64(len(content)) {
+		t.Fatalf("期望水印文件缺失时按原内容保存")
+	}
+
+	setTestSetting(t, consts.ConfigWatermarkImagePath, "logo.png")
+	content = newSolidPNG(t, 400, 301, bg)
+	res, err = testService.imageService.ProcessImageUpload(mustFileHeader(t, "b.png", content), u.ID, 0, 1<<20, moduledto.ImageUploadInfo{})
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	img := readStoredPNG(t, res.Image.Path)
+	// 水印不会放大：40x20 的纯红色块位于左上角边距之后
+	if changed := countChangedPixels(img, bg); changed != 40*20 {
+		t.Fatalf("期望改变 %d 个像素，实际为 %d", 40*20, changed)
+	}
+	if c := color.NRGBAModel.Convert(img.At(15, 15)).(color.NRGBA); c != (color.NRGBA{R: 255, A: 255}) {
+		t.Fatalf("期望左上角为水印颜色，实际为 %v", c)
+	}
+}
+
+// 测试内容：验证用户关闭水印需管理员允许；允许后上传内容保持不变。
+func TestProcessImageUpload_WatermarkOptOut(t *testing.T) {
+	setupTestDB(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+
+	setTestSetting(t, consts.ConfigWatermarkEnabled, "true")
+	content := newSolidPNG(t, 400, 300, color.NRGBA{R: 1, G: 2, B: 3, A: 255})
+	info := moduledto.ImageUploadInfo{DisableWatermark: true}
+
+	_, err := testService.imageService.ProcessImageUpload(mustFileHeader(t, "a.png", content), u.ID, 0, 1<<20, info)
+	assertServiceErrorCode(t, err, common.ErrorCodeForbidden)
+
+	setTestSetting(t, consts.ConfigWatermarkAllowUserDisable, "true")
+	res, err := testService.imageService.ProcessImageUpload(mustFileHeader(t, "a.png", content), u.ID, 0, 1<<20, info)
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if res.Image.Size != int64(len(content)) {
+		t.Fatalf("期望关闭水印后按原内容保存")
+	}
+}
+
+// 测试内容：验证水印相关设置的取值校验。
+func TestValidateSettingUpdate_Watermark(t *testing.T) {
+	valid := map[string][]string{
+		consts.ConfigWatermarkType:     {"text", "IMAGE"},
+		consts.ConfigWatermarkPosition: {"", "top-left", "center"},
+		consts.ConfigWatermarkOpacity:  {"1", "100"},
+		consts.ConfigWatermarkScale:    {"20"},
+		consts.ConfigWatermarkMinSize:  {"0", "300"},
+	}
+	for key, values := range valid {
+		for _, value := range values {
+			if err := validateSettingUpdate(moduledto.UpdateSettingRequest{Key: key, Value: value}); err != nil {
+				t.Fatalf("%s=%q: 期望合法，实际为 %v", key, value, err)
+			}
+		}
+	}
+	invalid := map[string][]string{
+		consts.ConfigWatermarkType:     {"", "logo"},
+		consts.ConfigWatermarkPosition: {"middle"},
+		consts.ConfigWatermarkOpacity:  {"0", "101"},
+		consts.ConfigWatermarkScale:    {"abc"},
+		consts.ConfigWatermarkMinSize:  {"-1"},
+	}
+	for key, values := range invalid {
+		for _, value := range values {
+			if err := validateSettingUpdate(moduledto.UpdateSettingRequest{Key: key, Value: value}); err == nil {
+				t.Fatalf("%s=%q: 期望非法取值返回错误", key, value)
+			}
+		}
+	}
+}
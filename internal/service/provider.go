@@ -5,7 +5,13 @@ import (
 	"perfect-pic-server/internal/pkg/cache"
 	"perfect-pic-server/internal/pkg/email"
 	"perfect-pic-server/internal/pkg/jwt"
+	"perfect-pic-server/internal/pkg/remotefetch"
+	"perfect-pic-server/internal/pkg/storage"
+	"perfect-pic-server/internal/pkg/urlsign"
 	repo "perfect-pic-server/internal/repository"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/google/wire"
 )
@@ -26,6 +32,26 @@ type ImageService struct {
 	imageStore   repo.ImageStore
 	dbConfig     *config.DBConfig
 	staticConfig *config.Config
+	storage      *storage.Manager
+	signer       *urlsign.Signer
+	fetcher      *remotefetch.Fetcher
+
+	// shortCodeCollisions 自进程启动以来生成随机短码时发生冲突的次数
+	shortCodeCollisions atomic.Int64
+	// reconciling 存储对账任务是否正在进行，同一时间只允许一个任务
+	reconciling atomic.Bool
+	// pendingViews 尚未写入数据库的访问计数，由 FlushImageViews 定期批量写入
+	viewsMu      sync.Mutex
+	pendingViews map[imageViewKey]int64
+}
+
+type TusService struct {
+	dbConfig   *config.DBConfig
+	cache      *cache.Store
+	dir        string
+	expiration time.Duration
+
+	locks sync.Map
 }
 
 type EmailService struct {
@@ -45,11 +71,20 @@ type PasskeyService struct {
 	passkeySessionCache *cache.Store
 }
 
+type AccessTokenService struct {
+	tokenStore repo.AccessTokenStore
+}
+
 type SettingsService struct {
 	settingStore repo.SettingStore
 	dbConfig     *config.DBConfig
 }
 
+type AlbumService struct {
+	albumStore repo.AlbumStore
+	imageStore repo.ImageStore
+}
+
 type CaptchaService struct {
 	dbConfig *config.DBConfig
 }
@@ -70,8 +105,20 @@ func NewUserService(userStore repo.UserStore, dbConfig *config.DBConfig, cache *
 	}
 }
 
-func NewImageService(imageStore repo.ImageStore, dbConfig *config.DBConfig, staticConfig *config.Config) *ImageService {
-	return &ImageService{imageStore: imageStore, dbConfig: dbConfig, staticConfig: staticConfig}
+func NewImageService(imageStore repo.ImageStore, dbConfig *config.DBConfig, staticConfig *config.Config, storages *storage.Manager, signer *urlsign.Signer, fetcher *remotefetch.Fetcher) *ImageService {
+	return &ImageService{imageStore: imageStore, dbConfig: dbConfig, staticConfig: staticConfig, storage: storages, signer: signer, fetcher: fetcher}
+}
+
+func NewTusService(dbConfig *config.DBConfig, staticConfig *config.Config, cache *cache.Store) *TusService {
+	dir := staticConfig.Upload.TusPath
+	if dir == "" {
+		dir = "uploads/tus"
+	}
+	expiration := time.Duration(staticConfig.Upload.TusExpirationHours) * time.Hour
+	if expiration <= 0 {
+		expiration = 24 * time.Hour
+	}
+	return &TusService{dbConfig: dbConfig, cache: cache, dir: dir, expiration: expiration}
 }
 
 func NewEmailService(dbConfig *config.DBConfig, mailer *email.Mailer, staticConfig *config.Config) *EmailService {
@@ -94,10 +141,18 @@ func NewPasskeyService(passkeyStore repo.PasskeyStore, dbConfig *config.DBConfig
 	}
 }
 
+func NewAccessTokenService(tokenStore repo.AccessTokenStore) *AccessTokenService {
+	return &AccessTokenService{tokenStore: tokenStore}
+}
+
 func NewSettingsService(settingStore repo.SettingStore, dbConfig *config.DBConfig) *SettingsService {
 	return &SettingsService{settingStore: settingStore, dbConfig: dbConfig}
 }
 
+func NewAlbumService(albumStore repo.AlbumStore, imageStore repo.ImageStore) *AlbumService {
+	return &AlbumService{albumStore: albumStore, imageStore: imageStore}
+}
+
 func NewCaptchaService(dbConfig *config.DBConfig) *CaptchaService {
 	return &CaptchaService{dbConfig: dbConfig}
 }
@@ -106,8 +161,11 @@ var ServiceSet = wire.NewSet(
 	NewAuthService,
 	NewUserService,
 	NewImageService,
+	NewTusService,
 	NewEmailService,
 	NewInitService,
 	NewPasskeyService,
+	NewAccessTokenService,
 	NewSettingsService,
+	NewAlbumService,
 	NewCaptchaService)
@@ -1,13 +1,19 @@
 package service
 
 import (
+	"fmt"
+	"net/url"
 	commonpkg "perfect-pic-server/internal/common"
+	"perfect-pic-server/internal/common/httpx"
 	"perfect-pic-server/internal/consts"
 	moduledto "perfect-pic-server/internal/dto"
 	"perfect-pic-server/internal/model"
+	"perfect-pic-server/internal/pkg/imagemeta"
+	"perfect-pic-server/internal/pkg/watermark"
 	settingsrepo "perfect-pic-server/internal/repository"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 // ListSettings 获取全部系统设置。
@@ -57,6 +63,109 @@ func validateSettingUpdate(item moduledto.UpdateSettingRequest) error {
 		if err != nil || quota <= 0 {
 			return commonpkg.NewValidationError("默认存储配额必须为正整数（单位：Bytes）")
 		}
+	case consts.ConfigDefaultImageTTL:
+		ttl, err := strconv.ParseInt(strings.TrimSpace(item.Value), 10, 64)
+		if err != nil || ttl < 0 || ttl > maxImageTTLSeconds {
+			return commonpkg.NewValidationError(fmt.Sprintf("默认图片有效期必须为 0-%d 之间的整数（单位：秒，0 表示永久保存）", maxImageTTLSeconds))
+		}
+	case consts.ConfigDefaultDailyUploadCount, consts.ConfigDefaultDailyUploadBytes, consts.ConfigDefaultMonthlyEgressBytes:
+		limit, err := strconv.ParseInt(strings.TrimSpace(item.Value), 10, 64)
+		if err != nil || limit < 0 {
+			return commonpkg.NewValidationError("用量限额必须为非负整数（0 表示不限制）")
+		}
+	case consts.ConfigImageTrashRetentionDays:
+		days, err := strconv.Atoi(strings.TrimSpace(item.Value))
+		if err != nil || days < 1 || days > maxTrashRetentionDays {
+			return commonpkg.NewValidationError(fmt.Sprintf("回收站保留天数必须为 1-%d 之间的整数", maxTrashRetentionDays))
+		}
+	case consts.ConfigIntegrationDeleteLinkHours:
+		hours, err := strconv.Atoi(strings.TrimSpace(item.Value))
+		if err != nil || hours < 1 || hours > maxIntegrationDeleteLinkHours {
+			return commonpkg.NewValidationError(fmt.Sprintf("删除链接有效期必须为 1-%d 之间的整数（单位：小时）", maxIntegrationDeleteLinkHours))
+		}
+	case consts.ConfigMaxBatchUploadFiles:
+		count, err := strconv.Atoi(strings.TrimSpace(item.Value))
+		if err != nil || count < 1 || count > maxBatchUploadFiles {
+			return commonpkg.NewValidationError(fmt.Sprintf("批量上传文件数必须为 1-%d 之间的整数", maxBatchUploadFiles))
+		}
+	case consts.ConfigImageVariantAllowedSizes:
+		for _, part := range strings.Split(item.Value, ",") {
+			size, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || size <= 0 || size > maxVariantSize {
+				return commonpkg.NewValidationError(fmt.Sprintf("缩略图尺寸必须为逗号分隔的正整数（1-%d）", maxVariantSize))
+			}
+		}
+	case consts.ConfigImageStripMetadata:
+		if _, ok := imagemeta.ParseStripMode(item.Value); !ok {
+			return commonpkg.NewValidationError("元数据清理模式只能为 all、gps 或 keep")
+		}
+	case consts.ConfigImageConvertFormat:
+		switch strings.ToLower(strings.TrimSpace(item.Value)) {
+		case "none", convertFormatWebPLossless, "jpeg":
+		case "webp":
+			return commonpkg.NewValidationError("内置 WebP 编码器仅支持无损编码，无法按质量压缩 JPEG 照片；如需将 PNG/BMP 转为无损 WebP 请选择 webp-lossless")
+		default:
+			return commonpkg.NewValidationError("转码格式只能为 none、webp-lossless 或 jpeg")
+		}
+	case consts.ConfigImageConvertMode:
+		switch strings.ToLower(strings.TrimSpace(item.Value)) {
+		case convertModeReplace, convertModeAlternate:
+		default:
+			return commonpkg.NewValidationError("转码保存方式只能为 replace 或 alternate")
+		}
+	case consts.ConfigImageConvertQuality:
+		quality, err := strconv.Atoi(strings.TrimSpace(item.Value))
+		if err != nil || quality < 1 || quality > 100 {
+			return commonpkg.NewValidationError("转码质量必须为 1-100 之间的整数")
+		}
+	case consts.ConfigWatermarkType:
+		switch strings.ToLower(strings.TrimSpace(item.Value)) {
+		case watermarkTypeText, watermarkTypeImage:
+		default:
+			return commonpkg.NewValidationError("水印类型只能为 text 或 image")
+		}
+	case consts.ConfigWatermarkText:
+		if utf8.RuneCountInString(item.Value) > maxWatermarkTextLength {
+			return commonpkg.NewValidationError(fmt.Sprintf("水印文字不能超过 %d 个字符", maxWatermarkTextLength))
+		}
+	case consts.ConfigWatermarkPosition:
+		if watermark.NormalizePosition(item.Value) == "" {
+			return commonpkg.NewValidationError("水印位置只能为 top-left、top-right、bottom-left、bottom-right 或 center")
+		}
+	case consts.ConfigWatermarkOpacity, consts.ConfigWatermarkScale:
+		percent, err := strconv.Atoi(strings.TrimSpace(item.Value))
+		if err != nil || percent < 1 || percent > 100 {
+			return commonpkg.NewValidationError("水印不透明度与比例必须为 1-100 之间的整数")
+		}
+	case consts.ConfigWatermarkMinSize:
+		size, err := strconv.Atoi(strings.TrimSpace(item.Value))
+		if err != nil || size < 0 {
+			return commonpkg.NewValidationError("水印最小图片边长必须为非负整数")
+		}
+	case consts.ConfigHotlinkAllowedReferers:
+		for _, part := range strings.Split(item.Value, ",") {
+			if strings.TrimSpace(part) == "" {
+				continue
+			}
+			if _, ok := httpx.NormalizeHostPattern(part); !ok {
+				return commonpkg.NewValidationError(fmt.Sprintf("防盗链来源域名格式错误: %s", strings.TrimSpace(part)))
+			}
+		}
+	case consts.ConfigHotlinkBlockAction:
+		switch strings.ToLower(strings.TrimSpace(item.Value)) {
+		case consts.HotlinkActionForbidden, consts.HotlinkActionPlaceholder:
+		default:
+			return commonpkg.NewValidationError("防盗链拦截方式只能为 forbidden 或 placeholder")
+		}
+	case consts.ConfigHotlinkPlaceholderURL:
+		value := strings.TrimSpace(item.Value)
+		if value == "" {
+			break
+		}
+		u, err := url.Parse(value)
+		if err != nil || (u.Scheme == "" && !strings.HasPrefix(value, "/")) || (u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https") || strings.HasPrefix(value, "//") {
+			return commonpkg.NewValidationError("占位图地址必须为 http(s) 地址或以 / 开头的站内路径")
+		}
 	}
 
 	return nil
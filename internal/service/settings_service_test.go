@@ -0,0 +1,66 @@
+package service
+
+import (
+	"testing"
+
+	"perfect-pic-server/internal/consts"
+	moduledto "perfect-pic-server/internal/dto"
+)
+
+// 测试内容：验证防盗链相关设置的取值校验。
+func TestValidateSettingUpdate_Hotlink(t *testing.T) {
+	valid := map[string][]string{
+		consts.ConfigHotlinkAllowedReferers: {"", "example.com", "example.com, *.cdn.example.com,", "https://blog.example.com/"},
+		consts.ConfigHotlinkBlockAction:     {"forbidden", "Placeholder"},
+		consts.ConfigHotlinkPlaceholderURL:  {"", "/static/blocked.png", "https://cdn.example.com/blocked.png"},
+	}
+	for key, values := range valid {
+		for _, value := range values {
+			if err := validateSettingUpdate(moduledto.UpdateSettingRequest{Key: key, Value: value}); err != nil {
+				t.Fatalf("%s=%q: 期望合法，实际为 %v", key, value, err)
+			}
+		}
+	}
+	invalid := map[string][]string{
+		consts.ConfigHotlinkAllowedReferers: {"*", "example.com, bad host"},
+		consts.ConfigHotlinkBlockAction:     {"", "redirect"},
+		consts.ConfigHotlinkPlaceholderURL:  {"blocked.png", "//evil.com/x.png", "javascript:alert(1)"},
+	}
+	for key, values := range invalid {
+		for _, value := range values {
+			if err := validateSettingUpdate(moduledto.UpdateSettingRequest{Key: key, Value: value}); err == nil {
+				t.Fatalf("%s=%q: 期望非法取值返回错误", key, value)
+			}
+		}
+	}
+}
+
+// 测试内容：验证批量上传文件数设置必须为 1 到上限之间的整数。
+func TestValidateSettingUpdate_MaxBatchUploadFiles(t *testing.T) {
+	for _, value := range []string{"1", "30", "100"} {
+		if err := validateSettingUpdate(moduledto.UpdateSettingRequest{Key: consts.ConfigMaxBatchUploadFiles, Value: value}); err != nil {
+			t.Fatalf("%q: 期望合法，实际为 %v", value, err)
+		}
+	}
+	for _, value := range []string{"", "0", "101", "abc"} {
+		if err := validateSettingUpdate(moduledto.UpdateSettingRequest{Key: consts.ConfigMaxBatchUploadFiles, Value: value}); err == nil {
+			t.Fatalf("%q: 期望非法取值返回错误", value)
+		}
+	}
+}
+
+// 测试内容：验证用量限额设置必须为非负整数，0 表示不限制。
+func TestValidateSettingUpdate_UsageLimits(t *testing.T) {
+	for _, key := range []string{consts.ConfigDefaultDailyUploadCount, consts.ConfigDefaultDailyUploadBytes, consts.ConfigDefaultMonthlyEgressBytes} {
+		for _, value := range []string{"0", "100", " 1073741824 "} {
+			if err := validateSettingUpdate(moduledto.UpdateSettingRequest{Key: key, Value: value}); err != nil {
+				t.Fatalf("%s=%q: 期望合法，实际为 %v", key, value, err)
+			}
+		}
+		for _, value := range []string{"", "-1", "1.5", "abc"} {
+			if err := validateSettingUpdate(moduledto.UpdateSettingRequest{Key: key, Value: value}); err == nil {
+				t.Fatalf("%s=%q: 期望非法取值返回错误", key, value)
+			}
+		}
+	}
+}
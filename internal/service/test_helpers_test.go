@@ -13,6 +13,9 @@ import (
 	"perfect-pic-server/internal/pkg/cache"
 	pkgmail "perfect-pic-server/internal/pkg/email"
 	jwtpkg "perfect-pic-server/internal/pkg/jwt"
+	"perfect-pic-server/internal/pkg/remotefetch"
+	"perfect-pic-server/internal/pkg/storage"
+	"perfect-pic-server/internal/pkg/urlsign"
 	"perfect-pic-server/internal/repository"
 	"perfect-pic-server/internal/testutils"
 
@@ -34,6 +37,7 @@ type Service struct {
 	captchaService *CaptchaService
 	initService    *InitService
 	passkeyService *PasskeyService
+	albumService   *AlbumService
 }
 
 func setupTestDB(t *testing.T) *gorm.DB {
@@ -54,11 +58,16 @@ func setupTestDB(t *testing.T) *gorm.DB {
 
 	authService := NewAuthService(dbConfig, tokenService)
 	userService := NewUserService(userStore, dbConfig, cacheStore, tokenService)
-	imageService := NewImageService(imageStore, dbConfig, staticConfig)
+	storages, err := storage.NewManager(config.NewStorageConfig(staticConfig))
+	if err != nil {
+		t.Fatalf("init storage: %v", err)
+	}
+	imageService := NewImageService(imageStore, dbConfig, staticConfig, storages, urlsign.NewSigner(config.NewURLSignConfig(staticConfig)), remotefetch.NewFetcher(&remotefetch.Config{AllowPrivateNetworks: true}))
 	emailService := NewEmailService(dbConfig, pkgmail.NewMailer(), staticConfig)
 	captchaService := NewCaptchaService(dbConfig)
 	initService := NewInitService(systemStore, dbConfig)
 	passkeyService := NewPasskeyService(passkeyStore, dbConfig, cacheStore)
+	albumService := NewAlbumService(repository.NewAlbumRepository(gdb), imageStore)
 
 	testService = &Service{
 		dbConfig:       dbConfig,
@@ -69,6 +78,7 @@ func setupTestDB(t *testing.T) *gorm.DB {
 		captchaService: captchaService,
 		initService:    initService,
 		passkeyService: passkeyService,
+		albumService:   albumService,
 	}
 
 	if err := testService.InitializeSettings(); err != nil {
@@ -0,0 +1,313 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	commonpkg "perfect-pic-server/internal/common"
+	"perfect-pic-server/internal/consts"
+	moduledto "perfect-pic-server/internal/dto"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tusIDBytes 上传标识的随机字节数，标识以十六进制字符串作为暂存文件名。
+const tusIDBytes = 16
+
+// MaxSize 返回单个可续传上传允许的最大字节数，与 max_upload_size 一致。
+func (s *TusService) MaxSize() int64 {
+	return int64(s.dbConfig.GetInt(consts.ConfigMaxUploadSize)) * 1024 * 1024
+}
+
+// CreateUpload 创建一个空的可续传上传：在暂存目录中创建文件，并将进度状态写入缓存。
+func (s *TusService) CreateUpload(uid uint, length int64, metadata string) (*moduledto.TusUpload, error) {
+	if length <= 0 {
+		return nil, commonpkg.NewValidationError("文件内容为空")
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		log.Printf("Create tus staging dir error: %v\n", err)
+		return nil, commonpkg.NewInternalError("系统错误: 创建上传暂存目录失败")
+	}
+
+	b := make([]byte, tusIDBytes)
+	if _, err := rand.Read(b); err != nil {
+		return nil, commonpkg.NewInternalError("系统错误: 生成上传标识失败")
+	}
+	id := hex.EncodeToString(b)
+
+	f, err := os.OpenFile(s.stagingPath(id), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Printf("Create tus staging file error: %v\n", err)
+		return nil, commonpkg.NewInternalError("系统错误: 创建上传暂存文件失败")
+	}
+	_ = f.Close()
+
+	upload := &moduledto.TusUpload{
+		ID:        id,
+		UserID:    uid,
+		Length:    length,
+		Metadata:  metadata,
+		ExpiresAt: time.Now().Add(s.expiration).Unix(),
+	}
+	if err := s.saveState(upload); err != nil {
+		_ = os.Remove(s.stagingPath(id))
+		return nil, err
+	}
+	return upload, nil
+}
+
+// GetUpload 读取上传进度；不存在、已过期或不属于该用户时返回未找到。
+func (s *TusService) GetUpload(uid uint, id string) (*moduledto.TusUpload, error) {
+	if !validTusID(id) {
+		return nil, commonpkg.NewNotFoundError("上传不存在或已过期")
+	}
+	raw, ok := s.cache.Get(s.stateKey(id))
+	if !ok {
+		return nil, commonpkg.NewNotFoundError("上传不存在或已过期")
+	}
+	var upload moduledto.TusUpload
+	if err := json.Unmarshal([]byte(raw), &upload); err != nil || upload.UserID != uid {
+		return nil, commonpkg.NewNotFoundError("上传不存在或已过期")
+	}
+	return &upload, nil
+}
+
+// WriteChunk 从 offset 处追加写入一段数据，最多写到声明的总长度。
+//
+// offset 必须等于当前进度；连接中断时已收到的部分同样会计入进度，客户端可通过 HEAD 查询后续传。
+func (s *TusService) WriteChunk(uid uint, id string, offset int64, body io.Reader) (*moduledto.TusUpload, error) {
+	upload, unlock, err := s.lockUpload(uid, id)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if offset != upload.Offset {
+		return upload, commonpkg.NewConflictError("上传偏移量与服务器记录不一致")
+	}
+
+	f, err := os.OpenFile(s.stagingPath(id), os.O_WRONLY, 0)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			s.cache.Delete(s.stateKey(id))
+			return nil, commonpkg.NewNotFoundError("上传不存在或已过期")
+		}
+		log.Printf("Open tus staging file error: %v\n", err)
+		return upload, commonpkg.NewInternalError("系统错误: 打开上传暂存文件失败")
+	}
+	defer func() { _ = f.Close() }()
+
+	// 丢弃此前中断的请求写入但未计入进度的数据
+	if err := f.Truncate(offset); err != nil {
+		log.Printf("Truncate tus staging file error: %v\n", err)
+		return upload, commonpkg.NewInternalError("系统错误: 写入上传数据失败")
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		log.Printf("Seek tus staging file error: %v\n", err)
+		return upload, commonpkg.NewInternalError("系统错误: 写入上传数据失败")
+	}
+	n, copyErr := io.Copy(f, io.LimitReader(body, upload.Length-offset))
+
+	upload.Offset += n
+	upload.ExpiresAt = time.Now().Add(s.expiration).Unix()
+	if err := s.saveState(upload); err != nil {
+		return upload, err
+	}
+	if copyErr != nil {
+		log.Printf("Write tus chunk error: %v\n", copyErr)
+		return upload, commonpkg.NewInternalError("写入上传数据失败，请从当前进度续传")
+	}
+	return upload, nil
+}
+
+// FinishUpload 读取已写满的上传内容交给 process 处理，处理成功后才删除其状态与暂存文件；
+// 处理失败时保留上传，客户端可以以当前偏移量发送空的 PATCH 重试。同一上传的完成过程串行执行，只会成功处理一次。
+func (s *TusService) FinishUpload(uid uint, id string, process func(content []byte) error) (*moduledto.TusUpload, error) {
+	upload, unlock, err := s.lockUpload(uid, id)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if upload.Offset < upload.Length {
+		return upload, commonpkg.NewConflictError("上传尚未完成")
+	}
+
+	content, err := os.ReadFile(s.stagingPath(id))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			s.cache.Delete(s.stateKey(id))
+			s.locks.Delete(id)
+			return nil, commonpkg.NewNotFoundError("上传不存在或已过期")
+		}
+		log.Printf("Read tus staging file error: %v\n", err)
+		return upload, commonpkg.NewInternalError("系统错误: 读取上传数据失败")
+	}
+	if int64(len(content)) != upload.Length {
+		return upload, commonpkg.NewInternalError("系统错误: 上传数据不完整，请重新上传")
+	}
+	if err := process(content); err != nil {
+		return upload, err
+	}
+
+	s.cache.Delete(s.stateKey(id))
+	s.removeStaging(id)
+	return upload, nil
+}
+
+// TerminateUpload 终止上传并删除已接收的数据。
+func (s *TusService) TerminateUpload(uid uint, id string) error {
+	_, unlock, err := s.lockUpload(uid, id)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	s.cache.Delete(s.stateKey(id))
+	s.removeStaging(id)
+	return nil
+}
+
+// CleanupExpiredUploads 删除过期的暂存文件：状态已从缓存中过期（或因服务重启丢失）且超过保留时长未再写入。
+func (s *TusService) CleanupExpiredUploads() {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("Read tus staging dir error: %v\n", err)
+		}
+		return
+	}
+
+	now := time.Now()
+	for _, entry := range entries {
+		id := entry.Name()
+		if entry.IsDir() || !validTusID(id) {
+			continue
+		}
+		if _, ok := s.cache.Get(s.stateKey(id)); ok {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || now.Sub(info.ModTime()) < s.expiration {
+			continue
+		}
+		s.removeStaging(id)
+	}
+}
+
+// StartCleanupLoop 启动后台任务，每隔 interval 清理过期的暂存文件；返回的 stop 函数可安全地重复调用。
+func (s *TusService) StartCleanupLoop(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				s.CleanupExpiredUploads()
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			wg.Wait()
+		})
+	}
+}
+
+func (s *TusService) saveState(upload *moduledto.TusUpload) error {
+	raw, err := json.Marshal(upload)
+	if err != nil {
+		return commonpkg.NewInternalError("系统错误: 保存上传进度失败")
+	}
+	s.cache.Set(s.stateKey(upload.ID), string(raw), time.Until(time.Unix(upload.ExpiresAt, 0)))
+	return nil
+}
+
+// lockUpload 确认上传存在且属于该用户后再加锁，并在锁内重新读取进度，返回解锁函数。
+// 先校验再加锁，避免以随机标识的请求不断创建锁；锁内发现上传已被并发完成或终止时同时移除该锁。
+func (s *TusService) lockUpload(uid uint, id string) (*moduledto.TusUpload, func(), error) {
+	if _, err := s.GetUpload(uid, id); err != nil {
+		return nil, nil, err
+	}
+	unlock := s.lock(id)
+	upload, err := s.GetUpload(uid, id)
+	if err != nil {
+		unlock()
+		s.locks.Delete(id)
+		return nil, nil, err
+	}
+	return upload, unlock, nil
+}
+
+// lock 串行化同一上传的写入、完成与终止，返回解锁函数。
+func (s *TusService) lock(id string) func() {
+	value, _ := s.locks.LoadOrStore(id, &sync.Mutex{})
+	mu := value.(*sync.Mutex)
+	mu.Lock()
+	return mu.Unlock
+}
+
+func (s *TusService) removeStaging(id string) {
+	if err := os.Remove(s.stagingPath(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("Remove tus staging file error: %v\n", err)
+	}
+	s.locks.Delete(id)
+}
+
+func (s *TusService) stateKey(id string) string {
+	return s.cache.RedisKey("tus", "upload", id)
+}
+
+func (s *TusService) stagingPath(id string) string {
+	return filepath.Join(s.dir, id)
+}
+
+// validTusID 校验上传标识格式，避免以任意字符串拼接暂存文件路径。
+func validTusID(id string) bool {
+	if len(id) != tusIDBytes*2 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil && strings.ToLower(id) == id
+}
+
+// ParseTusMetadata 解析 Upload-Metadata 请求头：逗号分隔的“键 base64值”对，值可以省略。
+func ParseTusMetadata(raw string) (map[string]string, error) {
+	meta := make(map[string]string)
+	if strings.TrimSpace(raw) == "" {
+		return meta, nil
+	}
+	for _, pair := range strings.Split(raw, ",") {
+		fields := strings.Fields(pair)
+		if len(fields) == 0 || len(fields) > 2 {
+			return nil, commonpkg.NewValidationError("Upload-Metadata 格式错误")
+		}
+		value := ""
+		if len(fields) == 2 {
+			decoded, err := base64.StdEncoding.DecodeString(fields[1])
+			if err != nil {
+				return nil, commonpkg.NewValidationError("Upload-Metadata 格式错误")
+			}
+			value = string(decoded)
+		}
+		meta[fields[0]] = value
+	}
+	return meta, nil
+}
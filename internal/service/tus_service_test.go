@@ -0,0 +1,239 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"perfect-pic-server/internal/common"
+	"perfect-pic-server/internal/config"
+	"perfect-pic-server/internal/pkg/cache"
+)
+
+func newTestTusService(t *testing.T) *TusService {
+	t.Helper()
+	staticConfig := config.NewStaticConfig()
+	staticConfig.Upload.TusPath = filepath.Join(t.TempDir(), "tus")
+	return NewTusService(testService.dbConfig, staticConfig, cache.NewStore(nil, config.NewCacheConfig(staticConfig)))
+}
+
+// brokenReader 返回部分数据后报错，模拟上传过程中连接中断。
+type brokenReader struct {
+	data []byte
+}
+
+func (r *brokenReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, errors.New("connection reset")
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+// 测试内容：验证可续传上传按偏移量分段写入、偏移量不一致返回冲突、连接中断时保留已接收部分，
+// 写满后处理失败时保留上传以便重试，处理成功后才清理状态。
+func TestTusService_WriteAndFinish(t *testing.T) {
+	setupTestDB(t)
+	s := newTestTusService(t)
+
+	upload, err := s.CreateUpload(1, 10, "filename YS5wbmc=")
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if !validTusID(upload.ID) || upload.Offset != 0 {
+		t.Fatalf("创建结果不符: %+v", upload)
+	}
+
+	if _, err := s.GetUpload(2, upload.ID); err == nil {
+		t.Fatalf("期望其他用户无法访问该上传")
+	}
+	_, err = s.WriteChunk(1, upload.ID, 3, strings.NewReader("abc"))
+	assertServiceErrorCode(t, err, common.ErrorCodeConflict)
+
+	upload, err = s.WriteChunk(1, upload.ID, 0, &brokenReader{data: []byte("0123")})
+	assertServiceErrorCode(t, err, common.ErrorCodeInternal)
+	if upload.Offset != 4 {
+		t.Fatalf("期望中断后保留已接收的 4 字节，实际为 %d", upload.Offset)
+	}
+
+	_, err = s.FinishUpload(1, upload.ID, func([]byte) error { return nil })
+	assertServiceErrorCode(t, err, common.ErrorCodeConflict)
+
+	// 超出声明长度的部分被忽略
+	upload, err = s.WriteChunk(1, upload.ID, 4, strings.NewReader("456789EXTRA"))
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if upload.Offset != 10 {
+		t.Fatalf("期望偏移量为 10，实际为 %d", upload.Offset)
+	}
+
+	_, err = s.FinishUpload(1, upload.ID, func([]byte) error { return common.NewForbiddenError("存储空间不足") })
+	assertServiceErrorCode(t, err, common.ErrorCodeForbidden)
+	if current, err := s.GetUpload(1, upload.ID); err != nil || current.Offset != 10 {
+		t.Fatalf("期望处理失败后保留上传，实际为 %+v %v", current, err)
+	}
+
+	var content []byte
+	_, err = s.FinishUpload(1, upload.ID, func(data []byte) error {
+		content = data
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("finish: %v", err)
+	}
+	if string(content) != "0123456789" {
+		t.Fatalf("期望内容为 0123456789，实际为 %q", content)
+	}
+	if _, err := os.Stat(s.stagingPath(upload.ID)); !os.IsNotExist(err) {
+		t.Fatalf("期望完成后删除暂存文件")
+	}
+	_, err = s.FinishUpload(1, upload.ID, func([]byte) error { return nil })
+	assertServiceErrorCode(t, err, common.ErrorCodeNotFound)
+}
+
+// 测试内容：验证终止上传删除状态与暂存文件，非法标识返回未找到。
+func TestTusService_Terminate(t *testing.T) {
+	setupTestDB(t)
+	s := newTestTusService(t)
+
+	upload, err := s.CreateUpload(1, 10, "")
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	assertServiceErrorCode(t, s.TerminateUpload(2, upload.ID), common.ErrorCodeNotFound)
+	if err := s.TerminateUpload(1, upload.ID); err != nil {
+		t.Fatalf("terminate: %v", err)
+	}
+	if _, err := os.Stat(s.stagingPath(upload.ID)); !os.IsNotExist(err) {
+		t.Fatalf("期望终止后删除暂存文件")
+	}
+	_, err = s.WriteChunk(1, upload.ID, 0, strings.NewReader("x"))
+	assertServiceErrorCode(t, err, common.ErrorCodeNotFound)
+
+	_, err = s.GetUpload(1, "../../etc/passwd")
+	assertServiceErrorCode(t, err, common.ErrorCodeNotFound)
+	_, err = s.CreateUpload(1, 0, "")
+	assertServiceErrorCode(t, err, common.ErrorCodeValidation)
+}
+
+// 测试内容：验证清理任务只删除状态已过期且超过保留时长未写入的暂存文件。
+func TestTusService_CleanupExpiredUploads(t *testing.T) {
+	setupTestDB(t)
+	s := newTestTusService(t)
+
+	active, err := s.CreateUpload(1, 10, "")
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	orphan := strings.Repeat("ab", tusIDBytes)
+	recent := strings.Repeat("cd", tusIDBytes)
+	for _, name := range []string{orphan, recent, "keep.txt"} {
+		if err := os.WriteFile(s.stagingPath(name), []byte("x"), 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	old := time.Now().Add(-2 * s.expiration)
+	for _, name := range []string{active.ID, orphan, "keep.txt"} {
+		_ = os.Chtimes(s.stagingPath(name), old, old)
+	}
+
+	s.CleanupExpiredUploads()
+	for name, want := range map[string]bool{active.ID: true, orphan: false, recent: true, "keep.txt": true} {
+		_, err := os.Stat(s.stagingPath(name))
+		if exists := err == nil; exists != want {
+			t.Fatalf("%s: 期望存在=%v，实际为 %v", name, want, exists)
+		}
+	}
+}
+
+// 测试内容：验证对不存在或不属于自己的上传发起写入、完成与终止时不会创建锁，上传结束后锁被移除。
+func TestTusService_LocksOnlyForOwnedUploads(t *testing.T) {
+	setupTestDB(t)
+	s := newTestTusService(t)
+
+	upload, err := s.CreateUpload(1, 3, "")
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	unknown := strings.Repeat("ef", tusIDBytes)
+	for _, id := range []string{unknown, upload.ID} {
+		_, err := s.WriteChunk(2, id, 0, strings.NewReader("abc"))
+		assertServiceErrorCode(t, err, common.ErrorCodeNotFound)
+		_, err = s.FinishUpload(2, id, func([]byte) error { return nil })
+		assertServiceErrorCode(t, err, common.ErrorCodeNotFound)
+		assertServiceErrorCode(t, s.TerminateUpload(2, id), common.ErrorCodeNotFound)
+	}
+	countLocks := func() int {
+		n := 0
+		s.locks.Range(func(any, any) bool { n++; return true })
+		return n
+	}
+	if n := countLocks(); n != 0 {
+		t.Fatalf("期望未通过校验的请求不创建锁，实际有 %d 个", n)
+	}
+
+	if _, err := s.WriteChunk(1, upload.ID, 0, strings.NewReader("abc")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := s.FinishUpload(1, upload.ID, func([]byte) error { return nil }); err != nil {
+		t.Fatalf("finish: %v", err)
+	}
+	if n := countLocks(); n != 0 {
+		t.Fatalf("期望上传完成后移除锁，实际有 %d 个", n)
+	}
+}
+
+// 测试内容：验证清理任务在停止后不再运行，且 stop 可重复调用。
+func TestTusService_StartCleanupLoop(t *testing.T) {
+	setupTestDB(t)
+	s := newTestTusService(t)
+
+	orphan := strings.Repeat("ab", tusIDBytes)
+	_ = os.MkdirAll(filepath.Dir(s.stagingPath(orphan)), 0755)
+	if err := os.WriteFile(s.stagingPath(orphan), []byte("x"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	old := time.Now().Add(-2 * s.expiration)
+	_ = os.Chtimes(s.stagingPath(orphan), old, old)
+
+	stop := s.StartCleanupLoop(10 * time.Millisecond)
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := os.Stat(s.stagingPath(orphan)); errors.Is(err, os.ErrNotExist) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("期望清理任务删除过期暂存文件")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	stop()
+	stop()
+}
+
+// 测试内容：验证 Upload-Metadata 解析支持省略值，并拒绝非法 base64 与多余字段。
+func TestParseTusMetadata(t *testing.T) {
+	meta, err := ParseTusMetadata("filename YS5wbmc=, title 5qCH6aKY,is_private")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if meta["filename"] != "a.png" || meta["title"] != "标题" {
+		t.Fatalf("解析结果不符: %v", meta)
+	}
+	if _, ok := meta["is_private"]; !ok {
+		t.Fatalf("期望保留省略值的键")
+	}
+	if meta, err := ParseTusMetadata(""); err != nil || len(meta) != 0 {
+		t.Fatalf("期望空元数据解析为空，实际为 %v %v", meta, err)
+	}
+	for _, raw := range []string{"filename !!!", "a b c", "a YQ==,,b"} {
+		if _, err := ParseTusMetadata(raw); err == nil {
+			t.Fatalf("%q: 期望解析失败", raw)
+		}
+	}
+}
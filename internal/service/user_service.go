@@ -300,6 +300,8 @@ func (s *UserService) GetUserProfile(userID uint) (*moduledto.UserProfileRespons
 		Admin:        user.Admin,
 		StorageQuota: user.StorageQuota,
 		StorageUsed:  user.StorageUsed,
+		ImageTTL:     user.ImageTTL,
+		Usage:        s.GetUsageInfo(user),
 	}, nil
 }
 
@@ -366,6 +368,12 @@ func (s *UserService) UpdateUser(userID uint, req moduledto.UpdateUserRequest, a
 	if err := s.prepareStorageQuotaUpdate(req.StorageQuota, updates); err != nil {
 		return err
 	}
+	if err := s.prepareImageTTLUpdate(req.ImageTTL, updates); err != nil {
+		return err
+	}
+	if err := s.prepareUsageLimitUpdates(req, updates); err != nil {
+		return err
+	}
 	if err := s.prepareStatusUpdate(req.Status, updates); err != nil {
 		return err
 	}
@@ -381,6 +389,7 @@ func (s *UserService) UpdateUser(userID uint, req moduledto.UpdateUserRequest, a
 	}
 	s.ClearUserAuthCache(userID)
 	s.ClearUserStatusCache(userID)
+	s.ClearUsageLimitCache(userID)
 	return nil
 }
 
@@ -1,6 +1,8 @@
 package service
 
 import (
+	"fmt"
+
 	commonpkg "perfect-pic-server/internal/common"
 	moduledto "perfect-pic-server/internal/dto"
 	"perfect-pic-server/internal/model"
@@ -98,6 +100,33 @@ func (s *UserService) applyCreateUserOptionals(user *model.User, input moduledto
 		}
 	}
 
+	if input.ImageTTL != nil {
+		ttl, err := normalizeUserImageTTL(*input.ImageTTL)
+		if err != nil {
+			return err
+		}
+		user.ImageTTL = ttl
+	}
+
+	for _, limit := range []struct {
+		input *int64
+		field **int64
+		label string
+	}{
+		{input.DailyUploadCount, &user.DailyUploadCount, "每日上传数量"},
+		{input.DailyUploadBytes, &user.DailyUploadBytes, "每日上传流量"},
+		{input.MonthlyEgressBytes, &user.MonthlyEgressBytes, "每月访问流量"},
+	} {
+		if limit.input == nil {
+			continue
+		}
+		value, err := normalizeUserUsageLimit(*limit.input, limit.label)
+		if err != nil {
+			return err
+		}
+		*limit.field = value
+	}
+
 	if input.Status != nil {
 		if *input.Status == 1 || *input.Status == 2 {
 			user.Status = *input.Status
@@ -200,6 +229,72 @@ func (s *UserService) prepareStorageQuotaUpdate(storageQuota *int64, updates map
 	return commonpkg.NewValidationError("存储配额不能为负数（-1除外）")
 }
 
+// prepareImageTTLUpdate 校验并准备图片默认有效期更新字段。
+func (s *UserService) prepareImageTTLUpdate(imageTTL *int64, updates map[string]interface{}) error {
+	if imageTTL == nil {
+		return nil
+	}
+	ttl, err := normalizeUserImageTTL(*imageTTL)
+	if err != nil {
+		return err
+	}
+	if ttl == nil {
+		updates["image_ttl"] = nil
+	} else {
+		updates["image_ttl"] = *ttl
+	}
+	return nil
+}
+
+// prepareUsageLimitUpdates 校验并准备用量限额更新字段。
+func (s *UserService) prepareUsageLimitUpdates(req moduledto.UpdateUserRequest, updates map[string]interface{}) error {
+	for _, limit := range []struct {
+		input  *int64
+		column string
+		label  string
+	}{
+		{req.DailyUploadCount, "daily_upload_count", "每日上传数量"},
+		{req.DailyUploadBytes, "daily_upload_bytes", "每日上传流量"},
+		{req.MonthlyEgressBytes, "monthly_egress_bytes", "每月访问流量"},
+	} {
+		if limit.input == nil {
+			continue
+		}
+		value, err := normalizeUserUsageLimit(*limit.input, limit.label)
+		if err != nil {
+			return err
+		}
+		if value == nil {
+			updates[limit.column] = nil
+		} else {
+			updates[limit.column] = *value
+		}
+	}
+	return nil
+}
+
+// normalizeUserUsageLimit 校验用户的用量限额：-1 表示恢复系统默认（返回 nil），0 表示不限制。
+func normalizeUserUsageLimit(limit int64, label string) (*int64, error) {
+	if limit == -1 {
+		return nil, nil
+	}
+	if limit < 0 {
+		return nil, commonpkg.NewValidationError(label + "限额不能为负数（-1除外）")
+	}
+	return &limit, nil
+}
+
+// normalizeUserImageTTL 校验用户的图片默认有效期：-1 表示恢复系统默认（返回 nil），0 表示永久保存。
+func normalizeUserImageTTL(ttl int64) (*int64, error) {
+	if ttl == -1 {
+		return nil, nil
+	}
+	if ttl < 0 || ttl > maxImageTTLSeconds {
+		return nil, commonpkg.NewValidationError(fmt.Sprintf("图片有效期必须为 0-%d 之间的整数（单位：秒，-1 表示使用系统默认值）", maxImageTTLSeconds))
+	}
+	return &ttl, nil
+}
+
 // prepareStatusUpdate 校验并准备用户状态更新字段。
 func (s *UserService) prepareStatusUpdate(status *int, updates map[string]interface{}) error {
 	if status == nil {
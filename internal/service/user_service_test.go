@@ -432,6 +432,11 @@ func TestUpdateUserForAdmin_MoreBranches(t *testing.T) {
 	err = testService.UpdateUser(u.ID, moduledto.UpdateUserRequest{StorageQuota: &badQuota}, true)
 	assertServiceErrorCode(t, err, platformservice.ErrorCodeValidation)
 
+	// 无效图片有效期
+	badTTL := int64(-2)
+	err = testService.UpdateUser(u.ID, moduledto.UpdateUserRequest{ImageTTL: &badTTL}, true)
+	assertServiceErrorCode(t, err, platformservice.ErrorCodeValidation)
+
 	// 邮箱已被占用
 	u2 := model.User{Username: "bobby", Password: string(hashed), Status: 1, Email: "taken@example.com"}
 	_ = testGormDB.Create(&u2).Error
@@ -439,10 +444,11 @@ func TestUpdateUserForAdmin_MoreBranches(t *testing.T) {
 	err = testService.UpdateUser(u.ID, moduledto.UpdateUserRequest{Email: &newEmail}, true)
 	assertServiceErrorCode(t, err, platformservice.ErrorCodeConflict)
 
-	// 更新密码并清空配额（-1）
+	// 更新密码与图片有效期并清空配额（-1）
 	newPass := "abc123456"
 	clearQuota := int64(-1)
-	err = testService.UpdateUser(u.ID, moduledto.UpdateUserRequest{Password: &newPass, StorageQuota: &clearQuota}, true)
+	ttl := int64(3600)
+	err = testService.UpdateUser(u.ID, moduledto.UpdateUserRequest{Password: &newPass, StorageQuota: &clearQuota, ImageTTL: &ttl}, true)
 	if err != nil {
 		t.Fatalf("期望 success，实际为 err=%v", err)
 	}
@@ -452,6 +458,9 @@ func TestUpdateUserForAdmin_MoreBranches(t *testing.T) {
 	if got.StorageQuota != nil {
 		t.Fatalf("期望 quota cleared，实际为 %+v", got.StorageQuota)
 	}
+	if got.ImageTTL == nil || *got.ImageTTL != ttl {
+		t.Fatalf("期望图片有效期为 %d，实际为 %v", ttl, got.ImageTTL)
+	}
 	if bcrypt.CompareHashAndPassword([]byte(got.Password), []byte(newPass)) != nil {
 		t.Fatalf("期望 password updated")
 	}
@@ -533,6 +542,15 @@ func TestGetUserProfile(t *testing.T) {
 	if profile.Username != "alice" || profile.Admin != true || profile.Avatar != "a.png" || profile.StorageUsed != 10 {
 		t.Fatalf("非预期 profile: %+v", profile)
 	}
+	if profile.Usage.DailyUploadCountLimit != 0 || profile.Usage.MonthlyEgressBytesUsed != 0 {
+		t.Fatalf("期望默认不限制且无用量，实际为 %+v", profile.Usage)
+	}
+
+	testService.userService.RecordEgress(u.ID, 42)
+	profile, _ = testService.GetUserProfile(u.ID)
+	if profile.Usage.MonthlyEgressBytesUsed != 42 {
+		t.Fatalf("期望 profile 中本月已用流量为 42，实际为 %+v", profile.Usage)
+	}
 }
 
 // 测试内容：验证通过旧密码更新密码的校验与成功路径。
@@ -0,0 +1,185 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	commonpkg "perfect-pic-server/internal/common"
+	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/model"
+	"strconv"
+	"time"
+)
+
+const (
+	// dailyUsageTTL/monthlyUsageTTL 用量计数的保留时长，略长于统计周期以便跨零点读取。
+	dailyUsageTTL   = 48 * time.Hour
+	monthlyUsageTTL = 62 * 24 * time.Hour
+	// egressLimitCacheTTL 图片访问时缓存所有者流量限额的时长，避免每次访问都查询用户；修改系统默认值后最长在该时长后生效。
+	egressLimitCacheTTL = 1 * time.Minute
+)
+
+// UsageLimits 用户当前生效的用量限额，0 表示不限制。
+type UsageLimits struct {
+	DailyUploadCount   int64
+	DailyUploadBytes   int64
+	MonthlyEgressBytes int64
+}
+
+// ResolveUsageLimits 合并用户的单独设置与系统默认值，得到当前生效的用量限额。
+func (s *UserService) ResolveUsageLimits(user *model.User) UsageLimits {
+	limits := UsageLimits{
+		DailyUploadCount:   s.dbConfig.GetDefaultDailyUploadCount(),
+		DailyUploadBytes:   s.dbConfig.GetDefaultDailyUploadBytes(),
+		MonthlyEgressBytes: s.dbConfig.GetDefaultMonthlyEgressBytes(),
+	}
+	if user.DailyUploadCount != nil {
+		limits.DailyUploadCount = *user.DailyUploadCount
+	}
+	if user.DailyUploadBytes != nil {
+		limits.DailyUploadBytes = *user.DailyUploadBytes
+	}
+	if user.MonthlyEgressBytes != nil {
+		limits.MonthlyEgressBytes = *user.MonthlyEgressBytes
+	}
+	return limits
+}
+
+// CheckDailyUpload 在接收上传内容前检查今日剩余的上传数量与流量是否足以容纳 size 字节，不占用额度。
+func (s *UserService) CheckDailyUpload(user *model.User, size int64) error {
+	if s.cache == nil {
+		return nil
+	}
+	limits := s.ResolveUsageLimits(user)
+	countKey, bytesKey := s.dailyUploadKeys(user.ID, time.Now())
+	if limits.DailyUploadCount > 0 && s.cache.GetInt64(countKey) >= limits.DailyUploadCount {
+		return dailyUploadCountExceededError(limits.DailyUploadCount)
+	}
+	if limits.DailyUploadBytes > 0 {
+		if used := s.cache.GetInt64(bytesKey); used+size > limits.DailyUploadBytes {
+			return dailyUploadBytesExceededError(used, limits.DailyUploadBytes)
+		}
+	}
+	return nil
+}
+
+// ReserveDailyUpload 原子地占用一次今日上传数量与 size 字节的上传流量，超出限额时不占用并返回错误。
+// 返回的 release 用于在上传未保存新图片（失败或命中重复内容）时归还本次占用。
+func (s *UserService) ReserveDailyUpload(user *model.User, size int64) (release func(), err error) {
+	if s.cache == nil {
+		return func() {}, nil
+	}
+	limits := s.ResolveUsageLimits(user)
+	countKey, bytesKey := s.dailyUploadKeys(user.ID, time.Now())
+
+	if count := s.cache.IncrBy(countKey, 1, dailyUsageTTL); limits.DailyUploadCount > 0 && count > limits.DailyUploadCount {
+		s.cache.IncrBy(countKey, -1, dailyUsageTTL)
+		return nil, dailyUploadCountExceededError(limits.DailyUploadCount)
+	}
+	if used := s.cache.IncrBy(bytesKey, size, dailyUsageTTL); limits.DailyUploadBytes > 0 && used > limits.DailyUploadBytes {
+		s.cache.IncrBy(bytesKey, -size, dailyUsageTTL)
+		s.cache.IncrBy(countKey, -1, dailyUsageTTL)
+		return nil, dailyUploadBytesExceededError(used-size, limits.DailyUploadBytes)
+	}
+	return func() {
+		s.cache.IncrBy(bytesKey, -size, dailyUsageTTL)
+		s.cache.IncrBy(countKey, -1, dailyUsageTTL)
+	}, nil
+}
+
+// CheckMonthlyEgress 检查图片所有者本月的访问流量是否已用尽；超出后其图片在本月内不再对外提供。
+func (s *UserService) CheckMonthlyEgress(userID uint) error {
+	if s.cache == nil {
+		return nil
+	}
+	used := s.cache.GetInt64(s.monthlyEgressKey(userID, time.Now()))
+	if used <= 0 {
+		return nil
+	}
+	limit, err := s.monthlyEgressLimit(userID)
+	if err != nil {
+		// 无法确定限额时不影响图片访问
+		log.Printf("Get egress limit error: %v\n", err)
+		return nil
+	}
+	if limit > 0 && used >= limit {
+		return commonpkg.NewForbiddenError("该图片所属用户本月的访问流量已用尽")
+	}
+	return nil
+}
+
+// RecordEgress 累加图片所有者本月的访问流量。
+func (s *UserService) RecordEgress(userID uint, size int64) {
+	if s.cache == nil || size <= 0 {
+		return
+	}
+	s.cache.IncrBy(s.monthlyEgressKey(userID, time.Now()), size, monthlyUsageTTL)
+}
+
+// GetUsageInfo 返回用户当前生效的用量限额与本周期已用量。
+func (s *UserService) GetUsageInfo(user *model.User) moduledto.UserUsageInfo {
+	limits := s.ResolveUsageLimits(user)
+	info := moduledto.UserUsageInfo{
+		DailyUploadCountLimit:   limits.DailyUploadCount,
+		DailyUploadBytesLimit:   limits.DailyUploadBytes,
+		MonthlyEgressBytesLimit: limits.MonthlyEgressBytes,
+	}
+	if s.cache == nil {
+		return info
+	}
+	now := time.Now()
+	countKey, bytesKey := s.dailyUploadKeys(user.ID, now)
+	info.DailyUploadCountUsed = max(s.cache.GetInt64(countKey), 0)
+	info.DailyUploadBytesUsed = max(s.cache.GetInt64(bytesKey), 0)
+	info.MonthlyEgressBytesUsed = max(s.cache.GetInt64(s.monthlyEgressKey(user.ID, now)), 0)
+	return info
+}
+
+// ClearUsageLimitCache 清除用户流量限额缓存，修改用户的单独限额后调用。
+func (s *UserService) ClearUsageLimitCache(userID uint) {
+	if s.cache == nil {
+		return
+	}
+	s.cache.Delete(s.egressLimitCacheKey(userID))
+}
+
+// monthlyEgressLimit 获取用户生效的每月流量限额，优先从缓存读取，未命中时回源数据库并回写缓存。
+func (s *UserService) monthlyEgressLimit(userID uint) (int64, error) {
+	limitKey := s.egressLimitCacheKey(userID)
+	if cached, ok := s.cache.Get(limitKey); ok {
+		if limit, err := strconv.ParseInt(cached, 10, 64); err == nil {
+			return limit, nil
+		}
+		s.cache.Delete(limitKey)
+	}
+
+	user, err := s.userStore.FindByID(userID)
+	if err != nil {
+		return 0, err
+	}
+	limit := s.ResolveUsageLimits(user).MonthlyEgressBytes
+	s.cache.Set(limitKey, strconv.FormatInt(limit, 10), egressLimitCacheTTL)
+	return limit, nil
+}
+
+func (s *UserService) dailyUploadKeys(userID uint, now time.Time) (countKey string, bytesKey string) {
+	uid := strconv.FormatUint(uint64(userID), 10)
+	day := now.Format("20060102")
+	return s.cache.RedisKey("usage", "upload_count", day, uid), s.cache.RedisKey("usage", "upload_bytes", day, uid)
+}
+
+func (s *UserService) monthlyEgressKey(userID uint, now time.Time) string {
+	return s.cache.RedisKey("usage", "egress", now.Format("200601"), strconv.FormatUint(uint64(userID), 10))
+}
+
+func (s *UserService) egressLimitCacheKey(userID uint) string {
+	return s.cache.RedisKey("usage", "egress_limit", strconv.FormatUint(uint64(userID), 10))
+}
+
+func dailyUploadCountExceededError(limit int64) error {
+	return commonpkg.NewForbiddenError(fmt.Sprintf("今日上传数量已达上限（%d 张），请明天再试", limit))
+}
+
+func dailyUploadBytesExceededError(used int64, limit int64) error {
+	remaining := max(limit-used, 0)
+	return commonpkg.NewForbiddenError(fmt.Sprintf("今日上传流量已达上限，上传失败。今日已用: %d B, 剩余: %d B", used, remaining))
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"testing"
+
+	platformservice "perfect-pic-server/internal/common"
+	"perfect-pic-server/internal/consts"
+	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/model"
+)
+
+// 测试内容：验证每日上传数量与流量按系统默认值与用户单独设置生效，超出时不占用额度，归还后可再次上传。
+func TestReserveDailyUpload_LimitsAndRelease(t *testing.T) {
+	setupTestDB(t)
+	setTestSetting(t, consts.ConfigDefaultDailyUploadCount, "2")
+	setTestSetting(t, consts.ConfigDefaultDailyUploadBytes, "1000")
+
+	bytesLimit := int64(100)
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com", DailyUploadBytes: &bytesLimit}
+	_ = testGormDB.Create(&u).Error
+	s := testService.userService
+
+	release, err := s.ReserveDailyUpload(&u, 40)
+	if err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	_, err = s.ReserveDailyUpload(&u, 70)
+	assertServiceErrorCode(t, err, platformservice.ErrorCodeForbidden)
+	assertServiceErrorCode(t, s.CheckDailyUpload(&u, 70), platformservice.ErrorCodeForbidden)
+	if usage := s.GetUsageInfo(&u); usage.DailyUploadCountUsed != 1 || usage.DailyUploadBytesUsed != 40 || usage.DailyUploadBytesLimit != 100 || usage.DailyUploadCountLimit != 2 {
+		t.Fatalf("期望超出限额的上传不占用额度，实际为 %+v", usage)
+	}
+
+	release()
+	if _, err := s.ReserveDailyUpload(&u, 70); err != nil {
+		t.Fatalf("期望归还后可再次上传: %v", err)
+	}
+	if _, err := s.ReserveDailyUpload(&u, 10); err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	_, err = s.ReserveDailyUpload(&u, 1)
+	assertServiceErrorCode(t, err, platformservice.ErrorCodeForbidden)
+	assertServiceErrorCode(t, s.CheckDailyUpload(&u, 0), platformservice.ErrorCodeForbidden)
+
+	// 单独设置为 0 表示不限制
+	unlimited := int64(0)
+	u.DailyUploadCount = &unlimited
+	if _, err := s.ReserveDailyUpload(&u, 20); err != nil {
+		t.Fatalf("期望不限制上传数量: %v", err)
+	}
+}
+
+// 测试内容：验证每月访问流量在用尽后拒绝访问，且流量限额缓存在修改用户设置后失效。
+func TestMonthlyEgress_LimitAndCache(t *testing.T) {
+	setupTestDB(t)
+	setTestSetting(t, consts.ConfigDefaultMonthlyEgressBytes, "100")
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+	s := testService.userService
+
+	if err := s.CheckMonthlyEgress(u.ID); err != nil {
+		t.Fatalf("期望未使用流量时允许访问: %v", err)
+	}
+	s.RecordEgress(u.ID, 60)
+	if err := s.CheckMonthlyEgress(u.ID); err != nil {
+		t.Fatalf("期望流量未用尽时允许访问: %v", err)
+	}
+	s.RecordEgress(u.ID, 60)
+	assertServiceErrorCode(t, s.CheckMonthlyEgress(u.ID), platformservice.ErrorCodeForbidden)
+
+	limit := int64(1000)
+	if err := s.UpdateUser(u.ID, moduledto.UpdateUserRequest{MonthlyEgressBytes: &limit}, true); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if err := s.CheckMonthlyEgress(u.ID); err != nil {
+		t.Fatalf("期望提高限额后立即允许访问: %v", err)
+	}
+	if usage := s.GetUsageInfo(&u); usage.MonthlyEgressBytesUsed != 120 {
+		t.Fatalf("期望本月已用流量为 120，实际为 %d", usage.MonthlyEgressBytesUsed)
+	}
+}
+
+// 测试内容：验证管理员设置用量限额时 -1 恢复系统默认值、0 表示不限制，其它负数被拒绝。
+func TestUpdateUser_UsageLimits(t *testing.T) {
+	setupTestDB(t)
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+	s := testService.userService
+
+	count, zero, reset, invalid := int64(5), int64(0), int64(-1), int64(-2)
+	if err := s.UpdateUser(u.ID, moduledto.UpdateUserRequest{DailyUploadCount: &count, DailyUploadBytes: &zero}, true); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	var got model.User
+	_ = testGormDB.First(&got, u.ID).Error
+	if got.DailyUploadCount == nil || *got.DailyUploadCount != 5 || got.DailyUploadBytes == nil || *got.DailyUploadBytes != 0 || got.MonthlyEgressBytes != nil {
+		t.Fatalf("限额更新结果不符: %+v", got)
+	}
+
+	if err := s.UpdateUser(u.ID, moduledto.UpdateUserRequest{DailyUploadCount: &reset}, true); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	_ = testGormDB.First(&got, u.ID).Error
+	if got.DailyUploadCount != nil {
+		t.Fatalf("期望 -1 恢复系统默认值，实际为 %v", *got.DailyUploadCount)
+	}
+
+	err := s.UpdateUser(u.ID, moduledto.UpdateUserRequest{MonthlyEgressBytes: &invalid}, true)
+	assertServiceErrorCode(t, err, platformservice.ErrorCodeValidation)
+
+	created, err := s.CreateUser(moduledto.CreateUserRequest{Username: "bobby", Password: "abc123456", MonthlyEgressBytes: &count}, true)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if created.MonthlyEgressBytes == nil || *created.MonthlyEgressBytes != 5 {
+		t.Fatalf("期望创建用户时保存流量限额，实际为 %v", created.MonthlyEgressBytes)
+	}
+}
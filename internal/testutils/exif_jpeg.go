@@ -0,0 +1,113 @@
+package testutils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"sort"
+)
+
+// EXIFFixture 描述测试 JPEG 中写入的 EXIF 字段，空值字段不写入。
+type EXIFFixture struct {
+	Make  string
+	Model string
+	// DateTimeOriginal 形如 "2024:05:06 07:08:09"
+	DateTimeOriginal string
+	// GPS 为 {纬度, 经度}，均为非负整数度数（北纬、东经）
+	GPS *[2]uint32
+}
+
+// JPEGWithEXIF 生成一张 8x8 的 JPEG，并在 SOI 之后插入包含指定字段的 EXIF APP1 段。
+func JPEGWithEXIF(fx EXIFFixture) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x * 32), G: uint8(y * 32), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	_ = jpeg.Encode(&buf, img, nil)
+	plain := buf.Bytes()
+
+	var ifd0 []exifEntry
+	if fx.Make != "" {
+		ifd0 = append(ifd0, exifASCII(0x010F, fx.Make))
+	}
+	if fx.Model != "" {
+		ifd0 = append(ifd0, exifASCII(0x0110, fx.Model))
+	}
+	if fx.DateTimeOriginal != "" {
+		ifd0 = append(ifd0, exifEntry{tag: 0x8769, child: []exifEntry{exifASCII(0x9003, fx.DateTimeOriginal)}})
+	}
+	if fx.GPS != nil {
+		ifd0 = append(ifd0, exifEntry{tag: 0x8825, child: []exifEntry{
+			exifASCII(0x0001, "N"),
+			exifRational(0x0002, fx.GPS[0], 1, 0, 1, 0, 1),
+			exifASCII(0x0003, "E"),
+			exifRational(0x0004, fx.GPS[1], 1, 0, 1, 0, 1),
+		}})
+	}
+	tiff := appendEXIFIFD([]byte{'I', 'I', 42, 0, 8, 0, 0, 0}, ifd0)
+
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	length := len(payload) + 2
+	out := append([]byte(nil), plain[:2]...)
+	out = append(out, 0xFF, 0xE1, byte(length>>8), byte(length))
+	out = append(out, payload...)
+	return append(out, plain[2:]...)
+}
+
+type exifEntry struct {
+	tag   uint16
+	typ   uint16
+	count uint32
+	value []byte
+	child []exifEntry
+}
+
+func exifASCII(tag uint16, s string) exifEntry {
+	return exifEntry{tag: tag, typ: 2, count: uint32(len(s) + 1), value: append([]byte(s), 0)}
+}
+
+func exifRational(tag uint16, values ...uint32) exifEntry {
+	var value []byte
+	for _, v := range values {
+		value = binary.LittleEndian.AppendUint32(value, v)
+	}
+	return exifEntry{tag: tag, typ: 5, count: uint32(len(values) / 2), value: value}
+}
+
+// appendEXIFIFD 以小端序写入一个 IFD，外部取值与子目录紧随其后。
+func appendEXIFIFD(buf []byte, entries []exifEntry) []byte {
+	entries = append([]exifEntry(nil), entries...)
+	sort.Slice(entries, func(i, j int) bool { return entries[i].tag < entries[j].tag })
+
+	start := len(buf)
+	buf = append(buf, make([]byte, 2+len(entries)*12+4)...)
+	binary.LittleEndian.PutUint16(buf[start:], uint16(len(entries)))
+	for i, e := range entries {
+		p := start + 2 + i*12
+		binary.LittleEndian.PutUint16(buf[p:], e.tag)
+		if e.child != nil {
+			binary.LittleEndian.PutUint16(buf[p+2:], 4)
+			binary.LittleEndian.PutUint32(buf[p+4:], 1)
+			binary.LittleEndian.PutUint32(buf[p+8:], uint32(len(buf)))
+			buf = appendEXIFIFD(buf, e.child)
+			continue
+		}
+		binary.LittleEndian.PutUint16(buf[p+2:], e.typ)
+		binary.LittleEndian.PutUint32(buf[p+4:], e.count)
+		if len(e.value) <= 4 {
+			copy(buf[p+8:], e.value)
+			continue
+		}
+		binary.LittleEndian.PutUint32(buf[p+8:], uint32(len(buf)))
+		buf = append(buf, e.value...)
+		if len(buf)%2 == 1 {
+			buf = append(buf, 0)
+		}
+	}
+	return buf
+}
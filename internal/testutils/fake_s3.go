@@ -0,0 +1,139 @@
+package testutils
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// FakeS3Object 是 FakeS3 中保存的单个对象。
+type FakeS3Object struct {
+	Data        []byte
+	ContentType string
+	ModTime     time.Time
+}
+
+// FakeS3 是进程内的 S3 兼容服务，仅支持 path-style 的 Put/Get/Head/Delete 与 ListObjectsV2。
+type FakeS3 struct {
+	Server *httptest.Server
+	Bucket string
+
+	mu      sync.Mutex
+	objects map[string]FakeS3Object
+}
+
+// NewFakeS3 启动一个进程内 S3 服务，测试结束时自动关闭。
+func NewFakeS3(t *testing.T, bucket string) *FakeS3 {
+	t.Helper()
+	f := &FakeS3{Bucket: bucket, objects: make(map[string]FakeS3Object)}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.serveHTTP))
+	t.Cleanup(f.Server.Close)
+	return f
+}
+
+// URL 返回服务地址，可直接作为 S3 endpoint。
+func (f *FakeS3) URL() string {
+	return f.Server.URL
+}
+
+// Object 读取指定 key 的对象。
+func (f *FakeS3) Object(key string) (FakeS3Object, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	obj, ok := f.objects[key]
+	return obj, ok
+}
+
+// Keys 返回全部对象 key（已排序）。
+func (f *FakeS3) Keys() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	keys := make([]string, 0, len(f.objects))
+	for k := range f.objects {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (f *FakeS3) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	p := strings.TrimPrefix(r.URL.Path, "/")
+	bucket, key, _ := strings.Cut(p, "/")
+	if bucket != f.Bucket {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if key == "" && r.Method == http.MethodGet {
+		f.list(w, r.URL.Query().Get("prefix"))
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.objects[key] = FakeS3Object{Data: data, ContentType: r.Header.Get("Content-Type"), ModTime: time.Now()}
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet, http.MethodHead:
+		obj, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", obj.ContentType)
+		w.Header().Set("Last-Modified", obj.ModTime.UTC().Format(http.TimeFormat))
+		w.Header().Set("Content-Length", strconv.Itoa(len(obj.Data)))
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(obj.Data)
+		}
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *FakeS3) list(w http.ResponseWriter, prefix string) {
+	type content struct {
+		Key          string `xml:"Key"`
+		Size         int    `xml:"Size"`
+		LastModified string `xml:"LastModified"`
+	}
+	type result struct {
+		XMLName     xml.Name  `xml:"ListBucketResult"`
+		Name        string    `xml:"Name"`
+		Prefix      string    `xml:"Prefix"`
+		IsTruncated bool      `xml:"IsTruncated"`
+		Contents    []content `xml:"Contents"`
+	}
+
+	res := result{Name: f.Bucket, Prefix: prefix}
+	for _, key := range f.Keys() {
+		if strings.HasPrefix(key, prefix) {
+			obj, _ := f.Object(key)
+			res.Contents = append(res.Contents, content{Key: key, Size: len(obj.Data), LastModified: obj.ModTime.UTC().Format(time.RFC3339Nano)})
+		}
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	_ = xml.NewEncoder(w).Encode(res)
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"perfect-pic-server/internal/model"
+	"perfect-pic-server/internal/pkg/database"
 
 	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
@@ -39,9 +40,14 @@ func SetupDB(t *testing.T) *gorm.DB {
 		_ = sqlDB.Close()
 	})
 
-	if err := gdb.AutoMigrate(&model.User{}, &model.Setting{}, &model.Image{}, &model.PasskeyCredential{}); err != nil {
+	if err := gdb.AutoMigrate(&model.User{}, &model.Setting{}, &model.Image{}, &model.PasskeyCredential{}, &model.ImageBlob{}, &model.ImageMetadata{}, &model.Album{}, &model.AlbumImage{},
+		&model.Tag{}, &model.ImageTag{}, &model.ImageSearchDocument{}, &model.PersonalAccessToken{},
+		&model.ImageViewStat{}, &model.ImageReferrerStat{}); err != nil {
 		t.Fatalf("automigrate: %v", err)
 	}
+	if err := database.MigrateSearchIndex(gdb); err != nil {
+		t.Fatalf("migrate search index: %v", err)
+	}
 
 	return gdb
 }
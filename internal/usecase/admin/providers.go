@@ -18,8 +18,9 @@ type SettingsUseCase struct {
 }
 
 type StatUseCase struct {
-	imageStore repository.ImageStore
-	userStore  repository.UserStore
+	imageStore   repository.ImageStore
+	userStore    repository.UserStore
+	imageService *service.ImageService
 }
 
 func NewUserManageUseCase(
@@ -38,8 +39,8 @@ func NewSettingsUseCase(emailService *service.EmailService) *SettingsUseCase {
 	return &SettingsUseCase{emailService: emailService}
 }
 
-func NewStatUseCase(imageStore repository.ImageStore, userStore repository.UserStore) *StatUseCase {
-	return &StatUseCase{imageStore: imageStore, userStore: userStore}
+func NewStatUseCase(imageStore repository.ImageStore, userStore repository.UserStore, imageService *service.ImageService) *StatUseCase {
+	return &StatUseCase{imageStore: imageStore, userStore: userStore, imageService: imageService}
 }
 
 var AdminUseCaseSet = wire.NewSet(
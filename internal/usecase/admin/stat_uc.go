@@ -24,9 +24,10 @@ func (c *StatUseCase) AdminGetServerStats() (*moduledto.ServerStatsResponse, err
 	}
 
 	return &moduledto.ServerStatsResponse{
-		ImageCount:   imageCount,
-		StorageUsage: totalSize,
-		UserCount:    userCount,
+		ImageCount:          imageCount,
+		StorageUsage:        totalSize,
+		UserCount:           userCount,
+		ShortLinkCollisions: c.imageService.ShortCodeCollisions(),
 		SystemInfo: moduledto.SystemInfoResponse{
 			OS:           runtime.GOOS,
 			Arch:         runtime.GOARCH,
@@ -7,6 +7,8 @@ import (
 	"perfect-pic-server/internal/pkg/cache"
 	pkgmail "perfect-pic-server/internal/pkg/email"
 	jwtpkg "perfect-pic-server/internal/pkg/jwt"
+	"perfect-pic-server/internal/pkg/storage"
+	"perfect-pic-server/internal/pkg/urlsign"
 	"perfect-pic-server/internal/repository"
 	"perfect-pic-server/internal/service"
 	"perfect-pic-server/internal/testutils"
@@ -49,7 +51,11 @@ func setupAdminFixture(t *testing.T) *adminFixture {
 	dbConfig.ClearCache()
 
 	userService := service.NewUserService(userStore, dbConfig, cacheStore, tokenService)
-	imageService := service.NewImageService(imageStore, dbConfig, staticConfig)
+	storages, err := storage.NewManager(config.NewStorageConfig(staticConfig))
+	if err != nil {
+		t.Fatalf("init storage: %v", err)
+	}
+	imageService := service.NewImageService(imageStore, dbConfig, staticConfig, storages, urlsign.NewSigner(config.NewURLSignConfig(staticConfig)), nil)
 	passkeyService := service.NewPasskeyService(passkeyStore, dbConfig, cacheStore)
 	emailService := service.NewEmailService(dbConfig, pkgmail.NewMailer(), staticConfig)
 	_ = service.NewInitService(systemStore, dbConfig)
@@ -59,7 +65,7 @@ func setupAdminFixture(t *testing.T) *adminFixture {
 		dbConfig:     dbConfig,
 		userManageUC: NewUserManageUseCase(userService, imageService, passkeyService),
 		settingsUC:   NewSettingsUseCase(emailService),
-		statUC:       NewStatUseCase(imageStore, userStore),
+		statUC:       NewStatUseCase(imageStore, userStore, imageService),
 		userService:  userService,
 		imageService: imageService,
 	}
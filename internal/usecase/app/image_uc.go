@@ -1,19 +1,88 @@
 package app
 
 import (
+	"context"
+	"fmt"
 	"log"
 	"mime/multipart"
 	commonpkg "perfect-pic-server/internal/common"
+	moduledto "perfect-pic-server/internal/dto"
 	"perfect-pic-server/internal/model"
 )
 
 // ProcessImageUpload 处理图片上传核心业务
-func (c *ImageUseCase) ProcessImageUpload(file *multipart.FileHeader, uid uint) (*model.Image, string, error) {
-	usedSize, quota, err := c.resolveUserStorageQuota(uid)
+func (c *ImageUseCase) ProcessImageUpload(file *multipart.FileHeader, uid uint, info moduledto.ImageUploadInfo) (*moduledto.ImageUploadResult, error) {
+	user, quota, err := c.resolveUploader(uid)
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
-	return c.imageService.ProcessImageUpload(file, uid, usedSize, quota)
+	info.Username = user.Username
+	info.DefaultTTL = user.ImageTTL
+	return c.uploadWithDailyLimit(user, file.Size, func() (*moduledto.ImageUploadResult, error) {
+		return c.imageService.ProcessImageUpload(file, uid, user.StorageUsed, quota, info)
+	})
+}
+
+// ProcessImageBatchUpload 批量上传图片：先按文件总大小检查剩余空间，再逐个处理，单个文件失败不影响其它文件保存。
+func (c *ImageUseCase) ProcessImageBatchUpload(files []*multipart.FileHeader, uid uint, info moduledto.ImageUploadInfo) ([]moduledto.ImageBatchUploadItem, error) {
+	if len(files) == 0 {
+		return nil, commonpkg.NewValidationError("请选择文件")
+	}
+	if maxFiles := c.imageService.MaxBatchUploadFiles(); len(files) > maxFiles {
+		return nil, commonpkg.NewValidationError(fmt.Sprintf("单次最多上传 %d 个文件", maxFiles))
+	}
+
+	user, quota, err := c.resolveUploader(uid)
+	if err != nil {
+		return nil, err
+	}
+	var totalSize int64
+	for _, file := range files {
+		totalSize += file.Size
+	}
+	if user.StorageUsed+totalSize > quota {
+		remaining := max(quota-user.StorageUsed, 0)
+		return nil, commonpkg.NewForbiddenError(fmt.Sprintf("存储空间不足，上传失败。本次共 %d B，剩余: %d B", totalSize, remaining))
+	}
+
+	info.Username = user.Username
+	info.DefaultTTL = user.ImageTTL
+	usedSize := user.StorageUsed
+	items := make([]moduledto.ImageBatchUploadItem, 0, len(files))
+	for _, file := range files {
+		result, err := c.uploadWithDailyLimit(user, file.Size, func() (*moduledto.ImageUploadResult, error) {
+			return c.imageService.ProcessImageUpload(file, uid, usedSize, quota, info)
+		})
+		if err == nil && !result.Duplicate {
+			usedSize += result.Image.StorageSize()
+		}
+		items = append(items, moduledto.ImageBatchUploadItem{Filename: file.Filename, Result: result, Err: err})
+	}
+	return items, nil
+}
+
+// ProcessImageURLUpload 按远程地址或 data URI 上传图片，读取内容后与文件上传共用同一处理流程。
+func (c *ImageUseCase) ProcessImageURLUpload(ctx context.Context, uid uint, source string, filename string, info moduledto.ImageUploadInfo) (*moduledto.ImageUploadResult, error) {
+	user, quota, err := c.resolveUploader(uid)
+	if err != nil {
+		return nil, err
+	}
+	if user.StorageUsed >= quota {
+		// 空间已满时无需再下载远程内容
+		return nil, commonpkg.NewForbiddenError(fmt.Sprintf("存储空间不足，上传失败。当前已用: %d B, 剩余: 0 B", user.StorageUsed))
+	}
+	if err := c.userService.CheckDailyUpload(user, 0); err != nil {
+		return nil, err
+	}
+	filename, content, err := c.imageService.LoadUploadSource(ctx, source, filename)
+	if err != nil {
+		return nil, err
+	}
+	info.Username = user.Username
+	info.DefaultTTL = user.ImageTTL
+	return c.uploadWithDailyLimit(user, int64(len(content)), func() (*moduledto.ImageUploadResult, error) {
+		return c.imageService.ProcessImageData(filename, content, uid, user.StorageUsed, quota, info)
+	})
 }
 
 // UpdateUserAvatar 更新用户头像
@@ -50,18 +119,31 @@ func (c *ImageUseCase) RemoveUserAvatar(user *model.User) error {
 	return nil
 }
 
-func (c *ImageUseCase) resolveUserStorageQuota(uid uint) (int64, int64, error) {
+func (c *ImageUseCase) resolveUploader(uid uint) (*model.User, int64, error) {
 	user, err := c.userStore.FindByID(uid)
 	if err != nil {
 		log.Printf("Get user error: %v\n", err)
-		return 0, 0, commonpkg.NewInternalError("查询用户信息失败")
+		return nil, 0, commonpkg.NewInternalError("查询用户信息失败")
 	}
 
 	quota := c.dbConfig.GetDefaultStorageQuota()
 	if user.StorageQuota != nil {
 		quota = *user.StorageQuota
 	}
-	return user.StorageUsed, quota, nil
+	return user, quota, nil
+}
+
+// uploadWithDailyLimit 占用今日的上传数量与上传流量后执行 upload；上传失败或命中重复内容（未保存新图片）时归还占用。
+func (c *ImageUseCase) uploadWithDailyLimit(user *model.User, size int64, upload func() (*moduledto.ImageUploadResult, error)) (*moduledto.ImageUploadResult, error) {
+	release, err := c.userService.ReserveDailyUpload(user, size)
+	if err != nil {
+		return nil, err
+	}
+	result, err := upload()
+	if err != nil || result.Duplicate {
+		release()
+	}
+	return result, err
 }
 
 func (c *ImageUseCase) removeAvatarFile(userID uint, filename string, action string) {
@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"perfect-pic-server/internal/common"
+	moduledto "perfect-pic-server/internal/dto"
 	"perfect-pic-server/internal/model"
 	"perfect-pic-server/internal/testutils"
 	"strconv"
@@ -28,7 +29,7 @@ func TestImageUseCase_ProcessImageUpload_QuotaExceeded(t *testing.T) {
 	}
 
 	fh := mustFileHeader(t, "a.png", testutils.MinimalPNG())
-	_, _, err := f.imageUC.ProcessImageUpload(fh, u.ID)
+	_, err := f.imageUC.ProcessImageUpload(fh, u.ID, moduledto.ImageUploadInfo{})
 	if serviceErr := assertServiceErrorCode(t, err, common.ErrorCodeForbidden); !strings.Contains(serviceErr.Message, "存储空间不足") {
 		t.Fatalf("expected quota exceeded message, got: %q", serviceErr.Message)
 	}
@@ -49,10 +50,11 @@ func TestImageUseCase_ProcessImageUpload_Success(t *testing.T) {
 	}
 
 	fh := mustFileHeader(t, "a.png", testutils.MinimalPNG())
-	img, url, err := f.imageUC.ProcessImageUpload(fh, u.ID)
+	result, err := f.imageUC.ProcessImageUpload(fh, u.ID, moduledto.ImageUploadInfo{})
 	if err != nil {
 		t.Fatalf("ProcessImageUpload failed: %v", err)
 	}
+	img, url := result.Image, result.URL
 	if img == nil || img.ID == 0 {
 		t.Fatalf("expected created image record")
 	}
@@ -77,6 +79,48 @@ func TestImageUseCase_ProcessImageUpload_Success(t *testing.T) {
 	}
 }
 
+// 测试内容：验证上传占用今日上传额度，命中重复内容时归还占用，达到上限后拒绝上传。
+func TestImageUseCase_ProcessImageUpload_DailyLimit(t *testing.T) {
+	f := setupAppFixture(t)
+	chdirForTest(t, t.TempDir())
+
+	limit := int64(2)
+	u := model.User{
+		Username:         "alice",
+		Password:         "x",
+		Status:           1,
+		Email:            "alice@example.com",
+		DailyUploadCount: &limit,
+	}
+	if err := testGormDB.Create(&u).Error; err != nil {
+		t.Fatalf("create user failed: %v", err)
+	}
+
+	content := testutils.MinimalPNG()
+	for i := 0; i < 3; i++ {
+		result, err := f.imageUC.ProcessImageUpload(mustFileHeader(t, "a.png", content), u.ID, moduledto.ImageUploadInfo{})
+		if err != nil {
+			t.Fatalf("upload #%d failed: %v", i, err)
+		}
+		if result.Duplicate != (i > 0) {
+			t.Fatalf("upload #%d: unexpected duplicate flag %v", i, result.Duplicate)
+		}
+	}
+	usage := f.userService.GetUsageInfo(&u)
+	if usage.DailyUploadCountUsed != 1 || usage.DailyUploadBytesUsed != int64(len(content)) {
+		t.Fatalf("expected duplicates not counted, got %+v", usage)
+	}
+
+	limit = 1
+	if err := testGormDB.Model(&u).Update("daily_upload_count", limit).Error; err != nil {
+		t.Fatalf("update limit failed: %v", err)
+	}
+	_, err := f.imageUC.ProcessImageUpload(mustFileHeader(t, "a.png", content), u.ID, moduledto.ImageUploadInfo{})
+	if serviceErr := assertServiceErrorCode(t, err, common.ErrorCodeForbidden); !strings.Contains(serviceErr.Message, "今日上传数量") {
+		t.Fatalf("expected daily limit message, got: %q", serviceErr.Message)
+	}
+}
+
 func TestImageUseCase_UpdateAndRemoveUserAvatar_Success(t *testing.T) {
 	f := setupAppFixture(t)
 	chdirForTest(t, t.TempDir())
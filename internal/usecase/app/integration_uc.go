@@ -0,0 +1,86 @@
+package app
+
+import (
+	"encoding/json"
+	"perfect-pic-server/internal/consts"
+	moduledto "perfect-pic-server/internal/dto"
+	"strings"
+)
+
+// integrationUploadPath 第三方客户端使用的兼容上传接口路径。
+const integrationUploadPath = "/api/user/integrations/upload"
+
+// picGoUploaderName PicGo 中 web-uploader 插件的图床标识。
+const picGoUploaderName = "web-uploader"
+
+// ShareXConfig 生成 ShareX 自定义上传器配置，并为其轮换签发一个仅具备 upload 权限的访问令牌。
+func (c *IntegrationUseCase) ShareXConfig(uid uint) (*moduledto.ShareXUploaderConfig, error) {
+	token, err := c.issueUploadToken(uid, consts.AccessTokenSourceShareX, "ShareX")
+	if err != nil {
+		return nil, err
+	}
+	return &moduledto.ShareXUploaderConfig{
+		Version:         "15.0.0",
+		Name:            c.siteName(),
+		DestinationType: "ImageUploader",
+		RequestMethod:   "POST",
+		RequestURL:      c.baseURL() + integrationUploadPath,
+		Headers:         map[string]string{"Authorization": "Bearer " + token},
+		Body:            "MultipartFormData",
+		FileFormName:    "file",
+		URL:             "{json:url}",
+		ThumbnailURL:    "{json:thumbnail_url}",
+		DeletionURL:     "{json:delete_url}",
+		ErrorMessage:    "{json:error}",
+	}, nil
+}
+
+// PicGoConfig 生成 PicGo / PicGo-Core 配置（Typora 通过 PicGo 上传时共用），并为其轮换签发一个仅具备 upload 权限的访问令牌。
+func (c *IntegrationUseCase) PicGoConfig(uid uint) (*moduledto.PicGoConfig, error) {
+	token, err := c.issueUploadToken(uid, consts.AccessTokenSourcePicGo, "PicGo")
+	if err != nil {
+		return nil, err
+	}
+	header, _ := json.Marshal(map[string]string{"Authorization": "Bearer " + token})
+	return &moduledto.PicGoConfig{
+		PicBed: moduledto.PicGoPicBedConfig{
+			Uploader: picGoUploaderName,
+			Current:  picGoUploaderName,
+			WebUploader: moduledto.PicGoWebUploaderConfig{
+				URL:          c.baseURL() + integrationUploadPath,
+				ParamName:    "file",
+				JSONPath:     "url",
+				CustomHeader: string(header),
+			},
+		},
+		PicGoPlugins: map[string]bool{"picgo-plugin-web-uploader": true},
+	}, nil
+}
+
+// issueUploadToken 为客户端配置签发不过期的上传令牌，每个用户每个客户端只保留一个：按令牌来源吊销该客户端此前由配置签发的令牌，
+// 重复下载配置不会累积令牌，旧配置随之失效，用户手动创建的令牌不受影响。令牌明文只出现在本次生成的配置中，可在令牌列表中吊销。
+func (c *IntegrationUseCase) issueUploadToken(uid uint, source string, client string) (string, error) {
+	created, err := c.tokenService.RotateSourceAccessToken(uid, source, moduledto.CreateAccessTokenRequest{
+		Name:   client + "（客户端配置）",
+		Scopes: []string{consts.AccessTokenScopeUpload},
+	})
+	if err != nil {
+		return "", err
+	}
+	return created.Token, nil
+}
+
+func (c *IntegrationUseCase) baseURL() string {
+	baseURL := strings.TrimSuffix(c.dbConfig.GetString(consts.ConfigBaseURL), "/")
+	if baseURL == "" {
+		baseURL = "http://localhost"
+	}
+	return baseURL
+}
+
+func (c *IntegrationUseCase) siteName() string {
+	if name := strings.TrimSpace(c.dbConfig.GetString(consts.ConfigSiteName)); name != "" {
+		return name
+	}
+	return "Perfect Pic"
+}
@@ -26,12 +26,18 @@ type UserUseCase struct {
 }
 type ImageUseCase struct {
 	imageService *service.ImageService
+	tusService   *service.TusService
 	userService  *service.UserService
 	userStore    repository.UserStore
 	dbConfig     *config.DBConfig
 	staticConfig *config.Config
 }
 
+type IntegrationUseCase struct {
+	tokenService *service.AccessTokenService
+	dbConfig     *config.DBConfig
+}
+
 type PasskeyUseCase struct {
 	passkeyService *service.PasskeyService
 	passkeyStore   repository.PasskeyStore
@@ -75,6 +81,7 @@ func NewUserUseCase(
 
 func NewImageUseCase(
 	imageService *service.ImageService,
+	tusService *service.TusService,
 	userService *service.UserService,
 	userStore repository.UserStore,
 	staticConfig *config.Config,
@@ -82,6 +89,7 @@ func NewImageUseCase(
 ) *ImageUseCase {
 	return &ImageUseCase{
 		imageService: imageService,
+		tusService:   tusService,
 		userService:  userService,
 		userStore:    userStore,
 		staticConfig: staticConfig,
@@ -89,6 +97,10 @@ func NewImageUseCase(
 	}
 }
 
+func NewIntegrationUseCase(tokenService *service.AccessTokenService, dbConfig *config.DBConfig) *IntegrationUseCase {
+	return &IntegrationUseCase{tokenService: tokenService, dbConfig: dbConfig}
+}
+
 func NewPasskeyUseCase(
 	passkeyService *service.PasskeyService,
 	passkeyStore repository.PasskeyStore,
@@ -107,5 +119,6 @@ var UseCaseSet = wire.NewSet(
 	NewAuthUseCase,
 	NewUserUseCase,
 	NewImageUseCase,
+	NewIntegrationUseCase,
 	NewPasskeyUseCase,
 )
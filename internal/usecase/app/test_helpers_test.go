@@ -13,6 +13,9 @@ import (
 	"perfect-pic-server/internal/pkg/cache"
 	pkgmail "perfect-pic-server/internal/pkg/email"
 	jwtpkg "perfect-pic-server/internal/pkg/jwt"
+	"perfect-pic-server/internal/pkg/remotefetch"
+	"perfect-pic-server/internal/pkg/storage"
+	"perfect-pic-server/internal/pkg/urlsign"
 	"perfect-pic-server/internal/repository"
 	"perfect-pic-server/internal/service"
 	"perfect-pic-server/internal/testutils"
@@ -72,7 +75,11 @@ func setupAppFixture(t *testing.T) *appFixture {
 
 	authService := service.NewAuthService(dbConfig, tokenService)
 	userService := service.NewUserService(userStore, dbConfig, cacheStore, tokenService)
-	imageService := service.NewImageService(imageStore, dbConfig, staticConfig)
+	storages, err := storage.NewManager(config.NewStorageConfig(staticConfig))
+	if err != nil {
+		t.Fatalf("init storage: %v", err)
+	}
+	imageService := service.NewImageService(imageStore, dbConfig, staticConfig, storages, urlsign.NewSigner(config.NewURLSignConfig(staticConfig)), remotefetch.NewFetcher(&remotefetch.Config{AllowPrivateNetworks: true}))
 	emailService := service.NewEmailService(dbConfig, pkgmail.NewMailer(), staticConfig)
 	captchaService := service.NewCaptchaService(dbConfig)
 	initService := service.NewInitService(systemStore, dbConfig)
@@ -80,7 +87,7 @@ func setupAppFixture(t *testing.T) *appFixture {
 
 	authUC := NewAuthUseCase(authService, userStore, userService, emailService, initService, dbConfig)
 	userUC := NewUserUseCase(authService, userService, userStore, emailService, dbConfig)
-	imageUC := NewImageUseCase(imageService, userService, userStore, staticConfig, dbConfig)
+	imageUC := NewImageUseCase(imageService, service.NewTusService(dbConfig, staticConfig, cacheStore), userService, userStore, staticConfig, dbConfig)
 	passkeyUC := NewPasskeyUseCase(passkeyService, passkeyStore, authService, userStore)
 
 	return &appFixture{
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	commonpkg "perfect-pic-server/internal/common"
+	moduledto "perfect-pic-server/internal/dto"
+	"perfect-pic-server/internal/model"
+	"perfect-pic-server/internal/service"
+	"strconv"
+)
+
+// TusMaxSize 返回可续传上传允许的最大字节数。
+func (c *ImageUseCase) TusMaxSize() int64 {
+	return c.tusService.MaxSize()
+}
+
+// CreateTusUpload 创建可续传上传，创建时即校验文件名、大小、元数据中的标题等信息、剩余空间与今日上传额度，避免上传完成后才被拒绝。
+func (c *ImageUseCase) CreateTusUpload(uid uint, length int64, metadata string) (*moduledto.TusUpload, error) {
+	meta, err := service.ParseTusMetadata(metadata)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.imageService.ValidateImageName(tusFilename(meta), length); err != nil {
+		return nil, err
+	}
+
+	user, quota, err := c.resolveUploader(uid)
+	if err != nil {
+		return nil, err
+	}
+	info, err := tusUploadInfo(meta, user)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.imageService.ValidateUploadInfo(info); err != nil {
+		return nil, err
+	}
+	if user.StorageUsed+length > quota {
+		remaining := max(quota-user.StorageUsed, 0)
+		return nil, commonpkg.NewForbiddenError(fmt.Sprintf("存储空间不足，上传失败。当前已用: %d B, 剩余: %d B", user.StorageUsed, remaining))
+	}
+	if err := c.userService.CheckDailyUpload(user, length); err != nil {
+		return nil, err
+	}
+	return c.tusService.CreateUpload(uid, length, metadata)
+}
+
+// GetTusUpload 查询可续传上传的当前进度。
+func (c *ImageUseCase) GetTusUpload(uid uint, id string) (*moduledto.TusUpload, error) {
+	return c.tusService.GetUpload(uid, id)
+}
+
+// WriteTusUpload 写入一段上传数据；数据写满后与普通上传共用校验、配额与保存流程，返回的 result 非空表示上传已完成。
+// 保存失败时保留已接收的数据，客户端可在解决问题（如释放空间）后以当前偏移量重试。
+func (c *ImageUseCase) WriteTusUpload(uid uint, id string, offset int64, body io.Reader) (*moduledto.TusUpload, *moduledto.ImageUploadResult, error) {
+	upload, err := c.tusService.WriteChunk(uid, id, offset, body)
+	if err != nil || upload.Offset < upload.Length {
+		return upload, nil, err
+	}
+
+	meta, err := service.ParseTusMetadata(upload.Metadata)
+	if err != nil {
+		return upload, nil, err
+	}
+	var result *moduledto.ImageUploadResult
+	upload, err = c.tusService.FinishUpload(uid, id, func(content []byte) error {
+		user, quota, err := c.resolveUploader(uid)
+		if err != nil {
+			return err
+		}
+		info, err := tusUploadInfo(meta, user)
+		if err != nil {
+			return err
+		}
+		result, err = c.uploadWithDailyLimit(user, int64(len(content)), func() (*moduledto.ImageUploadResult, error) {
+			return c.imageService.ProcessImageData(tusFilename(meta), content, uid, user.StorageUsed, quota, info)
+		})
+		return err
+	})
+	return upload, result, err
+}
+
+// TerminateTusUpload 终止可续传上传并删除已接收的数据。
+func (c *ImageUseCase) TerminateTusUpload(uid uint, id string) error {
+	return c.tusService.TerminateUpload(uid, id)
+}
+
+// tusFilename 读取元数据中的文件名，兼容常见客户端使用的 filename 与 name 两种键。
+func tusFilename(meta map[string]string) string {
+	if name := meta["filename"]; name != "" {
+		return name
+	}
+	return meta["name"]
+}
+
+// tusUploadInfo 将 Upload-Metadata 中的标题、描述、可见性、有效期与水印选项转换为上传信息。
+func tusUploadInfo(meta map[string]string, user *model.User) (moduledto.ImageUploadInfo, error) {
+	expiresIn, expiresAt, err := service.ParseUploadExpiry(meta["expires_in"], meta["expires_at"])
+	if err != nil {
+		return moduledto.ImageUploadInfo{}, err
+	}
+	info := moduledto.ImageUploadInfo{
+		Title:       meta["title"],
+		Description: meta["description"],
+		Visibility:  meta["visibility"],
+		Username:    user.Username,
+		ExpiresIn:   expiresIn,
+		ExpiresAt:   expiresAt,
+		DefaultTTL:  user.ImageTTL,
+	}
+	if watermark, err := strconv.ParseBool(meta["watermark"]); err == nil && !watermark {
+		info.DisableWatermark = true
+	}
+	return info, nil
+}
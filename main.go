@@ -13,9 +13,13 @@ import (
 	"os/signal"
 	"path/filepath"
 	"perfect-pic-server/internal/config"
+	"perfect-pic-server/internal/consts"
 	"perfect-pic-server/internal/di"
+	"perfect-pic-server/internal/handler"
 	"perfect-pic-server/internal/middleware"
 	"perfect-pic-server/internal/pkg/pathpkg"
+	"perfect-pic-server/internal/pkg/storage"
+	"perfect-pic-server/internal/service"
 	"strings"
 	"syscall"
 	"time"
@@ -23,6 +27,15 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// imageJanitorInterval 后台清理过期图片与回收站的间隔。
+const imageJanitorInterval = time.Minute
+
+// imageViewFlushInterval 将内存中的图片访问计数写入数据库的间隔。
+const imageViewFlushInterval = time.Minute
+
+// tusCleanupInterval 清理过期断点续传暂存文件的间隔。
+const tusCleanupInterval = 10 * time.Minute
+
 var (
 	AppName     = "Perfect Pic Server"
 	AppVersion  = "dev"
@@ -35,6 +48,8 @@ func main() {
 
 	exportRoutes := flag.Bool("export", false, "导出路由到 routes.json 并退出")
 	configDir := flag.String("config-dir", "config", "配置文件目录")
+	reconcileStorage := flag.Bool("reconcile-storage", false, "对账存储文件与数据库记录，输出 JSON 报告后退出")
+	dryRun := flag.Bool("dry-run", true, "与 -reconcile-storage 一起使用，设为 false 时执行修复")
 	flag.Parse()
 
 	config.InitConfig(*configDir)
@@ -62,7 +77,13 @@ func main() {
 		log.Fatal("❌ 初始化默认系统设置失败: ", err)
 	}
 
-	uploadPath, avatarPath := ensureDirectories(app.StaticConfig)
+	// 存储对账模式
+	if *reconcileStorage {
+		if err := runStorageReconcile(app.ImageService, *dryRun); err != nil {
+			log.Fatal("❌ 存储对账失败: ", err)
+		}
+		return
+	}
 
 	gin.SetMode(app.StaticConfig.Server.Mode)
 
@@ -73,7 +94,12 @@ func main() {
 	uploadURLPrefix := app.StaticConfig.Upload.URLPrefix
 	avatarURLPrefix := app.StaticConfig.Upload.AvatarURLPrefix
 
-	setupStaticFiles(r, uploadPath, avatarPath, app.StaticCacheMiddleware, uploadURLPrefix, avatarURLPrefix)
+	if app.Storage.IsLocal() {
+		_, avatarPath := ensureDirectories(app.StaticConfig)
+		setupStaticFiles(r, avatarPath, app.StaticCacheMiddleware, app.Hotlink, app.ShortLink, app.ImageAccess, app.ImageVariant, app.ImageHandler, uploadURLPrefix, avatarURLPrefix)
+	} else {
+		setupStorageProxy(r, app.Storage, app.StaticCacheMiddleware, app.Hotlink, app.ShortLink, app.ImageAccess, app.ImageVariant, app.ImageHandler, uploadURLPrefix, avatarURLPrefix)
+	}
 
 	distFS := GetFrontendAssets()
 	indexData := setupFrontend(r, distFS)
@@ -89,7 +115,10 @@ func main() {
 	// 打印启动欢迎语
 	printWelcomeMessage(app.StaticConfig.Server.Port)
 
-	startServer(r, app.StaticConfig.Server.Port)
+	stopJanitor := app.ImageService.StartImageJanitor(imageJanitorInterval)
+	stopViewFlusher := app.ImageService.StartImageViewFlusher(imageViewFlushInterval)
+	stopTusCleanup := app.TusService.StartCleanupLoop(tusCleanupInterval)
+	startServer(r, app.StaticConfig.Server.Port, stopJanitor, stopViewFlusher, stopTusCleanup)
 }
 
 func ensureDirectories(staticConfig *config.Config) (string, string) {
@@ -107,15 +136,66 @@ func ensureDirectories(staticConfig *config.Config) (string, string) {
 	return uploadPath, avatarPath
 }
 
-func setupStaticFiles(r *gin.Engine, uploadPath, avatarPath string, staticMiddleware *middleware.StaticCacheMiddleware, uploadURLPrefix string, avatarURLPrefix string) {
-	// 使用带缓存控制的静态文件服务
-	r.Group(uploadURLPrefix, staticMiddleware.StaticCacheMiddleware()).
-		StaticFS("", gin.Dir(uploadPath, false))
+func setupStaticFiles(r *gin.Engine, avatarPath string, staticMiddleware *middleware.StaticCacheMiddleware, hotlinkMiddleware *middleware.HotlinkMiddleware, shortLinkMiddleware *middleware.ShortLinkMiddleware, accessMiddleware *middleware.ImageAccessMiddleware, variantMiddleware *middleware.ImageVariantMiddleware, imageHandler *handler.ImageHandler, uploadURLPrefix string, avatarURLPrefix string) {
+	setupImageRoutes(r, staticMiddleware, hotlinkMiddleware, shortLinkMiddleware, accessMiddleware, variantMiddleware, imageHandler, uploadURLPrefix)
 
-	r.Group(avatarURLPrefix, staticMiddleware.StaticCacheMiddleware()).
+	r.Group(avatarURLPrefix, staticMiddleware.StaticCacheMiddleware(), hotlinkMiddleware.HotlinkProtection(false)).
 		StaticFS("", gin.Dir(avatarPath, false))
 }
 
+// setupImageRoutes 挂载图片访问前缀：先进行防盗链检查并校验私有图片的访问权限，携带缩略图参数时由变体中间件处理，
+// 否则由 ImageHandler 按 Accept 协商返回原图或备用格式。短链接 /s/:code 解析出图片 key 后走同一套处理。
+func setupImageRoutes(r *gin.Engine, staticMiddleware *middleware.StaticCacheMiddleware, hotlinkMiddleware *middleware.HotlinkMiddleware, shortLinkMiddleware *middleware.ShortLinkMiddleware, accessMiddleware *middleware.ImageAccessMiddleware, variantMiddleware *middleware.ImageVariantMiddleware, imageHandler *handler.ImageHandler, uploadURLPrefix string) {
+	imgGroup := r.Group(uploadURLPrefix, staticMiddleware.StaticCacheMiddleware(), hotlinkMiddleware.HotlinkProtection(true), accessMiddleware.ImageAccess(), variantMiddleware.ImageVariant())
+	imgGroup.GET("/*filepath", imageHandler.ServeImage)
+	imgGroup.HEAD("/*filepath", imageHandler.ServeImage)
+
+	shortGroup := r.Group(consts.ShortLinkPathPrefix, staticMiddleware.StaticCacheMiddleware(), shortLinkMiddleware.ResolveShortLink(), hotlinkMiddleware.HotlinkProtection(true), accessMiddleware.ImageAccess(), variantMiddleware.ImageVariant())
+	shortGroup.GET("/:code", imageHandler.ServeImage)
+	shortGroup.HEAD("/:code", imageHandler.ServeImage)
+}
+
+// setupStorageProxy 在使用对象存储时，通过服务端代理原有的图片与头像访问前缀。
+func setupStorageProxy(r *gin.Engine, storages *storage.Manager, staticMiddleware *middleware.StaticCacheMiddleware, hotlinkMiddleware *middleware.HotlinkMiddleware, shortLinkMiddleware *middleware.ShortLinkMiddleware, accessMiddleware *middleware.ImageAccessMiddleware, variantMiddleware *middleware.ImageVariantMiddleware, imageHandler *handler.ImageHandler, uploadURLPrefix string, avatarURLPrefix string) {
+	setupImageRoutes(r, staticMiddleware, hotlinkMiddleware, shortLinkMiddleware, accessMiddleware, variantMiddleware, imageHandler, uploadURLPrefix)
+
+	avatarGroup := r.Group(avatarURLPrefix, staticMiddleware.StaticCacheMiddleware(), hotlinkMiddleware.HotlinkProtection(false))
+	avatarGroup.GET("/*filepath", storageProxyHandler(storages.Avatars))
+	avatarGroup.HEAD("/*filepath", storageProxyHandler(storages.Avatars))
+}
+
+func storageProxyHandler(store storage.Storage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := strings.TrimPrefix(c.Param("filepath"), "/")
+		if _, err := storage.CleanKey(key); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+			return
+		}
+
+		reader, info, err := store.Get(key)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotExist) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+				return
+			}
+			log.Printf("Storage proxy get error: %v\n", err)
+			c.JSON(http.StatusBadGateway, gin.H{"error": "读取存储文件失败"})
+			return
+		}
+		defer func() { _ = reader.Close() }()
+
+		headers := map[string]string{}
+		if !info.LastModified.IsZero() {
+			headers["Last-Modified"] = info.LastModified.UTC().Format(http.TimeFormat)
+		}
+		contentType := info.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		c.DataFromReader(http.StatusOK, info.Size, contentType, reader, headers)
+	}
+}
+
 func getNoRouteHandler(distFS fs.FS, indexData []byte, uploadURLPrefix string, avatarURLPrefix string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if strings.HasPrefix(c.Request.URL.Path, "/api") {
@@ -161,7 +241,8 @@ func getNoRouteHandler(distFS fs.FS, indexData []byte, uploadURLPrefix string, a
 	}
 }
 
-func startServer(r *gin.Engine, port string) {
+// startServer 启动 HTTP 服务并等待中断信号；停机时在 HTTP 服务关闭后依次调用 onShutdown 停止后台任务。
+func startServer(r *gin.Engine, port string, onShutdown ...func()) {
 	// 停机配置
 	srv := &http.Server{
 		Addr:    ":" + port,
@@ -182,14 +263,25 @@ func startServer(r *gin.Engine, port string) {
 	<-quit
 	log.Println("🛑 正在关闭服务...")
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := srv.Shutdown(ctx); err != nil {
-		log.Fatal("❌ 服务强制关闭:", err)
+	if err := shutdownServer(srv, 5*time.Second, onShutdown...); err != nil {
+		log.Println("❌ 服务强制关闭:", err)
+		os.Exit(1)
 	}
 	log.Println("✅ 服务已退出")
 }
 
+// shutdownServer 在 timeout 内关闭 HTTP 服务，随后无论是否超时都依次调用 onShutdown 停止后台任务，
+// 确保超时退出时内存中待写入的数据（如访问计数）仍会被写入。
+func shutdownServer(srv *http.Server, timeout time.Duration, onShutdown ...func()) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err := srv.Shutdown(ctx)
+	for _, stop := range onShutdown {
+		stop()
+	}
+	return err
+}
+
 func printWelcomeMessage(port string) {
 
 	fmt.Println()
@@ -230,6 +322,17 @@ func exportAPI(r *gin.Engine) {
 	println("✅ 路由已成功导出到 routes.json")
 }
 
+// runStorageReconcile 执行一次存储对账，并将 JSON 报告输出到标准输出。
+func runStorageReconcile(imageService *service.ImageService, dryRun bool) error {
+	report, err := imageService.ReconcileStorage(dryRun)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
 func checkSecurePath(path string) {
 	absPath, err := filepath.Abs(path)
 	if err != nil {
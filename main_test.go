@@ -2,7 +2,9 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"io/fs"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -10,10 +12,18 @@ import (
 	"strings"
 	"testing"
 	"testing/fstest"
+	"time"
 
 	"perfect-pic-server/internal/config"
+	"perfect-pic-server/internal/consts"
+	"perfect-pic-server/internal/handler"
 	"perfect-pic-server/internal/middleware"
+	"perfect-pic-server/internal/model"
+	"perfect-pic-server/internal/pkg/cache"
+	"perfect-pic-server/internal/pkg/storage"
+	"perfect-pic-server/internal/pkg/urlsign"
 	"perfect-pic-server/internal/repository"
+	"perfect-pic-server/internal/service"
 	"perfect-pic-server/internal/testutils"
 
 	"github.com/gin-gonic/gin"
@@ -158,6 +168,33 @@ func TestEnsureDirectories_CreatesUploadAndAvatarDirs(t *testing.T) {
 	}
 }
 
+// 测试内容：验证关闭 HTTP 服务超时时仍会依次调用停止后台任务的回调，并返回超时错误。
+func TestShutdownServer_RunsStopHooksOnTimeout(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})}
+	go func() { _ = srv.Serve(ln) }()
+	go func() { _, _ = http.Get("http://" + ln.Addr().String()) }()
+	<-started
+
+	var calls []int
+	err = shutdownServer(srv, 10*time.Millisecond, func() { calls = append(calls, 1) }, func() { calls = append(calls, 2) })
+	if err == nil {
+		t.Fatalf("期望存在未完成请求时关闭超时")
+	}
+	if len(calls) != 2 || calls[0] != 1 || calls[1] != 2 {
+		t.Fatalf("期望超时后仍依次调用停止回调，实际为 %v", calls)
+	}
+}
+
 // 测试内容：验证 server.trusted_proxies 静态配置对信任代理的影响：空值禁用、有效列表生效、无效列表回退。
 func TestApplyTrustedProxies_UsesStaticConfigValue(t *testing.T) {
 	gin.SetMode(gin.TestMode)
@@ -219,7 +256,7 @@ func TestPrintWelcomeMessage(t *testing.T) {
 	printWelcomeMessage("8080")
 }
 
-// 测试内容：验证静态文件挂载后上传与头像文件可被访问。
+// 测试内容：验证静态文件挂载后上传与头像文件可被访问，图片响应保留缓存控制头。
 func TestSetupStaticFiles_ServesUploadsAndAvatars(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	setupTestDBForMain(t)
@@ -236,9 +273,13 @@ func TestSetupStaticFiles_ServesUploadsAndAvatars(t *testing.T) {
 	r := gin.New()
 	setupStaticFiles(
 		r,
-		uploadPath,
 		avatarPath,
 		buildTestStaticCacheMiddlewareForMain(),
+		middleware.NewHotlinkMiddleware(buildTestDBConfigForMain(), nil),
+		middleware.NewShortLinkMiddleware(buildTestDBConfigForMain(), buildTestImageServiceForMain(uploadPath)),
+		buildTestImageAccessMiddlewareForMain(uploadPath),
+		middleware.NewImageVariantMiddleware(nil),
+		buildTestImageHandlerForMain(uploadPath),
 		"/imgs/",
 		"/avatars/",
 	)
@@ -248,6 +289,9 @@ func TestSetupStaticFiles_ServesUploadsAndAvatars(t *testing.T) {
 	if w1.Code != http.StatusOK {
 		t.Fatalf("期望 200，实际为 %d", w1.Code)
 	}
+	if w1.Header().Get("Cache-Control") == "" {
+		t.Fatalf("期望图片响应保留 Cache-Control")
+	}
 
 	w2 := httptest.NewRecorder()
 	r.ServeHTTP(w2, httptest.NewRequest(http.MethodGet, "/avatars/b.txt", nil))
@@ -256,6 +300,170 @@ func TestSetupStaticFiles_ServesUploadsAndAvatars(t *testing.T) {
 	}
 }
 
+// 测试内容：验证短链接直接返回图片内容、私有图片仍需授权、未知短码返回 404，开启重定向后跳转到原地址并保留查询参数。
+func TestSetupStaticFiles_ServesShortLinks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDBForMain(t)
+
+	tmp := t.TempDir()
+	oldwd, _ := os.Getwd()
+	_ = os.Chdir(tmp)
+	defer func() { _ = os.Chdir(oldwd) }()
+
+	uploadPath, avatarPath := ensureDirectories(buildStaticConfigForMain("uploads/imgs", "uploads/avatars"))
+	_ = os.MkdirAll(filepath.Join(uploadPath, "2026"), 0755)
+	_ = os.WriteFile(filepath.Join(uploadPath, "2026", "a.png"), []byte("public"), 0644)
+	_ = os.WriteFile(filepath.Join(uploadPath, "2026", "b.png"), []byte("private"), 0644)
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+	publicCode, privateCode := "Ab3xY9z", "my-slug"
+	publicImage := model.Image{Filename: "a.png", Path: "2026/a.png", Size: 6, MimeType: "image/png", UserID: u.ID, Visibility: model.ImageVisibilityPublic, ShortCode: &publicCode}
+	_ = testGormDB.Create(&publicImage).Error
+	_ = testGormDB.Create(&model.Image{Filename: "b.png", Path: "2026/b.png", Size: 7, MimeType: "image/png", UserID: u.ID, Visibility: model.ImageVisibilityPrivate, ShortCode: &privateCode}).Error
+
+	dbConfig := buildTestDBConfigForMain()
+	r := gin.New()
+	setupStaticFiles(
+		r,
+		avatarPath,
+		buildTestStaticCacheMiddlewareForMain(),
+		middleware.NewHotlinkMiddleware(dbConfig, nil),
+		middleware.NewShortLinkMiddleware(dbConfig, buildTestImageServiceForMain(uploadPath)),
+		buildTestImageAccessMiddlewareForMain(uploadPath),
+		middleware.NewImageVariantMiddleware(nil),
+		buildTestImageHandlerForMain(uploadPath),
+		"/imgs/",
+		"/avatars/",
+	)
+
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	if w := get("/s/" + publicCode); w.Code != http.StatusOK || w.Body.String() != "public" {
+		t.Fatalf("期望直接返回图片内容，实际为 %d %q", w.Code, w.Body.String())
+	}
+	if w := get("/s/" + privateCode); w.Code != http.StatusForbidden {
+		t.Fatalf("私有图片期望 403，实际为 %d", w.Code)
+	}
+	if w := get("/s/unknown"); w.Code != http.StatusNotFound {
+		t.Fatalf("未知短码期望 404，实际为 %d", w.Code)
+	}
+
+	_ = testGormDB.Save(&model.Setting{Key: consts.ConfigShortLinkRedirect, Value: "true"}).Error
+	dbConfig.ClearCache()
+	w := get("/s/" + publicCode + "?w=128&id=999")
+	if want := fmt.Sprintf("/imgs/2026/a.png?id=%d&w=128", publicImage.ID); w.Code != http.StatusFound || w.Header().Get("Location") != want {
+		t.Fatalf("期望 302 跳转到图片记录地址 %q，实际为 %d %q", want, w.Code, w.Header().Get("Location"))
+	}
+}
+
+// 测试内容：验证使用配置了 public_url 的 S3 存储时，返回的图片地址经由服务端代理，访问流量计入所有者的每月流量并在用尽后拒绝访问。
+func TestSetupStorageProxy_S3PublicURLCountsEgress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDBForMain(t)
+
+	limit := int64(10)
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com", MonthlyEgressBytes: &limit}
+	_ = testGormDB.Create(&u).Error
+	r, storages, imageService, userService := newS3ProxyRouterForMain(t)
+	_ = storages.Images.Put("2026/a.png", strings.NewReader("abcdef"), 6, "image/png")
+	_ = testGormDB.Create(&model.Image{Filename: "a.png", Path: "2026/a.png", Size: 6, MimeType: "image/png", UserID: u.ID}).Error
+
+	url := imageService.ImageURL("2026/a.png")
+	if url != "/imgs/2026/a.png" {
+		t.Fatalf("期望返回代理地址，实际为 %q", url)
+	}
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusForbidden} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		if w.Code != want {
+			t.Fatalf("第 %d 次访问期望 %d，实际为 %d", i+1, want, w.Code)
+		}
+	}
+	if usage := userService.GetUsageInfo(&u); usage.MonthlyEgressBytesUsed != 12 {
+		t.Fatalf("期望本月已用流量为 12，实际为 %d", usage.MonthlyEgressBytesUsed)
+	}
+}
+
+// 测试内容：验证使用配置了 public_url 的 S3 存储时，图片（含短链接）经由服务端代理访问并计入访问统计。
+func TestSetupStorageProxy_S3PublicURLCountsViews(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupTestDBForMain(t)
+
+	u := model.User{Username: "alice", Password: "x", Status: 1, Email: "a@example.com"}
+	_ = testGormDB.Create(&u).Error
+	r, storages, imageService, _ := newS3ProxyRouterForMain(t)
+	_ = storages.Images.Put("2026/a.png", strings.NewReader("abcdef"), 6, "image/png")
+	code := "Ab3xY9z"
+	img := model.Image{Filename: "a.png", Path: "2026/a.png", Size: 6, MimeType: "image/png", UserID: u.ID, ShortCode: &code}
+	_ = testGormDB.Create(&img).Error
+
+	for _, target := range []string{imageService.ImageURL(img.Path), "/s/" + code} {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Referer", "https://blog.example.com/post")
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK || w.Body.String() != "abcdef" {
+			t.Fatalf("%s 期望返回图片内容，实际为 %d %q", target, w.Code, w.Body.String())
+		}
+	}
+	if err := imageService.FlushImageViews(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	stats, err := imageService.GetImageStats(img.ID, u.ID, 1)
+	if err != nil {
+		t.Fatalf("GetImageStats: %v", err)
+	}
+	if stats.TotalViews != 2 || len(stats.Referrers) != 1 || stats.Referrers[0].Host != "blog.example.com" {
+		t.Fatalf("期望两次访问均计入统计，实际为 %+v", stats)
+	}
+}
+
+// newS3ProxyRouterForMain 使用配置了 public_url 的 S3 存储注册图片与头像代理路由，返回共用的存储与服务。
+func newS3ProxyRouterForMain(t *testing.T) (*gin.Engine, *storage.Manager, *service.ImageService, *service.UserService) {
+	t.Helper()
+	fake := testutils.NewFakeS3(t, "pics")
+	storages, err := storage.NewManager(&storage.Config{
+		Driver: storage.DriverS3,
+		Local:  storage.LocalConfig{ImageURLPrefix: "/imgs/", AvatarURLPrefix: "/avatars/"},
+		S3: storage.S3Config{
+			Endpoint:     fake.URL(),
+			Bucket:       fake.Bucket,
+			AccessKey:    "ak",
+			SecretKey:    "sk",
+			UsePathStyle: true,
+			PublicURL:    "https://cdn.example.com",
+		},
+	})
+	if err != nil {
+		t.Fatalf("init storage: %v", err)
+	}
+
+	dbConfig := buildTestDBConfigForMain()
+	signer := urlsign.NewSigner(&urlsign.Config{Secret: []byte("test_secret")})
+	imageService := service.NewImageService(repository.NewImageRepository(testGormDB), dbConfig, nil, storages, signer, nil)
+	userService := service.NewUserService(repository.NewUserRepository(testGormDB), dbConfig, cache.NewStore(nil, &cache.Config{}), nil)
+
+	r := gin.New()
+	setupStorageProxy(
+		r,
+		storages,
+		buildTestStaticCacheMiddlewareForMain(),
+		middleware.NewHotlinkMiddleware(dbConfig, nil),
+		middleware.NewShortLinkMiddleware(dbConfig, imageService),
+		middleware.NewImageAccessMiddleware(nil, imageService, userService),
+		middleware.NewImageVariantMiddleware(nil),
+		handler.NewImageHandler(imageService, nil, nil),
+		"/imgs/",
+		"/avatars/",
+	)
+	return r, storages, imageService, userService
+}
+
 func setupTestDBForMain(t *testing.T) *gorm.DB {
 	gdb := testutils.SetupDB(t)
 	testGormDB = gdb
@@ -274,6 +482,20 @@ func buildTestStaticCacheMiddlewareForMain() *middleware.StaticCacheMiddleware {
 	return middleware.NewStaticCacheMiddleware(buildTestDBConfigForMain())
 }
 
+func buildTestImageServiceForMain(uploadPath string) *service.ImageService {
+	storages := &storage.Manager{Driver: storage.DriverLocal, Images: storage.NewLocalStorage(uploadPath, "/imgs/")}
+	signer := urlsign.NewSigner(&urlsign.Config{Secret: []byte("test_secret")})
+	return service.NewImageService(repository.NewImageRepository(testGormDB), buildTestDBConfigForMain(), nil, storages, signer, nil)
+}
+
+func buildTestImageHandlerForMain(uploadPath string) *handler.ImageHandler {
+	return handler.NewImageHandler(buildTestImageServiceForMain(uploadPath), nil, nil)
+}
+
+func buildTestImageAccessMiddlewareForMain(uploadPath string) *middleware.ImageAccessMiddleware {
+	return middleware.NewImageAccessMiddleware(nil, buildTestImageServiceForMain(uploadPath), nil)
+}
+
 func buildStaticConfigForMain(uploadPath, avatarPath string) *config.Config {
 	return &config.Config{
 		Upload: config.UploadConfig{